@@ -6,4 +6,6 @@ import (
 	_ "arhat.dev/dukkha/pkg/renderer/git"
 	_ "arhat.dev/dukkha/pkg/renderer/http"
 	_ "arhat.dev/dukkha/pkg/renderer/input"
+	_ "arhat.dev/dukkha/pkg/renderer/secret"
+	_ "arhat.dev/dukkha/pkg/renderer/ssh"
 )
@@ -4,11 +4,13 @@ import (
 	// Add default tools and tasks
 	_ "arhat.dev/dukkha/pkg/tools/archive"
 	_ "arhat.dev/dukkha/pkg/tools/buildah"
+	_ "arhat.dev/dukkha/pkg/tools/cargo"
 	_ "arhat.dev/dukkha/pkg/tools/cosign"
 	_ "arhat.dev/dukkha/pkg/tools/docker"
 	_ "arhat.dev/dukkha/pkg/tools/git"
 	_ "arhat.dev/dukkha/pkg/tools/github"
 	_ "arhat.dev/dukkha/pkg/tools/golang"
 	_ "arhat.dev/dukkha/pkg/tools/helm"
+	_ "arhat.dev/dukkha/pkg/tools/skopeo"
 	_ "arhat.dev/dukkha/pkg/tools/workflow"
 )
@@ -6,6 +6,7 @@ require (
 	arhat.dev/pkg v0.10.2-0.20220731090723-4d52b4c806d1
 	arhat.dev/rs v0.11.0
 	arhat.dev/tlang v0.0.0-20220721161405-faac9d553010
+	filippo.io/age v1.0.0
 	github.com/Masterminds/goutils v1.1.1
 	github.com/aoldershaw/ansi v0.0.0-20210128170437-8c5426635e02
 	github.com/benhoyt/goawk v1.20.0
@@ -17,6 +18,7 @@ require (
 	github.com/gosimple/slug v1.12.0
 	github.com/h2non/filetype v1.1.3
 	github.com/hashicorp/go-sockaddr v1.0.2
+	github.com/hashicorp/hcl v1.0.0
 	github.com/huandu/xstrings v1.3.2
 	github.com/itchyny/gojq v0.12.8
 	github.com/klauspost/compress v1.15.9
@@ -25,6 +27,7 @@ require (
 	github.com/muesli/termenv v0.12.0
 	github.com/nwaples/rardecode v1.1.3
 	github.com/open2b/scriggo v0.55.0
+	github.com/pelletier/go-toml/v2 v2.0.5
 	github.com/pierrec/lz4/v4 v4.1.15
 	github.com/spf13/cobra v1.5.0
 	github.com/spf13/pflag v1.0.5
@@ -37,6 +40,7 @@ require (
 	golang.org/x/sys v0.0.0-20220722155257-8c9f86f7a55f
 	golang.org/x/term v0.0.0-20220722155259-a9ba230a4035
 	golang.org/x/tools v0.1.11
+	gopkg.in/ini.v1 v1.62.0
 	gopkg.in/yaml.v3 v3.0.1
 	mvdan.cc/sh/v3 v3.5.1
 )
@@ -52,6 +56,7 @@ replace (
 
 require (
 	arhat.dev/pty v0.1.0 // indirect
+	filippo.io/edwards25519 v1.0.0-rc.1 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/dustin/go-humanize v1.0.0 // indirect
 	github.com/evanphx/json-patch/v5 v5.6.0 // indirect
@@ -84,5 +89,4 @@ require (
 	golang.org/x/mod v0.6.0-dev.0.20220419223038-86c51ed26bb4 // indirect
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c // indirect
 	golang.org/x/text v0.3.7 // indirect
-	gopkg.in/ini.v1 v1.62.0 // indirect
 )
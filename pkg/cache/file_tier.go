@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"arhat.dev/pkg/log"
+)
+
+var (
+	fileTierPoolsMu sync.Mutex
+	// fileTierPools maps parent dir of local cache dirs to the pool
+	fileTierPools = make(map[string]*fileTierPool)
+)
+
+// joinFileTierPool adds the local cache dir to the pool of its parent dir
+// (e.g. all renderer cache dirs share `${DUKKHA_CACHE_DIR}/renderer`)
+//
+// when maxBytes
+//   - <= 0, files in dir are not limited by total size
+//   - > 0, dir contributes maxBytes to the total size limit of the pool
+//
+// when itemMaxBytes
+//   - <= 0, no limit to item size
+//   - > 0, files larger than itemMaxBytes are evicted on next eviction
+func joinFileTierPool(dir string, maxBytes, itemMaxBytes int64) *fileTierMember {
+	if itemMaxBytes <= 0 {
+		itemMaxBytes = math.MaxInt64
+	}
+
+	parent := filepath.Dir(dir)
+
+	fileTierPoolsMu.Lock()
+	defer fileTierPoolsMu.Unlock()
+
+	pool, ok := fileTierPools[parent]
+	if !ok {
+		pool = &fileTierPool{}
+		fileTierPools[parent] = pool
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	m := &fileTierMember{
+		pool: pool,
+
+		dir:          dir,
+		maxBytes:     maxBytes,
+		itemMaxBytes: itemMaxBytes,
+	}
+
+	for i, v := range pool.members {
+		if v.dir == dir {
+			// same dir initialized again, use latest limits
+			pool.members[i] = m
+			return m
+		}
+	}
+
+	pool.members = append(pool.members, m)
+	return m
+}
+
+// fileTierPool is a group of local cache dirs sharing size limits, when total size
+// of these dirs exceeds sum of their limits, least recently used files are evicted
+// first regardless of which dir they belong to
+type fileTierPool struct {
+	mu      sync.Mutex
+	members []*fileTierMember
+}
+
+type fileTierMember struct {
+	pool *fileTierPool
+
+	dir          string
+	maxBytes     int64
+	itemMaxBytes int64
+}
+
+// touch marks the cache file as used at now
+func (m *fileTierMember) touch(file string, now int64) {
+	t := time.Unix(now, 0)
+	err := os.Chtimes(filepath.Join(m.dir, file), t, t)
+	if err != nil {
+		log.Log.V("updating cache file usage", log.String("file", file), log.Error(err))
+	}
+}
+
+// fileTierEvictGracePeriod is the time in seconds a cache file is protected from
+// eviction after it was used, so paths returned to callers (e.g. `cached-file`
+// consumers) are not removed by other caches in the pool before being read
+const fileTierEvictGracePeriod = 60
+
+// evict removes oversized and least recently used files in the pool until
+// limits are satisfied, file keep in this dir and files used in the grace period
+// before now are never removed (limits can be exceeded temporarily)
+func (m *fileTierMember) evict(keep string, now int64) {
+	keep = filepath.Join(m.dir, keep)
+
+	p := m.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	type fileInfo struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var (
+		files []fileInfo
+
+		total, limit int64
+	)
+
+	for _, v := range p.members {
+		entries, err := os.ReadDir(v.dir)
+		if err != nil {
+			continue
+		}
+
+		limited := v.maxBytes > 0
+		if limited {
+			limit += v.maxBytes
+		}
+
+		for _, ent := range entries {
+			if !ent.Type().IsRegular() {
+				continue
+			}
+
+			info, err := ent.Info()
+			if err != nil {
+				continue
+			}
+
+			f := fileInfo{
+				path:    filepath.Join(v.dir, ent.Name()),
+				size:    info.Size(),
+				modTime: info.ModTime(),
+			}
+
+			switch {
+			case f.path == keep:
+			case f.modTime.Unix() > now-fileTierEvictGracePeriod:
+			case f.size > v.itemMaxBytes:
+				removeCacheFile(f.path)
+				continue
+			case limited:
+				files = append(files, f)
+			}
+
+			if limited {
+				total += f.size
+			}
+		}
+	}
+
+	if total <= limit {
+		return
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	for _, f := range files {
+		if total <= limit {
+			break
+		}
+
+		if removeCacheFile(f.path) {
+			total -= f.size
+		}
+	}
+}
+
+func removeCacheFile(file string) bool {
+	// cache files are read-only
+	_ = os.Chmod(file, 0600)
+	err := os.Remove(file)
+	if err != nil && !os.IsNotExist(err) {
+		log.Log.I("evicting cache file", log.String("file", file), log.Error(err))
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"arhat.dev/pkg/fshelper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwoTierCache_fileTierLimits(t *testing.T) {
+	t.Parallel()
+
+	newCache := func(dir string, itemMaxBytes, maxBytes int64) *TwoTierCache {
+		assert.NoError(t, os.MkdirAll(dir, 0755))
+		return NewTwoTierCache(fshelper.NewOSFS(false, func(fshelper.Op, string) (string, error) {
+			return dir, nil
+		}), itemMaxBytes, maxBytes, 1000)
+	}
+
+	fetch := func(data string) RemoteCacheRefreshFunc {
+		return func(IdentifiableObject) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(data)), nil
+		}
+	}
+
+	countFiles := func(dir string) int {
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		return len(entries)
+	}
+
+	t.Run("LRU Across Dirs", func(t *testing.T) {
+		root := t.TempDir()
+		dirA, dirB := filepath.Join(root, "a"), filepath.Join(root, "b")
+
+		// total limit is 20 bytes, each item is 8 bytes
+		a := newCache(dirA, -1, 10)
+		b := newCache(dirB, -1, 10)
+
+		_, _, err := a.GetPath(IdentifiableString("1"), 1000, true, fetch("00000001"))
+		assert.NoError(t, err)
+		_, _, err = b.GetPath(IdentifiableString("2"), 1100, true, fetch("00000002"))
+		assert.NoError(t, err)
+
+		// use 1 again, 2 becomes the least recently used
+		_, _, err = a.GetPath(IdentifiableString("1"), 1200, true, fetch("not-used"))
+		assert.NoError(t, err)
+
+		_, _, err = a.GetPath(IdentifiableString("3"), 1300, true, fetch("00000003"))
+		assert.NoError(t, err)
+
+		assert.Equal(t, 2, countFiles(dirA))
+		assert.Equal(t, 0, countFiles(dirB))
+	})
+
+	t.Run("Recently Used", func(t *testing.T) {
+		root := t.TempDir()
+		dirA, dirB := filepath.Join(root, "a"), filepath.Join(root, "b")
+
+		a := newCache(dirA, -1, 10)
+		b := newCache(dirB, -1, 10)
+
+		// path returned to the caller of b is not removed by eviction of a
+		// before the grace period ends
+		file, _, err := b.GetPath(IdentifiableString("1"), 1000, true, fetch("00000001"))
+		assert.NoError(t, err)
+		_, _, err = a.GetPath(IdentifiableString("2"), 1010, true, fetch("00000002"))
+		assert.NoError(t, err)
+		_, _, err = a.GetPath(IdentifiableString("3"), 1020, true, fetch("00000003"))
+		assert.NoError(t, err)
+
+		assert.FileExists(t, file)
+		assert.Equal(t, 2, countFiles(dirA))
+
+		_, _, err = a.GetPath(IdentifiableString("4"), 1100, true, fetch("00000004"))
+		assert.NoError(t, err)
+
+		assert.NoFileExists(t, file)
+		assert.Equal(t, 2, countFiles(dirA))
+	})
+
+	t.Run("Oversized Item", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "c")
+		c := newCache(dir, 4, -1)
+
+		data, _, err := c.Get(IdentifiableString("1"), 1000, true, fetch("00000001"))
+		assert.NoError(t, err)
+		assert.Equal(t, "00000001", string(data))
+		assert.Equal(t, 0, countFiles(dir))
+
+		// file is kept when path is requested, until next eviction after the
+		// grace period
+		file, _, err := c.GetPath(IdentifiableString("2"), 1000, true, fetch("00000002"))
+		assert.NoError(t, err)
+		assert.FileExists(t, file)
+
+		_, _, err = c.GetPath(IdentifiableString("3"), 1100, true, fetch("3"))
+		assert.NoError(t, err)
+		assert.NoFileExists(t, file)
+		assert.Equal(t, 1, countFiles(dir))
+	})
+}
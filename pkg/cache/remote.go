@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+
+	"arhat.dev/pkg/log"
+	"arhat.dev/pkg/stringhelper"
+)
+
+// ErrRemoteCacheMiss is returned by RemoteCache when there is no content stored with the key
+var ErrRemoteCacheMiss = errors.New("remote cache miss")
+
+// RemoteCache is the optional cache tier shared between machines (e.g. ci runners),
+// it's used after local file cache and before fetching from the origin
+type RemoteCache interface {
+	// Get returns content stored with the key, ErrRemoteCacheMiss is returned
+	// when not found
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Put stores content read from r with the key, size is the size of the content
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+}
+
+// remoteCacheMetaSuffix is the suffix of key of the remote cache metadata
+const remoteCacheMetaSuffix = ".meta.json"
+
+// remoteCacheMeta is stored along with the content in remote cache
+type remoteCacheMeta struct {
+	// SHA256 hex encoded sha256 digest of the content
+	SHA256 string `json:"sha256"`
+
+	// Size of the content
+	Size int64 `json:"size"`
+
+	// Timestamp is the unix timestamp when the content was fetched from the origin
+	Timestamp int64 `json:"timestamp"`
+}
+
+// SetRemote sets remote cache tier of this cache
+//
+// namespace is the key prefix in remote cache to avoid conflicts between
+// caches of different owners
+func (c *TwoTierCache) SetRemote(remote RemoteCache, namespace string) {
+	c.remote = remote
+	c.remoteNamespace = namespace
+}
+
+func (c *TwoTierCache) formatRemoteKey(obj IdentifiableObject) string {
+	sum := sha256.Sum256(stringhelper.ToBytes[byte, byte](obj.ScopeUniqueID()))
+	return path.Join(c.remoteNamespace, hex.EncodeToString(sum[:])+obj.Ext())
+}
+
+// fetchRemote downloads content from remote cache to local cache file when
+// there is a valid one
+//
+// ok is false when remote cache is not usable
+func (c *TwoTierCache) fetchRemote(
+	obj IdentifiableObject,
+	cacheFilenamePrefix, suffix string,
+	now int64,
+	retConent bool,
+) (file string, content []byte, ok bool) {
+	key := c.formatRemoteKey(obj)
+	meta, err := c.getRemoteMeta(key)
+	if err != nil {
+		if !errors.Is(err, ErrRemoteCacheMiss) {
+			log.Log.I("checking remote cache", log.String("key", key), log.Error(err))
+		}
+
+		return
+	}
+
+	if meta.Timestamp < now-c.memcache.MaxAge || meta.Timestamp > now {
+		// expired or not valid
+		return
+	}
+
+	r, err := c.remote.Get(context.TODO(), key)
+	if err != nil {
+		log.Log.I("fetching remote cache", log.String("key", key), log.Error(err))
+		return
+	}
+	defer func() { _ = r.Close() }()
+
+	h := sha256.New()
+	_file := formatLocalCacheFilename(cacheFilenamePrefix, suffix, meta.Timestamp)
+	size, content, err := storeLocalCache(c.cacheFS, _file, io.TeeReader(r, h), retConent)
+	if err == nil && (size != meta.Size || hex.EncodeToString(h.Sum(nil)) != meta.SHA256) {
+		err = fmt.Errorf("sha256 digest mismatch")
+	}
+
+	if err == nil {
+		file, err = c.cacheFS.Abs(_file)
+	}
+
+	if err != nil {
+		log.Log.I("storing remote cache", log.String("key", key), log.Error(err))
+
+		_ = c.cacheFS.Chmod(_file, 0600)
+		_ = c.cacheFS.Remove(_file)
+		return "", nil, false
+	}
+
+	c.touch(_file, now)
+	if c.fileTier != nil {
+		c.fileTier.evict(_file, now)
+	}
+
+	if retConent && size <= c.itemMaxBytes && size <= c.memcache.MaxSize {
+		c.memcache.Set(obj.ScopeUniqueID(), content)
+	}
+
+	return file, content, true
+}
+
+func (c *TwoTierCache) getRemoteMeta(key string) (*remoteCacheMeta, error) {
+	r, err := c.remote.Get(context.TODO(), key+remoteCacheMetaSuffix)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	meta := &remoteCacheMeta{}
+	err = json.NewDecoder(r).Decode(meta)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote cache meta: %w", err)
+	}
+
+	return meta, nil
+}
+
+// storeRemote uploads local cache file to remote cache, content is uploaded
+// before its metadata, so partial uploads are never used
+func (c *TwoTierCache) storeRemote(obj IdentifiableObject, file string, now int64) error {
+	f, err := c.cacheFS.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.(io.Seeker).Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	key := c.formatRemoteKey(obj)
+	err = c.remote.Put(context.TODO(), key, f, size)
+	if err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(&remoteCacheMeta{
+		SHA256:    hex.EncodeToString(h.Sum(nil)),
+		Size:      size,
+		Timestamp: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.remote.Put(context.TODO(), key+remoteCacheMetaSuffix, bytes.NewReader(meta), int64(len(meta)))
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var _ RemoteCache = (*HTTPRemoteCache)(nil)
+
+// NewHTTPRemoteCache creates a remote cache using plain http GET and PUT requests
+// to `<baseURL>/<key>`
+func NewHTTPRemoteCache(client *http.Client, baseURL string, header http.Header) *HTTPRemoteCache {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &HTTPRemoteCache{
+		client:  client,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		header:  header,
+	}
+}
+
+// HTTPRemoteCache stores cache in http servers accepting PUT requests
+// (e.g. nginx with webdav module, artifactory generic repositories)
+type HTTPRemoteCache struct {
+	client  *http.Client
+	baseURL string
+	header  http.Header
+}
+
+func (c *HTTPRemoteCache) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, key, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		_ = resp.Body.Close()
+		return nil, ErrRemoteCacheMiss
+	case resp.StatusCode/100 != 2:
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected response status %q", resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+func (c *HTTPRemoteCache) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	resp, err := c.do(ctx, http.MethodPut, key, r, size)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected response status %q", resp.Status)
+	}
+
+	return nil
+}
+
+func (c *HTTPRemoteCache) do(
+	ctx context.Context, method, key string, body io.Reader, size int64,
+) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"/"+key, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+
+	if body != nil {
+		req.ContentLength = size
+	}
+
+	return c.client.Do(req)
+}
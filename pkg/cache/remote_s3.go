@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"path"
+
+	"github.com/minio/minio-go/v7"
+)
+
+var _ RemoteCache = (*S3RemoteCache)(nil)
+
+// NewS3RemoteCache creates a remote cache storing objects in the bucket
+// with basePath as key prefix
+func NewS3RemoteCache(client *minio.Client, bucket, basePath string) *S3RemoteCache {
+	return &S3RemoteCache{
+		client:   client,
+		bucket:   bucket,
+		basePath: basePath,
+	}
+}
+
+// S3RemoteCache stores cache in s3 compatible object storage
+type S3RemoteCache struct {
+	client *minio.Client
+
+	bucket   string
+	basePath string
+}
+
+func (c *S3RemoteCache) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := c.client.GetObject(ctx, c.bucket, path.Join(c.basePath, key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// GetObject does not send request until read or stat
+	_, err = obj.Stat()
+	if err != nil {
+		_ = obj.Close()
+
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrRemoteCacheMiss
+		}
+
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (c *S3RemoteCache) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	_, err := c.client.PutObject(ctx, c.bucket, path.Join(c.basePath, key), r, size, minio.PutObjectOptions{})
+	return err
+}
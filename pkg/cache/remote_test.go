@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"arhat.dev/pkg/fshelper"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRemoteServer(t *testing.T) (*httptest.Server, map[string][]byte) {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			_, _ = w.Write(data)
+		case http.MethodPut:
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			objects[r.URL.Path] = data
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, objects
+}
+
+func TestTwoTierCache_remote(t *testing.T) {
+	t.Parallel()
+
+	const (
+		cachedData = "test-data"
+		namespace  = "test"
+	)
+
+	obj := IdentifiableString("foo")
+
+	newCache := func(t *testing.T, baseURL string) *TwoTierCache {
+		cacheDir := t.TempDir()
+		c := NewTwoTierCache(fshelper.NewOSFS(false, func(fshelper.Op, string) (string, error) {
+			return cacheDir, nil
+		}), 1024, 1024, 100)
+		c.SetRemote(NewHTTPRemoteCache(nil, baseURL, nil), namespace)
+		return c
+	}
+
+	srv, objects := newTestRemoteServer(t)
+
+	called := 0
+	fetchOrigin := func(IdentifiableObject) (io.ReadCloser, error) {
+		called++
+		return io.NopCloser(strings.NewReader(cachedData)), nil
+	}
+
+	// first cache fetches from the origin and uploads to remote
+	data, expired, err := newCache(t, srv.URL).Get(obj, 1111111110, true, fetchOrigin)
+	assert.NoError(t, err)
+	assert.False(t, expired)
+	assert.Equal(t, cachedData, string(data))
+	assert.Equal(t, 1, called)
+
+	key := "/" + newCache(t, srv.URL).formatRemoteKey(obj)
+	assert.True(t, strings.HasPrefix(key, "/"+namespace+"/"))
+	assert.Equal(t, cachedData, string(objects[key]))
+	assert.Contains(t, string(objects[key+remoteCacheMetaSuffix]), `"timestamp":1111111110`)
+
+	t.Run("Fetch From Remote", func(t *testing.T) {
+		c := newCache(t, srv.URL)
+		data, expired, err := c.Get(obj, 1111111150, true, fetchOrigin)
+		assert.NoError(t, err)
+		assert.False(t, expired)
+		assert.Equal(t, cachedData, string(data))
+		assert.Equal(t, 1, called)
+
+		// stored in local cache with remote timestamp
+		path, _, err := c.GetPath(obj, 1111111150, true, nil)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasSuffix(path, "-00000000001111111110"), path)
+	})
+
+	t.Run("Remote Expired", func(t *testing.T) {
+		c := newCache(t, srv.URL)
+		data, _, err := c.Get(obj, 1111111211, true, func(IdentifiableObject) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("new-data")), nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "new-data", string(data))
+		assert.Contains(t, string(objects[key+remoteCacheMetaSuffix]), `"timestamp":1111111211`)
+	})
+
+	t.Run("Digest Mismatch", func(t *testing.T) {
+		srv, objects := newTestRemoteServer(t)
+
+		_, _, err := newCache(t, srv.URL).Get(obj, 1111111110, true, fetchOrigin)
+		assert.NoError(t, err)
+
+		objects[key] = []byte("tampered!")
+
+		originCalled := false
+		data, _, err := newCache(t, srv.URL).Get(obj, 1111111110, true, func(IdentifiableObject) (io.ReadCloser, error) {
+			originCalled = true
+			return io.NopCloser(strings.NewReader(cachedData)), nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, originCalled)
+		assert.Equal(t, cachedData, string(data))
+	})
+}
@@ -22,6 +22,12 @@ import (
 
 type RemoteCacheRefreshFunc = func(obj IdentifiableObject) (io.ReadCloser, error)
 
+// ErrNotModified can be returned by RemoteCacheRefreshFunc to indicate the remote
+// content is the same as last cached one, then the last expired cache is renewed
+//
+// when there is no expired cache, this error is returned to the caller
+var ErrNotModified = errors.New("not modified")
+
 // NewTwoTierCache creates a new two-tier caching bached by local file and runtime memory
 //
 // when itemMaxBytes
@@ -39,10 +45,24 @@ type RemoteCacheRefreshFunc = func(obj IdentifiableObject) (io.ReadCloser, error
 // 			but will always fetch from remote if in memory cache lost
 //	* > 0, limit both in memory and local file cache to this long.
 //
+// for local file cache, itemMaxBytes and maxBytes <= 0 means no limit, and
+// maxBytes is shared with other local cache dirs in the same parent dir
+// (see joinFileTierPool)
+//
 func NewTwoTierCache(
 	cacheFS *fshelper.OSFS,
 	itemMaxBytes, maxBytes, maxAgeSeconds int64,
 ) *TwoTierCache {
+	var fileTier *fileTierMember
+	if maxBytes > 0 || itemMaxBytes > 0 {
+		dir, err := cacheFS.Abs(".")
+		if err == nil {
+			fileTier = joinFileTierPool(dir, maxBytes, itemMaxBytes)
+		} else {
+			log.Log.I("local cache size limits not applied", log.Error(err))
+		}
+	}
+
 	if maxBytes < 0 {
 		maxBytes = math.MaxInt64
 	}
@@ -56,6 +76,7 @@ func NewTwoTierCache(
 
 		cacheFS:  cacheFS,
 		memcache: lru.New(maxBytes, maxAgeSeconds),
+		fileTier: fileTier,
 	}
 }
 
@@ -66,6 +87,13 @@ type TwoTierCache struct {
 
 	cacheFS  *fshelper.OSFS
 	memcache *lru.LruCache
+
+	// fileTier enforces size limits of local cache files, nil if not limited
+	fileTier *fileTierMember
+
+	// remote is the optional shared cache tier, nil if not set
+	remote          RemoteCache
+	remoteNamespace string
 }
 
 // Get returns latest cached content, but when there is no valid cache and refresh failed
@@ -136,6 +164,7 @@ func (c *TwoTierCache) get(
 		// use latest active cache
 		file = active[len(active)-1]
 		isExpired = false
+		c.touch(file, now)
 		if retConent {
 			content, err = c.cacheFS.ReadFile(file)
 		}
@@ -159,6 +188,14 @@ func (c *TwoTierCache) get(
 		}
 	}
 
+	if c.remote != nil {
+		var ok bool
+		file, content, ok = c.fetchRemote(obj, cacheFilenamePrefix, suffix, now, retConent)
+		if ok {
+			return file, content, false, nil
+		}
+	}
+
 	r, err := refresh(obj)
 	if err != nil {
 		// failed fetching from remote, fallback to last expired
@@ -167,8 +204,13 @@ func (c *TwoTierCache) get(
 			return
 		}
 
+		if errors.Is(err, ErrNotModified) {
+			return c.renew(obj, expired[len(expired)-1], cacheFilenamePrefix, suffix, now, retConent)
+		}
+
 		file = expired[len(expired)-1]
 		isExpired = true
+		c.touch(file, now)
 
 		var err2 error
 		if retConent {
@@ -199,6 +241,28 @@ func (c *TwoTierCache) get(
 		return
 	}
 
+	if c.remote != nil {
+		// best effort
+		err2 := c.storeRemote(obj, _file, now)
+		if err2 != nil {
+			log.Log.I("storing remote cache",
+				log.String("id", obj.ScopeUniqueID()), log.Error(err2),
+			)
+		}
+	}
+
+	c.touch(_file, now)
+	if c.fileTier != nil {
+		if retConent && size > c.fileTier.itemMaxBytes {
+			// too large to be kept in local cache, caller only needs the content
+			if removeCacheFile(file) {
+				file = ""
+			}
+		} else {
+			c.fileTier.evict(_file, now)
+		}
+	}
+
 	// no error, handle in memory cache
 
 	if size > c.itemMaxBytes || size > c.memcache.MaxSize {
@@ -214,6 +278,48 @@ func (c *TwoTierCache) get(
 	return
 }
 
+// renew makes the expired cache file active again
+func (c *TwoTierCache) renew(
+	obj IdentifiableObject,
+	expiredFile, cacheFilenamePrefix, suffix string,
+	now int64,
+	retConent bool,
+) (file string, content []byte, isExpired bool, err error) {
+	_file := formatLocalCacheFilename(cacheFilenamePrefix, suffix, now)
+	err = c.cacheFS.Rename(expiredFile, _file)
+	if err != nil {
+		return
+	}
+
+	file, err = c.cacheFS.Abs(_file)
+	if err != nil {
+		return
+	}
+
+	c.touch(_file, now)
+	if !retConent {
+		return
+	}
+
+	content, err = c.cacheFS.ReadFile(_file)
+	if err != nil {
+		return
+	}
+
+	if size := int64(len(content)); size <= c.itemMaxBytes && size <= c.memcache.MaxSize {
+		c.memcache.Set(obj.ScopeUniqueID(), content)
+	}
+
+	return
+}
+
+// touch marks the local cache file as recently used
+func (c *TwoTierCache) touch(file string, now int64) {
+	if c.fileTier != nil {
+		c.fileTier.touch(file, now)
+	}
+}
+
 func formatCacheFilenamePrefix(id string) string {
 	var buf [md5.Size * 2]byte
 
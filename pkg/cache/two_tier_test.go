@@ -206,6 +206,49 @@ func TestTwoTierCache(t *testing.T) {
 		assert.NoError(t, err)
 		assert.EqualValues(t, cachedData, string(data))
 	})
+
+	t.Run("Renew Expired When Not Modified", func(t *testing.T) {
+		calledNotModified := 0
+		// nolint:unparam
+		fetchRemoteNotModified := func(IdentifiableObject) (io.ReadCloser, error) {
+			calledNotModified++
+			return nil, fmt.Errorf("test: %w", ErrNotModified)
+		}
+
+		cacheDir := t.TempDir()
+		cache := NewTwoTierCache(fshelper.NewOSFS(false, func(fshelper.Op, string) (string, error) {
+			return cacheDir, nil
+		}), 0, 0, 100)
+
+		_, _, err := cache.Get(obj, 1111111111, true, fetchRemoteNotModified)
+		assert.ErrorIs(t, err, ErrNotModified, "no expired cache to renew")
+
+		_, _, err = cache.Get(obj, 1111111111, true, func(IdentifiableObject) (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(cachedData)), nil
+		})
+		assert.NoError(t, err)
+
+		path, expired, err := cache.GetPath(obj, 1111111311, true, fetchRemoteNotModified)
+		assert.NoError(t, err)
+		assert.False(t, expired)
+		assert.EqualValues(t, 2, calledNotModified)
+		assert.EqualValues(t, cacheFilenamePrefix+"-00000000001111111311", filepath.Base(path))
+
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.EqualValues(t, cachedData, string(data))
+
+		entries, err := os.ReadDir(cacheDir)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1, "expired cache should be renamed")
+
+		// renewed cache is active
+		data, expired, err = cache.Get(obj, 1111111311, true, fetchRemoteNotModified)
+		assert.NoError(t, err)
+		assert.False(t, expired)
+		assert.EqualValues(t, 2, calledNotModified)
+		assert.EqualValues(t, cachedData, string(data))
+	})
 }
 
 func TestFormatCacheFilenamePrefix(t *testing.T) {
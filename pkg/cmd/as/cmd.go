@@ -2,3 +2,164 @@
 //
 // Example: run `dukkha as golang local` will actually run configured `go` command for golang
 package as
+
+import (
+	"fmt"
+
+	"arhat.dev/pkg/exechelper"
+	"github.com/spf13/cobra"
+
+	"arhat.dev/dukkha/pkg/cmd/utils"
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/matrix"
+	"arhat.dev/dukkha/pkg/tools"
+)
+
+func NewAsCmd(ctx *dukkha.Context) *cobra.Command {
+	var (
+		matrixFilter []string
+	)
+
+	asCmd := &cobra.Command{
+		Use:   "as <tool-kind> <tool-name> [args...]",
+		Short: "Run tool command with tool specific cmd and env",
+		Example: `dukkha as golang in-docker version
+dukkha as -m kernel=linux,arch=arm64 golang local build ./cmd/foo`,
+
+		SilenceErrors: true,
+		SilenceUsage:  true,
+
+		Args: cobra.MinimumNArgs(2),
+
+		CompletionOptions: cobra.CompletionOptions{
+			DisableDefaultCmd:   true,
+			DisableNoDescFlag:   true,
+			DisableDescriptions: true,
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mFilter, err := matrix.ParseFilter(matrixFilter)
+			if err != nil {
+				return err
+			}
+
+			return run(*ctx, mFilter, args)
+		},
+	}
+
+	flags := asCmd.Flags()
+	// stop parsing flags after tool kind, remaining args are passed to the tool as is
+	flags.SetInterspersed(false)
+
+	utils.RegisterMatrixFilterFlag(flags, &matrixFilter)
+
+	utils.SetupToolCompletion(ctx, asCmd)
+
+	asCmd.SetHelpCommand(&cobra.Command{
+		SilenceUsage: true,
+		Hidden:       true,
+	})
+
+	return asCmd
+}
+
+func run(appCtx dukkha.Context, mFilter matrix.Filter, args []string) error {
+	// defensive check, arg count should be guarded by cobra
+	if len(args) < 2 {
+		return fmt.Errorf("expecting at least 2 args, got %d", len(args))
+	}
+
+	key := dukkha.ToolKey{
+		Kind: dukkha.ToolKind(args[0]),
+		Name: dukkha.ToolName(args[1]),
+	}
+
+	tool, ok := appCtx.GetTool(key)
+	if !ok {
+		return fmt.Errorf("tool %q not found", key)
+	}
+
+	ctx := appCtx.DeriveNew()
+
+	ms := mFilter.AsEntry()
+	if ms == nil {
+		ms = make(matrix.Entry)
+	}
+
+	if _, ok = ms["kernel"]; !ok {
+		ms["kernel"] = ctx.HostKernel()
+	}
+
+	if _, ok = ms["arch"]; !ok {
+		ms["arch"] = ctx.HostArch()
+	}
+
+	ctx.SetMatrixFilter(mFilter)
+	tools.AddMatrixEnv(ctx, ms)
+
+	var (
+		toolCmd []string
+		toolEnv dukkha.NameValueList
+	)
+
+	err := tool.DoAfterFieldsResolved(ctx, -1, true, func() error {
+		toolCmd = tool.GetCmd()
+
+		if t, ok := tool.(tools.ToolWithImplEnv); ok {
+			var err error
+			toolEnv, err = t.GetImplEnv(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("resolving tool %q: %w", key, err)
+	}
+
+	if len(toolCmd) == 0 {
+		return fmt.Errorf("tool %q has no cmd to run", key)
+	}
+
+	// put generated env as suggestion, so user defined tool env takes precedence
+	ctx.AddEnv(false, toolEnv...)
+
+	var cmd []string
+	for _, p := range toolCmd {
+		// tool cmd can not reference itself
+		if p == constant.DUKKHA_TOOL_CMD {
+			continue
+		}
+
+		cmd = append(cmd, p)
+	}
+	cmd = append(cmd, args[2:]...)
+
+	ctxEnv := ctx.Env()
+	env := make(map[string]string, len(ctxEnv))
+	for k, v := range ctxEnv {
+		env[k] = v.GetLazyValue()
+	}
+
+	p, err := exechelper.Do(exechelper.Spec{
+		Context: ctx,
+		Command: cmd,
+		Env:     env,
+
+		Stdin:  ctx.Stdin(),
+		Stdout: ctx.Stdout(),
+		Stderr: ctx.Stderr(),
+	})
+	if err != nil {
+		return fmt.Errorf("preparing command %q: %w", cmd, err)
+	}
+
+	_, err = p.Wait()
+	if err != nil {
+		return fmt.Errorf("command exited with error: %w", err)
+	}
+
+	return nil
+}
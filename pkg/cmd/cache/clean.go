@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"github.com/spf13/cobra"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+func newCacheCleanCmd(ctx *dukkha.Context) *cobra.Command {
+	var dryRun bool
+
+	cleanCmd := &cobra.Command{
+		Use:   "clean [names...]",
+		Short: "Remove all local cache files",
+		Example: `dukkha cache clean
+dukkha cache clean http`,
+
+		SilenceErrors: true,
+		SilenceUsage:  true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return removeCache(*ctx, args, dryRun, nil)
+		},
+	}
+
+	cleanCmd.Flags().BoolVar(&dryRun, "dry-run", false,
+		"only print cache files to be removed",
+	)
+
+	return cleanCmd
+}
@@ -0,0 +1,35 @@
+// Package cache implements dukkha subcommands to inspect and cleanup local cache
+//
+// Example: run `dukkha cache prune --older-than 168h` to remove cache files
+// not used in last 7 days
+package cache
+
+import (
+	"github.com/spf13/cobra"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+func NewCacheCmd(ctx *dukkha.Context) *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and cleanup local cache",
+
+		SilenceErrors: true,
+		SilenceUsage:  true,
+
+		CompletionOptions: cobra.CompletionOptions{
+			DisableDefaultCmd:   false,
+			DisableNoDescFlag:   false,
+			DisableDescriptions: true,
+		},
+	}
+
+	cacheCmd.AddCommand(
+		newCacheLsCmd(ctx),
+		newCachePruneCmd(ctx),
+		newCacheCleanCmd(ctx),
+	)
+
+	return cacheCmd
+}
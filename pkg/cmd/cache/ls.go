@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+func newCacheLsCmd(ctx *dukkha.Context) *cobra.Command {
+	return &cobra.Command{
+		Use:   "ls",
+		Short: "Show local cache usage of renderers, tools and tasks",
+
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			appCtx := *ctx
+
+			w, err := newCacheWalker(appCtx)
+			if err != nil {
+				return err
+			}
+
+			usage, err := w.collectUsage()
+			if err != nil {
+				return fmt.Errorf("check cache usage: %w", err)
+			}
+
+			var (
+				totalSize  int64
+				totalFiles int
+			)
+
+			tw := tabwriter.NewWriter(appCtx.Stdout(), 0, 0, 2, ' ', 0)
+			_, err = fmt.Fprintln(tw, "KIND\tNAME\tSIZE\tFILES\tLAST USED")
+			if err != nil {
+				return err
+			}
+
+			for _, ent := range usage {
+				totalSize += ent.Size
+				totalFiles += ent.Files
+
+				_, err = fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n",
+					ent.Kind, ent.Name, formatSize(ent.Size), ent.Files,
+					ent.LastUsed.Local().Format(time.RFC3339),
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			_, err = fmt.Fprintf(tw, "\t%s\t%s\t%d\t\n", w.cacheDir, formatSize(totalSize), totalFiles)
+			if err != nil {
+				return err
+			}
+
+			return tw.Flush()
+		},
+	}
+}
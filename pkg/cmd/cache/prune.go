@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+func newCachePruneCmd(ctx *dukkha.Context) *cobra.Command {
+	var (
+		olderThan time.Duration
+		dryRun    bool
+	)
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune --older-than <duration> [names...]",
+		Short: "Remove local cache files not used for a while",
+		Example: `dukkha cache prune --older-than 168h
+dukkha cache prune --older-than 24h http golang:local:build:my-app`,
+
+		SilenceErrors: true,
+		SilenceUsage:  true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if olderThan <= 0 {
+				return fmt.Errorf("invalid --older-than %q: expecting positive duration", olderThan)
+			}
+
+			notBefore := time.Now().Add(-olderThan)
+			return removeCache(*ctx, args, dryRun, func(info fs.FileInfo) bool {
+				return info.ModTime().Before(notBefore)
+			})
+		},
+	}
+
+	flags := pruneCmd.Flags()
+	flags.DurationVar(&olderThan, "older-than", 0,
+		"remove cache files last used before this long ago",
+	)
+	flags.BoolVar(&dryRun, "dry-run", false,
+		"only print cache files to be removed",
+	)
+
+	return pruneCmd
+}
+
+// removeCache removes cache files of renderers, tools and tasks selected by names
+// (as shown in `dukkha cache ls`), all cache files are selected when names is empty
+func removeCache(
+	appCtx dukkha.Context,
+	names []string,
+	dryRun bool,
+	filter func(info fs.FileInfo) bool,
+) error {
+	w, err := newCacheWalker(appCtx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		stdout = appCtx.Stdout()
+
+		size  int64
+		count int
+	)
+
+	err = w.removeFiles(names, dryRun, filter, func(file string, info fs.FileInfo) {
+		size += info.Size()
+		count++
+
+		if dryRun {
+			_, _ = fmt.Fprintln(stdout, file)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("remove cache: %w", err)
+	}
+
+	verb := "removed"
+	if dryRun {
+		verb = "would remove"
+	}
+
+	_, err = fmt.Fprintf(stdout, "%s %d files, %s in total\n", verb, count, formatSize(size))
+	return err
+}
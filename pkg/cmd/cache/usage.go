@@ -0,0 +1,298 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+// owner kinds of cache dirs
+const (
+	ownerKindRenderer = "renderer"
+	ownerKindTool     = "tool"
+	ownerKindTask     = "task"
+
+	// ownerKindOther is for cache dirs not owned by any configured
+	// renderer/tool/task (e.g. shared cache, cache of removed tasks)
+	ownerKindOther = "other"
+)
+
+// usageEntry is the local cache usage of a renderer/tool/task
+type usageEntry struct {
+	Kind string
+	Name string
+
+	Size     int64
+	Files    int
+	LastUsed time.Time
+}
+
+// cacheWalker attributes files in cache dir to renderers, tools and tasks
+type cacheWalker struct {
+	cacheDir string
+
+	// owners maps cache dir to its owner
+	owners map[string]*usageEntry
+}
+
+func newCacheWalker(appCtx dukkha.Context) (*cacheWalker, error) {
+	cacheDir, err := filepath.Abs(appCtx.CacheDir())
+	if err != nil {
+		return nil, fmt.Errorf("check cache dir: %w", err)
+	}
+
+	w := &cacheWalker{
+		cacheDir: cacheDir,
+		owners:   make(map[string]*usageEntry),
+	}
+
+	// renderer cache dirs are recognized by path (`renderer/<name>`)
+
+	rc, ok := appCtx.(dukkha.ConfigResolvingContext)
+	if !ok {
+		// tool and task cache dirs are reported as other
+		return w, nil
+	}
+
+	for k, tool := range rc.AllTools() {
+		w.addOwner(ownerKindTool, k.String(), rc.ToolCacheFS(tool).Abs)
+
+		for tk, tsk := range tool.AllTasks() {
+			w.addOwner(ownerKindTask, k.String()+":"+tk.String(), rc.TaskCacheFS(tsk).Abs)
+		}
+	}
+
+	return w, nil
+}
+
+func (w *cacheWalker) addOwner(kind, name string, abs func(string) (string, error)) {
+	dir, err := abs(".")
+	if err != nil {
+		return
+	}
+
+	w.owners[dir] = &usageEntry{Kind: kind, Name: name}
+}
+
+// ownerOf finds owner of the file by checking its parent dirs, for files not
+// in any known cache dir, a new owner is created using top level dir name
+func (w *cacheWalker) ownerOf(file string) *usageEntry {
+	for dir := filepath.Dir(file); len(dir) > len(w.cacheDir); dir = filepath.Dir(dir) {
+		if ent, ok := w.owners[dir]; ok {
+			return ent
+		}
+	}
+
+	rel, err := filepath.Rel(w.cacheDir, file)
+	if err != nil {
+		rel = file
+	}
+
+	parts := strings.SplitN(filepath.ToSlash(rel), "/", 3)
+
+	var (
+		dir  = w.cacheDir
+		kind = ownerKindOther
+		name = "."
+	)
+
+	switch {
+	case len(parts) == 1:
+	case len(parts) == 3 && parts[0] == ownerKindRenderer:
+		// renderer not configured
+		kind, name = ownerKindRenderer, parts[1]
+		dir = filepath.Join(w.cacheDir, parts[0], parts[1])
+	default:
+		name = parts[0]
+		dir = filepath.Join(w.cacheDir, parts[0])
+	}
+
+	ent, ok := w.owners[dir]
+	if !ok {
+		ent = &usageEntry{Kind: kind, Name: name}
+		w.owners[dir] = ent
+	}
+
+	return ent
+}
+
+// walk calls fn with every regular file in cache dir and its owner
+func (w *cacheWalker) walk(fn func(owner *usageEntry, file string, info fs.FileInfo) error) error {
+	err := filepath.WalkDir(w.cacheDir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		return fn(w.ownerOf(file), file, info)
+	})
+
+	if errors.Is(err, fs.ErrNotExist) {
+		// no cache
+		return nil
+	}
+
+	return err
+}
+
+// collectUsage returns cache usage of all owners with files in cache dir
+func (w *cacheWalker) collectUsage() ([]*usageEntry, error) {
+	err := w.walk(func(owner *usageEntry, _ string, info fs.FileInfo) error {
+		owner.Files++
+		owner.Size += info.Size()
+		if t := info.ModTime(); t.After(owner.LastUsed) {
+			owner.LastUsed = t
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*usageEntry
+	for _, ent := range w.owners {
+		if ent.Files != 0 {
+			ret = append(ret, ent)
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Kind != ret[j].Kind {
+			return ret[i].Kind < ret[j].Kind
+		}
+
+		return ret[i].Name < ret[j].Name
+	})
+
+	return ret, nil
+}
+
+// removeFiles removes files of owners selected by names (all when names is empty)
+// and accepted by filter, then removes empty dirs left in cache dir
+func (w *cacheWalker) removeFiles(
+	names []string,
+	dryRun bool,
+	filter func(info fs.FileInfo) bool,
+	onRemove func(file string, info fs.FileInfo),
+) error {
+	selected := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		selected[n] = struct{}{}
+	}
+
+	err := w.walk(func(owner *usageEntry, file string, info fs.FileInfo) error {
+		if len(selected) != 0 {
+			if _, ok := selected[owner.Name]; !ok {
+				return nil
+			}
+		}
+
+		if filter != nil && !filter(info) {
+			return nil
+		}
+
+		if !dryRun {
+			err := removeFile(file)
+			if err != nil {
+				return err
+			}
+		}
+
+		onRemove(file, info)
+		return nil
+	})
+	if err != nil || dryRun {
+		return err
+	}
+
+	return removeEmptyDirs(w.cacheDir)
+}
+
+// removeFile removes the file, making it and its parent dir writable if
+// necessary (e.g. read-only go module cache)
+func removeFile(file string) error {
+	err := os.Remove(file)
+	if err == nil || errors.Is(err, fs.ErrNotExist) || !errors.Is(err, fs.ErrPermission) {
+		return err
+	}
+
+	_ = os.Chmod(file, 0600)
+	dir := filepath.Dir(file)
+	if info, err2 := os.Stat(dir); err2 == nil {
+		_ = os.Chmod(dir, info.Mode().Perm()|0700)
+	}
+
+	return os.Remove(file)
+}
+
+// removeEmptyDirs removes all empty dirs inside root
+func removeEmptyDirs(root string) error {
+	var dirs []string
+	err := filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() && file != root {
+			dirs = append(dirs, file)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	// children first
+	for i := len(dirs) - 1; i >= 0; i-- {
+		entries, err := os.ReadDir(dirs[i])
+		if err != nil || len(entries) != 0 {
+			continue
+		}
+
+		_ = os.Remove(dirs[i])
+	}
+
+	return nil
+}
+
+// formatSize formats size in the same units accepted by cache config
+func formatSize(size int64) string {
+	const units = "KMGTP"
+
+	if size < 1024 {
+		return strconv.FormatInt(size, 10) + "B"
+	}
+
+	v, i := float64(size)/1024, 0
+	for ; v >= 1024 && i < len(units)-1; i++ {
+		v /= 1024
+	}
+
+	return strconv.FormatFloat(v, 'f', 1, 64) + units[i:i+1] + "B"
+}
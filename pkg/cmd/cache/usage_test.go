@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"arhat.dev/rs"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/tools"
+	"arhat.dev/dukkha/pkg/tools/workflow"
+)
+
+func newTestCacheWalker(t *testing.T) *cacheWalker {
+	cacheDir := t.TempDir()
+	ctx := dt.NewTestContext(context.TODO(), cacheDir)
+
+	tool := &workflow.Tool{}
+	tool.ToolName = "local"
+	rs.InitRecursively(reflect.ValueOf(tool), &rs.Options{
+		InterfaceTypeHandler: dukkha.GlobalInterfaceTypeHandler,
+	})
+	assert.NoError(t, tool.Init(ctx.ToolCacheFS(tool)))
+
+	tsk := tools.NewTask[workflow.TaskRun, *workflow.TaskRun]("local").(*workflow.TaskRun)
+	rs.InitRecursively(reflect.ValueOf(tsk), &rs.Options{
+		InterfaceTypeHandler: dukkha.GlobalInterfaceTypeHandler,
+	})
+	assert.NoError(t, yaml.Unmarshal([]byte("name: build"), tsk))
+	assert.NoError(t, tsk.Init(ctx.TaskCacheFS(tsk)))
+	assert.NoError(t, tool.AddTasks([]dukkha.Task{tsk}))
+	ctx.AddTool(tool.Key(), tool)
+
+	old := time.Now().Add(-48 * time.Hour)
+	for name, data := range map[string]string{
+		"renderer/http/a":            "1",
+		"renderer/http/b":            "22",
+		"workflow/local/tool-file":   "333",
+		"workflow/local/run/build/x": "4444",
+		"shared/foo/y":               "55555",
+		"top-level":                  "666666",
+	} {
+		file := filepath.Join(cacheDir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		assert.NoError(t, os.WriteFile(file, []byte(data), 0400))
+
+		if name == "renderer/http/a" || name == "shared/foo/y" {
+			assert.NoError(t, os.Chtimes(file, old, old))
+		}
+	}
+
+	w, err := newCacheWalker(ctx)
+	assert.NoError(t, err)
+	return w
+}
+
+func TestCacheWalker_collectUsage(t *testing.T) {
+	t.Parallel()
+
+	usage, err := newTestCacheWalker(t).collectUsage()
+	assert.NoError(t, err)
+
+	type entry struct {
+		kind, name string
+		size       int64
+		files      int
+	}
+
+	var actual []entry
+	for _, ent := range usage {
+		actual = append(actual, entry{ent.Kind, ent.Name, ent.Size, ent.Files})
+	}
+
+	assert.Equal(t, []entry{
+		{ownerKindOther, ".", 6, 1},
+		{ownerKindOther, "shared", 5, 1},
+		{ownerKindRenderer, "http", 3, 2},
+		{ownerKindTask, "workflow:local:run:build", 4, 1},
+		{ownerKindTool, "workflow:local", 3, 1},
+	}, actual)
+}
+
+func TestCacheWalker_removeFiles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Prune Selected", func(t *testing.T) {
+		w := newTestCacheWalker(t)
+
+		notBefore := time.Now().Add(-24 * time.Hour)
+		var removed []string
+		assert.NoError(t, w.removeFiles([]string{"http", "workflow:local"}, false,
+			func(info fs.FileInfo) bool { return info.ModTime().Before(notBefore) },
+			func(file string, _ fs.FileInfo) {
+				rel, _ := filepath.Rel(w.cacheDir, file)
+				removed = append(removed, filepath.ToSlash(rel))
+			},
+		))
+
+		assert.Equal(t, []string{"renderer/http/a"}, removed)
+		assert.FileExists(t, filepath.Join(w.cacheDir, "renderer", "http", "b"))
+		assert.FileExists(t, filepath.Join(w.cacheDir, "shared", "foo", "y"))
+	})
+
+	t.Run("Clean All", func(t *testing.T) {
+		w := newTestCacheWalker(t)
+
+		count := 0
+		assert.NoError(t, w.removeFiles(nil, false, nil, func(string, fs.FileInfo) { count++ }))
+		assert.Equal(t, 6, count)
+
+		entries, err := os.ReadDir(w.cacheDir)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 0)
+	})
+
+	t.Run("Dry Run", func(t *testing.T) {
+		w := newTestCacheWalker(t)
+
+		count := 0
+		assert.NoError(t, w.removeFiles(nil, true, nil, func(string, fs.FileInfo) { count++ }))
+		assert.Equal(t, 6, count)
+		assert.FileExists(t, filepath.Join(w.cacheDir, "top-level"))
+	})
+}
+
+func TestFormatSize(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		size     int64
+		expected string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1024, "1.0KB"},
+		{1536, "1.5KB"},
+		{5 * 1024 * 1024 * 1024, "5.0GB"},
+	} {
+		assert.Equal(t, test.expected, formatSize(test.size))
+	}
+}
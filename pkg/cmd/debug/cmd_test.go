@@ -111,6 +111,7 @@ func prepareDebugCmd(flags []string, cacheDir string) (checkFlags func() error,
 	ctx := dukkha_test.NewTestContext(context.TODO(), cacheDir)
 
 	config := conf.NewConfig()
+	sources := new(conf.Sources)
 	err := conf.Read(
 		ctx,
 		&conf.ReadSpec{
@@ -118,6 +119,7 @@ func prepareDebugCmd(flags []string, cacheDir string) (checkFlags func() error,
 			ConfFS:       os.DirFS("./testdata"),
 			VisitedPaths: &map[string]struct{}{},
 			MergedConfig: config,
+			Sources:      sources,
 		},
 		synchain.NewSynchain(),
 		[]string{"."},
@@ -142,7 +144,10 @@ func prepareDebugCmd(flags []string, cacheDir string) (checkFlags func() error,
 		NewDebugTaskSpecCmd(&appCtx, opts),
 	)
 
-	debugCmd.AddCommand(debugTaskCmd)
+	debugCmd.AddCommand(
+		NewDebugConfigCmd(&appCtx, opts, config, sources),
+		debugTaskCmd,
+	)
 	debugCmd.SetArgs(flags)
 	return func() error {
 		// TODO: test bad flags, currently always return nil due to we want
@@ -1,3 +1,304 @@
 package debug
 
-// TODO: debug dukkha config
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"arhat.dev/pkg/textquery"
+	"arhat.dev/rs"
+	"github.com/itchyny/gojq"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/conf"
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+// configSourceKinds are kinds of config entries in output order
+var configSourceKinds = []conf.SourceKind{
+	conf.SourceKind_Global,
+	conf.SourceKind_Env,
+	conf.SourceKind_Value,
+	conf.SourceKind_Renderer,
+	conf.SourceKind_Shell,
+	conf.SourceKind_Tool,
+	conf.SourceKind_Task,
+}
+
+func NewDebugConfigCmd(
+	ctx *dukkha.Context,
+	opts *Options,
+	config *conf.Config,
+	sources *conf.Sources,
+) *cobra.Command {
+	var (
+		depth int
+	)
+
+	validArgs := make([]string, len(configSourceKinds))
+	for i, k := range configSourceKinds {
+		validArgs[i] = string(k)
+	}
+
+	debugConfigCmd := &cobra.Command{
+		Use:   "config [kind...]",
+		Short: "Show merged config entries with their sources in json",
+		Long: "Show config entries merged from all config files, each entry is annotated with " +
+			"the file and line where it was defined, entries can be filtered by kind, one of [" +
+			strings.Join(validArgs, ", ") + "]",
+
+		Args:          cobra.OnlyValidArgs,
+		ValidArgs:     validArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+
+		CompletionOptions: cobra.CompletionOptions{
+			DisableDefaultCmd:   false,
+			DisableNoDescFlag:   false,
+			DisableDescriptions: true,
+		},
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			appCtx := *ctx
+			appCtx = appCtx.DeriveNew()
+
+			stderr := appCtx.Stderr()
+
+			query, err := opts.getQuery()
+			if err != nil {
+				return err
+			}
+
+			for _, e := range filterConfigEntries(sources.Entries(), args) {
+				err = debugConfigEntry(appCtx, opts, query, config, e, depth)
+				if err != nil {
+					_, _ = fmt.Fprintln(stderr, err.Error())
+				}
+			}
+
+			return nil
+		},
+	}
+
+	debugConfigCmd.Flags().IntVarP(&depth, "depth", "d", 0,
+		"resolve config entries up to this depth before printing, "+
+			"0 to print raw config as written, -1 to resolve all fields",
+	)
+
+	debugConfigCmd.SetHelpCommand(&cobra.Command{
+		SilenceUsage: true,
+		Hidden:       true,
+	})
+
+	return debugConfigCmd
+}
+
+type ConfigHeaderLineData struct {
+	Kind conf.SourceKind
+	Key  string
+	Name string
+
+	File string
+	Line int
+}
+
+func (s ConfigHeaderLineData) json() string {
+	var parts []string
+	parts = append(parts, `"kind": "`+string(s.Kind)+`"`)
+	if len(s.Key) != 0 {
+		parts = append(parts, `"key": "`+s.Key+`"`)
+	}
+
+	parts = append(parts, `"name": "`+s.Name+`"`)
+	parts = append(parts, `"source": "`+s.File+":"+strconv.Itoa(s.Line)+`"`)
+
+	return `{ ` + strings.Join(parts, `, `) + ` }`
+}
+
+// filterConfigEntries selects entries with kinds (all if not set) and sort
+// them by kind, entries of the same kind are kept in merged order
+func filterConfigEntries(entries []*conf.SourceEntry, kinds []string) []*conf.SourceEntry {
+	rank := make(map[conf.SourceKind]int, len(configSourceKinds))
+	for i, k := range configSourceKinds {
+		if len(kinds) == 0 {
+			rank[k] = i
+			continue
+		}
+
+		for _, want := range kinds {
+			if string(k) == want {
+				rank[k] = i
+			}
+		}
+	}
+
+	var ret []*conf.SourceEntry
+	for _, e := range entries {
+		if _, ok := rank[e.Kind]; ok {
+			ret = append(ret, e)
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return rank[ret[i].Kind] < rank[ret[j].Kind]
+	})
+
+	return ret
+}
+
+func debugConfigEntry(
+	appCtx dukkha.Context,
+	opts *Options,
+	query *gojq.Query,
+	config *conf.Config,
+	e *conf.SourceEntry,
+	depth int,
+) error {
+	name := e.Name
+	switch obj := e.Object.(type) {
+	case dukkha.Tool:
+		name = string(obj.Name())
+	case dukkha.Task:
+		name = string(obj.Name())
+	}
+
+	header := ConfigHeaderLineData{
+		Kind: e.Kind,
+		Key:  e.Key,
+		Name: name,
+		File: e.File,
+		Line: e.Line,
+	}
+
+	var (
+		data any
+		err  error
+	)
+
+	if depth == 0 {
+		err = e.Node.Decode(&data)
+	} else {
+		data, err = resolveConfigEntry(appCtx, config, e, depth)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", header.json(), err)
+	}
+
+	if query != nil {
+		var ret []any
+		ret, err = textquery.RunQuery(query, data, nil)
+		if err != nil {
+			return err
+		}
+
+		switch len(ret) {
+		case 0:
+			data = nil
+		case 1:
+			data = ret[0]
+		default:
+			data = ret
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(data)
+	if err != nil {
+		return err
+	}
+
+	stdout, stderr := appCtx.Stdout(), appCtx.Stderr()
+	err = opts.writeHeader(stdout, stderr, header.json())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(stdout, &buf)
+	return err
+}
+
+// resolveConfigEntry resolves fields of the config entry up to depth, entries not
+// decoded when reading config are returned as written
+func resolveConfigEntry(
+	appCtx dukkha.Context,
+	config *conf.Config,
+	e *conf.SourceEntry,
+	depth int,
+) (data any, err error) {
+	var obj rs.Field
+	switch e.Kind {
+	case conf.SourceKind_Global:
+		// name of global options can have rendering suffix
+		field, _, _ := strings.Cut(e.Name, "@")
+		if field == "global" {
+			// whole global config is using rendering suffix
+			err = config.ResolveFields(appCtx, depth, "global")
+			field = ""
+		} else {
+			err = config.Global.ResolveFields(appCtx, depth, field)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		data, err = toGenericData(&config.Global)
+		if err != nil || len(field) == 0 {
+			return
+		}
+
+		m, _ := data.(map[string]any)
+		return m[field], nil
+	case conf.SourceKind_Value:
+		v, ok := config.Global.Values.Data[e.Name]
+		if !ok {
+			break
+		}
+
+		err = v.ResolveFields(appCtx, depth)
+		if err != nil {
+			return nil, err
+		}
+
+		return v.NormalizedValue(), nil
+	default:
+		obj = e.Object
+	}
+
+	if obj == nil {
+		err = e.Node.Decode(&data)
+		return
+	}
+
+	err = obj.ResolveFields(appCtx, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	return toGenericData(obj)
+}
+
+func toGenericData(obj any) (data any, err error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	err = enc.Encode(obj)
+	if err != nil {
+		return
+	}
+
+	err = enc.Close()
+	if err != nil {
+		return
+	}
+
+	err = yaml.NewDecoder(&buf).Decode(&data)
+	return
+}
@@ -12,13 +12,13 @@ import (
 
 	"arhat.dev/dukkha/pkg/cmd/utils"
 	"arhat.dev/dukkha/pkg/dukkha"
-	"arhat.dev/dukkha/pkg/matrix"
 	"arhat.dev/dukkha/pkg/sliceutils"
 )
 
 func NewDebugTaskMatrixCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
 	var (
 		matrixFilter []string
+		matrixShard  string
 	)
 
 	debugTaskMatrixCmd := &cobra.Command{
@@ -38,7 +38,12 @@ func NewDebugTaskMatrixCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			appCtx := *ctx
 			appCtx = appCtx.DeriveNew()
-			appCtx.SetMatrixFilter(matrix.ParseMatrixFilter(matrixFilter))
+			mFilter, err := utils.ParseMatrixFilter(matrixFilter, matrixShard)
+			if err != nil {
+				return err
+			}
+
+			appCtx.SetMatrixFilter(mFilter)
 
 			query, err := opts.getQuery()
 			if err != nil {
@@ -74,11 +79,7 @@ func NewDebugTaskMatrixCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
 					if query != nil {
 						var ret []any
 						for _, ms := range matrixSpecs {
-							ent := make(map[string]any)
-							for k, v := range ms {
-								ent[k] = v
-							}
-							ret = append(ret, ent)
+							ret = append(ret, ms.Values())
 						}
 
 						ret, err = textquery.RunQuery(query, ret, nil)
@@ -107,7 +108,7 @@ func NewDebugTaskMatrixCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
 							buf.WriteString(",")
 						}
 						buf.WriteString("\n" + `  { "`)
-						buf.WriteString(strings.Join(sliceutils.FormatStringMap(ms, `": "`, false), `", "`))
+						buf.WriteString(strings.Join(sliceutils.FormatStringMap(ms.Flatten(), `": "`, false), `", "`))
 						buf.WriteString(`" }`)
 					}
 
@@ -126,6 +127,7 @@ func NewDebugTaskMatrixCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
 
 	flags := debugTaskMatrixCmd.Flags()
 	utils.RegisterMatrixFilterFlag(flags, &matrixFilter)
+	utils.RegisterMatrixShardFlag(flags, &matrixShard)
 	err := utils.SetupTaskAndTaskMatrixCompletion(ctx, debugTaskMatrixCmd)
 	if err != nil {
 		panic(err)
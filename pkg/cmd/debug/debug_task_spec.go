@@ -12,13 +12,13 @@ import (
 
 	"arhat.dev/dukkha/pkg/cmd/utils"
 	"arhat.dev/dukkha/pkg/dukkha"
-	"arhat.dev/dukkha/pkg/matrix"
 	"arhat.dev/dukkha/pkg/tools"
 )
 
 func NewDebugTaskSpecCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
 	var (
 		matrixFilter []string
+		matrixShard  string
 	)
 
 	debugTaskSpecCmd := &cobra.Command{
@@ -40,7 +40,12 @@ func NewDebugTaskSpecCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			appCtx := *ctx
 			appCtx = appCtx.DeriveNew()
-			appCtx.SetMatrixFilter(matrix.ParseMatrixFilter(matrixFilter))
+			mFilter, err := utils.ParseMatrixFilter(matrixFilter, matrixShard)
+			if err != nil {
+				return err
+			}
+
+			appCtx.SetMatrixFilter(mFilter)
 
 			stdout, stderr := appCtx.Stdout(), appCtx.Stderr()
 
@@ -122,7 +127,7 @@ func NewDebugTaskSpecCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
 								ToolName: tool.Name(),
 								TaskKind: task.Kind(),
 								TaskName: task.Name(),
-								Matrix:   ms,
+								Matrix:   ms.Flatten(),
 							}.json())
 							if err != nil {
 								return err
@@ -144,6 +149,7 @@ func NewDebugTaskSpecCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
 
 	flags := debugTaskSpecCmd.Flags()
 	utils.RegisterMatrixFilterFlag(flags, &matrixFilter)
+	utils.RegisterMatrixShardFlag(flags, &matrixShard)
 	err := utils.SetupTaskAndTaskMatrixCompletion(ctx, debugTaskSpecCmd)
 	if err != nil {
 		panic(err)
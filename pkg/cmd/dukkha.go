@@ -30,6 +30,8 @@ import (
 	"arhat.dev/pkg/versionhelper"
 	"github.com/spf13/cobra"
 
+	"arhat.dev/dukkha/pkg/cmd/as"
+	"arhat.dev/dukkha/pkg/cmd/cache"
 	"arhat.dev/dukkha/pkg/cmd/completion"
 	"arhat.dev/dukkha/pkg/cmd/debug"
 	"arhat.dev/dukkha/pkg/cmd/diff"
@@ -44,6 +46,7 @@ import (
 	"arhat.dev/dukkha/pkg/renderer/tlang"
 	"arhat.dev/dukkha/pkg/renderer/tmpl"
 	"arhat.dev/dukkha/pkg/renderer/transform"
+	"arhat.dev/dukkha/pkg/utils"
 )
 
 // NewRootCmd creates the dukkha command with all sub commands added
@@ -56,11 +59,20 @@ func NewRootCmd(prevCtx dukkha.Context) *cobra.Command {
 		configPaths []string
 		// merged config
 		config = conf.NewConfig()
+		// sources of merged config entries
+		configSources = new(conf.Sources)
 
 		appCtx                     = prevCtx
 		appBaseCtx context.Context = prevCtx
+
+		// masker of secret values in returned errors
+		masker *utils.Masker
 	)
 
+	if prevCtx != nil {
+		masker = prevCtx.Masker()
+	}
+
 	if appBaseCtx == nil { // started from outside, e.g. cli
 		var cancel context.CancelFunc
 
@@ -120,8 +132,6 @@ func NewRootCmd(prevCtx dukkha.Context) *cobra.Command {
 				return fmt.Errorf("init logger: %w", err)
 			}
 
-			logger := log.Log.WithName("pre-run")
-
 			cwd, err := os.Getwd()
 			if err != nil {
 				return fmt.Errorf("check working dir: %w", err)
@@ -134,6 +144,11 @@ func NewRootCmd(prevCtx dukkha.Context) *cobra.Command {
 			)
 			bootstrapCtx.AddListEnv(os.Environ()...)
 
+			// mask secret values registered during rendering in all logs
+			masker = bootstrapCtx.Masker()
+			log.Log = utils.NewMaskingLogger(log.Log, masker)
+			logger := log.Log.WithName("pre-run")
+
 			// add essential renderers for bootstraping
 			{
 				logger.V("creating essential renderers")
@@ -167,6 +182,7 @@ func NewRootCmd(prevCtx dukkha.Context) *cobra.Command {
 					}),
 					VisitedPaths: &visitedPaths,
 					MergedConfig: config,
+					Sources:      configSources,
 				},
 				synchain.NewSynchain(),
 				configPaths,
@@ -225,6 +241,7 @@ func NewRootCmd(prevCtx dukkha.Context) *cobra.Command {
 	)
 
 	debugCmd.AddCommand(
+		debug.NewDebugConfigCmd(&appCtx, debugCmdOpts, config, configSources),
 		debugTaskCmd,
 	)
 
@@ -241,7 +258,37 @@ func NewRootCmd(prevCtx dukkha.Context) *cobra.Command {
 		run.NewRunCmd(&appCtx),
 		// dukkha diff
 		diff.NewDiffCmd(&appCtx),
+		// dukkha as
+		as.NewAsCmd(&appCtx),
+		// dukkha cache
+		cache.NewCacheCmd(&appCtx),
 	)
 
+	// errors may contain resolved commands with secret values
+	maskErrors(rootCmd, func() *utils.Masker { return masker })
+
 	return rootCmd
 }
+
+// maskErrors wraps PersistentPreRunE and RunE of cmd and all its sub commands
+// to mask secret values in returned errors
+func maskErrors(cmd *cobra.Command, getMasker func() *utils.Masker) {
+	wrap := func(
+		run func(cmd *cobra.Command, args []string) error,
+	) func(cmd *cobra.Command, args []string) error {
+		if run == nil {
+			return nil
+		}
+
+		return func(cmd *cobra.Command, args []string) error {
+			return getMasker().MaskError(run(cmd, args))
+		}
+	}
+
+	cmd.PersistentPreRunE = wrap(cmd.PersistentPreRunE)
+	cmd.RunE = wrap(cmd.RunE)
+
+	for _, sub := range cmd.Commands() {
+		maskErrors(sub, getMasker)
+	}
+}
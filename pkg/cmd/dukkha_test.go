@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"io"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+
+	"arhat.dev/dukkha/pkg/utils"
+)
+
+func TestMaskErrors(t *testing.T) {
+	t.Parallel()
+
+	masker := utils.NewMasker()
+	masker.Add("some-secret-value")
+
+	rootCmd := &cobra.Command{
+		Use:           "root",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	rootCmd.AddCommand(&cobra.Command{
+		Use: "sub",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return &cmdError{msg: "preparing command [ login some-secret-value ]"}
+		},
+	})
+
+	maskErrors(rootCmd, func() *utils.Masker { return masker })
+
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetArgs([]string{"sub"})
+	err := rootCmd.Execute()
+	assert.EqualError(t, err, "preparing command [ login *** ]")
+
+	cmdErr := new(cmdError)
+	assert.ErrorAs(t, err, &cmdErr)
+}
+
+type cmdError struct{ msg string }
+
+func (e *cmdError) Error() string { return e.msg }
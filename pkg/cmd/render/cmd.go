@@ -43,7 +43,7 @@ func createOptionsFlags(cmd *cobra.Command, opts *Options) {
 		"set output destionation for specified inputs (args)",
 	)
 	flags.StringVarP(&opts.outputFormat, "output-format", "f", "yaml",
-		"set output format, one of [json, yaml]",
+		"set output format, one of [json, yaml, toml, ini, hcl]",
 	)
 	flags.IntVarP(&opts.indentSize, "indent-size", "n", 2,
 		"set indent size",
@@ -10,7 +10,10 @@ import (
 	"arhat.dev/pkg/textquery"
 	"arhat.dev/rs"
 	"github.com/itchyny/gojq"
+	"github.com/pelletier/go-toml/v2"
 	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/templateutils"
 )
 
 type encoder interface {
@@ -111,6 +114,19 @@ func newEncoder(
 		enc := yaml.NewEncoder(w)
 		enc.SetIndent(indentSize)
 		encImpl = enc.Encode
+	case "toml":
+		enc := toml.NewEncoder(w)
+		enc.SetIndentTables(true).SetIndentSymbol(strings.Repeat(indentStr, indentSize))
+		encImpl = enc.Encode
+	case "ini":
+		encImpl = func(v interface{}) error {
+			return templateutils.EncodeINI(w, v, false)
+		}
+	case "hcl":
+		indent := strings.Repeat(indentStr, indentSize)
+		encImpl = func(v interface{}) error {
+			return templateutils.EncodeHCL(w, v, indent)
+		}
 	default:
 		return nil, fmt.Errorf("unknown output format %q", outputFormat)
 	}
@@ -0,0 +1,46 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"arhat.dev/rs"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestNewEncoder(t *testing.T) {
+	const src = `
+a: b
+c:
+  d: 1
+`
+
+	for _, test := range []struct {
+		format   string
+		expected string
+	}{
+		{"json", "{\n  \"a\": \"b\",\n  \"c\": {\n    \"d\": 1\n  }\n}\n"},
+		{"yaml", "a: b\nc:\n  d: 1\n"},
+		{"toml", "a = 'b'\n\n[c]\n  d = 1\n"},
+		{"ini", "a = b\n\n[c]\nd = 1\n"},
+		{"hcl", "a = \"b\"\nc {\n  d = 1\n}\n"},
+	} {
+		t.Run(test.format, func(t *testing.T) {
+			obj := rs.Init(&rs.AnyObject{}, nil).(*rs.AnyObject)
+			assert.NoError(t, yaml.Unmarshal([]byte(src), obj))
+
+			var buf bytes.Buffer
+			enc, err := newEncoder(nil, &buf, test.format, " ", 2)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.NoError(t, enc.Encode(obj))
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+
+	_, err := newEncoder(nil, &bytes.Buffer{}, "unknown", " ", 2)
+	assert.Error(t, err)
+}
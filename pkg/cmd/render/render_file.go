@@ -91,6 +91,8 @@ func renderYamlFile(
 		case "json":
 			// change extension name
 			dest = strings.TrimSuffix(dest, path.Ext(srcPath)) + ".json"
+		case "toml", "ini", "hcl":
+			dest = strings.TrimSuffix(dest, path.Ext(srcPath)) + "." + opts.outputFormat
 		case "yaml":
 			// do nothing since source file is yaml as well
 			// no matter .yml or .yaml
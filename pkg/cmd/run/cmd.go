@@ -5,34 +5,52 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"go.uber.org/multierr"
 	"golang.org/x/term"
 
 	"arhat.dev/dukkha/pkg/cmd/utils"
 	"arhat.dev/dukkha/pkg/dukkha"
-	"arhat.dev/dukkha/pkg/matrix"
+	"arhat.dev/dukkha/pkg/report"
+	"arhat.dev/dukkha/pkg/tools"
 )
 
 func NewRunCmd(ctx *dukkha.Context) *cobra.Command {
 	var (
 		workerCount  = int(1)
 		failFast     = false
+		force        = false
+		reportFile   string
+		reportFormat string
 		forceColor   = false
 		matrixFilter []string
+		matrixShard  string
 
 		translateANSIStream = false
 		retainANSIStyle     = false
 	)
 
 	runCmd := &cobra.Command{
-		Use:   "run <tool-kind> <tool-name> <task-kind> <task-name>",
+		Use:   "run <tool-kind> <tool-name> <task-kind> <task-name> [<tool-kind> <tool-name> <task-kind> <task-name>...]",
 		Short: "Run your task",
+		Long: `Run your task
+
+When multiple tasks are given, they are executed along with their dependencies
+(defined in depends_on) in dependency order, every task is executed at most once
+for each matrix entry`,
 		Example: `dukkha run buildah local build my-image
-dukkha run golang in-docker build my-executable`,
+dukkha run golang in-docker build my-executable
+dukkha run golang local test my-pkg golang local build my-executable`,
 
 		SilenceErrors: true,
 		SilenceUsage:  true,
 
-		Args: cobra.ExactArgs(4),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 || len(args)%4 != 0 {
+				return fmt.Errorf("expecting multiple of 4 args, got %d", len(args))
+			}
+
+			return nil
+		},
 
 		CompletionOptions: cobra.CompletionOptions{
 			DisableDefaultCmd:   true,
@@ -57,25 +75,54 @@ dukkha run golang in-docker build my-executable`,
 
 			actualRetainANSIStyle := actualTranslateANSIStream && retainANSIStyle
 
+			switch reportFormat {
+			case report.FormatJSON, report.FormatJUnit:
+			default:
+				return fmt.Errorf("unsupported report format %q", reportFormat)
+			}
+
+			var runReport *report.Report
+			if len(reportFile) != 0 {
+				runReport = report.NewReport()
+			}
+
 			appCtx.SetRuntimeOptions(dukkha.RuntimeOptions{
 				FailFast:            failFast,
 				ColorOutput:         stdoutIsPty || forceColor,
 				TranslateANSIStream: actualTranslateANSIStream,
 				RetainANSIStyle:     actualRetainANSIStyle,
 				Workers:             workerCount,
+				Force:               force,
+				Report:              runReport,
 			})
 
-			appCtx.SetMatrixFilter(matrix.ParseMatrixFilter(matrixFilter))
+			mFilter, err := utils.ParseMatrixFilter(matrixFilter, matrixShard)
+			if err != nil {
+				return err
+			}
+
+			appCtx.SetMatrixFilter(mFilter)
 
-			return run(appCtx, args)
+			err = run(appCtx, args)
+			if runReport == nil {
+				return err
+			}
+
+			return multierr.Append(err, writeReport(runReport, reportFile, reportFormat))
 		},
 	}
 
 	flags := runCmd.Flags()
 
 	utils.RegisterMatrixFilterFlag(flags, &matrixFilter)
+	utils.RegisterMatrixShardFlag(flags, &matrixShard)
 	flags.IntVarP(&workerCount, "workers", "j", 1, "set parallel worker count")
 	flags.BoolVar(&failFast, "fail-fast", true, "cancel all task execution after one errored")
+	flags.BoolVar(&force, "force", false, "run tasks even when their inputs and outputs are unchanged")
+	flags.StringVar(&reportFile, "report", "", "write task execution report to this file")
+	flags.StringVar(&reportFormat, "report-format", report.FormatJSON,
+		"set format of the task execution report, one of [json, junit]",
+	)
 	flags.BoolVar(&forceColor, "force-color", false, "force color output even when not given a tty")
 	flags.BoolVar(&translateANSIStream, "translate-ansi-stream", false,
 		"when set to true, will translate ansi stream to plain text before write to stdout/stderr, "+
@@ -92,6 +139,14 @@ dukkha run golang in-docker build my-executable`,
 		panic(err)
 	}
 
+	// complete args of the task being typed
+	completeTask := runCmd.ValidArgsFunction
+	runCmd.ValidArgsFunction = func(
+		cmd *cobra.Command, args []string, toComplete string,
+	) ([]string, cobra.ShellCompDirective) {
+		return completeTask(cmd, args[len(args)/4*4:], toComplete)
+	}
+
 	runCmd.SetHelpCommand(&cobra.Command{
 		SilenceUsage: true,
 		Hidden:       true,
@@ -102,18 +157,38 @@ dukkha run golang in-docker build my-executable`,
 
 func run(appCtx dukkha.Context, args []string) error {
 	// defensive check, arg count should be guarded by cobra
-	if len(args) != 4 {
-		return fmt.Errorf("expecting 4 args, got %d", len(args))
+	if len(args) == 0 || len(args)%4 != 0 {
+		return fmt.Errorf("expecting multiple of 4 args, got %d", len(args))
 	}
 
-	return appCtx.RunTask(
-		dukkha.ToolKey{
-			Kind: dukkha.ToolKind(args[0]),
-			Name: dukkha.ToolName(args[1]),
-		},
-		dukkha.TaskKey{
-			Kind: dukkha.TaskKind(args[2]),
-			Name: dukkha.TaskName(args[3]),
-		},
-	)
+	targets := make([]tools.TaskTarget, 0, len(args)/4)
+	for i := 0; i < len(args); i += 4 {
+		targets = append(targets, tools.TaskTarget{
+			Tool: dukkha.ToolKey{
+				Kind: dukkha.ToolKind(args[i]),
+				Name: dukkha.ToolName(args[i+1]),
+			},
+			Task: dukkha.TaskKey{
+				Kind: dukkha.TaskKind(args[i+2]),
+				Name: dukkha.TaskName(args[i+3]),
+			},
+		})
+	}
+
+	return tools.RunTaskGraph(appCtx, targets)
+}
+
+func writeReport(r *report.Report, file, format string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("creating report file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	err = r.Write(f, format)
+	if err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+
+	return nil
 }
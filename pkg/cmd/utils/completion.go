@@ -7,16 +7,55 @@ import (
 	"github.com/spf13/pflag"
 
 	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/matrix"
 )
 
-const MatrixFilterFlagName = "matrix"
+const (
+	MatrixFilterFlagName = "matrix"
+	MatrixShardFlagName  = "matrix-shard"
+)
 
 func RegisterMatrixFilterFlag(flags *pflag.FlagSet, matrixFilter *[]string) {
 	flags.StringSliceVarP(matrixFilter, MatrixFilterFlagName, "m", nil,
-		"set matrix filter, format: `-m <name>=<value>` to match, `-m <name>!=<value>` to filter out",
+		"set matrix filter, format: `-m <name>=<value>` to match, `-m <name>!=<value>` to filter out, "+
+			"values can be glob patterns, expressions like `-m 'arch in (amd64,arm64)'` "+
+			"and `-m 'kernel=linux && !(arch=~^mips)'` are also supported",
 	)
 }
 
+func RegisterMatrixShardFlag(flags *pflag.FlagSet, matrixShard *string) {
+	flags.StringVar(matrixShard, MatrixShardFlagName, "",
+		"only use the i-th of n shards of matched matrix entries, format: `<i>/<n>` (1-based)",
+	)
+}
+
+// ParseMatrixFilter parses values of matrix filter and matrix shard flags
+func ParseMatrixFilter(matrixFilter []string, matrixShard string) (matrix.Filter, error) {
+	ret, err := matrix.ParseFilter(matrixFilter)
+	if err != nil {
+		return ret, err
+	}
+
+	if len(matrixShard) != 0 {
+		index, total, err := matrix.ParseShard(matrixShard)
+		if err != nil {
+			return ret, err
+		}
+
+		ret.SetShard(index, total)
+	}
+
+	return ret, nil
+}
+
+func SetupToolCompletion(ctx *dukkha.Context, cmd *cobra.Command) {
+	cmd.ValidArgsFunction = func(
+		cmd *cobra.Command, args []string, toComplete string,
+	) ([]string, cobra.ShellCompDirective) {
+		return handleToolCompletion(*ctx, args, toComplete)
+	}
+}
+
 func SetupTaskCompletion(ctx *dukkha.Context, cmd *cobra.Command) {
 	cmd.ValidArgsFunction = func(
 		cmd *cobra.Command, args []string, toComplete string,
@@ -14,10 +14,13 @@ func handleTaskMatrixCompletion(
 	existingFilters []string,
 	args []string, toComplete string,
 ) ([]string, cobra.ShellCompDirective) {
-	if len(args) != 4 {
+	// commands may accept multiple tasks, complete matrix of the last one
+	if len(args) == 0 || len(args)%4 != 0 {
 		return nil, cobra.ShellCompDirectiveError
 	}
 
+	args = args[len(args)-4:]
+
 	k := dukkha.ToolKey{
 		Kind: dukkha.ToolKind(args[0]),
 		Name: dukkha.ToolName(args[1]),
@@ -54,6 +57,9 @@ func handleTaskMatrixCompletion(
 		return nil, cobra.ShellCompDirectiveError
 	}
 
+	// complete the last condition of filter expression
+	head, toComplete := splitFilterExprTail(toComplete)
+
 	usedPairs := make(map[string]struct{})
 	for _, v := range existingFilters {
 		usedPairs[v] = struct{}{}
@@ -62,7 +68,7 @@ func handleTaskMatrixCompletion(
 	var values []string
 	visited := make(map[string]struct{})
 	for _, spec := range mSpecs {
-		for k, v := range spec {
+		for k, v := range spec.Flatten() {
 			val := k + "=" + v
 			_, ok := usedPairs[val]
 			if ok {
@@ -77,7 +83,7 @@ func handleTaskMatrixCompletion(
 				continue
 			}
 
-			values = append(values, val)
+			values = append(values, head+val)
 			visited[val] = struct{}{}
 		}
 	}
@@ -85,3 +91,35 @@ func handleTaskMatrixCompletion(
 
 	return values, cobra.ShellCompDirectiveNoFileComp
 }
+
+// splitFilterExprTail splits matrix filter expression s into head and the
+// condition being typed at the end (tail), which starts after the last `&&`,
+// `||`, `!` or `(` not belonging to an operator or value list
+func splitFilterExprTail(s string) (head, tail string) {
+	pos := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case strings.HasPrefix(s[i:], "&&"), strings.HasPrefix(s[i:], "||"):
+			i++
+			pos = i + 1
+		case c == '!':
+			if i+1 < len(s) && (s[i+1] == '=' || s[i+1] == '~') {
+				i++
+				continue
+			}
+
+			pos = i + 1
+		case c == '(':
+			// `(` starting a group, not a value list like `in (...)`
+			if len(strings.TrimSpace(s[pos:i])) == 0 {
+				pos = i + 1
+			}
+		}
+	}
+
+	for pos < len(s) && (s[pos] == ' ' || s[pos] == '\t') {
+		pos++
+	}
+
+	return s[:pos], s[pos:]
+}
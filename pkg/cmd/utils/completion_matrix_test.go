@@ -70,6 +70,18 @@ func TestHandleTaskMatrixCompletion(t *testing.T) {
 				directive:  cobra.ShellCompDirectiveNoFileComp,
 			},
 		},
+		{
+			name:       "Expression",
+			existing:   []string{"-m"},
+			args:       []string{"workflow", "local", "run", "test"},
+			toComplete: "b=b && !(a",
+			expected: Result{
+				candidates: []string{
+					"b=b && !(a=a1", "b=b && !(a=a2",
+				},
+				directive: cobra.ShellCompDirectiveNoFileComp,
+			},
+		},
 	} {
 		ctx := newCompletionContext(t)
 
@@ -82,3 +94,26 @@ func TestHandleTaskMatrixCompletion(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitFilterExprTail(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		input string
+		head  string
+		tail  string
+	}{
+		{"", "", ""},
+		{"a=a1", "", "a=a1"},
+		{"a!=a1", "", "a!=a1"},
+		{"a=a1 && b", "a=a1 && ", "b"},
+		{"a=a1||!b", "a=a1||!", "b"},
+		{"(a=a1 || b=b) && !(b=~^c", "(a=a1 || b=b) && !(", "b=~^c"},
+		{"a in (a1", "", "a in (a1"},
+		{"b=b && a in (a1", "b=b && ", "a in (a1"},
+	} {
+		head, tail := splitFilterExprTail(test.input)
+		assert.Equal(t, test.head, head, test.input)
+		assert.Equal(t, test.tail, tail, test.input)
+	}
+}
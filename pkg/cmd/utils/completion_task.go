@@ -94,6 +94,40 @@ func handleTaskCompletion(
 	return ret, cobra.ShellCompDirectiveNoFileComp
 }
 
+func handleToolCompletion(
+	appCtx dukkha.Context,
+	args []string,
+	toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	var (
+		ret []string
+	)
+
+	switch len(args) {
+	case 0:
+		ret = tryFindToolKinds(
+			appCtx.AllTools(), toComplete,
+		)
+	case 1:
+		ret = tryFindToolNames(
+			appCtx.AllTools(),
+			dukkha.ToolKind(args[0]),
+			toComplete,
+		)
+	default:
+		// args to the tool
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	if len(ret) == 0 {
+		return nil, cobra.ShellCompDirectiveNoSpace
+	}
+
+	sort.Strings(ret)
+
+	return ret, cobra.ShellCompDirectiveNoFileComp
+}
+
 func tryFindToolKinds(
 	allTools map[dukkha.ToolKey]dukkha.Tool,
 	toComplete string,
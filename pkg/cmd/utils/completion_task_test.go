@@ -192,3 +192,68 @@ func TestHandleTaskCompletion(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleToolCompletion(t *testing.T) {
+	t.Parallel()
+
+	type Result struct {
+		candidates []string
+		directive  cobra.ShellCompDirective
+	}
+
+	for _, test := range []struct {
+		name string
+
+		args       []string
+		toComplete string
+
+		expected Result
+	}{
+		{
+			name:       "Tool Kind",
+			args:       []string{},
+			toComplete: "",
+			expected: Result{
+				candidates: []string{"workflow"},
+				directive:  cobra.ShellCompDirectiveNoFileComp,
+			},
+		},
+		{
+			name:       "Tool Name",
+			args:       []string{"workflow"},
+			toComplete: "l",
+			expected: Result{
+				candidates: []string{"local"},
+				directive:  cobra.ShellCompDirectiveNoFileComp,
+			},
+		},
+		{
+			name:       "Non Existing Tool Name",
+			args:       []string{"workflow"},
+			toComplete: "FOO",
+			expected: Result{
+				candidates: nil,
+				directive:  cobra.ShellCompDirectiveNoSpace,
+			},
+		},
+		{
+			name:       "Tool Args",
+			args:       []string{"workflow", "local"},
+			toComplete: "",
+			expected: Result{
+				candidates: nil,
+				directive:  cobra.ShellCompDirectiveDefault,
+			},
+		},
+	} {
+		ctx := newCompletionContext(t)
+
+		t.Run(test.name, func(t *testing.T) {
+			actualCandidates, directive := handleToolCompletion(
+				ctx, test.args, test.toComplete,
+			)
+			assert.EqualValues(t, test.expected.candidates, actualCandidates)
+			assert.EqualValues(t, test.expected.directive, directive)
+		})
+	}
+}
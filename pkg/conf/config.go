@@ -51,6 +51,14 @@ type Config struct {
 	// With path glob pattern '*' and '**' support
 	Include []*IncludeEntry `yaml:"include"`
 
+	// Plugins to load before decoding the rest of the config
+	//
+	// plugins are go source files interpreted at runtime, they can register
+	// renderers and tasks, see package arhat.dev/dukkha/pkg/plugins for details
+	//
+	// NOTE: renderers defined in the same document are not available here
+	Plugins []*PluginEntry `yaml:"plugins"`
+
 	// Shells for command execution
 	Shells []*tools_shell.Tool `yaml:"shells"`
 
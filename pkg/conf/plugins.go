@@ -0,0 +1,119 @@
+package conf
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"arhat.dev/pkg/log"
+	"arhat.dev/rs"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/plugins"
+)
+
+type PluginEntry struct {
+	rs.BaseField `yaml:"-"`
+
+	// Path is the local path to go source file of the plugin, relative to current file
+	//
+	// Path and Source are mutually exclusive
+	Path string `yaml:"path"`
+
+	// Source is the go source code of the plugin, usually used with rendering suffix
+	// to load remote plugin
+	//
+	// Path and Source are mutually exclusive
+	Source string `yaml:"source"`
+}
+
+// pluginsOverview is a subset of Config to load plugins before decoding
+// the whole config document, so plugins can be used in the same document
+type pluginsOverview struct {
+	rs.BaseField `yaml:"-"`
+
+	Plugins []*PluginEntry `yaml:"plugins"`
+}
+
+// loadPlugins builds and runs plugins defined in the config document, plugins
+// register their renderers and tasks when loaded
+func loadPlugins(
+	appCtx dukkha.ConfigResolvingContext,
+	confFS fs.FS,
+	currentFile string,
+	doc *yaml.Node,
+) error {
+	logger := log.Log.WithName("config")
+
+	m := doc
+	if m.Kind == yaml.DocumentNode && len(m.Content) == 1 {
+		m = m.Content[0]
+	}
+
+	if m.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	overview := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		key := m.Content[i].Value
+		if key == "plugins" || strings.HasPrefix(key, "plugins@") {
+			overview.Content = append(overview.Content, m.Content[i], m.Content[i+1])
+		}
+	}
+
+	if len(overview.Content) == 0 {
+		return nil
+	}
+
+	c := &pluginsOverview{}
+	_ = rs.Init(c, &rs.Options{
+		InterfaceTypeHandler: dukkha.GlobalInterfaceTypeHandler,
+	})
+
+	err := overview.Decode(c)
+	if err != nil {
+		return fmt.Errorf("decode plugins: %w", err)
+	}
+
+	err = c.ResolveFields(appCtx, -1)
+	if err != nil {
+		return fmt.Errorf("resolving plugins: %w", err)
+	}
+
+	logger.D("loading plugins", log.Int("count", len(c.Plugins)))
+	for i, p := range c.Plugins {
+		var (
+			name = fmt.Sprintf("#%d of %s", i, currentFile)
+			src  []byte
+		)
+
+		switch {
+		case len(p.Path) != 0 && len(p.Source) != 0:
+			return fmt.Errorf("plugin %s: unexpected both path and source set", name)
+		case len(p.Path) != 0:
+			file := p.Path
+			if !path.IsAbs(file) {
+				file = path.Join(path.Dir(currentFile), file)
+			}
+
+			name = file
+			src, err = fs.ReadFile(confFS, file)
+			if err != nil {
+				return fmt.Errorf("reading plugin %q: %w", file, err)
+			}
+		default:
+			src = []byte(p.Source)
+		}
+
+		logger.V("loading plugin", log.String("name", name))
+		err = plugins.Load(appCtx, name, src)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
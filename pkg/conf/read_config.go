@@ -36,6 +36,9 @@ type ReadSpec struct {
 	VisitedPaths *map[string]struct{}
 	MergedConfig *Config
 
+	// Sources records where config entries were defined when set
+	Sources *Sources
+
 	lock sync.Mutex
 }
 
@@ -175,18 +178,19 @@ func loadConfig(
 	j synchain.Ticket,
 ) {
 	var (
-		configs []*Config
-		err     error
+		docs []*yaml.Node
+		err  error
 	)
 
 	defer sg.Done()
 
-	// decode yaml config in parallel
+	// parse yaml docs in parallel, they are decoded as Config in sequence
+	// since plugins loaded by previous docs may be used
 	dec := yaml.NewDecoder(r)
 	for {
-		current := NewConfig()
+		doc := new(yaml.Node)
 
-		err = dec.Decode(current)
+		err = dec.Decode(doc)
 		if err != nil {
 			if err == io.EOF {
 				err = nil
@@ -197,7 +201,7 @@ func loadConfig(
 			break
 		}
 
-		configs = append(configs, current)
+		docs = append(docs, doc)
 	}
 	_ = r.Close()
 
@@ -217,7 +221,20 @@ func loadConfig(
 		flags    = spec.Flags
 	)
 
-	for i, cfg := range configs {
+	for i, doc := range docs {
+		err = loadPlugins(rc, spec.ConfFS, filename, doc)
+		if err != nil {
+			sg.Cancel(fmt.Errorf("%s #%d: load plugins: %w", filename, i, err))
+			return
+		}
+
+		cfg := NewConfig()
+		err = doc.Decode(cfg)
+		if err != nil {
+			sg.Cancel(fmt.Errorf("decode yaml config %q: %w", filename, err))
+			return
+		}
+
 		if flags&ReadFlag_Renderer != 0 {
 			err = cfg.resolveRenderers(rc)
 			if err != nil {
@@ -248,6 +265,10 @@ func loadConfig(
 			return
 		}
 
+		if spec.Sources != nil {
+			spec.Sources.record(filename, doc, cfg)
+		}
+
 		spec.lock.Unlock()
 	}
 
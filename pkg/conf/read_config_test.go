@@ -176,3 +176,132 @@ func configBytes(t *testing.T, c *Config) []byte {
 	assert.NoError(t, err, "")
 	return data
 }
+
+func TestReadPlugins(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"plugins/renderer.go": &fstest.MapFile{Data: []byte(`package main
+
+import (
+	"fmt"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/plugins"
+)
+
+func main() {
+	plugins.RegisterRenderer("test-conf-plugin", func(
+		rc dukkha.RenderingContext, rawData any, attributes []dukkha.RendererAttribute,
+	) ([]byte, error) {
+		return []byte(fmt.Sprint("plugin-", rawData)), nil
+	})
+}
+`)},
+		"config.yaml": &fstest.MapFile{Data: []byte(`
+plugins:
+- path: plugins/renderer.go
+
+renderers:
+- test-conf-plugin: {}
+
+global:
+  values:
+    foo@test-conf-plugin: bar
+`)},
+	}
+
+	visitedPaths := make(map[string]struct{})
+	mergedConfig := NewConfig()
+
+	rc := dukkha_test.NewTestContext(context.TODO(), t.TempDir())
+	err := Read(
+		rc,
+		&ReadSpec{
+			Flags:        ReadFlag_Full,
+			ConfFS:       testFS,
+			VisitedPaths: &visitedPaths,
+			MergedConfig: mergedConfig,
+		},
+		synchain.NewSynchain(),
+		[]string{"config.yaml"},
+		false,
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, ok := rc.AllRenderers()["test-conf-plugin"]
+	assert.True(t, ok)
+
+	assert.NoError(t, mergedConfig.Resolve(rc, ReadFlag_Global))
+	assert.Equal(t, map[string]any{"foo": "plugin-bar"}, mergedConfig.Global.Values.NormalizedValue())
+}
+
+func TestReadSources(t *testing.T) {
+	t.Parallel()
+
+	testFS := fstest.MapFS{
+		"a.yaml": &fstest.MapFile{Data: []byte(`
+include:
+- path: b.yaml
+
+global:
+  values:
+    foo: a
+    bar: a
+
+workflow:run:
+- name: a-run
+`)},
+		"b.yaml": &fstest.MapFile{Data: []byte(`
+global:
+  values:
+    foo: b
+
+tools:
+  workflow:
+  - name: b-tool
+`)},
+	}
+
+	sources := new(Sources)
+	rc := dukkha_test.NewTestContext(context.TODO(), t.TempDir())
+	err := Read(
+		rc,
+		&ReadSpec{
+			Flags:        ReadFlag_Full,
+			ConfFS:       testFS,
+			VisitedPaths: &map[string]struct{}{},
+			MergedConfig: NewConfig(),
+			Sources:      sources,
+		},
+		synchain.NewSynchain(),
+		[]string{"a.yaml"},
+		false,
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	type entry struct {
+		kind SourceKind
+		key  string
+		name string
+		file string
+		line int
+	}
+
+	var actual []entry
+	for _, e := range sources.Entries() {
+		actual = append(actual, entry{e.Kind, e.Key, e.Name, e.File, e.Line})
+	}
+
+	assert.ElementsMatch(t, []entry{
+		{SourceKind_Value, "", "bar", "a.yaml", 8},
+		{SourceKind_Task, "workflow:run", "a-run", "a.yaml", 11},
+		// value foo overridden by the included file
+		{SourceKind_Value, "", "foo", "b.yaml", 4},
+		{SourceKind_Tool, "workflow", "b-tool", "b.yaml", 8},
+	}, actual)
+}
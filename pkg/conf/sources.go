@@ -0,0 +1,262 @@
+package conf
+
+import (
+	"strings"
+	"sync"
+
+	"arhat.dev/rs"
+	"gopkg.in/yaml.v3"
+)
+
+type SourceKind string
+
+const (
+	// SourceKind_Global is global option other than env and values
+	SourceKind_Global SourceKind = "global"
+
+	// SourceKind_Env is global env entry
+	SourceKind_Env SourceKind = "env"
+
+	// SourceKind_Value is top level key in global values
+	SourceKind_Value SourceKind = "value"
+
+	SourceKind_Renderer SourceKind = "renderer"
+	SourceKind_Shell    SourceKind = "shell"
+	SourceKind_Tool     SourceKind = "tool"
+	SourceKind_Task     SourceKind = "task"
+)
+
+// SourceEntry is a config entry with the location where it was defined
+type SourceEntry struct {
+	Kind SourceKind
+
+	// Key is the tool kind of tool entries, or the yaml key of task entries
+	// (e.g. `workflow:run`)
+	Key string
+
+	// Name of the entry, set when it's known from the yaml key (e.g. renderer
+	// name, value key, global option name), or the plain `name` field
+	Name string
+
+	// File is the config file path, or `text#<index> of <file>` for text includes
+	File string
+
+	// Line number of the entry in File
+	Line int
+
+	// Node is the raw yaml of the entry
+	Node *yaml.Node
+
+	// Object is the decoded entry, nil if not decoded when reading config
+	// (e.g. the parent field is using rendering suffix)
+	//
+	// for global options and values, always nil since they are merged by key
+	Object rs.Field
+}
+
+// Sources tracks where config entries were defined during Read
+type Sources struct {
+	mu      sync.Mutex
+	entries []*SourceEntry
+}
+
+// Entries returns all entries in the order they were merged
+func (s *Sources) Entries() []*SourceEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*SourceEntry(nil), s.entries...)
+}
+
+func (s *Sources) add(e *SourceEntry) {
+	switch e.Kind {
+	case SourceKind_Global, SourceKind_Value:
+		// merged by key, later definition overrides
+		for i, v := range s.entries {
+			if v.Kind == e.Kind && v.Name == e.Name {
+				s.entries = append(s.entries[:i], s.entries[i+1:]...)
+				break
+			}
+		}
+	}
+
+	s.entries = append(s.entries, e)
+}
+
+// record adds entries defined in the yaml doc, cfg MUST be decoded from doc
+//
+// fields using rendering suffix are recorded as a whole entry since their
+// content is unknown before resolving
+func (s *Sources) record(file string, doc *yaml.Node, cfg *Config) {
+	root := doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) != 0 {
+		root = root.Content[0]
+	}
+
+	if root.Kind != yaml.MappingNode {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	newEntry := func(kind SourceKind, key, name string, line int, n *yaml.Node, obj rs.Field) {
+		if len(name) == 0 {
+			name = plainNameOf(n)
+		}
+
+		s.add(&SourceEntry{
+			Kind:   kind,
+			Key:    key,
+			Name:   name,
+			File:   file,
+			Line:   line,
+			Node:   n,
+			Object: obj,
+		})
+	}
+
+	forEachPair(root, func(k, v *yaml.Node) {
+		key := trimRenderingSuffix(k.Value)
+		switch key {
+		case "include", "plugins":
+		case "global":
+			if v.Kind != yaml.MappingNode {
+				newEntry(SourceKind_Global, "", k.Value, k.Line, v, nil)
+				return
+			}
+
+			forEachPair(v, func(gk, gv *yaml.Node) {
+				switch {
+				case gk.Value == "env" && gv.Kind == yaml.SequenceNode:
+					for i, item := range gv.Content {
+						var obj rs.Field
+						if i < len(cfg.Global.Env) {
+							obj = cfg.Global.Env[i]
+						}
+
+						newEntry(SourceKind_Env, "", "", item.Line, item, obj)
+					}
+				case gk.Value == "values" && gv.Kind == yaml.MappingNode:
+					forEachPair(gv, func(vk, vv *yaml.Node) {
+						newEntry(SourceKind_Value, "", trimRenderingSuffix(vk.Value), vk.Line, vv, nil)
+					})
+				default:
+					newEntry(SourceKind_Global, "", gk.Value, gk.Line, gv, nil)
+				}
+			})
+		case "renderers":
+			if v.Kind != yaml.SequenceNode {
+				newEntry(SourceKind_Renderer, "", "", k.Line, v, nil)
+				return
+			}
+
+			forEachItem(v, func(i int, item *yaml.Node) {
+				forEachPair(item, func(rk, rv *yaml.Node) {
+					name := trimRenderingSuffix(rk.Value)
+
+					var obj rs.Field
+					if i < len(cfg.Renderers) {
+						if r, ok := cfg.Renderers[i].Renderers[name]; ok {
+							obj = r
+						}
+					}
+
+					newEntry(SourceKind_Renderer, "", name, rk.Line, rv, obj)
+				})
+			})
+		case "shells":
+			if v.Kind != yaml.SequenceNode {
+				newEntry(SourceKind_Shell, "", "", k.Line, v, nil)
+				return
+			}
+
+			forEachItem(v, func(i int, item *yaml.Node) {
+				var obj rs.Field
+				if i < len(cfg.Shells) {
+					obj = cfg.Shells[i]
+				}
+
+				newEntry(SourceKind_Shell, "", "", item.Line, item, obj)
+			})
+		case "tools":
+			if v.Kind != yaml.MappingNode {
+				newEntry(SourceKind_Tool, "", "", k.Line, v, nil)
+				return
+			}
+
+			forEachPair(v, func(tk, tv *yaml.Node) {
+				toolKind := trimRenderingSuffix(tk.Value)
+				if tv.Kind != yaml.SequenceNode {
+					newEntry(SourceKind_Tool, toolKind, "", tk.Line, tv, nil)
+					return
+				}
+
+				tools := cfg.Tools.Tools[toolKind]
+				forEachItem(tv, func(i int, item *yaml.Node) {
+					var obj rs.Field
+					if i < len(tools) {
+						obj = tools[i]
+					}
+
+					newEntry(SourceKind_Tool, toolKind, "", item.Line, item, obj)
+				})
+			})
+		default:
+			// tasks
+			if v.Kind != yaml.SequenceNode {
+				newEntry(SourceKind_Task, key, "", k.Line, v, nil)
+				return
+			}
+
+			tasks := cfg.Tasks[key]
+			forEachItem(v, func(i int, item *yaml.Node) {
+				var obj rs.Field
+				if i < len(tasks) {
+					obj = tasks[i]
+				}
+
+				newEntry(SourceKind_Task, key, "", item.Line, item, obj)
+			})
+		}
+	})
+}
+
+func forEachPair(n *yaml.Node, do func(k, v *yaml.Node)) {
+	if n.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		do(n.Content[i], n.Content[i+1])
+	}
+}
+
+func forEachItem(n *yaml.Node, do func(i int, item *yaml.Node)) {
+	if n.Kind != yaml.SequenceNode {
+		return
+	}
+
+	for i, item := range n.Content {
+		do(i, item)
+	}
+}
+
+// plainNameOf returns value of the `name` field without rendering suffix
+func plainNameOf(n *yaml.Node) (name string) {
+	forEachPair(n, func(k, v *yaml.Node) {
+		if k.Value == "name" && v.Kind == yaml.ScalarNode {
+			name = v.Value
+		}
+	})
+
+	return
+}
+
+func trimRenderingSuffix(key string) string {
+	if idx := strings.IndexByte(key, '@'); idx != -1 {
+		return key[:idx]
+	}
+
+	return key
+}
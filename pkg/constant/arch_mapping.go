@@ -59,6 +59,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "x86",
 		platformID_Zig:  "i386",
+		platformID_Rust: "i686",
 	},
 	archID_X86_SF: {
 		platformID_Alpine: "x86",
@@ -76,6 +77,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "x86",
 		platformID_Zig:  "i386",
+		platformID_Rust: "i586",
 	},
 	archID_AMD64: {
 		platformID_Alpine: "x86_64",
@@ -93,6 +95,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "x86_64",
 		platformID_Zig:  "x86_64",
+		platformID_Rust: "x86_64",
 	},
 	archID_AMD64_V1: {
 		platformID_Alpine: "x86_64",
@@ -110,6 +113,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "x86_64",
 		platformID_Zig:  "x86_64",
+		platformID_Rust: "x86_64",
 	},
 	archID_AMD64_V2: {
 		platformID_Alpine: "x86_64",
@@ -127,6 +131,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "x86_64",
 		platformID_Zig:  "x86_64",
+		platformID_Rust: "x86_64",
 	},
 	archID_AMD64_V3: {
 		platformID_Alpine: "x86_64",
@@ -144,6 +149,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "x86_64",
 		platformID_Zig:  "x86_64",
+		platformID_Rust: "x86_64",
 	},
 	archID_AMD64_V4: {
 		platformID_Alpine: "x86_64",
@@ -161,6 +167,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "x86_64",
 		platformID_Zig:  "x86_64",
+		platformID_Rust: "x86_64",
 	},
 	archID_ARM: {
 		platformID_Alpine: "armv7",
@@ -178,6 +185,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "armv7",
 		platformID_Zig:  "armv7a",
+		platformID_Rust: "armv7",
 	},
 	archID_ARM_V5: {
 		platformID_Alpine: "armv5l",
@@ -195,6 +203,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "armv5",
 		platformID_Zig:  "armv5",
+		platformID_Rust: "armv5te",
 	},
 	archID_ARM_V6: {
 		platformID_Alpine: "armhf",
@@ -212,6 +221,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "armv6",
 		platformID_Zig:  "armv6",
+		platformID_Rust: "arm",
 	},
 	archID_ARM_V7: {
 		platformID_Alpine: "armv7",
@@ -229,6 +239,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "armv7",
 		platformID_Zig:  "armv7a",
+		platformID_Rust: "armv7",
 	},
 	archID_ARM64: {
 		platformID_Alpine: "aarch64",
@@ -246,6 +257,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "aarch64",
 		platformID_Zig:  "aarch64",
+		platformID_Rust: "aarch64",
 	},
 	archID_ARM64_V8: {
 		platformID_Alpine: "aarch64",
@@ -263,6 +275,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "aarch64",
 		platformID_Zig:  "aarch64",
+		platformID_Rust: "aarch64",
 	},
 	// TODO: revise once standardized
 	archID_ARM64_V9: {
@@ -281,6 +294,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "aarch64",
 		platformID_Zig:  "aarch64",
+		platformID_Rust: "aarch64",
 	},
 	archID_PPC: {
 		platformID_Alpine: "",
@@ -298,6 +312,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "",
 		platformID_Zig:  "powerpc",
+		platformID_Rust: "powerpc",
 	},
 	archID_PPC_SF: {
 		platformID_Alpine: "",
@@ -315,6 +330,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "",
 		platformID_Zig:  "powerpc",
+		platformID_Rust: "powerpc",
 	},
 	archID_PPC_LE: {
 		platformID_Alpine: "",
@@ -332,6 +348,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "",
 		platformID_Zig:  "powerpcle",
+		platformID_Rust: "powerpcle",
 	},
 	archID_PPC_LE_SF: {
 		platformID_Alpine: "",
@@ -349,6 +366,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "",
 		platformID_Zig:  "powerpcle",
+		platformID_Rust: "powerpcle",
 	},
 	archID_PPC64: {
 		platformID_Alpine: "ppc64",
@@ -366,6 +384,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "",
 		platformID_Zig:  "powerpc64",
+		platformID_Rust: "powerpc64",
 	},
 	archID_PPC64_V8: {
 		platformID_Alpine: "ppc64",
@@ -383,6 +402,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "",
 		platformID_Zig:  "powerpc64",
+		platformID_Rust: "powerpc64",
 	},
 	archID_PPC64_V9: {
 		platformID_Alpine: "ppc64",
@@ -400,6 +420,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "",
 		platformID_Zig:  "powerpc64",
+		platformID_Rust: "powerpc64",
 	},
 	archID_PPC64_LE: {
 		platformID_Alpine: "ppc64le",
@@ -417,6 +438,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "ppc64le",
 		platformID_Zig:  "powerpc64le",
+		platformID_Rust: "powerpc64le",
 	},
 	archID_PPC64_LE_V8: {
 		platformID_Alpine: "ppc64le",
@@ -434,6 +456,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "ppc64le",
 		platformID_Zig:  "powerpc64le",
+		platformID_Rust: "powerpc64le",
 	},
 	archID_PPC64_LE_V9: {
 		platformID_Alpine: "ppc64le",
@@ -451,6 +474,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "ppc64le",
 		platformID_Zig:  "powerpc64le",
+		platformID_Rust: "powerpc64le",
 	},
 	archID_MIPS: {
 		platformID_Alpine: "mips",
@@ -468,6 +492,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "",
 		platformID_Zig:  "mips",
+		platformID_Rust: "mips",
 	},
 	archID_MIPS_SF: {
 		platformID_Alpine: "mips",
@@ -485,6 +510,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "",
 		platformID_Zig:  "mips",
+		platformID_Rust: "mips",
 	},
 	archID_MIPS_LE: {
 		platformID_Alpine: "mipsel",
@@ -502,6 +528,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "",
 		platformID_Zig:  "mipsel",
+		platformID_Rust: "mipsel",
 	},
 	archID_MIPS_LE_SF: {
 		platformID_Alpine: "mipsel",
@@ -519,6 +546,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "",
 		platformID_Zig:  "mipsel",
+		platformID_Rust: "mipsel",
 	},
 	archID_MIPS64: {
 		platformID_Alpine: "mips64",
@@ -536,6 +564,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "",
 		platformID_Zig:  "mips64",
+		platformID_Rust: "mips64",
 	},
 	archID_MIPS64_SF: {
 		platformID_Alpine: "mips64",
@@ -553,6 +582,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "",
 		platformID_Zig:  "mips64",
+		platformID_Rust: "mips64",
 	},
 	archID_MIPS64_LE: {
 		platformID_Alpine: "mips64el",
@@ -570,6 +600,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "mips64el",
 		platformID_Zig:  "mips64el",
+		platformID_Rust: "mips64el",
 	},
 	archID_MIPS64_LE_SF: {
 		platformID_Alpine: "mips64el",
@@ -587,6 +618,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "mips64el",
 		platformID_Zig:  "mips64el",
+		platformID_Rust: "mips64el",
 	},
 	archID_RISCV64: {
 		platformID_Alpine: "riscv64",
@@ -603,6 +635,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "",
 		platformID_Zig:  "riscv64",
+		platformID_Rust: "riscv64gc",
 	},
 	archID_S390X: {
 		platformID_Alpine: "s390x",
@@ -620,6 +653,7 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "systemz",
 		platformID_Zig:  "s390x",
+		platformID_Rust: "s390x",
 	},
 	archID_IA64: {
 		platformID_Alpine: "",
@@ -637,5 +671,6 @@ var archMapping = [archID_COUNT]platformArchMapping{
 
 		platformID_LLVM: "",
 		platformID_Zig:  "",
+		platformID_Rust: "",
 	},
 }
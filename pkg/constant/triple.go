@@ -507,7 +507,8 @@ func GetLLVMTripleName(mArch, targetKernel, targetLibc string) (triple string, _
 //
 // targetKernel defaults to linux kernel
 //
-// targetLibc defaults to musl
+// targetLibc defaults to musl, it's ignored for darwin (always `none`) and
+// windows (always `gnu`)
 func GetZigTripleName(mArch, targetKernel, targetLibc string) (triple string, _ bool) {
 	aid := arch_id_of(mArch)
 	if aid == _unknown_arch {
@@ -577,8 +578,10 @@ func GetZigTripleName(mArch, targetKernel, targetLibc string) (triple string, _
 		os = "linux"
 	case KERNEL_Darwin:
 		os = "macos"
+		abi = "none"
 	case KERNEL_Windows:
 		os = "windows"
+		abi = "gnu"
 	default:
 		return
 	}
@@ -597,3 +600,75 @@ func GetZigTripleName(mArch, targetKernel, targetLibc string) (triple string, _
 
 	return arch + "-" + os + "-" + abi, true
 }
+
+// GetRustTripleName returns a valid value to `--target` of `cargo` and `rustc`
+//
+// targetKernel defaults to linux kernel
+//
+// targetLibc defaults to gnu, it's ignored for kernels other than linux and
+// windows
+//
+// ref: https://doc.rust-lang.org/nightly/rustc/platform-support.html
+func GetRustTripleName(mArch, targetKernel, targetLibc string) (triple string, _ bool) {
+	aid := arch_id_of(mArch)
+	if aid == _unknown_arch {
+		return
+	}
+
+	arch := archMapping[aid][platformID_Rust]
+	if len(arch) == 0 {
+		return
+	}
+
+	switch targetKernel {
+	case KERNEL_Linux, "":
+	case KERNEL_Darwin:
+		return arch + "-apple-darwin", true
+	case KERNEL_iOS:
+		return arch + "-apple-ios", true
+	case KERNEL_Windows:
+		switch targetLibc {
+		case LIBC_MSVC:
+			return arch + "-pc-windows-msvc", true
+		case LIBC_GNU, "":
+			return arch + "-pc-windows-gnu", true
+		default:
+			return
+		}
+	case KERNEL_Android:
+		switch aid {
+		case archID_ARM, archID_ARM_V7:
+			return arch + "-linux-androideabi", true
+		default:
+			return arch + "-linux-android", true
+		}
+	case KERNEL_Solaris:
+		return arch + "-pc-solaris", true
+	case KERNEL_FreeBSD, KERNEL_NetBSD, KERNEL_OpenBSD, KERNEL_Illumos:
+		return arch + "-unknown-" + targetKernel, true
+	default:
+		return
+	}
+
+	var abi string
+	switch targetLibc {
+	case LIBC_GNU, "":
+		abi = "gnu"
+	case LIBC_MUSL:
+		abi = "musl"
+	default:
+		return
+	}
+
+	switch aid {
+	case archID_ARM_V5:
+		abi += "eabi"
+	case archID_ARM, archID_ARM_V6, archID_ARM_V7:
+		abi += "eabihf"
+	case archID_MIPS64, archID_MIPS64_SF,
+		archID_MIPS64_LE, archID_MIPS64_LE_SF:
+		abi += "abi64"
+	}
+
+	return arch + "-unknown-linux-" + abi, true
+}
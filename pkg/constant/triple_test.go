@@ -18,6 +18,22 @@ func TestGetTriple(t *testing.T) {
 	}{
 		{"armv7", "", "", GetZigTripleName, "arm-linux-musleabihf"},
 		{"arm64", "", "", GetZigTripleName, "aarch64-linux-musl"},
+		{"arm64", KERNEL_Linux, LIBC_GNU, GetZigTripleName, "aarch64-linux-gnu"},
+		{"amd64", KERNEL_Darwin, "", GetZigTripleName, "x86_64-macos-none"},
+		{"arm64", KERNEL_Darwin, LIBC_GNU, GetZigTripleName, "aarch64-macos-none"},
+		{"amd64", KERNEL_Windows, "", GetZigTripleName, "x86_64-windows-gnu"},
+
+		{"amd64", "", "", GetRustTripleName, "x86_64-unknown-linux-gnu"},
+		{"arm64", KERNEL_Linux, LIBC_MUSL, GetRustTripleName, "aarch64-unknown-linux-musl"},
+		{"armv5", KERNEL_Linux, LIBC_GNU, GetRustTripleName, "armv5te-unknown-linux-gnueabi"},
+		{"armv6", KERNEL_Linux, LIBC_MUSL, GetRustTripleName, "arm-unknown-linux-musleabihf"},
+		{"armv7", KERNEL_Linux, LIBC_GNU, GetRustTripleName, "armv7-unknown-linux-gnueabihf"},
+		{"mips64le", KERNEL_Linux, LIBC_GNU, GetRustTripleName, "mips64el-unknown-linux-gnuabi64"},
+		{"riscv64", KERNEL_Linux, LIBC_GNU, GetRustTripleName, "riscv64gc-unknown-linux-gnu"},
+		{"arm64", KERNEL_Darwin, "", GetRustTripleName, "aarch64-apple-darwin"},
+		{"amd64", KERNEL_Windows, "", GetRustTripleName, "x86_64-pc-windows-gnu"},
+		{"amd64", KERNEL_Windows, LIBC_MSVC, GetRustTripleName, "x86_64-pc-windows-msvc"},
+		{"amd64", KERNEL_FreeBSD, "", GetRustTripleName, "x86_64-unknown-freebsd"},
 	} {
 		t.Run("", func(t *testing.T) {
 			actual, ok := test.get(test.arch, test.kernel, test.libc)
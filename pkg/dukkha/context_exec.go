@@ -2,6 +2,8 @@ package dukkha
 
 import (
 	"github.com/muesli/termenv"
+
+	"arhat.dev/dukkha/pkg/report"
 )
 
 // RuntimeOptions for task execution
@@ -11,6 +13,12 @@ type RuntimeOptions struct {
 	TranslateANSIStream bool
 	RetainANSIStyle     bool
 	Workers             int
+
+	// Force to run tasks even when their inputs and outputs are unchanged
+	Force bool
+
+	// Report to record task execution results, nil to disable reporting
+	Report *report.Report
 }
 
 type TaskExecOptions interface {
@@ -81,7 +89,17 @@ type ExecValues interface {
 	RetainANSIStyle() bool
 	ColorOutput() bool
 	FailFast() bool
-	ClaimWorkers(n int) int
+	Force() bool
+
+	// Workers returns the worker pool shared by all tasks and matrix entries
+	Workers() *WorkerPool
+
+	// RunReport returns the report to record task execution results, can be nil
+	RunReport() *report.Report
+
+	// SetCommandRecorder sets recorder for commands executed in this context
+	SetCommandRecorder(r report.CommandRecorder)
+	CommandRecorder() report.CommandRecorder
 
 	SetState(s TaskExecState)
 	State() TaskExecState
@@ -116,7 +134,10 @@ type contextExec struct {
 
 	state TaskExecState
 
+	cmdRecorder report.CommandRecorder
+
 	runtimeOpts RuntimeOptions
+	workers     *WorkerPool
 }
 
 func (c *contextExec) deriveNew() contextExec {
@@ -131,7 +152,10 @@ func (c *contextExec) deriveNew() contextExec {
 		prefixColor:  c.prefixColor,
 		outputColor:  c.outputColor,
 
+		cmdRecorder: c.cmdRecorder,
+
 		runtimeOpts: c.runtimeOpts,
+		workers:     c.workers,
 	}
 }
 
@@ -160,21 +184,22 @@ func (c *contextExec) OutputColor() termenv.Color { return c.outputColor }
 func (c *contextExec) CurrentTool() ToolKey { return ToolKey{Kind: c.toolKind, Name: c.toolName} }
 func (c *contextExec) CurrentTask() TaskKey { return TaskKey{Kind: c.taskKind, Name: c.taskName} }
 
-func (c *contextExec) SetRuntimeOptions(opts RuntimeOptions) { c.runtimeOpts = opts }
+func (c *contextExec) SetRuntimeOptions(opts RuntimeOptions) {
+	c.runtimeOpts = opts
+	c.workers = NewWorkerPool(opts.Workers)
+}
 
 func (c *contextExec) FailFast() bool            { return c.runtimeOpts.FailFast }
+func (c *contextExec) Force() bool               { return c.runtimeOpts.Force }
+func (c *contextExec) RunReport() *report.Report { return c.runtimeOpts.Report }
 func (c *contextExec) ColorOutput() bool         { return c.runtimeOpts.ColorOutput }
 func (c *contextExec) TranslateANSIStream() bool { return c.runtimeOpts.TranslateANSIStream }
 func (c *contextExec) RetainANSIStyle() bool     { return c.runtimeOpts.RetainANSIStyle }
 
-func (c *contextExec) ClaimWorkers(n int) int {
-	if c.runtimeOpts.Workers > n {
-		return n
-	}
-
-	// TODO: limit workers
-	return c.runtimeOpts.Workers
-}
+func (c *contextExec) Workers() *WorkerPool { return c.workers }
 
 func (c *contextExec) SetState(s TaskExecState) { c.state = s }
 func (c *contextExec) State() TaskExecState     { return c.state }
+
+func (c *contextExec) SetCommandRecorder(r report.CommandRecorder) { c.cmdRecorder = r }
+func (c *contextExec) CommandRecorder() report.CommandRecorder     { return c.cmdRecorder }
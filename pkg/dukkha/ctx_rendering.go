@@ -24,6 +24,7 @@ import (
 	"mvdan.cc/sh/v3/expand"
 
 	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/utils"
 )
 
 type RenderingContext interface {
@@ -46,6 +47,12 @@ type RenderingContext interface {
 
 	GlobalCacheFS(subdir string) *fshelper.OSFS
 
+	// AddSecrets registers secret values, they are masked in task output and logs
+	AddSecrets(values ...string)
+
+	// Masker returns the masker replacing all registered secret values
+	Masker() *utils.Masker
+
 	Stdin() io.Reader
 	Stdout() io.Writer
 	Stderr() io.Writer
@@ -66,6 +73,7 @@ func newContextRendering(
 		ifaceTypeHandler: ifaceTypeHandler,
 		renderers:        make(map[string]Renderer),
 		values:           make(map[string]any),
+		masker:           utils.NewMasker(),
 
 		fs: lazilyEnsuredSubFS(fshelper.NewOSFS(false, func(fshelper.Op, string) (string, error) {
 			return globalEnv[constant.GlobalEnv_DUKKHA_WORKDIR].GetLazyValue(), nil
@@ -89,6 +97,9 @@ type contextRendering struct {
 
 	values map[string]any
 
+	// masker is shared by all derived contexts
+	masker *utils.Masker
+
 	// nolint:revive
 	_VALUE any
 
@@ -113,6 +124,7 @@ func (c *contextRendering) clone(newCtx contextStd, separateEnv bool) contextRen
 
 		// values are global scoped, DO NOT deep copy in any case
 		values: c.values,
+		masker: c.masker,
 
 		fs:      c.fs,
 		cacheFS: c.cacheFS,
@@ -153,6 +165,9 @@ func (c *contextRendering) SetStdIO(stdin io.Reader, stdout, stderr io.Writer) {
 
 func (c *contextRendering) FS() *fshelper.OSFS { return c.fs }
 
+func (c *contextRendering) AddSecrets(values ...string) { c.masker.Add(values...) }
+func (c *contextRendering) Masker() *utils.Masker       { return c.masker }
+
 func (c *contextRendering) GlobalCacheFS(subdir string) *fshelper.OSFS {
 	return lazilyEnsuredSubFS(c.cacheFS, false, subdir)
 }
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 
 	"arhat.dev/rs"
 )
@@ -129,11 +130,25 @@ type (
 var _ rs.InterfaceTypeHandler = (*TypeManager)(nil)
 
 type TypeManager struct {
+	// mu guards types, plugins can register types while config is being decoded
+	mu    sync.RWMutex
 	types map[IfaceTypeKey]*IfaceFactory
 }
 
+// Types returns a snapshot of all registered types, it's safe to use while
+// plugins are registering types
 func (h *TypeManager) Types() map[IfaceTypeKey]*IfaceFactory {
-	return h.types
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	ret := make(map[IfaceTypeKey]*IfaceFactory, len(h.types))
+	for k, v := range h.types {
+		ret[k] = &IfaceFactory{
+			Factories: append([]*IfaceFactoryImpl(nil), v.Factories...),
+		}
+	}
+
+	return ret
 }
 
 // Create implements rs.InterfaceTypeHandler
@@ -142,6 +157,9 @@ func (h *TypeManager) Create(typ reflect.Type, yamlKey string) (any, error) {
 		Typ: typ,
 	}
 
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
 	v, ok := h.types[key]
 	if !ok {
 		return nil, fmt.Errorf(
@@ -175,6 +193,9 @@ func (h *TypeManager) register(
 		Typ: ifaceType,
 	}
 
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	v, ok := h.types[key]
 	if ok {
 		v.Factories = append(v.Factories,
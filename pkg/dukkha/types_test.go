@@ -0,0 +1,28 @@
+package dukkha
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypeManager_Types(t *testing.T) {
+	t.Parallel()
+
+	h := &TypeManager{types: make(map[IfaceTypeKey]*IfaceFactory)}
+	key := IfaceTypeKey{Typ: reflect.TypeOf("")}
+	create := func([]string) any { return nil }
+
+	h.register("a", key.Typ, regexp.MustCompile(`^a$`), create)
+	types := h.Types()
+
+	// registering after Types() returned MUST not affect the snapshot
+	h.register("b", key.Typ, regexp.MustCompile(`^b$`), create)
+	h.register("c", reflect.TypeOf(0), regexp.MustCompile(`^c$`), create)
+
+	assert.Len(t, types, 1)
+	assert.Len(t, types[key].Factories, 1)
+	assert.Len(t, h.Types()[key].Factories, 2)
+}
@@ -0,0 +1,57 @@
+package dukkha
+
+// WorkerPool limits the total number of task graph nodes and matrix entries
+// running at the same time
+type WorkerPool struct {
+	tokens chan struct{}
+}
+
+// NewWorkerPool creates a worker pool with n workers, n less than 1 is
+// treated as 1
+func NewWorkerPool(n int) *WorkerPool {
+	if n < 1 {
+		n = 1
+	}
+
+	p := &WorkerPool{tokens: make(chan struct{}, n)}
+	for i := 0; i < n; i++ {
+		p.tokens <- struct{}{}
+	}
+
+	return p
+}
+
+// Acquire blocks until a worker is available
+//
+// reserved is an optional channel of workers already held by the caller,
+// they are preferred over workers in the pool, and released back to
+// reserved
+//
+// it returns false when done is closed before any worker is available,
+// otherwise the returned release func MUST be called once the work is done
+//
+// a nil WorkerPool has no limit
+func (p *WorkerPool) Acquire(done <-chan struct{}, reserved chan struct{}) (release func(), ok bool) {
+	releaseReserved := func() { reserved <- struct{}{} }
+
+	select {
+	case <-reserved:
+		return releaseReserved, true
+	default:
+	}
+
+	if p == nil {
+		return func() {}, true
+	}
+
+	select {
+	case <-done:
+		return nil, false
+	case <-reserved:
+		return releaseReserved, true
+	case <-p.tokens:
+		return p.release, true
+	}
+}
+
+func (p *WorkerPool) release() { p.tokens <- struct{}{} }
@@ -0,0 +1,47 @@
+package dukkha
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool(t *testing.T) {
+	t.Parallel()
+
+	p := NewWorkerPool(0)
+	done := make(chan struct{})
+
+	release, ok := p.Acquire(done, nil)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	reserved := make(chan struct{}, 1)
+	reserved <- struct{}{}
+
+	// pool exhausted, reserved worker used
+	releaseReserved, ok := p.Acquire(done, reserved)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Len(t, reserved, 0)
+
+	close(done)
+	_, ok = p.Acquire(done, reserved)
+	assert.False(t, ok)
+
+	releaseReserved()
+	assert.Len(t, reserved, 1)
+
+	release()
+	release, ok = p.Acquire(make(chan struct{}), nil)
+	assert.True(t, ok)
+	release()
+
+	// no limit
+	var nilPool *WorkerPool
+	release, ok = nilPool.Acquire(done, nil)
+	assert.True(t, ok)
+	release()
+}
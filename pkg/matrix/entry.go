@@ -7,6 +7,8 @@ import (
 )
 
 // An Entry represents a set of all key value pairs of a matrix operation
+//
+// object values are stored as compact json, use Flatten or Values to access their fields
 type Entry map[string]string
 
 // String formats the Entry as
@@ -16,9 +18,54 @@ func (m Entry) String() string {
 	return strings.Join(sliceutils.FormatStringMap(m, ": ", false), ", ")
 }
 
-// BriefString return all values concatenated with slash
+// BriefString return all values concatenated with slash, fields of object
+// values are included instead of the object
 func (m Entry) BriefString() string {
-	return strings.Join(sliceutils.FormatStringMap(m, "", true), "/")
+	return strings.Join(sliceutils.FormatStringMap(m.Flatten(), "", true), "/")
+}
+
+// Flatten returns a copy of the Entry with object values replaced by their fields
+// as `<key>.<field>`
+func (m Entry) Flatten() map[string]string {
+	if m == nil {
+		return nil
+	}
+
+	ret := make(map[string]string, len(m))
+	for k, v := range m {
+		flattenValue(k, v, ret)
+	}
+
+	return ret
+}
+
+// Values returns all values in the Entry with object values decoded
+func (m Entry) Values() map[string]any {
+	if m == nil {
+		return nil
+	}
+
+	ret := make(map[string]any, len(m))
+	for k, v := range m {
+		obj, ok := decodeObjectValue(v)
+		if ok {
+			ret[k] = typedValue(obj)
+		} else {
+			ret[k] = v
+		}
+	}
+
+	return ret
+}
+
+// Get returns the value of key, key can be `<key>.<field>` for fields of object values
+func (m Entry) Get(key string) string {
+	v, ok := m[key]
+	if ok || !strings.Contains(key, ".") {
+		return v
+	}
+
+	return m.Flatten()[key]
 }
 
 // Contains returns true when x is a subset of the Entry
@@ -28,7 +75,7 @@ func (m Entry) Contains(x map[string]string) bool {
 	}
 
 	for k, v := range x {
-		if m[k] != v {
+		if m.Get(k) != v {
 			return false
 		}
 	}
@@ -38,7 +85,7 @@ func (m Entry) Contains(x map[string]string) bool {
 
 // MatchKV returns true when it possesses the same key value pair
 func (m Entry) MatchKV(key, value string) bool {
-	return m[key] == value
+	return m.Get(key) == value
 }
 
 // Equals returns true all entries in x are the same with all entries in m
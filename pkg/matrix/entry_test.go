@@ -0,0 +1,45 @@
+package matrix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntry_ObjectValues(t *testing.T) {
+	t.Parallel()
+
+	ent := Entry{
+		"kernel": "linux",
+		"runner": `{"cpus":4,"qemu":true,"size":1.5,"labels":["big"],"extra":{"a":null}}`,
+	}
+
+	assert.Equal(t, map[string]string{
+		"kernel":          "linux",
+		"runner.cpus":     "4",
+		"runner.qemu":     "true",
+		"runner.size":     "1.5",
+		"runner.labels.0": "big",
+		"runner.extra.a":  "",
+	}, ent.Flatten())
+
+	assert.Equal(t, map[string]any{
+		"kernel": "linux",
+		"runner": map[string]any{
+			"cpus":   4,
+			"qemu":   true,
+			"size":   1.5,
+			"labels": []any{"big"},
+			"extra":  map[string]any{"a": nil},
+		},
+	}, ent.Values())
+
+	assert.Equal(t, "4", ent.Get("runner.cpus"))
+	assert.Equal(t, "", ent.Get("runner.none"))
+	assert.True(t, ent.MatchKV("runner.qemu", "true"))
+	assert.True(t, ent.Contains(map[string]string{"kernel": "linux", "runner.cpus": "4"}))
+	assert.False(t, ent.Contains(map[string]string{"runner.cpus": "2"}))
+
+	assert.Equal(t, "linux/4", Entry{"kernel": "linux", "runner": `{"cpus":4}`}.BriefString())
+	assert.Equal(t, map[string]any{"foo": "{not json}"}, Entry{"foo": "{not json}"}.Values())
+}
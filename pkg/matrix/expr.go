@@ -0,0 +1,485 @@
+package matrix
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// filter expressions
+//
+// 	expr := or
+// 	or   := and ( "||" and )*
+// 	and  := not ( "&&" not )*
+// 	not  := "!" not | "(" expr ")" | cond
+// 	cond := key ( "=" | "==" | "!=" ) value
+// 	      | key ( "=~" | "!~" ) regexp
+// 	      | key [ "not" ] "in" "(" value ( "," value )* ")"
+//
+// values containing `*`, `?` or `[` are glob patterns, values can be quoted
+// with `"` or `'` when containing spaces or special characters
+
+// An expr is a boolean expression over matrix entries
+type expr interface {
+	Match(ent Entry) bool
+
+	// String formats the expr in the same form accepted by parseExpr
+	String() string
+}
+
+type exprOr []expr
+
+func (e exprOr) Match(ent Entry) bool {
+	for _, x := range e {
+		if x.Match(ent) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e exprOr) String() string {
+	parts := make([]string, len(e))
+	for i, x := range e {
+		parts[i] = x.String()
+	}
+
+	return strings.Join(parts, " || ")
+}
+
+type exprAnd []expr
+
+func (e exprAnd) Match(ent Entry) bool {
+	for _, x := range e {
+		if !x.Match(ent) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e exprAnd) String() string {
+	parts := make([]string, len(e))
+	for i, x := range e {
+		if _, ok := x.(exprOr); ok {
+			parts[i] = "(" + x.String() + ")"
+		} else {
+			parts[i] = x.String()
+		}
+	}
+
+	return strings.Join(parts, " && ")
+}
+
+type exprNot struct{ x expr }
+
+func (e exprNot) Match(ent Entry) bool { return !e.x.Match(ent) }
+func (e exprNot) String() string       { return "!(" + e.x.String() + ")" }
+
+const (
+	opEqual    = "="
+	opNotEqual = "!="
+	opRegex    = "=~"
+	opNotRegex = "!~"
+	opIn       = "in"
+	opNotIn    = "not in"
+)
+
+// exprCond matches value of a single key
+type exprCond struct {
+	key    string
+	op     string
+	values []string
+
+	// patterns for values, nil for values matched as is
+	patterns []*regexp.Regexp
+}
+
+func newExprCond(key, op string, values ...string) (*exprCond, error) {
+	ret := &exprCond{
+		key:      key,
+		op:       op,
+		values:   values,
+		patterns: make([]*regexp.Regexp, len(values)),
+	}
+
+	for i, v := range values {
+		var (
+			pattern *regexp.Regexp
+			err     error
+		)
+
+		switch {
+		case op == opRegex || op == opNotRegex:
+			pattern, err = regexp.Compile(v)
+		case isGlob(v):
+			pattern, err = compileGlob(v)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q for key %q: %w", v, key, err)
+		}
+
+		ret.patterns[i] = pattern
+	}
+
+	return ret, nil
+}
+
+func (e *exprCond) Match(ent Entry) bool {
+	v := ent.Get(e.key)
+
+	matched := false
+	for i, want := range e.values {
+		if p := e.patterns[i]; p != nil {
+			matched = p.MatchString(v)
+		} else {
+			matched = v == want
+		}
+
+		if matched {
+			break
+		}
+	}
+
+	switch e.op {
+	case opNotEqual, opNotRegex, opNotIn:
+		return !matched
+	default:
+		return matched
+	}
+}
+
+func (e *exprCond) String() string {
+	switch e.op {
+	case opIn, opNotIn:
+		values := make([]string, len(e.values))
+		for i, v := range e.values {
+			values[i] = quoteExprValue(v)
+		}
+
+		return e.key + " " + e.op + " (" + strings.Join(values, ",") + ")"
+	default:
+		return e.key + e.op + quoteExprValue(e.values[0])
+	}
+}
+
+func isGlob(v string) bool { return strings.ContainsAny(v, "*?[") }
+
+// compileGlob converts glob pattern to regexp, supports `*`, `?` and `[...]`
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed character class")
+			}
+
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	return regexp.Compile(sb.String())
+}
+
+// quoteExprValue quotes v when it cannot be parsed back as a raw value
+func quoteExprValue(v string) string {
+	if len(v) == 0 ||
+		strings.ContainsAny(v, " \t\"',") ||
+		strings.Contains(v, "&&") ||
+		strings.Contains(v, "||") {
+		return strconv.Quote(v)
+	}
+
+	depth := 0
+	for _, c := range v {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+
+		if depth < 0 {
+			return strconv.Quote(v)
+		}
+	}
+
+	if depth != 0 {
+		return strconv.Quote(v)
+	}
+
+	return v
+}
+
+// parseExpr parses filter expression s
+func parseExpr(s string) (expr, error) {
+	p := &exprParser{s: s}
+
+	ret, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid matrix filter %q: %w", s, err)
+	}
+
+	p.skipSpaces()
+	if !p.eof() {
+		return nil, fmt.Errorf("invalid matrix filter %q: unexpected %q at %d", s, p.s[p.pos:], p.pos)
+	}
+
+	return ret, nil
+}
+
+type exprParser struct {
+	s   string
+	pos int
+}
+
+func (p *exprParser) eof() bool { return p.pos >= len(p.s) }
+
+func (p *exprParser) skipSpaces() {
+	for !p.eof() && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// consume skips spaces and token if the remaining input starts with it
+func (p *exprParser) consume(token string) bool {
+	p.skipSpaces()
+	if strings.HasPrefix(p.s[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+
+	return false
+}
+
+// consumeWord is like consume, but the word must be followed by a space or `(`
+func (p *exprParser) consumeWord(word string) bool {
+	p.skipSpaces()
+	rest := p.s[p.pos:]
+	if strings.HasPrefix(rest, word+" ") || strings.HasPrefix(rest, word+"(") {
+		p.pos += len(word)
+		return true
+	}
+
+	return false
+}
+
+func (p *exprParser) parseOr() (expr, error) {
+	var ret exprOr
+	for {
+		x, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, x)
+		if !p.consume("||") {
+			break
+		}
+	}
+
+	if len(ret) == 1 {
+		return ret[0], nil
+	}
+
+	return ret, nil
+}
+
+func (p *exprParser) parseAnd() (expr, error) {
+	var ret exprAnd
+	for {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, x)
+		if !p.consume("&&") {
+			break
+		}
+	}
+
+	if len(ret) == 1 {
+		return ret[0], nil
+	}
+
+	return ret, nil
+}
+
+func (p *exprParser) parseNot() (expr, error) {
+	switch {
+	case p.consume("!"):
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return exprNot{x: x}, nil
+	case p.consume("("):
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.consume(")") {
+			return nil, fmt.Errorf("missing `)` at %d", p.pos)
+		}
+
+		return x, nil
+	default:
+		return p.parseCond()
+	}
+}
+
+func (p *exprParser) parseCond() (expr, error) {
+	p.skipSpaces()
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t=!~()&|,'\"", rune(p.s[p.pos])) {
+		p.pos++
+	}
+
+	key := p.s[start:p.pos]
+	if len(key) == 0 {
+		return nil, fmt.Errorf("missing key at %d", start)
+	}
+
+	var op string
+	switch {
+	case p.consume(opRegex):
+		op = opRegex
+	case p.consume("=="), p.consume(opEqual):
+		op = opEqual
+	case p.consume(opNotEqual):
+		op = opNotEqual
+	case p.consume(opNotRegex):
+		op = opNotRegex
+	case p.consumeWord("not"):
+		if !p.consumeWord(opIn) {
+			return nil, fmt.Errorf("expecting `in` after `not` at %d", p.pos)
+		}
+
+		op = opNotIn
+	case p.consumeWord(opIn):
+		op = opIn
+	default:
+		return nil, fmt.Errorf("missing operator for key %q at %d", key, p.pos)
+	}
+
+	if op != opIn && op != opNotIn {
+		value, err := p.parseValue(false)
+		if err != nil {
+			return nil, err
+		}
+
+		return newExprCond(key, op, value)
+	}
+
+	if !p.consume("(") {
+		return nil, fmt.Errorf("expecting `(` after `%s` at %d", op, p.pos)
+	}
+
+	var values []string
+	for {
+		value, err := p.parseValue(true)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+		if p.consume(")") {
+			break
+		}
+
+		if !p.consume(",") {
+			return nil, fmt.Errorf("expecting `,` or `)` at %d", p.pos)
+		}
+	}
+
+	return newExprCond(key, op, values...)
+}
+
+// parseValue parses a quoted value or a raw value ended by space, `&&`, `||`,
+// unbalanced `)` or (inList) `,`
+func (p *exprParser) parseValue(inList bool) (string, error) {
+	p.skipSpaces()
+	if p.eof() {
+		return "", nil
+	}
+
+	switch q := p.s[p.pos]; q {
+	case '"':
+		end := p.pos + 1
+		for ; end < len(p.s); end++ {
+			if p.s[end] == '\\' {
+				end++
+				continue
+			}
+
+			if p.s[end] == '"' {
+				break
+			}
+		}
+
+		if end >= len(p.s) {
+			return "", fmt.Errorf("unclosed quote at %d", p.pos)
+		}
+
+		value, err := strconv.Unquote(p.s[p.pos : end+1])
+		p.pos = end + 1
+		return value, err
+	case '\'':
+		end := strings.IndexByte(p.s[p.pos+1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unclosed quote at %d", p.pos)
+		}
+
+		value := p.s[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return value, nil
+	}
+
+	start, depth := p.pos, 0
+loop:
+	for ; !p.eof(); p.pos++ {
+		rest := p.s[p.pos:]
+		switch c := rest[0]; {
+		case c == ' ' || c == '\t':
+			break loop
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				break loop
+			}
+
+			depth--
+		case depth != 0:
+		case c == ',' && inList:
+			break loop
+		case strings.HasPrefix(rest, "&&"), strings.HasPrefix(rest, "||"):
+			break loop
+		}
+	}
+
+	return p.s[start:p.pos], nil
+}
@@ -1,9 +1,27 @@
 package matrix
 
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
 // A Filter represents a set of match/ignore rules of a matrix
 type Filter struct {
 	match  map[string]*Vector
 	ignore [][2]string
+
+	// globs are compiled glob patterns in match and ignore rules
+	globs map[string]*regexp.Regexp
+
+	// exprs are filter expressions all entries MUST match
+	exprs []expr
+
+	// shard is the 1-based index and total count of shards, zero for no sharding
+	shardIndex int
+	shardTotal int
 }
 
 // Equals returns true when everything in x is the same with what in f
@@ -16,7 +34,11 @@ func (f *Filter) Equals(x *Filter) bool {
 		return false
 	}
 
-	if len(f.match) != len(x.match) || len(f.ignore) != len(x.ignore) {
+	if len(f.match) != len(x.match) ||
+		len(f.ignore) != len(x.ignore) ||
+		len(f.exprs) != len(x.exprs) ||
+		f.shardIndex != x.shardIndex ||
+		f.shardTotal != x.shardTotal {
 		return false
 	}
 
@@ -39,11 +61,30 @@ func (f *Filter) Equals(x *Filter) bool {
 		}
 	}
 
+	for i, v := range f.exprs {
+		if v.String() != x.exprs[i].String() {
+			return false
+		}
+	}
+
 	return true
 }
 
 // AddMatch adds a key value match pair
-func (f *Filter) AddMatch(key, value string) {
+//
+// value being an invalid glob pattern only matches the same value, use
+// ParseFilter to report invalid glob patterns
+func (f *Filter) AddMatch(key, value string) { _ = f.addMatch(key, value) }
+
+// AddIgnore adds a ignore rule matching key=value
+//
+// value being an invalid glob pattern only matches the same value, use
+// ParseFilter to report invalid glob patterns
+func (f *Filter) AddIgnore(key, value string) { _ = f.addIgnore(key, value) }
+
+// addMatch adds the match rule and returns error when value is an invalid
+// glob pattern
+func (f *Filter) addMatch(key, value string) error {
 	if f.match == nil {
 		f.match = make(map[string]*Vector)
 	}
@@ -54,11 +95,140 @@ func (f *Filter) AddMatch(key, value string) {
 	} else {
 		f.match[key] = NewVector(value)
 	}
+
+	return f.addGlob(value)
 }
 
-// AddIgnore adds a ignore rule matching key=value
-func (f *Filter) AddIgnore(key, value string) {
+// addIgnore adds the ignore rule and returns error when value is an invalid
+// glob pattern
+func (f *Filter) addIgnore(key, value string) error {
 	f.ignore = append(f.ignore, [2]string{key, value})
+	return f.addGlob(value)
+}
+
+func (f *Filter) addGlob(pattern string) error {
+	if !isGlob(pattern) {
+		return nil
+	}
+
+	if _, ok := f.globs[pattern]; ok {
+		return nil
+	}
+
+	re, err := compileGlob(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+	}
+
+	if f.globs == nil {
+		f.globs = make(map[string]*regexp.Regexp)
+	}
+
+	f.globs[pattern] = re
+	return nil
+}
+
+// AddExpr parses and adds a filter expression, entries MUST match all expressions
+func (f *Filter) AddExpr(s string) error {
+	x, err := parseExpr(s)
+	if err != nil {
+		return err
+	}
+
+	f.exprs = append(f.exprs, x)
+	return nil
+}
+
+// SetShard only keeps the index-th of total shards of generated entries
+// (index is 1-based), set total to 0 to disable sharding
+func (f *Filter) SetShard(index, total int) {
+	f.shardIndex, f.shardTotal = index, total
+}
+
+// Shard returns the 1-based shard index and total count of shards,
+// total is 0 when sharding is not enabled
+func (f *Filter) Shard() (index, total int) {
+	return f.shardIndex, f.shardTotal
+}
+
+// Match returns true when ent is selected by match rules, not ignored and
+// matches all filter expressions, sharding is not considered
+//
+// values in match and ignore rules containing `*`, `?` or `[` are glob patterns
+func (f *Filter) Match(ent Entry) bool {
+	if f == nil {
+		return true
+	}
+
+	for _, kv := range f.ignore {
+		if f.matchValue(kv[1], ent.Get(kv[0])) {
+			return false
+		}
+	}
+
+	for k, vec := range f.match {
+		v := ent.Get(k)
+
+		matched := false
+		for _, pattern := range vec.Vec {
+			if f.matchValue(pattern, v) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	for _, x := range f.exprs {
+		if !x.Match(ent) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchValue returns true when v equals to pattern or matches glob pattern
+func (f *Filter) matchValue(pattern, v string) bool {
+	if pattern == v {
+		return true
+	}
+
+	re, ok := f.globs[pattern]
+	return ok && re.MatchString(v)
+}
+
+// InShard returns true when the i-th (0-based) entry belongs to the shard of f,
+// all entries belong to the shard when sharding is not enabled
+func (f *Filter) InShard(i int) bool {
+	return f.shardTotal <= 1 || i%f.shardTotal == f.shardIndex-1
+}
+
+// ParseShard parses shard spec in the form of `<index>/<total>`,
+// index is 1-based
+func ParseShard(s string) (index, total int, err error) {
+	i, n, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid matrix shard %q: expecting <index>/<total>", s)
+	}
+
+	index, err = strconv.Atoi(strings.TrimSpace(i))
+	if err == nil {
+		total, err = strconv.Atoi(strings.TrimSpace(n))
+	}
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid matrix shard %q: %w", s, err)
+	}
+
+	if total < 1 || index < 1 || index > total {
+		return 0, 0, fmt.Errorf("invalid matrix shard %q: index MUST be in range [1, total]", s)
+	}
+
+	return
 }
 
 // AsEntry converts f.match to a matrix [Entry] (used for task matrix)
@@ -95,10 +265,55 @@ func (f *Filter) Clone() (ret Filter) {
 		ret.ignore[i] = [2]string{kv[0], kv[1]}
 	}
 
+	// compiled globs are immutable, only the map is copied
+	if len(f.globs) != 0 {
+		ret.globs = make(map[string]*regexp.Regexp, len(f.globs))
+		for k, v := range f.globs {
+			ret.globs[k] = v
+		}
+	}
+
+	// exprs are immutable once parsed
+	ret.exprs = append([]expr(nil), f.exprs...)
+	ret.shardIndex, ret.shardTotal = f.shardIndex, f.shardTotal
+
 	return
 }
 
 // IsEmpty returns true when there is nothing in f
 func (f *Filter) IsEmpty() bool {
-	return f == nil || (len(f.match) == 0 && len(f.ignore) == 0)
+	return f == nil || (len(f.match) == 0 &&
+		len(f.ignore) == 0 &&
+		len(f.exprs) == 0 &&
+		f.shardTotal == 0)
+}
+
+// String formats rules of f (sorted) as a comma separated list like values of
+// the `-m` flag, shard is not included
+//
+// the result is not a single rule, split it by comma before passing it to
+// ParseFilter (as the flag does), values with commas are only preserved inside
+// `in (...)` expressions
+func (f *Filter) String() string {
+	if f.IsEmpty() {
+		return ""
+	}
+
+	var rules []string
+	for k, v := range f.match {
+		for _, value := range v.Vec {
+			rules = append(rules, k+"="+value)
+		}
+	}
+
+	for _, kv := range f.ignore {
+		rules = append(rules, kv[0]+"!="+kv[1])
+	}
+
+	for _, x := range f.exprs {
+		rules = append(rules, x.String())
+	}
+
+	sort.Strings(rules)
+	return strings.Join(rules, ",")
 }
@@ -1,6 +1,8 @@
 package matrix
 
 import (
+	"sort"
+
 	"arhat.dev/pkg/matrixhelper"
 	"arhat.dev/rs"
 
@@ -27,10 +29,47 @@ type Spec struct {
 	// NOTE: included entries will be excluded by Exclude entires as well
 	Include []*SpecItem `yaml:"include,omitempty"`
 
+	// Computed dimensions, values of them are derived from other dimensions
+	// of each generated entry, computed in the order of their names
+	Computed map[string][]*ComputedValue `yaml:"computed,omitempty"`
+
 	// Values of all top-level key value vectors
 	Values map[string]*Vector `yaml:",inline,omitempty"`
 }
 
+// ComputedValue sets values of a computed dimension for matched entries
+type ComputedValue struct {
+	rs.BaseField `yaml:"-"`
+
+	// Match entries having any of the values for all keys, match all entries when not set
+	Match map[string]*Vector `yaml:"match,omitempty"`
+
+	// Values of the computed dimension for matched entries, entries are
+	// duplicated for each value when there are multiple values
+	Values *Vector `yaml:"values"`
+}
+
+// matches returns true when ent has one of the values for all keys in cv.Match
+func (cv *ComputedValue) matches(ent Entry) bool {
+	for k, vec := range cv.Match {
+		v := ent.Get(k)
+
+		found := false
+		for _, want := range vec.Vec {
+			if v == want {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
 // IsEmpty returns true when this is no value in s
 func (s *Spec) IsEmpty() bool {
 	if s == nil {
@@ -40,7 +79,9 @@ func (s *Spec) IsEmpty() bool {
 	return len(s.Include) == 0 && len(s.Exclude) == 0 && len(s.Values) == 0
 }
 
-// GenerateEntries generates a set of matrix entries from the spec
+// GenerateEntries generates a set of matrix entries from the spec, only entries
+// matching the filter are included, when the filter has shard set, only
+// entries in that shard are returned
 func (s *Spec) GenerateEntries(filter Filter) (ret []Entry) {
 	if s.IsEmpty() {
 		return
@@ -64,18 +105,7 @@ func (s *Spec) GenerateEntries(filter Filter) (ret []Entry) {
 		)
 	}
 
-	var (
-		matchFilter  []map[string]string
-		ignoreFilter = filter.ignore
-	)
-	if len(filter.match) != 0 {
-		matchFilter = matrixhelper.CartesianProduct(
-			flattenVectorMap(filter.match),
-			sliceutils.SortByKernelCmdArchLibcOther,
-		)
-	}
-
-	mat := matrixhelper.CartesianProduct(all, sliceutils.SortByKernelCmdArchLibcOther)
+	mat := s.compute(matrixhelper.CartesianProduct(all, sliceutils.SortByKernelCmdArchLibcOther))
 loop:
 	for i := range mat {
 		spec := Entry(mat[i])
@@ -86,29 +116,14 @@ loop:
 			}
 		}
 
-		for _, f := range ignoreFilter {
-			if spec.MatchKV(f[0], f[1]) {
-				continue loop
-			}
-		}
-
-		if len(matchFilter) == 0 {
-			// no filter, add it
+		if filter.Match(spec) {
 			ret = append(ret, spec)
-			continue
-		}
-
-		for _, f := range matchFilter {
-			if spec.Contains(f) {
-				ret = append(ret, spec)
-				continue loop
-			}
 		}
 	}
 
 	// add included
 	for _, inc := range s.Include {
-		mat := matrixhelper.CartesianProduct(flattenVectorMap(inc.Data), sliceutils.SortByKernelCmdArchLibcOther)
+		mat := s.compute(matrixhelper.CartesianProduct(flattenVectorMap(inc.Data), sliceutils.SortByKernelCmdArchLibcOther))
 	addInclude:
 		for i := range mat {
 			includeEntry := Entry(mat[i])
@@ -120,27 +135,83 @@ loop:
 				}
 			}
 
-			for _, f := range ignoreFilter {
-				if includeEntry.MatchKV(f[0], f[1]) {
-					continue addInclude
-				}
+			if filter.Match(includeEntry) {
+				ret = append(ret, includeEntry)
 			}
+		}
+	}
 
-			if len(matchFilter) == 0 {
-				ret = append(ret, includeEntry)
+	if filter.shardTotal > 1 {
+		shard := ret[:0]
+		for i, ent := range ret {
+			if filter.InShard(i) {
+				shard = append(shard, ent)
+			}
+		}
+
+		ret = shard
+	}
+
+	return
+}
+
+// compute adds computed dimensions to entries in mat
+//
+// values of a computed dimension come from the first matched ComputedValue, entries
+// matching none of them or already having the key are left unchanged
+func (s *Spec) compute(mat []map[string]string) []map[string]string {
+	if len(s.Computed) == 0 {
+		return mat
+	}
+
+	names := make([]string, 0, len(s.Computed))
+	for name := range s.Computed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		ret := make([]map[string]string, 0, len(mat))
+		for _, ent := range mat {
+			values := s.computedValues(name, ent)
+			if len(values) == 0 {
+				ret = append(ret, ent)
 				continue
 			}
 
-			for _, f := range matchFilter {
-				if includeEntry.Contains(f) {
-					ret = append(ret, includeEntry)
-					continue addInclude
+			for _, v := range values {
+				newEntry := make(map[string]string, len(ent)+1)
+				for k, v := range ent {
+					newEntry[k] = v
 				}
+
+				newEntry[name] = v
+				ret = append(ret, newEntry)
 			}
 		}
+
+		mat = ret
 	}
 
-	return
+	return mat
+}
+
+func (s *Spec) computedValues(name string, ent Entry) []string {
+	if _, ok := ent[name]; ok {
+		return nil
+	}
+
+	for _, cv := range s.Computed[name] {
+		if cv != nil && cv.matches(ent) {
+			if cv.Values == nil {
+				return nil
+			}
+
+			return cv.Values.Vec
+		}
+	}
+
+	return nil
 }
 
 // AsFilter creates a Filter based on this spec
@@ -204,6 +204,8 @@ func TestSpec_GenerateEntries_Fixture(t *testing.T) {
 
 		MatchFilter  map[string]*Vector `yaml:"match_filter"`
 		IgnoreFilter [][2]string        `yaml:"ignore_fitler"`
+		Filter       []string           `yaml:"filter"`
+		Shard        string             `yaml:"shard"`
 		Spec         Spec               `yaml:"spec"`
 	}
 
@@ -226,10 +228,26 @@ func TestSpec_GenerateEntries_Fixture(t *testing.T) {
 			), -1)
 			assert.NoError(t, err)
 
-			actual := spec.Spec.GenerateEntries(Filter{
-				match:  spec.MatchFilter,
-				ignore: spec.IgnoreFilter,
-			})
+			filter, err := ParseFilter(spec.Filter)
+			assert.NoError(t, err)
+
+			for k, v := range spec.MatchFilter {
+				for _, value := range v.Vec {
+					filter.AddMatch(k, value)
+				}
+			}
+
+			for _, kv := range spec.IgnoreFilter {
+				filter.AddIgnore(kv[0], kv[1])
+			}
+
+			if len(spec.Shard) != 0 {
+				index, total, err := ParseShard(spec.Shard)
+				assert.NoError(t, err)
+				filter.SetShard(index, total)
+			}
+
+			actual := spec.Spec.GenerateEntries(filter)
 
 			assert.EqualValues(t, exp, &actual)
 		},
@@ -1,26 +1,102 @@
 package matrix
 
 import (
+	"fmt"
 	"strings"
 )
 
 // ParseMatrixFilter parses text form of Filter
 // 	- a "key=value" is considered as a match rule
 // 	- a "key!=value" is considered as an ignore rule
+//
+// invalid filter expressions are ignored, use ParseFilter to check errors
 func ParseMatrixFilter(arr []string) (ret Filter) {
-	for _, v := range arr {
+	ret, _ = ParseFilter(arr)
+	return
+}
+
+// ParseFilter parses text form of Filter like ParseMatrixFilter, values of match
+// and ignore rules can be glob patterns (e.g. "arch=arm*"), other rules are
+// parsed as filter expressions (e.g. "kernel=linux && !(arch=~^mips)") and
+// entries MUST match all of them
+//
+// rules split by comma (e.g. "arch in (amd64,arm64)" passed to a string slice flag)
+// are joined back before parsing
+func ParseFilter(arr []string) (ret Filter, err error) {
+	for _, v := range joinFilterRules(arr) {
+		if isFilterExpr(v) {
+			err = ret.AddExpr(v)
+			if err != nil {
+				return
+			}
+
+			continue
+		}
+
 		key, value, found := strings.Cut(v, "!=")
 		if found {
-			ret.AddIgnore(key, value)
+			err = ret.addIgnore(key, value)
+			if err != nil {
+				return ret, fmt.Errorf("invalid matrix filter rule %q: %w", v, err)
+			}
+
 			continue
 		}
 
 		key, value, found = strings.Cut(v, "=")
 		if found {
-			ret.AddMatch(key, value)
+			err = ret.addMatch(key, value)
+			if err != nil {
+				return ret, fmt.Errorf("invalid matrix filter rule %q: %w", v, err)
+			}
+
+			continue
+		}
+	}
+
+	return
+}
+
+// isFilterExpr returns true when rule is not a plain `key=value` or `key!=value` rule
+func isFilterExpr(rule string) bool {
+	rule = strings.TrimSpace(rule)
+	return strings.HasPrefix(rule, "!") ||
+		strings.HasPrefix(rule, "(") ||
+		strings.Contains(rule, "&&") ||
+		strings.Contains(rule, "||") ||
+		strings.Contains(rule, "=~") ||
+		strings.Contains(rule, "!~") ||
+		strings.Contains(rule, " in ") ||
+		strings.Contains(rule, " in(")
+}
+
+// joinFilterRules joins rules separated by comma inside parentheses
+func joinFilterRules(arr []string) (ret []string) {
+	var (
+		pending string
+		depth   int
+	)
+
+	for _, v := range arr {
+		if depth > 0 {
+			pending += "," + v
+		} else {
+			pending = v
+		}
+
+		depth += strings.Count(v, "(") - strings.Count(v, ")")
+		if depth > 0 {
 			continue
 		}
+
+		ret = append(ret, pending)
+		depth = 0
+	}
+
+	if depth > 0 {
+		// unbalanced, let the expression parser report it
+		ret = append(ret, pending)
 	}
 
-	return ret
+	return
 }
@@ -1,6 +1,7 @@
 package matrix
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -78,3 +79,126 @@ func TestParseMatrixFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFilter(t *testing.T) {
+	t.Parallel()
+
+	ent := Entry{"kernel": "linux", "arch": "arm64", "libc": "musl"}
+
+	for _, test := range []struct {
+		name    string
+		filters []string
+
+		str   string
+		match bool
+		err   bool
+	}{
+		{
+			name:    "Glob",
+			filters: []string{"arch=arm*", "kernel!=win?ows"},
+			str:     "arch=arm*,kernel!=win?ows",
+			match:   true,
+		},
+		{
+			name:    "In Split By Comma",
+			filters: []string{"arch in (amd64", "arm64)"},
+			str:     "arch in (amd64,arm64)",
+			match:   true,
+		},
+		{
+			name:    "In With Other Rules",
+			filters: []string{"kernel=linux", "arch in (amd64,arm64)"},
+			str:     "arch in (amd64,arm64),kernel=linux",
+			match:   true,
+		},
+		{
+			name:    "Not In",
+			filters: []string{"arch not in (arm64,'mips 64')"},
+			str:     `arch not in (arm64,"mips 64")`,
+			match:   false,
+		},
+		{
+			name:    "Regexp",
+			filters: []string{"libc=~^(gnu|musl)$", "arch!~^mips"},
+			str:     "arch!~^mips,libc=~^(gnu|musl)$",
+			match:   true,
+		},
+		{
+			name:    "Boolean",
+			filters: []string{"kernel==linux && !(arch=~^mips)"},
+			str:     "kernel=linux && !(arch=~^mips)",
+			match:   true,
+		},
+		{
+			name:    "Precedence",
+			filters: []string{"arch=amd64 || kernel=linux && libc=gnu"},
+			str:     "arch=amd64 || kernel=linux && libc=gnu",
+			match:   false,
+		},
+		{
+			name:    "Group",
+			filters: []string{"(arch=amd64 || kernel=linux) && libc=musl"},
+			str:     "(arch=amd64 || kernel=linux) && libc=musl",
+			match:   true,
+		},
+		{
+			name:    "Unbalanced",
+			filters: []string{"(arch=amd64"},
+			err:     true,
+		},
+		{
+			name:    "Invalid Regexp",
+			filters: []string{"arch=~(amd64"},
+			err:     true,
+		},
+		{
+			name:    "Invalid Glob",
+			filters: []string{"arch=["},
+			err:     true,
+		},
+		{
+			name:    "Invalid Glob Ignore",
+			filters: []string{"arch!=amd[64"},
+			err:     true,
+		},
+		{
+			name:    "Missing Operator",
+			filters: []string{"arch && kernel=linux"},
+			err:     true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f, err := ParseFilter(test.filters)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, test.str, f.String())
+			assert.Equal(t, test.match, f.Match(ent))
+
+			// text form can be parsed back after split by comma (as string slice flag)
+			f2, err := ParseFilter(strings.Split(f.String(), ","))
+			assert.NoError(t, err)
+			assert.Equal(t, test.str, f2.String())
+		})
+	}
+}
+
+func TestParseShard(t *testing.T) {
+	t.Parallel()
+
+	index, total, err := ParseShard("2/3")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, index)
+	assert.Equal(t, 3, total)
+
+	for _, s := range []string{"", "1", "0/2", "3/2", "a/2", "1/0"} {
+		_, _, err = ParseShard(s)
+		assert.Error(t, err, s)
+	}
+}
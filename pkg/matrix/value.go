@@ -0,0 +1,176 @@
+package matrix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// object values in vectors are stored as compact json in Vector.Vec and Entry,
+// fields of them are flattened as `<key>.<field>` when matching entries and
+// setting environment variables
+
+// encodeObjectNodes converts object values in the vector node to json scalars
+func encodeObjectNodes(n *yaml.Node) (*yaml.Node, error) {
+	switch n.Kind {
+	case yaml.SequenceNode:
+		var ret *yaml.Node
+		for i, elem := range n.Content {
+			if !isObjectNode(elem) {
+				continue
+			}
+
+			if ret == nil {
+				ret = &yaml.Node{}
+				*ret = *n
+				ret.Content = append([]*yaml.Node{}, n.Content...)
+			}
+
+			val, err := encodeObjectNode(elem)
+			if err != nil {
+				return nil, fmt.Errorf("invalid object value #%d: %w", i, err)
+			}
+
+			ret.Content[i] = val
+		}
+
+		if ret == nil {
+			return n, nil
+		}
+
+		return ret, nil
+	case yaml.MappingNode:
+		if !isObjectNode(n) {
+			return n, nil
+		}
+
+		// single object value
+		val, err := encodeObjectNode(n)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object value: %w", err)
+		}
+
+		return &yaml.Node{
+			Kind:    yaml.SequenceNode,
+			Tag:     "!!seq",
+			Content: []*yaml.Node{val},
+		}, nil
+	default:
+		return n, nil
+	}
+}
+
+// isObjectNode returns true when n is a mapping node without virtual keys
+func isObjectNode(n *yaml.Node) bool {
+	if n.Kind != yaml.MappingNode {
+		return false
+	}
+
+	for i := 0; i < len(n.Content); i += 2 {
+		if strings.HasPrefix(n.Content[i].Value, "__") {
+			return false
+		}
+	}
+
+	return true
+}
+
+func encodeObjectNode(n *yaml.Node) (*yaml.Node, error) {
+	var obj map[string]any
+	err := n.Decode(&obj)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Tag:   "!!str",
+		Value: string(data),
+	}, nil
+}
+
+// IsObjectValue returns true when v is an object value in json form
+func IsObjectValue(v string) bool {
+	return len(v) > 1 && v[0] == '{' && v[len(v)-1] == '}' && json.Valid([]byte(v))
+}
+
+// decodeObjectValue decodes object value v, numbers are kept as json.Number
+func decodeObjectValue(v string) (ret map[string]any, ok bool) {
+	if !IsObjectValue(v) {
+		return nil, false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader([]byte(v)))
+	dec.UseNumber()
+	if dec.Decode(&ret) != nil {
+		return nil, false
+	}
+
+	return ret, true
+}
+
+// flattenValue adds key and scalar value to out, fields of object value are
+// added as `<key>.<field>` recursively
+func flattenValue(key string, v any, out map[string]string) {
+	switch t := v.(type) {
+	case string:
+		obj, ok := decodeObjectValue(t)
+		if !ok {
+			out[key] = t
+			return
+		}
+
+		flattenValue(key, obj, out)
+	case map[string]any:
+		for k, v := range t {
+			flattenValue(key+"."+k, v, out)
+		}
+	case []any:
+		for i, v := range t {
+			flattenValue(key+"."+strconv.Itoa(i), v, out)
+		}
+	case nil:
+		out[key] = ""
+	default:
+		out[key] = fmt.Sprint(t)
+	}
+}
+
+// typedValue converts decoded json value for template use
+func typedValue(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return int(i)
+		}
+
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+
+		return t.String()
+	case map[string]any:
+		for k, v := range t {
+			t[k] = typedValue(v)
+		}
+
+		return t
+	case []any:
+		for i, v := range t {
+			t[i] = typedValue(v)
+		}
+
+		return t
+	default:
+		return v
+	}
+}
@@ -19,6 +19,8 @@ func NewVector(elems ...string) *Vector {
 }
 
 // Vector is a wrapper of a string slice for rendering suffix support
+//
+// values can be strings or objects, object values are stored as compact json
 type Vector struct {
 	rs.BaseField
 
@@ -57,6 +59,11 @@ func (v *Vector) Empty() bool {
 
 // UnmarshalYAML pretends
 func (v *Vector) UnmarshalYAML(value *yaml.Node) error {
+	value, err := encodeObjectNodes(value)
+	if err != nil {
+		return err
+	}
+
 	// fake a map for vector
 	return v.BaseField.UnmarshalYAML(&yaml.Node{
 		Kind:  yaml.MappingNode,
@@ -82,5 +89,15 @@ func (v *Vector) MarshalYAML() (interface{}, error) {
 		return nil, nil
 	}
 
-	return v.Vec, nil
+	ret := make([]any, len(v.Vec))
+	for i, el := range v.Vec {
+		obj, ok := decodeObjectValue(el)
+		if ok {
+			ret[i] = typedValue(obj)
+		} else {
+			ret[i] = el
+		}
+	}
+
+	return ret, nil
 }
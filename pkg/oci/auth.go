@@ -0,0 +1,102 @@
+package oci
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CredentialsFunc returns username and password for the registry host
+type CredentialsFunc func(registry string) (username, password string, ok bool)
+
+// authFile is the common format of containers auth.json and docker config.json
+type authFile struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"auths"`
+}
+
+// DefaultAuthFiles returns auth files used by buildah, podman and docker
+// in the order of lookup
+func DefaultAuthFiles() []string {
+	var files []string
+	if f := os.Getenv("REGISTRY_AUTH_FILE"); len(f) != 0 {
+		files = append(files, f)
+	}
+
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); len(dir) != 0 {
+		files = append(files, filepath.Join(dir, "containers", "auth.json"))
+	}
+
+	home, _ := os.UserHomeDir()
+	if len(home) != 0 {
+		files = append(files, filepath.Join(home, ".config", "containers", "auth.json"))
+	}
+
+	if dir := os.Getenv("DOCKER_CONFIG"); len(dir) != 0 {
+		files = append(files, filepath.Join(dir, "config.json"))
+	} else if len(home) != 0 {
+		files = append(files, filepath.Join(home, ".docker", "config.json"))
+	}
+
+	return files
+}
+
+// CredentialsFromAuthFiles looks up static credentials in auth files,
+// credential helpers are not supported
+func CredentialsFromAuthFiles(files ...string) CredentialsFunc {
+	return func(registry string) (string, string, bool) {
+		keys := []string{registry}
+		if registry == dockerHubRegistry {
+			keys = append(keys,
+				"index.docker.io",
+				"https://index.docker.io/v1/",
+				dockerHubAPIEndpoint,
+			)
+		}
+
+		for _, f := range files {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				continue
+			}
+
+			var af authFile
+			if json.Unmarshal(data, &af) != nil {
+				continue
+			}
+
+			for _, k := range keys {
+				entry, ok := af.Auths[k]
+				if !ok {
+					entry, ok = af.Auths["https://"+k]
+				}
+
+				if !ok {
+					continue
+				}
+
+				if len(entry.Username) != 0 {
+					return entry.Username, entry.Password, true
+				}
+
+				decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+				if err != nil {
+					continue
+				}
+
+				username, password, ok := strings.Cut(string(decoded), ":")
+				if ok {
+					return username, password, true
+				}
+			}
+		}
+
+		return "", "", false
+	}
+}
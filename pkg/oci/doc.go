@@ -0,0 +1,3 @@
+// Package oci provides minimal OCI image index types and a registry client
+// to assemble and push image indexes without external tools
+package oci
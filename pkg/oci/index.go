@@ -0,0 +1,118 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+// nolint:revive
+const (
+	MediaTypeImageIndex    = "application/vnd.oci.image.index.v1+json"
+	MediaTypeImageManifest = "application/vnd.oci.image.manifest.v1+json"
+
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// Platform of an image manifest
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// String returns the platform in `os/arch[/variant]` format
+func (p Platform) String() string {
+	ret := p.OS + "/" + p.Architecture
+	if len(p.Variant) != 0 {
+		ret += "/" + p.Variant
+	}
+
+	return ret
+}
+
+// Descriptor of a manifest in the image index
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Platform    *Platform         `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Index is the OCI image index
+//
+// ref: https://github.com/opencontainers/image-spec/blob/main/image-index.md
+type Index struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	Manifests     []Descriptor      `json:"manifests"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// NewIndex creates an image index of manifests, manifests with the same
+// platform are deduplicated (the last one wins) and sorted by platform
+func NewIndex(manifests []Descriptor) *Index {
+	var (
+		seen = make(map[string]int)
+		ret  []Descriptor
+	)
+
+	for _, m := range manifests {
+		var key string
+		if m.Platform != nil {
+			key = m.Platform.String()
+		} else {
+			key = m.Digest
+		}
+
+		if i, ok := seen[key]; ok {
+			ret[i] = m
+			continue
+		}
+
+		seen[key] = len(ret)
+		ret = append(ret, m)
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		var pi, pj string
+		if p := ret[i].Platform; p != nil {
+			pi = p.String()
+		}
+
+		if p := ret[j].Platform; p != nil {
+			pj = p.String()
+		}
+
+		return pi < pj
+	})
+
+	if ret == nil {
+		ret = []Descriptor{}
+	}
+
+	return &Index{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeImageIndex,
+		Manifests:     ret,
+	}
+}
+
+// Marshal encodes the index as json, returns its content and digest
+func (idx *Index) Marshal() (data []byte, digest string, err error) {
+	data, err = json.Marshal(idx)
+	if err != nil {
+		return
+	}
+
+	return data, Digest(data), nil
+}
+
+// Digest returns the sha256 digest of data in `sha256:<hex>` format
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,152 @@
+// Package ocitest provides an in-process registry for testing
+package ocitest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const testToken = "test-token"
+
+// Manifest stored in the registry
+type Manifest struct {
+	MediaType string
+	Data      []byte
+}
+
+// Registry is an in-process registry serving only manifest apis
+//
+// when Username is set, requests require bearer token obtained using basic auth
+type Registry struct {
+	Server *httptest.Server
+
+	Username string
+	Password string
+
+	mu sync.Mutex
+	// repo -> tag or digest -> manifest
+	manifests map[string]map[string]*Manifest
+}
+
+// NewRegistry starts a new in-process registry, call Close when finished
+func NewRegistry(username, password string) *Registry {
+	r := &Registry{
+		Username:  username,
+		Password:  password,
+		manifests: make(map[string]map[string]*Manifest),
+	}
+
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serve))
+	return r
+}
+
+// Host returns host:port of the registry
+func (r *Registry) Host() string {
+	return strings.TrimPrefix(r.Server.URL, "http://")
+}
+
+// Close stops the registry
+func (r *Registry) Close() { r.Server.Close() }
+
+// AddManifest stores manifest data in repo, returns its digest
+func (r *Registry) AddManifest(repo, tag, mediaType string, data []byte) string {
+	sum := sha256.Sum256(data)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	m := &Manifest{MediaType: mediaType, Data: data}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.manifests[repo] == nil {
+		r.manifests[repo] = make(map[string]*Manifest)
+	}
+
+	r.manifests[repo][digest] = m
+	if len(tag) != 0 {
+		r.manifests[repo][tag] = m
+	}
+
+	return digest
+}
+
+// GetManifest returns the manifest stored in repo with tag or digest
+func (r *Registry) GetManifest(repo, ref string) (*Manifest, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.manifests[repo][ref]
+	return m, ok
+}
+
+func (r *Registry) serve(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		username, password, ok := req.BasicAuth()
+		if !ok || username != r.Username || password != r.Password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"token":"` + testToken + `"}`))
+		return
+	}
+
+	if len(r.Username) != 0 && req.Header.Get("Authorization") != "Bearer "+testToken {
+		w.Header().Set("WWW-Authenticate",
+			`Bearer realm="`+r.Server.URL+`/token",service="ocitest"`,
+		)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	i := strings.LastIndex(path, "/manifests/")
+	if i < 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	repo, ref := path[:i], path[i+len("/manifests/"):]
+	switch req.Method {
+	case http.MethodHead, http.MethodGet:
+		m, ok := r.GetManifest(repo, ref)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		sum := sha256.Sum256(m.Data)
+		w.Header().Set("Content-Type", m.MediaType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(m.Data)))
+		w.Header().Set("Docker-Content-Digest", "sha256:"+hex.EncodeToString(sum[:]))
+		w.WriteHeader(http.StatusOK)
+
+		if req.Method == http.MethodGet {
+			_, _ = w.Write(m.Data)
+		}
+	case http.MethodPut:
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		tag := ref
+		if strings.Contains(ref, ":") {
+			tag = ""
+		}
+
+		digest := r.AddManifest(repo, tag, req.Header.Get("Content-Type"), data)
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
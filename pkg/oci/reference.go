@@ -0,0 +1,104 @@
+package oci
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	dockerHubRegistry    = "docker.io"
+	dockerHubAPIEndpoint = "registry-1.docker.io"
+)
+
+// Reference to an image or manifest in a registry
+type Reference struct {
+	// Registry host (with port if any)
+	Registry string
+
+	// Repository path in the registry
+	Repository string
+
+	// Tag of the image, empty when Digest is set
+	Tag string
+
+	// Digest of the image
+	Digest string
+}
+
+// ParseReference parses image name like `ghcr.io/foo/bar:v1`, `foo/bar@sha256:...`
+//
+// registry defaults to docker.io and tag defaults to latest when there is no digest
+func ParseReference(name string) (ref Reference, err error) {
+	if len(name) == 0 {
+		return ref, fmt.Errorf("invalid empty image name")
+	}
+
+	remainder := name
+	if i := strings.IndexByte(remainder, '@'); i >= 0 {
+		ref.Digest = remainder[i+1:]
+		remainder = remainder[:i]
+
+		if !strings.Contains(ref.Digest, ":") {
+			return ref, fmt.Errorf("invalid digest in image name %q", name)
+		}
+	}
+
+	slash := strings.LastIndexByte(remainder, '/')
+	if i := strings.LastIndexByte(remainder, ':'); i > slash {
+		ref.Tag = remainder[i+1:]
+		remainder = remainder[:i]
+	}
+
+	parts := strings.SplitN(remainder, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry, ref.Repository = parts[0], parts[1]
+	} else {
+		ref.Registry, ref.Repository = dockerHubRegistry, remainder
+	}
+
+	if ref.Registry == dockerHubRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+
+	if len(ref.Repository) == 0 {
+		return ref, fmt.Errorf("invalid image name %q: no repository", name)
+	}
+
+	if len(ref.Tag) == 0 && len(ref.Digest) == 0 {
+		ref.Tag = "latest"
+	}
+
+	return ref, nil
+}
+
+// Name returns the repository name with registry
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// Object returns the digest if set, otherwise the tag
+func (r Reference) Object() string {
+	if len(r.Digest) != 0 {
+		return r.Digest
+	}
+
+	return r.Tag
+}
+
+// String returns the full reference
+func (r Reference) String() string {
+	if len(r.Digest) != 0 {
+		return r.Name() + "@" + r.Digest
+	}
+
+	return r.Name() + ":" + r.Tag
+}
+
+// apiEndpoint returns the host serving registry api
+func (r Reference) apiEndpoint() string {
+	if r.Registry == dockerHubRegistry {
+		return dockerHubAPIEndpoint
+	}
+
+	return r.Registry
+}
@@ -0,0 +1,34 @@
+package oci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReference(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		name     string
+		expected Reference
+	}{
+		{"foo", Reference{Registry: "docker.io", Repository: "library/foo", Tag: "latest"}},
+		{"foo/bar:v1", Reference{Registry: "docker.io", Repository: "foo/bar", Tag: "v1"}},
+		{"ghcr.io/foo/bar", Reference{Registry: "ghcr.io", Repository: "foo/bar", Tag: "latest"}},
+		{"localhost:5000/foo:v1", Reference{Registry: "localhost:5000", Repository: "foo", Tag: "v1"}},
+		{"localhost/foo/bar@sha256:abc", Reference{Registry: "localhost", Repository: "foo/bar", Digest: "sha256:abc"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ref, err := ParseReference(test.name)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, ref)
+		})
+	}
+
+	_, err := ParseReference("")
+	assert.Error(t, err)
+
+	_, err = ParseReference("foo@bar")
+	assert.Error(t, err)
+}
@@ -0,0 +1,307 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// manifestAccept is the list of manifest media types accepted when querying manifests
+var manifestAccept = strings.Join([]string{
+	MediaTypeImageManifest,
+	MediaTypeImageIndex,
+	MediaTypeDockerManifest,
+	MediaTypeDockerManifestList,
+}, ", ")
+
+// Client is a minimal client of the OCI distribution api
+//
+// ref: https://github.com/opencontainers/distribution-spec/blob/main/spec.md
+type Client struct {
+	// HTTPClient to send requests, defaults to http.DefaultClient
+	HTTPClient *http.Client
+
+	// PlainHTTP to talk to registries without tls
+	PlainHTTP bool
+
+	// Credentials for registry authentication, optional
+	Credentials CredentialsFunc
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// HeadManifest returns the descriptor of the manifest referenced by ref
+func (c *Client) HeadManifest(ctx context.Context, ref Reference) (*Descriptor, error) {
+	resp, err := c.do(ctx, ref, http.MethodHead,
+		"/manifests/"+ref.Object(), "", nil,
+		map[string]string{"Accept": manifestAccept},
+	)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("query manifest %q: unexpected status %s", ref.String(), resp.Status)
+	}
+
+	desc := &Descriptor{
+		MediaType: resp.Header.Get("Content-Type"),
+		Digest:    resp.Header.Get("Docker-Content-Digest"),
+		Size:      resp.ContentLength,
+	}
+
+	if len(desc.Digest) == 0 {
+		desc.Digest = ref.Digest
+	}
+
+	if len(desc.Digest) == 0 || len(desc.MediaType) == 0 || desc.Size < 0 {
+		return nil, fmt.Errorf("query manifest %q: incomplete descriptor in response", ref.String())
+	}
+
+	return desc, nil
+}
+
+// PutManifest uploads the manifest to ref (tag or digest), returns the digest of the manifest
+func (c *Client) PutManifest(
+	ctx context.Context, ref Reference, mediaType string, data []byte,
+) (string, error) {
+	resp, err := c.do(ctx, ref, http.MethodPut,
+		"/manifests/"+ref.Object(), mediaType, data, nil,
+	)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("push manifest %q: unexpected status %s: %s",
+			ref.String(), resp.Status, bytes.TrimSpace(msg),
+		)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if len(digest) == 0 {
+		digest = Digest(data)
+	}
+
+	return digest, nil
+}
+
+func (c *Client) do(
+	ctx context.Context,
+	ref Reference,
+	method, path, contentType string,
+	body []byte,
+	headers map[string]string,
+) (*http.Response, error) {
+	scheme := "https"
+	if c.PlainHTTP {
+		scheme = "http"
+	}
+
+	targetURL := scheme + "://" + ref.apiEndpoint() + "/v2/" + ref.Repository + path
+	scope := "repository:" + ref.Repository + ":pull"
+	if method == http.MethodPut {
+		scope += ",push"
+	}
+
+	for retried := false; ; retried = true {
+		req, err := http.NewRequestWithContext(ctx, method, targetURL, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("creating registry request: %w", err)
+		}
+
+		if len(contentType) != 0 {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		c.setAuth(req, ref.Registry, scope)
+
+		resp, err := c.client().Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusUnauthorized || retried {
+			return resp, nil
+		}
+
+		challenge := resp.Header.Get("WWW-Authenticate")
+		_ = resp.Body.Close()
+
+		err = c.authorize(ctx, ref.Registry, scope, challenge)
+		if err != nil {
+			return nil, fmt.Errorf("registry authorization: %w", err)
+		}
+	}
+}
+
+func (c *Client) client() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+
+	return http.DefaultClient
+}
+
+func (c *Client) setAuth(req *http.Request, registry, scope string) {
+	c.mu.Lock()
+	token, ok := c.tokens[registry+" "+scope]
+	c.mu.Unlock()
+
+	if ok {
+		req.Header.Set("Authorization", token)
+	}
+}
+
+// authorize handles the auth challenge from registry, and cache the authorization header value
+func (c *Client) authorize(ctx context.Context, registry, scope, challenge string) error {
+	var username, password string
+	hasCreds := false
+	if c.Credentials != nil {
+		username, password, hasCreds = c.Credentials(registry)
+	}
+
+	scheme, params := parseChallenge(challenge)
+
+	var authz string
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if !hasCreds {
+			return fmt.Errorf("no credentials for %q", registry)
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(username, password)
+		authz = req.Header.Get("Authorization")
+	case "bearer":
+		token, err := c.fetchToken(ctx, params, scope, username, password, hasCreds)
+		if err != nil {
+			return err
+		}
+
+		authz = "Bearer " + token
+	default:
+		return fmt.Errorf("unsupported auth challenge %q", challenge)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tokens == nil {
+		c.tokens = make(map[string]string)
+	}
+
+	c.tokens[registry+" "+scope] = authz
+	return nil
+}
+
+func (c *Client) fetchToken(
+	ctx context.Context,
+	params map[string]string,
+	scope, username, password string,
+	hasCreds bool,
+) (string, error) {
+	realm := params["realm"]
+	if len(realm) == 0 {
+		return "", fmt.Errorf("no realm in bearer auth challenge")
+	}
+
+	query := url.Values{}
+	if svc := params["service"]; len(svc) != 0 {
+		query.Set("service", svc)
+	}
+	query.Set("scope", scope)
+
+	tokenURL := realm
+	if strings.Contains(realm, "?") {
+		tokenURL += "&" + query.Encode()
+	} else {
+		tokenURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating token request: %w", err)
+	}
+
+	if hasCreds {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetch token: unexpected status %s", resp.Status)
+	}
+
+	var result struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return "", fmt.Errorf("decode token response: %w", err)
+	}
+
+	if len(result.Token) != 0 {
+		return result.Token, nil
+	}
+
+	if len(result.AccessToken) != 0 {
+		return result.AccessToken, nil
+	}
+
+	return "", fmt.Errorf("no token in token response")
+}
+
+// parseChallenge parses WWW-Authenticate header value like
+// `Bearer realm="https://auth.example.com/token",service="registry.example.com"`
+func parseChallenge(challenge string) (scheme string, params map[string]string) {
+	params = make(map[string]string)
+
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	for len(rest) != 0 {
+		rest = strings.TrimLeft(rest, " ,")
+
+		var key string
+		key, rest, _ = strings.Cut(rest, "=")
+		if len(key) == 0 {
+			break
+		}
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+
+	return
+}
@@ -0,0 +1,99 @@
+package oci_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"arhat.dev/dukkha/pkg/oci"
+	"arhat.dev/dukkha/pkg/oci/ocitest"
+)
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	reg := ocitest.NewRegistry("user", "pass")
+	defer reg.Close()
+
+	amd64Digest := reg.AddManifest("foo", "amd64", oci.MediaTypeImageManifest, []byte(`{"amd64":true}`))
+	arm64Digest := reg.AddManifest("foo", "arm64", oci.MediaTypeImageManifest, []byte(`{"arm64":true}`))
+
+	client := &oci.Client{
+		PlainHTTP: true,
+		Credentials: func(registry string) (string, string, bool) {
+			return "user", "pass", registry == reg.Host()
+		},
+	}
+
+	ref, err := oci.ParseReference(reg.Host() + "/foo@" + arm64Digest)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	arm64, err := client.HeadManifest(context.TODO(), ref)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, &oci.Descriptor{
+		MediaType: oci.MediaTypeImageManifest,
+		Digest:    arm64Digest,
+		Size:      int64(len(`{"arm64":true}`)),
+	}, arm64)
+	arm64.Platform = &oci.Platform{OS: "linux", Architecture: "arm64"}
+
+	ref.Digest = amd64Digest
+	amd64, err := client.HeadManifest(context.TODO(), ref)
+	if !assert.NoError(t, err) {
+		return
+	}
+	amd64.Platform = &oci.Platform{OS: "linux", Architecture: "amd64"}
+
+	idx := oci.NewIndex([]oci.Descriptor{*arm64, *amd64})
+	data, digest, err := idx.Marshal()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ref.Digest, ref.Tag = "", "latest"
+	pushed, err := client.PutManifest(context.TODO(), ref, oci.MediaTypeImageIndex, data)
+	assert.NoError(t, err)
+	assert.Equal(t, digest, pushed)
+
+	m, ok := reg.GetManifest("foo", "latest")
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, oci.MediaTypeImageIndex, m.MediaType)
+
+	var actual oci.Index
+	assert.NoError(t, json.Unmarshal(m.Data, &actual))
+	if assert.Len(t, actual.Manifests, 2) {
+		// sorted by platform
+		assert.Equal(t, amd64Digest, actual.Manifests[0].Digest)
+		assert.Equal(t, arm64Digest, actual.Manifests[1].Digest)
+	}
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		_, err := (&oci.Client{PlainHTTP: true}).HeadManifest(context.TODO(), ref)
+		assert.Error(t, err)
+	})
+}
+
+func TestNewIndex(t *testing.T) {
+	t.Parallel()
+
+	idx := oci.NewIndex([]oci.Descriptor{
+		{Digest: "sha256:a", Platform: &oci.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}},
+		{Digest: "sha256:b", Platform: &oci.Platform{OS: "linux", Architecture: "amd64"}},
+		{Digest: "sha256:c", Platform: &oci.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}},
+	})
+
+	assert.Equal(t, 2, idx.SchemaVersion)
+	assert.Equal(t, oci.MediaTypeImageIndex, idx.MediaType)
+	if assert.Len(t, idx.Manifests, 2) {
+		assert.Equal(t, "sha256:b", idx.Manifests[0].Digest)
+		assert.Equal(t, "sha256:c", idx.Manifests[1].Digest)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"mvdan.cc/sh/v3/syntax"
 
 	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/utils"
 )
 
 func WriteExecStart(
@@ -17,6 +18,7 @@ func WriteExecStart(
 	k dukkha.ToolKey,
 	cmds []string,
 	scriptName string,
+	masker *utils.Masker,
 ) {
 	var sb strings.Builder
 	sb.WriteString(">>> ")
@@ -40,9 +42,9 @@ func WriteExecStart(
 	}
 
 	if prefixColor != nil {
-		printlnWithColor(stdout, sb.String(), prefixColor)
+		printlnWithColor(stdout, masker.Mask(sb.String()), prefixColor)
 	} else {
-		_, _ = fmt.Fprintln(stdout, sb.String())
+		_, _ = fmt.Fprintln(stdout, masker.Mask(sb.String()))
 	}
 }
 
@@ -53,6 +55,7 @@ func WriteExecResult(
 	tk dukkha.TaskKey,
 	matrixSpec string,
 	err error,
+	masker *utils.Masker,
 ) {
 	var sb strings.Builder
 	if err != nil {
@@ -69,7 +72,7 @@ func WriteExecResult(
 
 	if err != nil {
 		sb.WriteString(" }: ")
-		sb.WriteString(err.Error())
+		sb.WriteString(masker.Mask(err.Error()))
 	} else {
 		sb.WriteString(" }")
 	}
@@ -34,6 +34,31 @@ func WriteTaskStart(
 	}
 }
 
+// WriteTaskUpToDate writes message for skipped matrix entry with unchanged
+// inputs and outputs
+func WriteTaskUpToDate(
+	stderr io.Writer,
+	prefixColor termenv.Color,
+	k dukkha.ToolKey,
+	tk dukkha.TaskKey,
+	matrixSpec matrix.Entry,
+) {
+	var sb strings.Builder
+	sb.WriteString("UP-TO-DATE ")
+	sb.WriteString(AssembleTaskKindID(k, tk.Kind))
+	sb.WriteString(" [ ")
+	sb.WriteString(string(tk.Name))
+	sb.WriteString(" ] { ")
+	sb.WriteString(matrixSpec.String())
+	sb.WriteString(" }")
+
+	if prefixColor != nil {
+		printlnWithColor(stderr, sb.String(), prefixColor)
+	} else {
+		_, _ = fmt.Fprintln(stderr, sb.String())
+	}
+}
+
 func printlnWithColor(stdout io.Writer, str string, color termenv.Color) {
 	style := termenv.String(str).Foreground(color)
 	_, _ = fmt.Fprintln(stdout, style.String())
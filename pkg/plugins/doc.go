@@ -1,2 +1,21 @@
 // Package plugins provides an interface for using third party renderers & tools on the fly
+//
+// Plugins are go source files interpreted by scriggo, they register renderers and
+// tasks by calling functions in package `arhat.dev/dukkha/pkg/plugins` in their
+// `main` function:
+//
+//	package main
+//
+//	import (
+//		"arhat.dev/dukkha/pkg/dukkha"
+//		"arhat.dev/dukkha/pkg/plugins"
+//	)
+//
+//	func main() {
+//		plugins.RegisterRenderer("my-renderer", render)
+//	}
+//
+//	func render(rc dukkha.RenderingContext, rawData any, attrs []dukkha.RendererAttribute) ([]byte, error) {
+//		return []byte("hello"), nil
+//	}
 package plugins
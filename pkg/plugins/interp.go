@@ -1 +1,75 @@
 package plugins
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing/fstest"
+
+	"github.com/open2b/scriggo"
+	"github.com/open2b/scriggo/native"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/plugins/internal"
+)
+
+// PackagePath is the import path of the package providing plugin registration
+// functions to plugins
+const PackagePath = "arhat.dev/dukkha/pkg/plugins"
+
+var packages = native.CombinedImporter{
+	internal.NativePackages,
+	native.Packages{
+		PackagePath: native.Package{
+			Name: "plugins",
+			Declarations: native.Declarations{
+				"RegisterRenderer": RegisterRenderer,
+				"RegisterTool":     RegisterTool,
+				"RegisterTask":     RegisterTask,
+
+				"RenderFunc":       reflect.TypeOf((*RenderFunc)(nil)).Elem(),
+				"GetExecSpecsFunc": reflect.TypeOf((*GetExecSpecsFunc)(nil)).Elem(),
+			},
+		},
+	},
+}
+
+// Load builds plugin source as program and runs it
+//
+// the plugin is expected to register renderers and tasks in its main function
+func Load(ctx context.Context, name string, src []byte) error {
+	prog, err := scriggo.Build(fstest.MapFS{
+		"main.go": &fstest.MapFile{Data: src},
+	}, &scriggo.BuildOptions{
+		AllowGoStmt: false,
+		Packages:    packages,
+	})
+	if err != nil {
+		return fmt.Errorf("plugin %q: build: %w", name, err)
+	}
+
+	err = prog.Run(&scriggo.RunOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("plugin %q: run: %w", name, err)
+	}
+
+	return nil
+}
+
+// RenderFunc is the function implementing dukkha.Renderer.RenderYaml
+type RenderFunc = func(
+	rc dukkha.RenderingContext,
+	rawData any,
+	attributes []dukkha.RendererAttribute,
+) ([]byte, error)
+
+// GetExecSpecsFunc is the function implementing tools.TaskImpl.GetExecSpecs
+//
+// spec is the task config with all fields resolved
+type GetExecSpecsFunc = func(
+	rc dukkha.TaskExecContext,
+	spec map[string]any,
+	options dukkha.TaskMatrixExecOptions,
+) ([]dukkha.TaskExecSpec, error)
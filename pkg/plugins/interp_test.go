@@ -2,12 +2,17 @@ package plugins
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
+	"arhat.dev/rs"
 	"github.com/open2b/scriggo"
 	"github.com/open2b/scriggo/native"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 
+	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
 	"arhat.dev/dukkha/pkg/plugins/internal"
 )
 
@@ -90,3 +95,78 @@ func BenchmarkRun(b *testing.B) {
 		_ = prog.Run(nil)
 	}
 }
+
+const testSrcPlugin = `package main
+
+import (
+	"fmt"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/plugins"
+)
+
+func main() {
+	plugins.RegisterRenderer("test-plugin", func(
+		rc dukkha.RenderingContext, rawData any, attributes []dukkha.RendererAttribute,
+	) ([]byte, error) {
+		return []byte(fmt.Sprint("plugin: ", rawData)), nil
+	})
+
+	plugins.RegisterTool("test-plugin", "echo")
+	plugins.RegisterTask("test-plugin", "say", func(
+		rc dukkha.TaskExecContext, spec map[string]any, options dukkha.TaskMatrixExecOptions,
+	) ([]dukkha.TaskExecSpec, error) {
+		return []dukkha.TaskExecSpec{{
+			Command: []string{"echo", fmt.Sprint(spec["message"])},
+		}}, nil
+	})
+}
+`
+
+func TestLoad(t *testing.T) {
+	assert.Error(t, Load(context.TODO(), "invalid", []byte("package main\n\nfunc main() { undefined() }")))
+
+	if !assert.NoError(t, Load(context.TODO(), "test", []byte(testSrcPlugin))) {
+		return
+	}
+
+	rc := dt.NewTestContext(context.TODO(), t.TempDir())
+
+	r, err := dukkha.GlobalInterfaceTypeHandler.Create(
+		reflect.TypeOf((*dukkha.Renderer)(nil)).Elem(), "test-plugin",
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ret, err := r.(dukkha.Renderer).RenderYaml(rc, "foo", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "plugin: foo", string(ret))
+
+	tool, err := dukkha.GlobalInterfaceTypeHandler.Create(
+		reflect.TypeOf((*dukkha.Tool)(nil)).Elem(), "test-plugin",
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, dukkha.ToolKind("test-plugin"), tool.(dukkha.Tool).Kind())
+
+	tsk, err := dukkha.GlobalInterfaceTypeHandler.Create(
+		reflect.TypeOf((*dukkha.Task)(nil)).Elem(), "test-plugin:say",
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	task := tsk.(dukkha.Task)
+	rs.InitRecursively(reflect.ValueOf(task), nil)
+	assert.NoError(t, yaml.Unmarshal([]byte(`{ name: test, message: hello }`), task))
+	assert.NoError(t, task.Init(nil))
+	assert.Equal(t, dukkha.TaskKey{Kind: "say", Name: "test"}, task.Key())
+
+	specs, err := task.GetExecSpecs(rc, dt.CreateTaskMatrixExecOptions())
+	assert.NoError(t, err)
+	assert.Equal(t, []dukkha.TaskExecSpec{{
+		Command: []string{"echo", "hello"},
+	}}, specs)
+}
@@ -0,0 +1,38 @@
+package plugins
+
+import (
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/renderer"
+)
+
+// RegisterRenderer registers a renderer implemented by plugin
+func RegisterRenderer(name string, render RenderFunc) {
+	dukkha.RegisterRenderer(name, func(name string) dukkha.Renderer {
+		return &scriptRenderer{name: name, render: render}
+	})
+}
+
+var _ dukkha.Renderer = (*scriptRenderer)(nil)
+
+type scriptRenderer struct {
+	rs.BaseField `yaml:"-"`
+
+	renderer.BaseRenderer `yaml:",inline"`
+
+	name   string
+	render RenderFunc
+}
+
+func (d *scriptRenderer) RenderYaml(
+	rc dukkha.RenderingContext, rawData any, attributes []dukkha.RendererAttribute,
+) ([]byte, error) {
+	// plugins always get plain go values
+	rawData, err := rs.NormalizeRawData(rawData)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.render(rc, rawData, d.Attributes(attributes))
+}
@@ -0,0 +1,75 @@
+package plugins
+
+import (
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/tools"
+)
+
+// RegisterTool registers a tool kind for tasks implemented by plugin
+//
+// defaultExecutable is used as the tool cmd when `cmd` is not set in tool config
+func RegisterTool(kind dukkha.ToolKind, defaultExecutable string) {
+	dukkha.RegisterTool(kind, func() dukkha.Tool {
+		t := &scriptTool{}
+		t.Impl.kind = kind
+		t.Impl.defaultExecutable = defaultExecutable
+		return t
+	})
+}
+
+type scriptToolImpl struct {
+	kind              dukkha.ToolKind
+	defaultExecutable string
+}
+
+func (t *scriptToolImpl) DefaultExecutable() string { return t.defaultExecutable }
+func (t *scriptToolImpl) Kind() dukkha.ToolKind     { return t.kind }
+
+type scriptTool struct {
+	tools.BaseTool[scriptToolImpl, *scriptToolImpl]
+}
+
+// RegisterTask registers a task implemented by plugin
+//
+// the tool kind can be a builtin one or one registered by RegisterTool
+func RegisterTask(toolKind dukkha.ToolKind, taskKind dukkha.TaskKind, getExecSpecs GetExecSpecsFunc) {
+	dukkha.RegisterTask(toolKind, taskKind, func(toolName string) dukkha.Task {
+		t := tools.NewTask[scriptTask, *scriptTask](toolName).(*scriptTask)
+		t.Impl.toolKind = toolKind
+		t.Impl.kind = taskKind
+		t.Impl.getExecSpecs = getExecSpecs
+		return t
+	})
+}
+
+type scriptTask struct {
+	tools.BaseTask[scriptTaskImpl, *scriptTaskImpl]
+}
+
+type scriptTaskImpl struct {
+	// Spec is the plugin defined task config
+	Spec map[string]any `yaml:",inline"`
+
+	toolKind     dukkha.ToolKind
+	kind         dukkha.TaskKind
+	getExecSpecs GetExecSpecsFunc
+
+	parent tools.BaseTaskType
+}
+
+func (c *scriptTaskImpl) ToolKind() dukkha.ToolKind       { return c.toolKind }
+func (c *scriptTaskImpl) Kind() dukkha.TaskKind           { return c.kind }
+func (c *scriptTaskImpl) LinkParent(p tools.BaseTaskType) { c.parent = p }
+
+func (c *scriptTaskImpl) GetExecSpecs(
+	rc dukkha.TaskExecContext, options dukkha.TaskMatrixExecOptions,
+) ([]dukkha.TaskExecSpec, error) {
+	var ret []dukkha.TaskExecSpec
+	err := c.parent.DoAfterFieldsResolved(rc, -1, true, func() error {
+		var err error
+		ret, err = c.getExecSpecs(rc, c.Spec, options)
+		return err
+	})
+
+	return ret, err
+}
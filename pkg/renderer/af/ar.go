@@ -0,0 +1,173 @@
+package af
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ar archive format
+//
+// ref: https://en.wikipedia.org/wiki/Ar_(Unix)
+
+const (
+	arMagic     = "!<arch>\n"
+	arHeaderLen = 60
+)
+
+func walkAr(src io.ReadSeeker, _ string, fn walkFunc) error {
+	var (
+		buf       [arHeaderLen]byte
+		longNames []byte
+	)
+
+	_, err := io.ReadFull(src, buf[:len(arMagic)])
+	if err != nil {
+		return fmt.Errorf("unar: reading magic: %w", err)
+	}
+
+	if string(buf[:len(arMagic)]) != arMagic {
+		return fmt.Errorf("unar: invalid ar archive")
+	}
+
+	for {
+		_, err = io.ReadFull(src, buf[:])
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return fmt.Errorf("unar: reading header: %w", err)
+		}
+
+		if string(buf[58:60]) != "`\n" {
+			return fmt.Errorf("unar: invalid file header")
+		}
+
+		size, err := strconv.ParseInt(strings.TrimSpace(string(buf[48:58])), 10, 64)
+		if err != nil {
+			return fmt.Errorf("unar: invalid file size: %w", err)
+		}
+
+		var (
+			name = strings.TrimRight(string(buf[:16]), " ")
+			data = &io.LimitedReader{R: src, N: size}
+		)
+
+		switch {
+		case name == "/", name == "/SYM64/":
+			// gnu symbol table
+			name = ""
+		case name == "//":
+			// gnu long file names
+			longNames, err = io.ReadAll(data)
+			if err != nil {
+				return fmt.Errorf("unar: reading long file names: %w", err)
+			}
+
+			name = ""
+		case strings.HasPrefix(name, "#1/"):
+			// bsd long file name
+			var nameBytes []byte
+			nameBytes, err = readArBSDName(data, name)
+			if err != nil {
+				return err
+			}
+
+			name = string(bytes.TrimRight(nameBytes, "\x00"))
+		case strings.HasPrefix(name, "/"):
+			// gnu long file name reference
+			var offset int
+			offset, err = strconv.Atoi(name[1:])
+			if err != nil || offset >= len(longNames) {
+				return fmt.Errorf("unar: invalid long file name reference %q", name)
+			}
+
+			name = string(longNames[offset:])
+			if idx := strings.IndexByte(name, '\n'); idx != -1 {
+				name = name[:idx]
+			}
+
+			name = strings.TrimSuffix(name, "/")
+		default:
+			name = strings.TrimSuffix(name, "/")
+		}
+
+		if len(name) != 0 {
+			err = fn(&archiveEntry{Name: normalizeArchivePath(name)}, data)
+			if err != nil {
+				return err
+			}
+		}
+
+		// skip unread data and padding
+		_, err = src.Seek(data.N+size%2, io.SeekCurrent)
+		if err != nil {
+			return fmt.Errorf("unar: %w", err)
+		}
+	}
+}
+
+func readArBSDName(data io.Reader, name string) ([]byte, error) {
+	size, err := strconv.Atoi(name[3:])
+	if err != nil {
+		return nil, fmt.Errorf("unar: invalid bsd long file name %q", name)
+	}
+
+	ret := make([]byte, size)
+	_, err = io.ReadFull(data, ret)
+	if err != nil {
+		return nil, fmt.Errorf("unar: reading bsd long file name: %w", err)
+	}
+
+	return ret, nil
+}
+
+// extractFromDeb finds target in ar archive, for debian packages, target
+// not found in ar archive is looked up in the data.tar.* file
+func extractFromDeb(src archiveSource, target, password string) (io.ReadCloser, error) {
+	restore, err := prepareSeekRestore(src)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := extractFromArchive(src, walkAr, target, password)
+	if err == nil {
+		return ret, nil
+	}
+
+	err2 := restore()
+	if err2 != nil {
+		return nil, err2
+	}
+
+	data, err2 := lookupDebData(src)
+	if err2 != nil {
+		return nil, err
+	}
+
+	return unarchiveNext(src, data, target, password)
+}
+
+// lookupDebData finds the data.tar.* file in debian package
+func lookupDebData(src io.ReadSeeker) (ret io.Reader, err error) {
+	err = walkAr(src, "", func(ent *archiveEntry, r io.Reader) error {
+		if strings.HasPrefix(ent.Name, "data.tar") {
+			ret = r
+			return errStopWalk
+		}
+
+		return nil
+	})
+	if err != nil && err != errStopWalk {
+		return nil, err
+	}
+
+	if ret == nil {
+		return nil, fmt.Errorf("unar: data file not found in debian package")
+	}
+
+	return ret, nil
+}
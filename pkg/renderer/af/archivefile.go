@@ -3,6 +3,8 @@
 package af
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -143,5 +145,20 @@ func extractFileFromArchive(ofs *fshelper.OSFS, obj cache.IdentifiableObject) (i
 		*os.File
 	}
 
-	return unarchive(&src{info, f.(*os.File)}, typ, spec.Path, spec.Password)
+	if !spec.Flatten {
+		return unarchive(&src{info, f.(*os.File)}, typ, spec.Path, spec.Password)
+	}
+
+	files, err := flatten(&src{info, f.(*os.File)}, typ, spec.Path, spec.Password)
+	_ = f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(files)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
 }
@@ -82,6 +82,13 @@ func TestDriver(t *testing.T) {
 					"103.zip.bz2",
 					"104.zip.lzma",
 					"105.zip.xz",
+
+					// packages
+					"201.deb",
+					"301.rpm",
+
+					// 7z
+					"401.7z",
 				}, " "),
 			})
 
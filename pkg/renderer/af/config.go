@@ -22,8 +22,12 @@ type inputSpec struct {
 }
 
 func (s *inputSpec) ScopeUniqueID() string {
-	// return append([]byte(s.Archive), "|...|"+s.Path...))
-	return s.Archive
+	id := s.Archive + ":" + s.Path
+	if s.Flatten {
+		id += "|flatten"
+	}
+
+	return id
 }
 
 func (s *inputSpec) Ext() string {
@@ -0,0 +1,111 @@
+package af
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// cpio archive format (newc), used as rpm payload
+//
+// ref: https://man7.org/linux/man-pages/man5/cpio.5.html
+
+const (
+	cpioMagicNewc    = "070701"
+	cpioMagicNewcCRC = "070702"
+
+	cpioHeaderLen = 110
+	cpioTrailer   = "TRAILER!!!"
+
+	cpioModeTypeMask = 0170000
+	cpioModeDir      = 0040000
+	cpioModeRegular  = 0100000
+	cpioModeSymlink  = 0120000
+)
+
+func walkCpio(src io.ReadSeeker, _ string, fn walkFunc) error {
+	var hdr [cpioHeaderLen]byte
+
+	for {
+		_, err := io.ReadFull(src, hdr[:])
+		if err != nil {
+			return fmt.Errorf("uncpio: reading header: %w", err)
+		}
+
+		if magic := string(hdr[:6]); magic != cpioMagicNewc && magic != cpioMagicNewcCRC {
+			return fmt.Errorf("uncpio: unsupported format %q", magic)
+		}
+
+		field := func(i int) (int64, error) {
+			start := 6 + 8*i
+			return strconv.ParseInt(string(hdr[start:start+8]), 16, 64)
+		}
+
+		mode, err := field(1)
+		if err != nil {
+			return fmt.Errorf("uncpio: invalid mode: %w", err)
+		}
+
+		size, err := field(6)
+		if err != nil {
+			return fmt.Errorf("uncpio: invalid file size: %w", err)
+		}
+
+		nameSize, err := field(11)
+		if err != nil {
+			return fmt.Errorf("uncpio: invalid name size: %w", err)
+		}
+
+		// name is padded to make header + name multiple of 4
+		nameBytes := make([]byte, nameSize+cpioPadding(cpioHeaderLen+nameSize))
+		_, err = io.ReadFull(src, nameBytes)
+		if err != nil {
+			return fmt.Errorf("uncpio: reading name: %w", err)
+		}
+
+		name := strings.TrimRight(string(nameBytes[:nameSize]), "\x00")
+		if name == cpioTrailer {
+			return nil
+		}
+
+		var (
+			ent  = &archiveEntry{Name: normalizeArchivePath(name)}
+			data = &io.LimitedReader{R: src, N: size}
+		)
+
+		switch mode & cpioModeTypeMask {
+		case cpioModeRegular:
+		case cpioModeDir:
+			ent.Mode = fs.ModeDir
+		case cpioModeSymlink:
+			ent.Mode = fs.ModeSymlink
+
+			var target []byte
+			target, err = io.ReadAll(data)
+			if err != nil {
+				return fmt.Errorf("uncpio: reading link %q: %w", name, err)
+			}
+
+			ent.Linkname = string(target)
+		default:
+			ent.Mode = fs.ModeIrregular
+		}
+
+		err = fn(ent, data)
+		if err != nil {
+			return err
+		}
+
+		// skip unread data and padding
+		_, err = src.Seek(data.N+cpioPadding(size), io.SeekCurrent)
+		if err != nil {
+			return fmt.Errorf("uncpio: %w", err)
+		}
+	}
+}
+
+func cpioPadding(n int64) int64 {
+	return (4 - n%4) % 4
+}
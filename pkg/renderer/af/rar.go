@@ -3,18 +3,46 @@ package af
 import (
 	"fmt"
 	"io"
+	"io/fs"
 
 	"github.com/nwaples/rardecode"
 )
 
-// nolint:unparam
-func unrar(src io.Reader, target, password string) (io.Reader, error) {
-	// TODO: implement
-	_ = target
+func walkRar(src io.ReadSeeker, password string, fn walkFunc) error {
 	r, err := rardecode.NewReader(src, password)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("unrar: %w", err)
+	}
+
+	for {
+		hdr, err := r.Next()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return fmt.Errorf("unrar: %w", err)
+		}
+
+		ent := &archiveEntry{
+			Name: normalizeArchivePath(hdr.Name),
+			Mode: hdr.Mode() & fs.ModeType,
+		}
+
+		if ent.Mode&fs.ModeSymlink != 0 {
+			// link target is stored as file content
+			var target []byte
+			target, err = io.ReadAll(r)
+			if err != nil {
+				return fmt.Errorf("unrar: reading link %q: %w", hdr.Name, err)
+			}
+
+			ent.Linkname = string(target)
+		}
+
+		err = fn(ent, r)
+		if err != nil {
+			return err
+		}
 	}
-	_ = r
-	return nil, fmt.Errorf("no implementation")
 }
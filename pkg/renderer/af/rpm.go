@@ -0,0 +1,60 @@
+package af
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// rpm package format
+//
+// ref: https://rpm-software-management.github.io/rpm/manual/format.html
+
+const (
+	rpmLeadLen = 96
+
+	rpmHeaderMagic = "\x8e\xad\xe8\x01"
+)
+
+// rpmPayload skips rpm lead, signature and header, returns the reader of
+// the (compressed) cpio payload
+func rpmPayload(src io.ReadSeeker) (io.Reader, error) {
+	_, err := src.Seek(rpmLeadLen, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("unrpm: skipping lead: %w", err)
+	}
+
+	// signature header is padded to multiple of 8
+	err = skipRpmHeader(src, 8)
+	if err != nil {
+		return nil, fmt.Errorf("unrpm: skipping signature: %w", err)
+	}
+
+	err = skipRpmHeader(src, 1)
+	if err != nil {
+		return nil, fmt.Errorf("unrpm: skipping header: %w", err)
+	}
+
+	return src, nil
+}
+
+func skipRpmHeader(src io.ReadSeeker, align int64) error {
+	var hdr [16]byte
+	_, err := io.ReadFull(src, hdr[:])
+	if err != nil {
+		return err
+	}
+
+	if string(hdr[:4]) != rpmHeaderMagic {
+		return fmt.Errorf("invalid header magic")
+	}
+
+	var (
+		count = int64(binary.BigEndian.Uint32(hdr[8:]))
+		size  = int64(binary.BigEndian.Uint32(hdr[12:])) + count*16
+	)
+
+	size += (align - size%align) % align
+	_, err = src.Seek(size, io.SeekCurrent)
+	return err
+}
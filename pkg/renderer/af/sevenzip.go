@@ -0,0 +1,1021 @@
+package af
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"unicode/utf16"
+
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// minimal 7z archive reader, only folders with single coder are supported
+// (copy, lzma, lzma2, deflate and bzip2), archives with multiple coders
+// (e.g. BCJ filters) or encryption are rejected with errSevenZipUnsupported
+//
+// ref: https://py7zr.readthedocs.io/en/latest/archive_format.html
+
+const (
+	sevenZipSignature    = "7z\xbc\xaf\x27\x1c"
+	sevenZipSigHeaderLen = 32
+)
+
+// nolint:deadcode,varcheck
+const (
+	sevenZipPropEnd = iota
+	sevenZipPropHeader
+	sevenZipPropArchiveProperties
+	sevenZipPropAdditionalStreamsInfo
+	sevenZipPropMainStreamsInfo
+	sevenZipPropFilesInfo
+	sevenZipPropPackInfo
+	sevenZipPropUnpackInfo
+	sevenZipPropSubStreamsInfo
+	sevenZipPropSize
+	sevenZipPropCRC
+	sevenZipPropFolder
+	sevenZipPropCodersUnpackSize
+	sevenZipPropNumUnpackStream
+	sevenZipPropEmptyStream
+	sevenZipPropEmptyFile
+	sevenZipPropAnti
+	sevenZipPropName
+	sevenZipPropCTime
+	sevenZipPropATime
+	sevenZipPropMTime
+	sevenZipPropWinAttributes
+	sevenZipPropComment
+	sevenZipPropEncodedHeader
+	sevenZipPropStartPos
+	sevenZipPropDummy
+)
+
+const (
+	sevenZipCoderCopy    = "\x00"
+	sevenZipCoderLZMA    = "\x03\x01\x01"
+	sevenZipCoderLZMA2   = "\x21"
+	sevenZipCoderDeflate = "\x04\x01\x08"
+	sevenZipCoderBZip2   = "\x04\x02\x02"
+	sevenZipCoderAES     = "\x06\xf1\x07\x01"
+	sevenZipCoderBCJ     = "\x03\x03\x01\x03"
+	sevenZipCoderBCJ2    = "\x03\x03\x01\x1b"
+)
+
+var (
+	errSevenZipInvalid     = errors.New("invalid 7z archive")
+	errSevenZipUnsupported = errors.New("unsupported 7z archive")
+)
+
+type sevenZipFolder struct {
+	coderID    string
+	coderProps []byte
+
+	unpackSize int64
+
+	// numSubstreams is the count of files stored in this folder
+	numSubstreams int
+	substreams    []int64
+
+	packIndex int
+}
+
+type sevenZipStreams struct {
+	packPos   int64
+	packSizes []int64
+
+	folders []*sevenZipFolder
+}
+
+type sevenZipFile struct {
+	name      string
+	hasStream bool
+	isDir     bool
+	attrib    uint32
+}
+
+func walk7z(src io.ReadSeeker, password string, fn walkFunc) error {
+	if len(password) != 0 {
+		return fmt.Errorf("un7z: %w: password protected 7z archive", errSevenZipUnsupported)
+	}
+
+	sra, ok := src.(SizedReaderAt)
+	if !ok {
+		return fmt.Errorf("un7z: unexpected non seekable source")
+	}
+
+	var sigHdr [sevenZipSigHeaderLen]byte
+	_, err := sra.ReadAt(sigHdr[:], 0)
+	if err != nil {
+		return fmt.Errorf("un7z: reading signature header: %w", err)
+	}
+
+	if string(sigHdr[:6]) != sevenZipSignature {
+		return fmt.Errorf("un7z: %w: bad signature", errSevenZipInvalid)
+	}
+
+	var (
+		nextHeaderOffset = int64(binary.LittleEndian.Uint64(sigHdr[12:]))
+		nextHeaderSize   = int64(binary.LittleEndian.Uint64(sigHdr[20:]))
+		maxHeaderSize    = sra.Size() - sevenZipSigHeaderLen - nextHeaderOffset
+	)
+
+	// values come from the archive, check before allocating
+	if nextHeaderOffset < 0 || nextHeaderSize < 0 || nextHeaderSize > maxHeaderSize {
+		return fmt.Errorf("un7z: %w: header out of range (offset %d, size %d)",
+			errSevenZipInvalid, nextHeaderOffset, nextHeaderSize,
+		)
+	}
+
+	header := make([]byte, nextHeaderSize)
+	_, err = sra.ReadAt(header, sevenZipSigHeaderLen+nextHeaderOffset)
+	if err != nil {
+		return fmt.Errorf("un7z: reading header: %w", err)
+	}
+
+	var (
+		streams *sevenZipStreams
+		files   []*sevenZipFile
+	)
+
+	for {
+		r := bytes.NewReader(header)
+		id, err := r.ReadByte()
+		if err != nil {
+			return fmt.Errorf("un7z: %w", err)
+		}
+
+		if id == sevenZipPropHeader {
+			streams, files, err = read7zHeader(r)
+			if err != nil {
+				return fmt.Errorf("un7z: %w", err)
+			}
+
+			break
+		}
+
+		if id != sevenZipPropEncodedHeader {
+			return fmt.Errorf("un7z: %w: unexpected header type %d", errSevenZipInvalid, id)
+		}
+
+		encoded, err := read7zStreamsInfo(r)
+		if err != nil {
+			return fmt.Errorf("un7z: reading encoded header: %w", err)
+		}
+
+		if len(encoded.folders) == 0 {
+			return fmt.Errorf("un7z: %w: empty encoded header", errSevenZipInvalid)
+		}
+
+		rd, err := encoded.openFolder(sra, 0)
+		if err != nil {
+			return fmt.Errorf("un7z: decoding header: %w", err)
+		}
+
+		header, err = io.ReadAll(rd)
+		if err != nil {
+			return fmt.Errorf("un7z: decoding header: %w", err)
+		}
+	}
+
+	var (
+		folderIndex    int
+		substreamIndex int
+		folderReader   io.Reader
+	)
+
+	for _, f := range files {
+		ent := &archiveEntry{Name: normalizeArchivePath(f.name)}
+		switch {
+		case f.isDir || f.attrib&0x10 != 0:
+			ent.Mode = fs.ModeDir
+		case f.attrib&0x8000 != 0:
+			// unix extension, high 16 bits are unix mode
+			switch (f.attrib >> 16) & cpioModeTypeMask {
+			case cpioModeRegular:
+			case cpioModeDir:
+				ent.Mode = fs.ModeDir
+			case cpioModeSymlink:
+				ent.Mode = fs.ModeSymlink
+			default:
+				ent.Mode = fs.ModeIrregular
+			}
+		}
+
+		if !f.hasStream {
+			err = fn(ent, bytes.NewReader(nil))
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if streams == nil {
+			return fmt.Errorf("un7z: %w: missing streams info", errSevenZipInvalid)
+		}
+
+		// find next non-empty folder
+		for folderIndex < len(streams.folders) &&
+			substreamIndex >= streams.folders[folderIndex].numSubstreams {
+			folderIndex++
+			substreamIndex = 0
+			folderReader = nil
+		}
+
+		if folderIndex >= len(streams.folders) {
+			return fmt.Errorf("un7z: %w: missing file data", errSevenZipInvalid)
+		}
+
+		if folderReader == nil {
+			folderReader, err = streams.openFolder(sra, folderIndex)
+			if err != nil {
+				return fmt.Errorf("un7z: %w", err)
+			}
+		}
+
+		data := &io.LimitedReader{
+			R: folderReader,
+			N: streams.folders[folderIndex].substreams[substreamIndex],
+		}
+		substreamIndex++
+
+		if ent.Mode&fs.ModeSymlink != 0 {
+			var target []byte
+			target, err = io.ReadAll(data)
+			if err != nil {
+				return fmt.Errorf("un7z: reading link %q: %w", f.name, err)
+			}
+
+			ent.Linkname = string(target)
+		}
+
+		err = fn(ent, data)
+		if err != nil {
+			return err
+		}
+
+		// skip unread data
+		_, err = io.Copy(io.Discard, data)
+		if err != nil {
+			return fmt.Errorf("un7z: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *sevenZipStreams) openFolder(sra SizedReaderAt, i int) (io.Reader, error) {
+	folder := s.folders[i]
+	if folder.packIndex >= len(s.packSizes) {
+		return nil, fmt.Errorf("%w: missing pack stream", errSevenZipInvalid)
+	}
+
+	offset := sevenZipSigHeaderLen + s.packPos
+	for _, size := range s.packSizes[:folder.packIndex] {
+		offset += size
+	}
+
+	var (
+		packed = io.NewSectionReader(sra, offset, s.packSizes[folder.packIndex])
+		r      io.Reader
+		err    error
+	)
+
+	switch folder.coderID {
+	case sevenZipCoderCopy:
+		r = packed
+	case sevenZipCoderLZMA:
+		if len(folder.coderProps) != 5 {
+			return nil, fmt.Errorf("%w: invalid lzma properties", errSevenZipInvalid)
+		}
+
+		// construct lzma header: properties, dict size, uncompressed size
+		var hdr [13]byte
+		copy(hdr[:], folder.coderProps)
+		binary.LittleEndian.PutUint64(hdr[5:], uint64(folder.unpackSize))
+
+		r, err = lzma.ReaderConfig{}.NewReader(io.MultiReader(bytes.NewReader(hdr[:]), packed))
+	case sevenZipCoderLZMA2:
+		if len(folder.coderProps) != 1 || folder.coderProps[0] > 40 {
+			return nil, fmt.Errorf("%w: invalid lzma2 properties", errSevenZipInvalid)
+		}
+
+		var (
+			p       = int64(folder.coderProps[0])
+			dictCap = int64(lzma.MaxDictCap)
+		)
+
+		if p < 40 {
+			dictCap = (2 | (p & 1)) << (p/2 + 11)
+		}
+
+		// avoid allocating dict larger than necessary
+		if dictCap > folder.unpackSize {
+			dictCap = folder.unpackSize
+		}
+
+		if dictCap < lzma.MinDictCap {
+			dictCap = lzma.MinDictCap
+		}
+
+		r, err = lzma.Reader2Config{DictCap: int(dictCap)}.NewReader2(packed)
+	case sevenZipCoderDeflate:
+		r = flate.NewReader(packed)
+	case sevenZipCoderBZip2:
+		r = bzip2.NewReader(packed)
+	case sevenZipCoderAES:
+		return nil, fmt.Errorf("%w: encrypted 7z archive", errSevenZipUnsupported)
+	case sevenZipCoderBCJ, sevenZipCoderBCJ2:
+		return nil, fmt.Errorf("%w: BCJ filter", errSevenZipUnsupported)
+	default:
+		return nil, fmt.Errorf("%w: coder %x", errSevenZipUnsupported, folder.coderID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return io.LimitReader(r, folder.unpackSize), nil
+}
+
+type sevenZipReader interface {
+	io.Reader
+	io.ByteReader
+
+	// Len returns the number of unread bytes
+	Len() int
+}
+
+func read7zNumber(r io.ByteReader) (uint64, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		mask  byte = 0x80
+		value uint64
+	)
+
+	for i := 0; i < 8; i++ {
+		if first&mask == 0 {
+			high := uint64(first & (mask - 1))
+			return value | high<<(8*i), nil
+		}
+
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		value |= uint64(b) << (8 * i)
+		mask >>= 1
+	}
+
+	return value, nil
+}
+
+// read7zInt reads a count or size of data in the header
+//
+// every counted item takes at least one byte in the header, so the value
+// MUST NOT exceed bytes left in r, this avoids huge allocation with malformed
+// archive
+func read7zInt(r sevenZipReader) (int, error) {
+	n, err := read7zNumber(r)
+	if err != nil {
+		return 0, err
+	}
+
+	if n > uint64(r.Len()) {
+		return 0, fmt.Errorf("%w: number %d exceeds remaining header size %d",
+			errSevenZipInvalid, n, r.Len(),
+		)
+	}
+
+	return int(n), nil
+}
+
+func read7zBits(r io.ByteReader, n int) ([]bool, error) {
+	var (
+		ret  = make([]bool, n)
+		b    byte
+		mask byte
+		err  error
+	)
+
+	for i := range ret {
+		if mask == 0 {
+			b, err = r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+
+			mask = 0x80
+		}
+
+		ret[i] = b&mask != 0
+		mask >>= 1
+	}
+
+	return ret, nil
+}
+
+// read7zDefinedBits reads optional bit vector preceded by all-defined flag
+func read7zDefinedBits(r io.ByteReader, n int) ([]bool, error) {
+	allDefined, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	if allDefined == 0 {
+		return read7zBits(r, n)
+	}
+
+	ret := make([]bool, n)
+	for i := range ret {
+		ret[i] = true
+	}
+
+	return ret, nil
+}
+
+// skip7zDigests skips crc digests of n items, returns which are defined
+func skip7zDigests(r sevenZipReader, n int) ([]bool, error) {
+	defined, err := read7zDefinedBits(r, n)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range defined {
+		if !d {
+			continue
+		}
+
+		_, err = io.CopyN(io.Discard, r, 4)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return defined, nil
+}
+
+func expect7zID(r io.ByteReader, expected byte) error {
+	id, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	if id != expected {
+		return fmt.Errorf("%w: expecting property %d, got %d", errSevenZipInvalid, expected, id)
+	}
+
+	return nil
+}
+
+func read7zHeader(r sevenZipReader) (streams *sevenZipStreams, files []*sevenZipFile, err error) {
+	id, err := r.ReadByte()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if id == sevenZipPropArchiveProperties {
+		for {
+			var typ uint64
+			typ, err = read7zNumber(r)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if typ == sevenZipPropEnd {
+				break
+			}
+
+			err = skip7zProperty(r)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		id, err = r.ReadByte()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if id == sevenZipPropAdditionalStreamsInfo {
+		_, err = read7zStreamsInfo(r)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		id, err = r.ReadByte()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if id == sevenZipPropMainStreamsInfo {
+		streams, err = read7zStreamsInfo(r)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		id, err = r.ReadByte()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if id == sevenZipPropFilesInfo {
+		files, err = read7zFilesInfo(r)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		id, err = r.ReadByte()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if id != sevenZipPropEnd {
+		return nil, nil, fmt.Errorf("%w: unexpected property %d in header", errSevenZipInvalid, id)
+	}
+
+	return streams, files, nil
+}
+
+func skip7zProperty(r sevenZipReader) error {
+	size, err := read7zNumber(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.CopyN(io.Discard, r, int64(size))
+	return err
+}
+
+func read7zStreamsInfo(r sevenZipReader) (*sevenZipStreams, error) {
+	ret := &sevenZipStreams{}
+
+	id, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	if id == sevenZipPropPackInfo {
+		err = ret.readPackInfo(r)
+		if err != nil {
+			return nil, err
+		}
+
+		id, err = r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	folderCRCDefined := []bool{}
+	if id == sevenZipPropUnpackInfo {
+		folderCRCDefined, err = ret.readUnpackInfo(r)
+		if err != nil {
+			return nil, err
+		}
+
+		id, err = r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, f := range ret.folders {
+		f.numSubstreams = 1
+		f.substreams = []int64{f.unpackSize}
+	}
+
+	if id == sevenZipPropSubStreamsInfo {
+		err = ret.readSubStreamsInfo(r, folderCRCDefined)
+		if err != nil {
+			return nil, err
+		}
+
+		id, err = r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if id != sevenZipPropEnd {
+		return nil, fmt.Errorf("%w: unexpected property %d in streams info", errSevenZipInvalid, id)
+	}
+
+	return ret, nil
+}
+
+func (s *sevenZipStreams) readPackInfo(r sevenZipReader) error {
+	packPos, err := read7zNumber(r)
+	if err != nil {
+		return err
+	}
+
+	s.packPos = int64(packPos)
+
+	numPackStreams, err := read7zInt(r)
+	if err != nil {
+		return err
+	}
+
+	for {
+		id, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+
+		switch id {
+		case sevenZipPropEnd:
+			return nil
+		case sevenZipPropSize:
+			s.packSizes = make([]int64, numPackStreams)
+			for i := range s.packSizes {
+				var size uint64
+				size, err = read7zNumber(r)
+				if err != nil {
+					return err
+				}
+
+				s.packSizes[i] = int64(size)
+			}
+		case sevenZipPropCRC:
+			_, err = skip7zDigests(r, numPackStreams)
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: unexpected property %d in pack info", errSevenZipInvalid, id)
+		}
+	}
+}
+
+func (s *sevenZipStreams) readUnpackInfo(r sevenZipReader) (crcDefined []bool, err error) {
+	err = expect7zID(r, sevenZipPropFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	numFolders, err := read7zInt(r)
+	if err != nil {
+		return nil, err
+	}
+
+	external, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	if external != 0 {
+		return nil, fmt.Errorf("external folders are not supported")
+	}
+
+	var (
+		numOutStreams = make([]int, numFolders)
+		packIndex     int
+	)
+
+	s.folders = make([]*sevenZipFolder, numFolders)
+	for i := range s.folders {
+		s.folders[i], numOutStreams[i], err = read7zFolder(r)
+		if err != nil {
+			return nil, err
+		}
+
+		s.folders[i].packIndex = packIndex
+		packIndex++
+	}
+
+	err = expect7zID(r, sevenZipPropCodersUnpackSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, f := range s.folders {
+		for j := 0; j < numOutStreams[i]; j++ {
+			var size uint64
+			size, err = read7zNumber(r)
+			if err != nil {
+				return nil, err
+			}
+
+			f.unpackSize = int64(size)
+		}
+	}
+
+	crcDefined = make([]bool, numFolders)
+	for {
+		id, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		switch id {
+		case sevenZipPropEnd:
+			return crcDefined, nil
+		case sevenZipPropCRC:
+			crcDefined, err = skip7zDigests(r, numFolders)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%w: unexpected property %d in unpack info", errSevenZipInvalid, id)
+		}
+	}
+}
+
+// read7zFolder reads folder info, returns the folder and count of its output streams
+func read7zFolder(r sevenZipReader) (*sevenZipFolder, int, error) {
+	numCoders, err := read7zInt(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if numCoders != 1 {
+		// filters like BCJ are chained as extra coders
+		return nil, 0, fmt.Errorf("%w: folder with %d coders", errSevenZipUnsupported, numCoders)
+	}
+
+	flag, err := r.ReadByte()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if flag&0x10 != 0 || flag&0x80 != 0 {
+		return nil, 0, fmt.Errorf("%w: complex coder", errSevenZipUnsupported)
+	}
+
+	ret := &sevenZipFolder{}
+
+	id := make([]byte, flag&0x0f)
+	_, err = io.ReadFull(r, id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ret.coderID = string(id)
+
+	if flag&0x20 != 0 {
+		var size int
+		size, err = read7zInt(r)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		ret.coderProps = make([]byte, size)
+		_, err = io.ReadFull(r, ret.coderProps)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// single coder has one output stream, no bind pair and single packed stream
+	return ret, 1, nil
+}
+
+func (s *sevenZipStreams) readSubStreamsInfo(r sevenZipReader, folderCRCDefined []bool) error {
+	id, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	if id == sevenZipPropNumUnpackStream {
+		for _, f := range s.folders {
+			f.numSubstreams, err = read7zInt(r)
+			if err != nil {
+				return err
+			}
+		}
+
+		id, err = r.ReadByte()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, f := range s.folders {
+		if f.numSubstreams == 0 {
+			f.substreams = nil
+			continue
+		}
+
+		f.substreams = make([]int64, f.numSubstreams)
+		f.substreams[f.numSubstreams-1] = f.unpackSize
+	}
+
+	if id == sevenZipPropSize {
+		for _, f := range s.folders {
+			var sum int64
+			for j := 0; j < f.numSubstreams-1; j++ {
+				var size uint64
+				size, err = read7zNumber(r)
+				if err != nil {
+					return err
+				}
+
+				f.substreams[j] = int64(size)
+				sum += int64(size)
+			}
+
+			if f.numSubstreams != 0 {
+				f.substreams[f.numSubstreams-1] = f.unpackSize - sum
+			}
+		}
+
+		id, err = r.ReadByte()
+		if err != nil {
+			return err
+		}
+	}
+
+	if id == sevenZipPropCRC {
+		numDigests := 0
+		for i, f := range s.folders {
+			if f.numSubstreams == 1 && i < len(folderCRCDefined) && folderCRCDefined[i] {
+				continue
+			}
+
+			numDigests += f.numSubstreams
+		}
+
+		_, err = skip7zDigests(r, numDigests)
+		if err != nil {
+			return err
+		}
+
+		id, err = r.ReadByte()
+		if err != nil {
+			return err
+		}
+	}
+
+	if id != sevenZipPropEnd {
+		return fmt.Errorf("%w: unexpected property %d in substreams info", errSevenZipInvalid, id)
+	}
+
+	return nil
+}
+
+func read7zFilesInfo(r sevenZipReader) ([]*sevenZipFile, error) {
+	numFiles, err := read7zInt(r)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*sevenZipFile, numFiles)
+	for i := range files {
+		files[i] = &sevenZipFile{hasStream: true}
+	}
+
+	var emptyStreams []bool
+	for {
+		typ, err := read7zNumber(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if typ == sevenZipPropEnd {
+			return files, nil
+		}
+
+		size, err := read7zInt(r)
+		if err != nil {
+			return nil, err
+		}
+
+		data := make([]byte, size)
+		_, err = io.ReadFull(r, data)
+		if err != nil {
+			return nil, err
+		}
+
+		pr := bytes.NewReader(data)
+		switch typ {
+		case sevenZipPropEmptyStream:
+			emptyStreams, err = read7zBits(pr, numFiles)
+			if err != nil {
+				return nil, err
+			}
+
+			for i, empty := range emptyStreams {
+				files[i].hasStream = !empty
+				// empty stream without empty file flag is a directory
+				files[i].isDir = empty
+			}
+		case sevenZipPropEmptyFile:
+			numEmpty := 0
+			for _, empty := range emptyStreams {
+				if empty {
+					numEmpty++
+				}
+			}
+
+			var emptyFiles []bool
+			emptyFiles, err = read7zBits(pr, numEmpty)
+			if err != nil {
+				return nil, err
+			}
+
+			j := 0
+			for i, empty := range emptyStreams {
+				if !empty {
+					continue
+				}
+
+				files[i].isDir = !emptyFiles[j]
+				j++
+			}
+		case sevenZipPropName:
+			err = read7zNames(pr, files)
+			if err != nil {
+				return nil, err
+			}
+		case sevenZipPropWinAttributes:
+			err = read7zAttributes(pr, files)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func read7zNames(r *bytes.Reader, files []*sevenZipFile) error {
+	external, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	if external != 0 {
+		return fmt.Errorf("external file names are not supported")
+	}
+
+	var (
+		buf [2]byte
+		u16 []uint16
+	)
+
+	for _, f := range files {
+		u16 = u16[:0]
+		for {
+			_, err = io.ReadFull(r, buf[:])
+			if err != nil {
+				return fmt.Errorf("%w: reading file name: %v", errSevenZipInvalid, err)
+			}
+
+			c := binary.LittleEndian.Uint16(buf[:])
+			if c == 0 {
+				break
+			}
+
+			u16 = append(u16, c)
+		}
+
+		f.name = string(utf16.Decode(u16))
+	}
+
+	return nil
+}
+
+func read7zAttributes(r *bytes.Reader, files []*sevenZipFile) error {
+	defined, err := read7zDefinedBits(r, len(files))
+	if err != nil {
+		return err
+	}
+
+	external, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	if external != 0 {
+		return fmt.Errorf("external file attributes are not supported")
+	}
+
+	var buf [4]byte
+	for i, d := range defined {
+		if !d {
+			continue
+		}
+
+		_, err = io.ReadFull(r, buf[:])
+		if err != nil {
+			return err
+		}
+
+		files[i].attrib = binary.LittleEndian.Uint32(buf[:])
+	}
+
+	return nil
+}
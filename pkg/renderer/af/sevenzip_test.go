@@ -0,0 +1,66 @@
+package af
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalk7z_Invalid(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile("testdata/401.7z")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	walk := func(data []byte, password string) error {
+		return walk7z(bytes.NewReader(data), password, func(*archiveEntry, io.Reader) error { return nil })
+	}
+
+	assert.NoError(t, walk(data, ""))
+
+	for _, test := range []struct {
+		name   string
+		offset int
+		value  uint64
+	}{
+		{"Huge Header Size", 20, 1 << 62},
+		{"Negative Header Size", 20, 1 << 63},
+		{"Header Size Exceeds File", 20, uint64(len(data))},
+		{"Negative Header Offset", 12, 1 << 63},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			corrupted := append([]byte(nil), data...)
+			binary.LittleEndian.PutUint64(corrupted[test.offset:], test.value)
+
+			err := walk(corrupted, "")
+			assert.True(t, errors.Is(err, errSevenZipInvalid), err)
+		})
+	}
+
+	t.Run("Huge Count", func(t *testing.T) {
+		// header with files info claiming 4Mi files
+		header := []byte{sevenZipPropHeader, sevenZipPropFilesInfo, 0xe0, 0x00, 0x00, 0x40, 0x00, 0x00}
+
+		archive := make([]byte, sevenZipSigHeaderLen, sevenZipSigHeaderLen+len(header))
+		copy(archive, sevenZipSignature)
+		binary.LittleEndian.PutUint64(archive[20:], uint64(len(header)))
+		archive = append(archive, header...)
+
+		err := walk(archive, "")
+		if assert.True(t, errors.Is(err, errSevenZipInvalid), err) {
+			assert.Contains(t, err.Error(), "number 4194304 exceeds remaining header size 2")
+		}
+	})
+
+	t.Run("Password", func(t *testing.T) {
+		err := walk(data, "secret")
+		assert.True(t, errors.Is(err, errSevenZipUnsupported), err)
+	})
+}
@@ -4,60 +4,58 @@ import (
 	"archive/tar"
 	"fmt"
 	"io"
-	"path"
-
-	"arhat.dev/pkg/pathhelper"
+	"io/fs"
 )
 
 // when target is empty or `.`, return reader of first regular file
 // in the tarball
 func untar(r io.ReadSeeker, target string) (io.Reader, error) {
-	restore, err := prepareSeekRestore(r)
+	ret, nested, err := lookupArchive(r, walkTar, target, "")
 	if err != nil {
 		return nil, err
 	}
 
-	rd := tar.NewReader(r)
+	if len(nested) != 0 {
+		return nil, fmt.Errorf("untar: file %q not found in archive", target)
+	}
+
+	return ret, nil
+}
+
+func walkTar(src io.ReadSeeker, _ string, fn walkFunc) error {
+	rd := tar.NewReader(src)
 	for {
 		hdr, err := rd.Next()
 		if err != nil {
 			if err == io.EOF {
-				break
+				return nil
 			}
 
-			return nil, fmt.Errorf("untar: %w", err)
+			return fmt.Errorf("untar: %w", err)
 		}
 
-		name := path.Clean(hdr.Name)
-		switch {
-		case target == ".",
-			len(target) == 0:
-			// empty target, grab first regular file
-			switch hdr.Typeflag {
-			case tar.TypeReg, tar.TypeRegA:
-				return rd, nil
-			default:
-				continue
-			}
-		case name != target:
-			continue
+		ent := &archiveEntry{
+			Name:     normalizeArchivePath(hdr.Name),
+			Linkname: hdr.Linkname,
 		}
 
 		switch hdr.Typeflag {
 		case tar.TypeReg, tar.TypeRegA:
-			return rd, nil
-		case tar.TypeLink, tar.TypeSymlink:
-			err = restore()
-			if err != nil {
-				return nil, err
-			}
-
-			// only allow internal link
-			return untar(r, pathhelper.EvalLink(name, hdr.Linkname))
+		case tar.TypeLink:
+			ent.Hardlink = true
+		case tar.TypeSymlink:
+			ent.Mode = fs.ModeSymlink
+		case tar.TypeDir:
+			ent.Mode = fs.ModeDir
 		default:
-			return nil, fmt.Errorf("untar: unsupported non regular file %q", name)
+			ent.Mode = fs.ModeIrregular
 		}
-	}
 
-	return nil, fmt.Errorf("untar: file %q not found in archive", target)
+		ent.Mode |= fs.FileMode(hdr.Mode).Perm()
+
+		err = fn(ent, rd)
+		if err != nil {
+			return err
+		}
+	}
 }
@@ -4,10 +4,15 @@ import (
 	"compress/bzip2"
 	"compress/flate"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"path"
+	"strings"
 
 	"arhat.dev/pkg/iohelper"
+	"arhat.dev/pkg/pathhelper"
 	"github.com/h2non/filetype"
 	"github.com/h2non/filetype/matchers"
 	"github.com/h2non/filetype/types"
@@ -20,6 +25,7 @@ import (
 var (
 	lzmaType = filetype.NewType("lzma", "application/vnd.lzma")
 	lz4Type  = filetype.NewType("lz4", "application/vnd.lz4")
+	cpioType = filetype.NewType("cpio", "application/x-cpio")
 )
 
 // magic number ref: https://www.kernel.org/doc/html/latest/x86/boot.html
@@ -33,148 +39,357 @@ func init() {
 		return len(b) >= 2 &&
 			b[0] == 0x02 && b[1] == 0x21
 	})
+
+	filetype.AddMatcher(cpioType, func(b []byte) bool {
+		return len(b) >= 6 &&
+			(string(b[:6]) == cpioMagicNewc || string(b[:6]) == cpioMagicNewcCRC)
+	})
+}
+
+// archiveEntry is a file entry in archive
+type archiveEntry struct {
+	// Name is the normalized path of the entry in archive
+	Name string
+
+	// Mode of the entry, only file type bits are required, permission bits
+	// are set for tar and zip archives
+	Mode fs.FileMode
+
+	// Linkname is the target of symlink or hardlink
+	Linkname string
+
+	// Hardlink is set to true when the entry is a hardlink to Linkname
+	// (path relative to archive root)
+	Hardlink bool
+}
+
+// errStopWalk is returned by walkFunc to stop walking through the archive
+var errStopWalk = errors.New("stop walk")
+
+// walkFunc is called for every entry in archive, r is only valid until
+// the walkFunc returned, unless errStopWalk is returned
+type walkFunc func(ent *archiveEntry, r io.Reader) error
+
+// archiveWalker walks through all entries in the archive
+//
+// src MUST implement SizedReaderAt for zip and 7z archives
+type archiveWalker func(src io.ReadSeeker, password string, fn walkFunc) error
+
+// archiveWalkers are archive types supporting file lookup and flatten
+var archiveWalkers = map[types.Type]archiveWalker{
+	matchers.TypeZip: walkZip,
+	matchers.TypeTar: walkTar,
+	matchers.TypeRar: walkRar,
+	matchers.Type7z:  walk7z,
+	matchers.TypeDeb: walkAr,
+	matchers.TypeAr:  walkAr,
+	cpioType:         walkCpio,
+}
+
+// normalizeArchivePath returns the path without leading slash and `./`
+func normalizeArchivePath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
 }
 
 func unarchive(src archiveSource, typ types.Type, inArchivePath, password string) (io.ReadCloser, error) {
 	switch typ {
-	case matchers.TypeZip:
-		rd, err := unzip(src, inArchivePath, password)
+	case matchers.TypeRpm:
+		payload, err := rpmPayload(src)
 		if err != nil {
 			return nil, err
 		}
 
-		return iohelper.CustomReadCloser(rd, src.Close), nil
-	case matchers.TypeTar:
-		r, err := untar(src, inArchivePath)
-		if err != nil {
-			return nil, err
+		if len(inArchivePath) == 0 {
+			// rpm payload is always compressed, use first file in the payload
+			inArchivePath = "."
 		}
 
-		return iohelper.CustomReadCloser(r, src.Close), nil
-	case matchers.TypeRar:
-		rd, err := unrar(src, inArchivePath, password)
-		if err != nil {
-			return nil, err
-		}
+		return unarchiveNext(src, payload, inArchivePath, password)
+	case matchers.TypeDeb, matchers.TypeAr:
+		return extractFromDeb(src, inArchivePath, password)
+	}
 
-		return iohelper.CustomReadCloser(rd, src.Close), nil
-	case matchers.TypeGz:
-		r, err := gzip.NewReader(src)
-		if err != nil {
-			return nil, err
-		}
+	if walk, ok := archiveWalkers[typ]; ok {
+		return extractFromArchive(src, walk, inArchivePath, password)
+	}
 
-		if len(inArchivePath) == 0 {
-			return iohelper.CustomReadCloser(r, func() error {
-				_ = r.Close()
-				return src.Close()
-			}), nil
-		}
+	r, err := decompress(src, typ)
+	if err != nil {
+		return nil, err
+	}
 
-		return unarchiveNext(src, r, inArchivePath, password)
-	case matchers.TypeBz2:
-		r := bzip2.NewReader(src)
-		if len(inArchivePath) == 0 {
-			return iohelper.CustomReadCloser(r, func() error {
-				return src.Close()
-			}), nil
-		}
+	if len(inArchivePath) == 0 {
+		return iohelper.CustomReadCloser(r, func() error {
+			if c, ok := r.(io.Closer); ok {
+				_ = c.Close()
+			}
 
-		return unarchiveNext(src, r, inArchivePath, password)
-	case matchers.TypeXz:
-		r, err := xz.ReaderConfig{}.NewReader(src)
-		if err != nil {
-			return nil, err
-		}
+			return src.Close()
+		}), nil
+	}
 
-		if len(inArchivePath) == 0 {
-			return iohelper.CustomReadCloser(r, func() error {
-				return src.Close()
-			}), nil
-		}
+	return unarchiveNext(src, r, inArchivePath, password)
+}
 
-		return unarchiveNext(src, r, inArchivePath, password)
+// decompress creates reader for compressed data
+func decompress(src archiveSource, typ types.Type) (io.Reader, error) {
+	switch typ {
+	case matchers.TypeGz:
+		return gzip.NewReader(src)
+	case matchers.TypeBz2:
+		return bzip2.NewReader(src), nil
+	case matchers.TypeXz:
+		return xz.ReaderConfig{}.NewReader(src)
 	case matchers.TypeZstd:
 		r, err := zstd.NewReader(src)
 		if err != nil {
 			return nil, err
 		}
 
-		if len(inArchivePath) == 0 {
-			return iohelper.CustomReadCloser(r, func() error {
-				r.Close()
-				return src.Close()
-			}), nil
-		}
-
-		return unarchiveNext(src, r, inArchivePath, password)
+		return r.IOReadCloser(), nil
 	case lz4Type:
-		r := lz4.NewReader(src)
-		if len(inArchivePath) == 0 {
-			return iohelper.CustomReadCloser(r, func() error {
-				return src.Close()
-			}), nil
-		}
-
-		return unarchiveNext(src, r, inArchivePath, password)
+		return lz4.NewReader(src), nil
 	case lzmaType:
-		r, err := lzma.ReaderConfig{}.NewReader(src)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(inArchivePath) == 0 {
-			return iohelper.CustomReadCloser(r, func() error {
-				return src.Close()
-			}), nil
-		}
-
-		return unarchiveNext(src, r, inArchivePath, password)
-	case matchers.Type7z:
-		// TODO
+		return lzma.ReaderConfig{}.NewReader(src)
 	case matchers.TypePdf:
 		// TODO
-	case matchers.TypeDeb:
-		// TODO
-	case matchers.TypeAr:
-		// unix archive
-		// TODO
-	case matchers.TypeRpm:
-		// TODO
+		return nil, fmt.Errorf("no implementation")
 	default:
 		// assume deflate
-		r := flate.NewReader(src)
-
-		if len(inArchivePath) == 0 {
-			return iohelper.CustomReadCloser(r, func() error {
-				_ = r.Close()
-				return src.Close()
-			}), nil
-		}
+		return flate.NewReader(src), nil
+	}
+}
 
-		return unarchiveNext(src, r, inArchivePath, password)
+func unarchiveNext(src archiveSource, r io.Reader, inArchivePath, password string) (io.ReadCloser, error) {
+	src, typ, err := nextArchiveSource(src, r)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("no implementation")
+	return unarchive(src, typ, inArchivePath, password)
 }
 
-func unarchiveNext(src archiveSource, r io.Reader, inArchivePath, password string) (io.ReadCloser, error) {
+func nextArchiveSource(src archiveSource, r io.Reader) (archiveSource, types.Type, error) {
 	src = newArchiveSource(src, r)
 
 	restore, err := prepareSeekRestore(src)
 	if err != nil {
-		return nil, err
+		return nil, types.Unknown, err
 	}
 
 	typ, err := filetype.MatchReader(src)
 	if err != nil {
-		return nil, err
+		return nil, types.Unknown, err
 	}
 
 	err = restore()
+	if err != nil {
+		return nil, types.Unknown, err
+	}
+
+	return src, typ, nil
+}
+
+// extractFromArchive finds target file in archive
+//
+// when target is inside a regular file in the archive (e.g. `data.tar.xz/foo`),
+// the regular file is treated as a nested archive
+func extractFromArchive(src archiveSource, walk archiveWalker, target, password string) (io.ReadCloser, error) {
+	r, nested, err := lookupArchive(src, walk, target, password)
 	if err != nil {
 		return nil, err
 	}
 
-	return unarchive(src, typ, inArchivePath, password)
+	if len(nested) != 0 {
+		return unarchiveNext(src, r, nested, password)
+	}
+
+	return iohelper.CustomReadCloser(r, src.Close), nil
+}
+
+// lookupArchive finds the regular file of target in archive, links are
+// evaluated inside the archive
+//
+// when target is empty or `.`, return reader of first regular file in the archive
+//
+// when a regular file in the archive is a parent of target, return its reader
+// and the path of the target relative to it
+func lookupArchive(
+	src io.ReadSeeker, walk archiveWalker, target, password string,
+) (ret io.Reader, nested string, err error) {
+	firstRegular := target == "." || len(target) == 0
+	target = normalizeArchivePath(target)
+
+	// limit link evaluation to avoid link loop
+	for i := 0; i < 255; i++ {
+		restore, err := prepareSeekRestore(src)
+		if err != nil {
+			return nil, "", err
+		}
+
+		var next string
+		err = walk(src, password, func(ent *archiveEntry, r io.Reader) error {
+			switch {
+			case firstRegular:
+				if ent.Mode.IsRegular() && !ent.Hardlink {
+					ret = r
+					return errStopWalk
+				}
+
+				return nil
+			case ent.Name == target:
+			case ent.Mode.IsRegular() && !ent.Hardlink && strings.HasPrefix(target, ent.Name+"/"):
+				ret, nested = r, strings.TrimPrefix(target, ent.Name+"/")
+				return errStopWalk
+			default:
+				return nil
+			}
+
+			switch {
+			case ent.Hardlink:
+				next = normalizeArchivePath(ent.Linkname)
+			case ent.Mode.IsRegular():
+				ret = r
+			case ent.Mode&fs.ModeSymlink != 0:
+				// only allow internal link
+				next = normalizeArchivePath(pathhelper.EvalLink(ent.Name, ent.Linkname))
+			default:
+				return fmt.Errorf("unsupported non regular file %q", ent.Name)
+			}
+
+			return errStopWalk
+		})
+
+		switch {
+		case err != nil && err != errStopWalk:
+			return nil, "", err
+		case ret != nil:
+			return ret, nested, nil
+		case len(next) != 0:
+			err = restore()
+			if err != nil {
+				return nil, "", err
+			}
+
+			target = next
+		default:
+			return nil, "", fmt.Errorf("file %q not found in archive", target)
+		}
+	}
+
+	return nil, "", fmt.Errorf("too many levels of links")
+}
+
+// flatten reads all regular files in dir of the archive as map of
+// path relative to dir to file content
+func flatten(src archiveSource, typ types.Type, dir, password string) (map[string]string, error) {
+	dir = normalizeArchivePath(dir)
+
+	var (
+		ret   = make(map[string]string)
+		links = make(map[string]string)
+	)
+
+	err := walkAll(src, typ, password, func(ent *archiveEntry, r io.Reader) error {
+		switch {
+		case ent.Hardlink:
+			links[ent.Name] = normalizeArchivePath(ent.Linkname)
+		case ent.Mode&fs.ModeSymlink != 0:
+			links[ent.Name] = normalizeArchivePath(pathhelper.EvalLink(ent.Name, ent.Linkname))
+		case ent.Mode.IsRegular():
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return fmt.Errorf("reading %q: %w", ent.Name, err)
+			}
+
+			ret[ent.Name] = string(data)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for name, target := range links {
+		// limit link evaluation to avoid link loop
+		for i := 0; i < 255; i++ {
+			next, isLink := links[target]
+			if !isLink {
+				break
+			}
+
+			target = next
+		}
+
+		if data, ok := ret[target]; ok {
+			ret[name] = data
+		}
+	}
+
+	if len(dir) == 0 {
+		return ret, nil
+	}
+
+	files := make(map[string]string, len(ret))
+	for name, data := range ret {
+		if strings.HasPrefix(name, dir+"/") {
+			files[strings.TrimPrefix(name, dir+"/")] = data
+		}
+	}
+
+	return files, nil
+}
+
+// walkAll walks through all entries in the archive
+//
+// compressed archives are decompressed, data.tar.* in debian packages and
+// payload of rpm packages are walked instead of the package
+func walkAll(src archiveSource, typ types.Type, password string, fn walkFunc) error {
+	switch typ {
+	case matchers.TypeRpm:
+		payload, err := rpmPayload(src)
+		if err != nil {
+			return err
+		}
+
+		return walkNext(src, payload, password, fn)
+	case matchers.TypeDeb, matchers.TypeAr:
+		restore, err := prepareSeekRestore(src)
+		if err != nil {
+			return err
+		}
+
+		// walk data.tar.* of debian packages
+		data, err := lookupDebData(src)
+		if err == nil {
+			return walkNext(src, data, password, fn)
+		}
+
+		err = restore()
+		if err != nil {
+			return err
+		}
+	}
+
+	walk, ok := archiveWalkers[typ]
+	if !ok {
+		r, err := decompress(src, typ)
+		if err != nil {
+			return err
+		}
+
+		return walkNext(src, r, password, fn)
+	}
+
+	return walk(src, password, fn)
+}
+
+func walkNext(src archiveSource, r io.Reader, password string, fn walkFunc) error {
+	src, typ, err := nextArchiveSource(src, r)
+	if err != nil {
+		return err
+	}
+
+	return walkAll(src, typ, password, fn)
 }
@@ -110,3 +110,23 @@ func (s *bufferredReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
 
 	return 0, err
 }
+
+// lazyReader calls open on first read
+type lazyReader struct {
+	open func() (io.Reader, error)
+
+	r   io.Reader
+	err error
+}
+
+func (r *lazyReader) Read(p []byte) (int, error) {
+	if r.r == nil && r.err == nil {
+		r.r, r.err = r.open()
+	}
+
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	return r.r.Read(p)
+}
@@ -0,0 +1,50 @@
+package af
+
+import (
+	"io"
+	"os"
+
+	"arhat.dev/pkg/fshelper"
+	"github.com/h2non/filetype"
+)
+
+// Entry is a file entry in archive
+type Entry = archiveEntry
+
+// Walk walks through all entries in the archive file, format and compression
+// of the archive are detected from its content as the renderer does
+//
+// fn is called for every entry in the archive, r is only valid until fn returned
+func Walk(
+	ofs *fshelper.OSFS,
+	file, password string,
+	fn func(ent *Entry, r io.Reader) error,
+) error {
+	absPath, err := ofs.Abs(file)
+	if err != nil {
+		return err
+	}
+
+	info, err := ofs.Stat(file)
+	if err != nil {
+		return err
+	}
+
+	typ, err := filetype.MatchFile(absPath)
+	if err != nil {
+		return err
+	}
+
+	f, err := ofs.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	type src struct {
+		sizeIface
+		*os.File
+	}
+
+	return walkAll(&src{info, f.(*os.File)}, typ, password, fn)
+}
@@ -3,14 +3,13 @@ package af
 import (
 	"archive/zip"
 	"compress/bzip2"
+	"compress/flate"
 	"fmt"
 	"io"
 	"io/fs"
 	"io/ioutil"
-	"strings"
 
 	"arhat.dev/pkg/iohelper"
-	"arhat.dev/pkg/pathhelper"
 	"github.com/klauspost/compress/zstd"
 	"github.com/ulikunitz/xz"
 	"github.com/ulikunitz/xz/lzma"
@@ -23,70 +22,145 @@ type SizedReaderAt interface {
 	io.ReaderAt
 }
 
-func unzip(src SizedReaderAt, target, password string) (io.Reader, error) {
-	// TODO: support encrypted zip file
-	_ = password
-
-	r, err := zip.NewReader(src, src.Size())
-	if err != nil {
-		return nil, err
-	}
-
-	r.RegisterDecompressor(uint16(constant.ZipCompressionMethod_BZIP2), func(r io.Reader) io.ReadCloser {
+// zipDecompressors are decompressors not registered in archive/zip by default
+var zipDecompressors = map[constant.ZipCompressionMethod]zip.Decompressor{
+	constant.ZipCompressionMethod_BZIP2: func(r io.Reader) io.ReadCloser {
 		return iohelper.CustomReadCloser(bzip2.NewReader(r), func() error { return nil })
-	})
-
-	r.RegisterDecompressor(uint16(constant.ZipCompressionMethod_LZMA), func(r io.Reader) io.ReadCloser {
+	},
+	constant.ZipCompressionMethod_LZMA: func(r io.Reader) io.ReadCloser {
 		rd, err := lzma.ReaderConfig{}.NewReader(r)
 		if err != nil {
 			return nil
 		}
 
 		return iohelper.CustomReadCloser(rd, func() error { return nil })
-	})
-
-	r.RegisterDecompressor(uint16(constant.ZipCompressionMethod_ZSTD), func(r io.Reader) io.ReadCloser {
+	},
+	constant.ZipCompressionMethod_ZSTD: func(r io.Reader) io.ReadCloser {
 		zr, err := zstd.NewReader(r)
 		if err != nil {
 			return nil
 		}
 		return zr.IOReadCloser()
-	})
-
-	r.RegisterDecompressor(uint16(constant.ZipCompressionMethod_XZ), func(r io.Reader) io.ReadCloser {
+	},
+	constant.ZipCompressionMethod_XZ: func(r io.Reader) io.ReadCloser {
 		xr, err := xz.NewReader(r)
 		if err != nil {
 			return nil
 		}
 		return ioutil.NopCloser(xr)
-	})
+	},
+}
+
+func walkZip(src io.ReadSeeker, password string, fn walkFunc) error {
+	sra, ok := src.(SizedReaderAt)
+	if !ok {
+		return fmt.Errorf("unzip: unexpected non seekable source")
+	}
+
+	r, err := zip.NewReader(sra, sra.Size())
+	if err != nil {
+		return fmt.Errorf("unzip: %w", err)
+	}
+
+	for m, d := range zipDecompressors {
+		r.RegisterDecompressor(uint16(m), d)
+	}
+
+	for _, f := range r.File {
+		ent := &archiveEntry{
+			Name: normalizeArchivePath(f.Name),
+			Mode: f.Mode() & (fs.ModeType | fs.ModePerm),
+		}
+
+		// open file lazily, so encrypted files not requested will not
+		// cause error when password is not set
+		var (
+			f  = f
+			rd = &lazyReader{open: func() (io.Reader, error) {
+				r, err := openZipFile(f, password)
+				if err != nil {
+					return nil, fmt.Errorf("unzip: %q: %w", f.Name, err)
+				}
+
+				return r, nil
+			}}
+		)
+
+		if ent.Mode&fs.ModeSymlink != 0 {
+			var target []byte
+			target, err = io.ReadAll(rd)
+			if err != nil {
+				return fmt.Errorf("unzip: reading link %q: %w", f.Name, err)
+			}
+
+			ent.Linkname = string(target)
+		}
 
-	for {
-		f, err := r.Open(strings.TrimPrefix(target, "/"))
+		err = fn(ent, rd)
 		if err != nil {
-			return nil, fmt.Errorf("unzip: %w", err)
+			return err
 		}
+	}
+
+	return nil
+}
+
+// openZipFile opens the zip file entry, decrypt it if necessary
+func openZipFile(f *zip.File, password string) (io.Reader, error) {
+	const (
+		flagEncrypted = 0x1
+	)
+
+	if f.Flags&flagEncrypted == 0 {
+		return f.Open()
+	}
+
+	if len(password) == 0 {
+		return nil, fmt.Errorf("password required for encrypted file")
+	}
+
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
 
-		info, err := f.Stat()
+	method := constant.ZipCompressionMethod(f.Method)
+	if f.Method == zipMethodWinZipAES {
+		var ext *winzipAESExtra
+		ext, err = parseWinZipAESExtra(f.Extra)
 		if err != nil {
-			return nil, fmt.Errorf("unzip: %w", err)
+			return nil, err
 		}
 
-		switch m := info.Mode() & fs.ModeType; m {
-		case 0:
-			// file
-			return f, nil
-		case fs.ModeSymlink:
-			// TODO: redirect to links
-			targetBytes, err := io.ReadAll(f)
-			if err != nil {
-				return nil, err
-			}
+		raw, err = decryptWinZipAES(raw, password, ext.strength)
+		if err != nil {
+			return nil, err
+		}
 
-			target = pathhelper.EvalLink(info.Name(), string(targetBytes))
-			continue
-		default:
-			return nil, fmt.Errorf("unzip: unsupported non regular file %q: %v", info.Name(), m)
+		method = ext.method
+	} else {
+		raw, err = decryptZipCrypto(raw, password, zipCryptoCheckByte(&f.FileHeader))
+		if err != nil {
+			return nil, err
 		}
 	}
+
+	switch method {
+	case constant.ZipCompressionMethod_Store:
+		return raw, nil
+	case constant.ZipCompressionMethod_Deflate:
+		return flate.NewReader(raw), nil
+	}
+
+	d, ok := zipDecompressors[method]
+	if !ok {
+		return nil, zip.ErrAlgorithm
+	}
+
+	rd := d(raw)
+	if rd == nil {
+		return nil, fmt.Errorf("invalid %s compressed data", method.String())
+	}
+
+	return rd, nil
 }
@@ -0,0 +1,246 @@
+package af
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"arhat.dev/dukkha/pkg/constant"
+)
+
+// encryption support for zip files
+//
+// ref:
+// 	- traditional PKWARE encryption (ZipCrypto): APPNOTE.TXT section 6.1
+// 	- WinZip AES encryption: https://www.winzip.com/en/support/aes-encryption/
+
+const (
+	zipMethodWinZipAES = 99
+
+	zipExtraIDWinZipAES = 0x9901
+)
+
+// zipCryptoCheckByte returns the expected last byte of the decrypted
+// ZipCrypto encryption header
+func zipCryptoCheckByte(fh *zip.FileHeader) byte {
+	const (
+		flagDataDescriptor = 0x8
+	)
+
+	if fh.Flags&flagDataDescriptor != 0 {
+		// nolint:staticcheck
+		return byte(fh.ModifiedTime >> 8)
+	}
+
+	return byte(fh.CRC32 >> 24)
+}
+
+type zipCryptoKeys [3]uint32
+
+func newZipCryptoKeys(password string) *zipCryptoKeys {
+	k := &zipCryptoKeys{0x12345678, 0x23456789, 0x34567890}
+	for i := 0; i < len(password); i++ {
+		k.update(password[i])
+	}
+
+	return k
+}
+
+func crc32Update(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ (crc >> 8)
+}
+
+func (k *zipCryptoKeys) update(b byte) {
+	k[0] = crc32Update(k[0], b)
+	k[1] = (k[1]+(k[0]&0xff))*134775813 + 1
+	k[2] = crc32Update(k[2], byte(k[1]>>24))
+}
+
+func (k *zipCryptoKeys) decrypt(p []byte) {
+	for i, c := range p {
+		tmp := k[2] | 2
+		p[i] = c ^ byte((tmp*(tmp^1))>>8)
+		k.update(p[i])
+	}
+}
+
+type zipCryptoReader struct {
+	keys *zipCryptoKeys
+	r    io.Reader
+}
+
+func (r *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.keys.decrypt(p[:n])
+	return n, err
+}
+
+func decryptZipCrypto(raw io.Reader, password string, check byte) (io.Reader, error) {
+	var hdr [12]byte
+	_, err := io.ReadFull(raw, hdr[:])
+	if err != nil {
+		return nil, fmt.Errorf("reading encryption header: %w", err)
+	}
+
+	keys := newZipCryptoKeys(password)
+	keys.decrypt(hdr[:])
+	if hdr[11] != check {
+		return nil, fmt.Errorf("invalid password")
+	}
+
+	return &zipCryptoReader{keys: keys, r: raw}, nil
+}
+
+type winzipAESExtra struct {
+	// strength of aes encryption, 1 for AES-128, 2 for AES-192, 3 for AES-256
+	strength byte
+
+	// method is the actual compression method
+	method constant.ZipCompressionMethod
+}
+
+func parseWinZipAESExtra(extra []byte) (*winzipAESExtra, error) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			break
+		}
+
+		data := extra[:size]
+		extra = extra[size:]
+
+		if id != zipExtraIDWinZipAES {
+			continue
+		}
+
+		if size < 7 || string(data[2:4]) != "AE" {
+			return nil, fmt.Errorf("invalid aes extra field")
+		}
+
+		return &winzipAESExtra{
+			strength: data[4],
+			method:   constant.ZipCompressionMethod(binary.LittleEndian.Uint16(data[5:])),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("aes extra field not found")
+}
+
+func decryptWinZipAES(raw io.Reader, password string, strength byte) (io.Reader, error) {
+	const (
+		pwvLen  = 2
+		authLen = 10
+	)
+
+	if strength < 1 || strength > 3 {
+		return nil, fmt.Errorf("invalid aes strength %d", strength)
+	}
+
+	var (
+		keyLen  = 8 * (int(strength) + 1)
+		saltLen = keyLen / 2
+	)
+
+	data, err := io.ReadAll(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < saltLen+pwvLen+authLen {
+		return nil, fmt.Errorf("invalid aes encrypted data")
+	}
+
+	var (
+		salt    = data[:saltLen]
+		pwv     = data[saltLen : saltLen+pwvLen]
+		content = data[saltLen+pwvLen : len(data)-authLen]
+		auth    = data[len(data)-authLen:]
+	)
+
+	key := pbkdf2SHA1([]byte(password), salt, 1000, 2*keyLen+pwvLen)
+	if subtle.ConstantTimeCompare(key[2*keyLen:], pwv) != 1 {
+		return nil, fmt.Errorf("invalid password")
+	}
+
+	mac := hmac.New(sha1.New, key[keyLen:2*keyLen])
+	_, _ = mac.Write(content)
+	if !hmac.Equal(mac.Sum(nil)[:authLen], auth) {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	block, err := aes.NewCipher(key[:keyLen])
+	if err != nil {
+		return nil, err
+	}
+
+	// aes-ctr with little endian counter starting from 1
+	var (
+		counter   [aes.BlockSize]byte
+		keyStream [aes.BlockSize]byte
+	)
+
+	for i := 0; i < len(content); i += aes.BlockSize {
+		for j := range counter {
+			counter[j]++
+			if counter[j] != 0 {
+				break
+			}
+		}
+
+		block.Encrypt(keyStream[:], counter[:])
+		end := i + aes.BlockSize
+		if end > len(content) {
+			end = len(content)
+		}
+
+		for j := i; j < end; j++ {
+			content[j] ^= keyStream[j-i]
+		}
+	}
+
+	return bytes.NewReader(content), nil
+}
+
+// pbkdf2SHA1 implements PBKDF2 (RFC 8018) with HMAC-SHA1 as PRF
+func pbkdf2SHA1(password, salt []byte, iter, keyLen int) []byte {
+	var (
+		prf       = hmac.New(sha1.New, password)
+		hashLen   = prf.Size()
+		numBlocks = (keyLen + hashLen - 1) / hashLen
+
+		buf [4]byte
+		dk  = make([]byte, 0, numBlocks*hashLen)
+		u   = make([]byte, hashLen)
+	)
+
+	for block := 1; block <= numBlocks; block++ {
+		prf.Reset()
+		_, _ = prf.Write(salt)
+		binary.BigEndian.PutUint32(buf[:], uint32(block))
+		_, _ = prf.Write(buf[:])
+		dk = prf.Sum(dk)
+
+		t := dk[len(dk)-hashLen:]
+		copy(u, t)
+
+		for n := 2; n <= iter; n++ {
+			prf.Reset()
+			_, _ = prf.Write(u)
+			u = prf.Sum(u[:0])
+			for x := range u {
+				t[x] ^= u[x]
+			}
+		}
+	}
+
+	return dk[:keyLen]
+}
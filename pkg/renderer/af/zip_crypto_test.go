@@ -0,0 +1,135 @@
+package af
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPBKDF2SHA1(t *testing.T) {
+	t.Parallel()
+
+	// test vectors from RFC 6070
+	for _, test := range []struct {
+		password string
+		salt     string
+		iter     int
+		keyLen   int
+		expected string
+	}{
+		{"password", "salt", 1, 20, "0c60c80f961f0e71f3a9b524af6012062fe037a6"},
+		{"password", "salt", 2, 20, "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957"},
+		{"password", "salt", 4096, 20, "4b007901b765489abead49d926f721d065a429c1"},
+		{
+			"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, 25,
+			"3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038",
+		},
+	} {
+		key := pbkdf2SHA1([]byte(test.password), []byte(test.salt), test.iter, test.keyLen)
+		assert.Equal(t, test.expected, hex.EncodeToString(key))
+	}
+}
+
+func newWinZipAESArchive(t *testing.T, name, password string, content []byte) *bytes.Reader {
+	const (
+		strength = 1 // aes-128
+		keyLen   = 16
+		saltLen  = 8
+	)
+
+	salt := []byte("12345678")[:saltLen]
+	key := pbkdf2SHA1([]byte(password), salt, 1000, 2*keyLen+2)
+
+	block, err := aes.NewCipher(key[:keyLen])
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// content is shorter than one block, big endian counter works
+	// the same as little endian one
+	iv := make([]byte, aes.BlockSize)
+	iv[0] = 1
+	encrypted := make([]byte, len(content))
+	cipher.NewCTR(block, iv).XORKeyStream(encrypted, content)
+
+	mac := hmac.New(sha1.New, key[keyLen:2*keyLen])
+	_, _ = mac.Write(encrypted)
+
+	var data []byte
+	data = append(data, salt...)
+	data = append(data, key[2*keyLen:]...)
+	data = append(data, encrypted...)
+	data = append(data, mac.Sum(nil)[:10]...)
+
+	extra := make([]byte, 11)
+	binary.LittleEndian.PutUint16(extra, zipExtraIDWinZipAES)
+	binary.LittleEndian.PutUint16(extra[2:], 7)
+	binary.LittleEndian.PutUint16(extra[4:], 2) // AE-2
+	copy(extra[6:], "AE")
+	extra[8] = strength
+	binary.LittleEndian.PutUint16(extra[9:], 0) // stored
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	fw, err := w.CreateRaw(&zip.FileHeader{
+		Name:               name,
+		Method:             zipMethodWinZipAES,
+		Flags:              0x1,
+		Extra:              extra,
+		CompressedSize64:   uint64(len(data)),
+		UncompressedSize64: uint64(len(content)),
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	_, err = fw.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestEncryptedZip(t *testing.T) {
+	t.Parallel()
+
+	const (
+		fileContent = "test-data"
+	)
+
+	for _, test := range []struct {
+		name      string
+		password  string
+		expectErr bool
+	}{
+		{name: "Correct Password", password: "dukkha"},
+		{name: "Wrong Password", password: "wrong", expectErr: true},
+		{name: "No Password", password: "", expectErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			src := newWinZipAESArchive(t, "foo", "dukkha", []byte(fileContent))
+
+			r, nested, err := lookupArchive(src, walkZip, "foo", test.password)
+			assert.NoError(t, err)
+			assert.Empty(t, nested)
+
+			data, err := io.ReadAll(r)
+			if test.expectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.EqualValues(t, fileContent, string(data))
+		})
+	}
+}
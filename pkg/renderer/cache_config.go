@@ -1,10 +1,16 @@
 package renderer
 
 import (
+	"fmt"
+	"net/http"
 	"time"
 
+	"arhat.dev/pkg/tlshelper"
 	"arhat.dev/rs"
 
+	"arhat.dev/dukkha/pkg/cache"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/s3utils"
 	"arhat.dev/dukkha/pkg/utils"
 )
 
@@ -17,13 +23,18 @@ type CacheConfig struct {
 	// * for renderers reading data directly from local disk (e.g. file):
 	//     will cache content in memory with size limit applied
 	// * for renderers doing remote fetch (e.g. http, git, af):
-	//     will cache data on local disk first (cache size limiting is not effective at this time)
-	// 	   then cache data in memory with size limit applied
+	//     will cache data on local disk first, then cache data in memory,
+	// 	   size limits are applied to both, remote cache is used when configured
 	//
 	// Defaults to `false`
 	Enabled bool `yaml:"enabled"`
 
 	// MaxItemSize is the maximum size limit an item can be cached in memory
+	// and on local disk
+	//
+	// items larger than this are not kept on local disk, unless the local
+	// file path is requested (e.g. attribute `cached-file`), in which case
+	// the file is removed on next eviction
 	//
 	// Format: <number><unit>
 	// 	where unit can be one of: [ , B, KB, MB, GB, TB, PB]
@@ -31,7 +42,11 @@ type CacheConfig struct {
 	// Defaults to `0` (no size limit for single item)
 	MaxItemSize utils.Size `yaml:"max_item_size"`
 
-	// Size limits maximum in memory size of cached content
+	// Size limits maximum in memory size and local disk usage of cached content
+	//
+	// local disk limits of renderers with size set are shared, when the total
+	// disk usage of their caches exceeds the sum of their limits, least recently
+	// used cache files are removed first
 	//
 	// Format: <number><unit>
 	// 	where unit can be one of: [ , B, KB, MB, GB, TB, PB]
@@ -47,4 +62,91 @@ type CacheConfig struct {
 	//
 	// Defaults to `0`
 	Timeout time.Duration `yaml:"timeout"`
+
+	// Remote cache shared between machines (e.g. ci runners)
+	//
+	// only effective for renderers doing remote fetch, it's checked after local
+	// file cache and before fetching from the origin, content fetched from the
+	// origin is uploaded to it
+	Remote RemoteCacheConfig `yaml:"remote"`
+}
+
+// RemoteCacheConfig selects the remote cache backend, at most one backend can be set
+type RemoteCacheConfig struct {
+	rs.BaseField `yaml:"-"`
+
+	// HTTP server accepting GET and PUT requests
+	HTTP *RemoteCacheHTTPConfig `yaml:"http"`
+
+	// S3 compatible object storage
+	S3 *RemoteCacheS3Config `yaml:"s3"`
+}
+
+type RemoteCacheHTTPConfig struct {
+	rs.BaseField `yaml:"-"`
+
+	// BaseURL of cache objects, requests are sent to `<base_url>/<renderer-name>/<key>`
+	BaseURL string `yaml:"base_url"`
+
+	// Headers added to all requests (e.g. Authorization)
+	Headers []*dukkha.NameValueEntry `yaml:"headers"`
+
+	TLS tlshelper.TLSConfig `yaml:"tls"`
+
+	// Timeout of a single request, including reading response body
+	//
+	// Defaults to `0` (no timeout)
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type RemoteCacheS3Config struct {
+	rs.BaseField `yaml:"-"`
+
+	EndpointURL string `yaml:"endpoint_url"`
+	Region      string `yaml:"region"`
+
+	Bucket string `yaml:"bucket"`
+
+	// BasePath of cache objects, objects are stored as `<base_path>/<renderer-name>/<key>`
+	BasePath string `yaml:"base_path"`
+
+	AccessKeyID     string `yaml:"access_key_id"`
+	AccessKeySecret string `yaml:"access_key_secret"`
+}
+
+// createRemote creates remote cache, returns nil when not configured
+func (c *RemoteCacheConfig) createRemote() (cache.RemoteCache, error) {
+	switch {
+	case c.HTTP != nil && c.S3 != nil:
+		return nil, fmt.Errorf("invalid remote cache config: only one of http and s3 can be set")
+	case c.HTTP != nil:
+		tlsConfig, err := c.HTTP.TLS.GetTLSConfig(false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid remote cache tls config: %w", err)
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+
+		header := make(http.Header)
+		for _, h := range c.HTTP.Headers {
+			header.Add(h.Name, h.Value)
+		}
+
+		return cache.NewHTTPRemoteCache(&http.Client{
+			Transport: transport,
+			Timeout:   c.HTTP.Timeout,
+		}, c.HTTP.BaseURL, header), nil
+	case c.S3 != nil:
+		client, err := s3utils.NewClient(
+			c.S3.EndpointURL, c.S3.Region, c.S3.AccessKeyID, c.S3.AccessKeySecret,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("invalid remote cache s3 config: %w", err)
+		}
+
+		return cache.NewS3RemoteCache(client, c.S3.Bucket, c.S3.BasePath), nil
+	default:
+		return nil, nil
+	}
 }
@@ -45,6 +45,89 @@ type rendererHTTPConfig struct {
 
 	// BaseURL
 	BaseURL string `yaml:"base_url"`
+
+	// AcceptedStatusCodes are response status codes considered successful
+	//
+	// Defaults to all 2xx status codes
+	AcceptedStatusCodes []int `yaml:"accepted_status_codes"`
+
+	// Timeout of a single request attempt, including reading response body
+	//
+	// Defaults to `0` (no timeout)
+	Timeout time.Duration `yaml:"timeout"`
+
+	// Retry options for failed requests
+	Retry retryConfig `yaml:"retry"`
+}
+
+type retryConfig struct {
+	rs.BaseField `yaml:"-"`
+
+	// MaxAttempts is the maximum count of attempts to send the request
+	//
+	// Defaults to `1` (no retry)
+	MaxAttempts int `yaml:"max_attempts"`
+
+	// Backoff is the delay before first retry, the delay doubles for
+	// each following retry
+	//
+	// Defaults to `1s`
+	Backoff time.Duration `yaml:"backoff"`
+
+	// MaxBackoff is the upper limit of delay between retries
+	//
+	// Defaults to `30s`
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+}
+
+// backoff returns the delay before the nth retry (starting from 1)
+func (c *retryConfig) backoff(n int) time.Duration {
+	var (
+		delay      = c.Backoff
+		maxBackoff = c.MaxBackoff
+	)
+
+	if delay <= 0 {
+		delay = time.Second
+	}
+
+	if maxBackoff <= 0 {
+		maxBackoff = 30 * time.Second
+	}
+
+	for i := 1; i < n && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+
+	return delay
+}
+
+func (c *rendererHTTPConfig) isAcceptedStatus(code int) bool {
+	if len(c.AcceptedStatusCodes) == 0 {
+		return code >= 200 && code < 300
+	}
+
+	for _, accepted := range c.AcceptedStatusCodes {
+		if code == accepted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isRetriableStatus checks whether the status code indicates a temporary error
+func isRetriableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	default:
+		return code >= 500
+	}
 }
 
 // inputHTTPSpec for renderer value
@@ -81,12 +164,16 @@ func (c rendererHTTPConfig) createClient() (*http.Client, error) {
 		proxy = http.ProxyFromEnvironment
 	}
 
+	dialTimeout := 30 * time.Second
+	if c.Timeout > 0 && c.Timeout < dialTimeout {
+		dialTimeout = c.Timeout
+	}
+
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy: proxy,
 			DialContext: (&net.Dialer{
-				// TODO: allow setting timers?
-				Timeout:       30 * time.Second,
+				Timeout:       dialTimeout,
 				KeepAlive:     30 * time.Second,
 				FallbackDelay: 300 * time.Millisecond,
 			}).DialContext,
@@ -110,7 +197,7 @@ func (c rendererHTTPConfig) createClient() (*http.Client, error) {
 		},
 		CheckRedirect: nil,
 		Jar:           nil,
-		Timeout:       0,
+		Timeout:       c.Timeout,
 	}
 
 	return client, nil
@@ -1,12 +1,15 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"arhat.dev/pkg/fshelper"
 	"arhat.dev/pkg/yamlhelper"
@@ -40,6 +43,8 @@ type Driver struct {
 	DefaultConfig rendererHTTPConfig `yaml:",inline"`
 
 	defaultClient *http.Client
+
+	cacheFS *fshelper.OSFS
 }
 
 func (d *Driver) Init(cacheFS *fshelper.OSFS) error {
@@ -48,6 +53,7 @@ func (d *Driver) Init(cacheFS *fshelper.OSFS) error {
 		return err
 	}
 
+	d.cacheFS = cacheFS
 	d.defaultClient, err = d.DefaultConfig.createClient()
 	return err
 }
@@ -123,14 +129,22 @@ func (d *Driver) RenderYaml(
 		config = &spec.Config
 	}
 
-	data, err := renderer.HandleRenderingRequestWithRemoteFetch(
-		d.Cache,
-		cache.IdentifiableString(reqURL),
-		func(_ cache.IdentifiableObject) (io.ReadCloser, error) {
-			return d.fetchRemote(client, reqURL, config)
-		},
-		d.Attributes(attributes),
-	)
+	fetch := func(revalidate bool) ([]byte, error) {
+		return renderer.HandleRenderingRequestWithRemoteFetch(
+			d.Cache,
+			cache.IdentifiableString(reqURL),
+			func(_ cache.IdentifiableObject) (io.ReadCloser, error) {
+				return d.fetchRemote(rc, client, reqURL, reqURL, config, revalidate)
+			},
+			d.Attributes(attributes),
+		)
+	}
+
+	data, err := fetch(true)
+	if errors.Is(err, cache.ErrNotModified) {
+		// local cache is gone, fetch full content
+		data, err = fetch(false)
+	}
 
 	if err != nil {
 		return nil, fmt.Errorf(
@@ -143,28 +157,20 @@ func (d *Driver) RenderYaml(
 }
 
 func (d *Driver) fetchRemote(
+	ctx context.Context,
 	client *http.Client,
+	cacheKey string,
 	targetURL string,
 	config *rendererHTTPConfig,
+	revalidate bool,
 ) (io.ReadCloser, error) {
-	var (
-		req *http.Request
-		err error
-	)
-
-	var body io.Reader
-	if config.Body != nil {
-		body = strings.NewReader(*config.Body)
-	}
-
 	method := strings.ToUpper(config.Method)
 	if len(method) == 0 {
 		method = http.MethodGet
 	}
 
 	if len(config.BaseURL) != 0 {
-		var baseURL *url.URL
-		baseURL, err = url.Parse(config.BaseURL)
+		baseURL, err := url.Parse(config.BaseURL)
 		if err != nil {
 			return nil, err
 		}
@@ -173,7 +179,71 @@ func (d *Driver) fetchRemote(
 		targetURL = baseURL.String()
 	}
 
-	req, err = http.NewRequest(method, targetURL, body)
+	// only do conditional request for requests without side effect
+	var cv *cacheValidators
+	if revalidate && config.Body == nil &&
+		(method == http.MethodGet || method == http.MethodHead) {
+		cv = d.loadCacheValidators(cacheKey)
+	}
+
+	maxAttempts := config.Retry.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var lastErr error
+	for i := 0; i < maxAttempts; i++ {
+		if i != 0 {
+			timer := time.NewTimer(config.Retry.backoff(i))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, fmt.Errorf("%v: %w", lastErr, ctx.Err())
+			case <-timer.C:
+			}
+		}
+
+		resp, err := d.doRequest(ctx, client, method, targetURL, config, cv)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		switch code := resp.StatusCode; {
+		case code == http.StatusNotModified && cv != nil:
+			_ = resp.Body.Close()
+			return nil, cache.ErrNotModified
+		case config.isAcceptedStatus(code):
+			d.saveCacheValidators(cacheKey, resp.Header)
+			return resp.Body, nil
+		}
+
+		// include a short piece of the response body for debugging
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		_ = resp.Body.Close()
+
+		lastErr = fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+		if !isRetriableStatus(resp.StatusCode) {
+			break
+		}
+	}
+
+	return nil, lastErr
+}
+
+func (d *Driver) doRequest(
+	ctx context.Context,
+	client *http.Client,
+	method, targetURL string,
+	config *rendererHTTPConfig,
+	cv *cacheValidators,
+) (*http.Response, error) {
+	var body io.Reader
+	if config.Body != nil {
+		body = strings.NewReader(*config.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, targetURL, body)
 	if err != nil {
 		return nil, fmt.Errorf("creating http request: %w", err)
 	}
@@ -193,10 +263,15 @@ func (d *Driver) fetchRemote(
 		}
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	if cv != nil {
+		if len(cv.ETag) != 0 {
+			req.Header.Set("If-None-Match", cv.ETag)
+		}
+
+		if len(cv.LastModified) != 0 {
+			req.Header.Set("If-Modified-Since", cv.LastModified)
+		}
 	}
 
-	return resp.Body, nil
+	return client.Do(req)
 }
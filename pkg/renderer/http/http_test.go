@@ -4,7 +4,9 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"arhat.dev/pkg/tlshelper"
 	"arhat.dev/rs"
@@ -77,4 +79,160 @@ func TestDriver_RenderYaml(t *testing.T) {
 		assert.NoError(t, err)
 		assert.EqualValues(t, "/no-password", string(result))
 	})
+
+	t.Run("Status Codes", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/not-found":
+					w.WriteHeader(http.StatusNotFound)
+				case "/created":
+					w.WriteHeader(http.StatusCreated)
+				}
+
+				_, err := w.Write([]byte(r.URL.Path))
+				assert.NoError(t, err)
+			},
+		))
+		defer srv.Close()
+
+		d := &Driver{}
+		rc := dt.NewTestContext(context.TODO(), t.TempDir())
+		assert.NoError(t, d.Init(rc.RendererCacheFS("test")))
+
+		_, err := d.RenderYaml(rc, srv.URL+"/not-found", nil)
+		assert.ErrorContains(t, err, "unexpected status code 404")
+
+		result, err := d.RenderYaml(rc, srv.URL+"/created", nil)
+		assert.NoError(t, err)
+		assert.EqualValues(t, "/created", string(result))
+
+		result, err = d.RenderYaml(rc, rs.Init(&inputHTTPSpec{
+			URL: srv.URL + "/not-found",
+			Config: rendererHTTPConfig{
+				AcceptedStatusCodes: []int{http.StatusNotFound},
+			},
+		}, nil), nil)
+		assert.NoError(t, err)
+		assert.EqualValues(t, "/not-found", string(result))
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		var requests int32
+		srv := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&requests, 1)
+				switch {
+				case r.URL.Path == "/bad-request":
+					w.WriteHeader(http.StatusBadRequest)
+				case n < 3:
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			},
+		))
+		defer srv.Close()
+
+		d := &Driver{
+			DefaultConfig: rendererHTTPConfig{
+				Retry: retryConfig{
+					MaxAttempts: 3,
+					Backoff:     time.Millisecond,
+				},
+			},
+		}
+		rc := dt.NewTestContext(context.TODO(), t.TempDir())
+		assert.NoError(t, d.Init(rc.RendererCacheFS("test")))
+
+		_, err := d.RenderYaml(rc, srv.URL+"/unavailable", nil)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 3, atomic.LoadInt32(&requests))
+
+		atomic.StoreInt32(&requests, 0)
+		_, err = d.RenderYaml(rc, srv.URL+"/bad-request", nil)
+		assert.ErrorContains(t, err, "unexpected status code 400")
+		assert.EqualValues(t, 1, atomic.LoadInt32(&requests), "should not retry on client error")
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+				}
+			},
+		))
+		defer srv.Close()
+
+		d := &Driver{
+			DefaultConfig: rendererHTTPConfig{
+				Timeout: 10 * time.Millisecond,
+			},
+		}
+		rc := dt.NewTestContext(context.TODO(), t.TempDir())
+		assert.NoError(t, d.Init(rc.RendererCacheFS("test")))
+
+		_, err := d.RenderYaml(rc, srv.URL, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Revalidate Expired Cache", func(t *testing.T) {
+		const (
+			etag    = `"v1"`
+			content = "cached-content"
+		)
+
+		var full, notModified int32
+		srv := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("If-None-Match") == etag {
+					atomic.AddInt32(&notModified, 1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+
+				atomic.AddInt32(&full, 1)
+				w.Header().Set("ETag", etag)
+				_, err := w.Write([]byte(content))
+				assert.NoError(t, err)
+			},
+		))
+		defer srv.Close()
+
+		// cache disabled, local cache always expires
+		d := &Driver{}
+		rc := dt.NewTestContext(context.TODO(), t.TempDir())
+		cacheFS := rc.RendererCacheFS("test")
+		assert.NoError(t, d.Init(cacheFS))
+
+		result, err := d.RenderYaml(rc, srv.URL, nil)
+		assert.NoError(t, err)
+		assert.EqualValues(t, content, string(result))
+		assert.EqualValues(t, 1, atomic.LoadInt32(&full))
+
+		// wait for the cache to expire
+		time.Sleep(1100 * time.Millisecond)
+
+		result, err = d.RenderYaml(rc, srv.URL, nil)
+		assert.NoError(t, err)
+		assert.EqualValues(t, content, string(result))
+		assert.EqualValues(t, 1, atomic.LoadInt32(&full))
+		assert.EqualValues(t, 1, atomic.LoadInt32(&notModified))
+
+		// remove local cache, should fetch full content
+		entries, err := cacheFS.ReadDir(".")
+		assert.NoError(t, err)
+		for _, ent := range entries {
+			if !ent.IsDir() {
+				assert.NoError(t, cacheFS.Chmod(ent.Name(), 0600))
+				assert.NoError(t, cacheFS.Remove(ent.Name()))
+			}
+		}
+
+		result, err = d.RenderYaml(rc, srv.URL, nil)
+		assert.NoError(t, err)
+		assert.EqualValues(t, content, string(result))
+		assert.EqualValues(t, 2, atomic.LoadInt32(&full))
+		assert.EqualValues(t, 2, atomic.LoadInt32(&notModified))
+	})
 }
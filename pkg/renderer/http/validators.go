@@ -0,0 +1,75 @@
+package http
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"path"
+)
+
+// validatorsDir is the dir in renderer cache dir to store cache validators
+//
+// its name never collides with cache files, which are named with hex encoded
+// md5 prefix
+const validatorsDir = "validators"
+
+// cacheValidators are response headers used to revalidate expired cache
+// with conditional request
+type cacheValidators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+func validatorsFile(cacheKey string) string {
+	h := md5.Sum([]byte(cacheKey))
+	return path.Join(validatorsDir, hex.EncodeToString(h[:])+".json")
+}
+
+func (d *Driver) loadCacheValidators(cacheKey string) *cacheValidators {
+	if d.cacheFS == nil {
+		return nil
+	}
+
+	data, err := d.cacheFS.ReadFile(validatorsFile(cacheKey))
+	if err != nil {
+		return nil
+	}
+
+	ret := &cacheValidators{}
+	err = json.Unmarshal(data, ret)
+	if err != nil || (len(ret.ETag) == 0 && len(ret.LastModified) == 0) {
+		return nil
+	}
+
+	return ret
+}
+
+// saveCacheValidators stores validators in response header, best effort
+func (d *Driver) saveCacheValidators(cacheKey string, header http.Header) {
+	if d.cacheFS == nil {
+		return
+	}
+
+	cv := &cacheValidators{
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	}
+
+	file := validatorsFile(cacheKey)
+	if len(cv.ETag) == 0 && len(cv.LastModified) == 0 {
+		_ = d.cacheFS.Remove(file)
+		return
+	}
+
+	data, err := json.Marshal(cv)
+	if err != nil {
+		return
+	}
+
+	if d.cacheFS.MkdirAll(validatorsDir, 0755) != nil {
+		return
+	}
+
+	_ = d.cacheFS.WriteFile(file, data, 0644)
+}
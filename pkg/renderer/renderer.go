@@ -1,6 +1,8 @@
 package renderer
 
 import (
+	"path/filepath"
+
 	"arhat.dev/pkg/fshelper"
 	"arhat.dev/rs"
 
@@ -71,16 +73,30 @@ type BaseTwoTierCachedRenderer struct {
 }
 
 func (d *BaseTwoTierCachedRenderer) Init(cacheFS *fshelper.OSFS) error {
-	if d.CacheConfig.Enabled {
-		d.Cache = cache.NewTwoTierCache(
-			cacheFS,
-			int64(d.CacheConfig.MaxItemSize),
-			int64(d.CacheConfig.Size),
-			int64(d.CacheConfig.Timeout.Seconds()),
-		)
-	} else {
+	if !d.CacheConfig.Enabled {
 		d.Cache = cache.NewTwoTierCache(cacheFS, 0, 0, -1)
+		return nil
+	}
+
+	d.Cache = cache.NewTwoTierCache(
+		cacheFS,
+		int64(d.CacheConfig.MaxItemSize),
+		int64(d.CacheConfig.Size),
+		int64(d.CacheConfig.Timeout.Seconds()),
+	)
+
+	remote, err := d.CacheConfig.Remote.createRemote()
+	if err != nil || remote == nil {
+		return err
+	}
+
+	// cacheFS is dedicated to the renderer, use its dir name to separate
+	// remote cache of different renderers
+	dir, err := cacheFS.Abs(".")
+	if err != nil {
+		return err
 	}
 
+	d.Cache.SetRemote(remote, filepath.Base(dir))
 	return nil
 }
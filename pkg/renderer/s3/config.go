@@ -2,14 +2,13 @@ package s3
 
 import (
 	"context"
-	"fmt"
 	"io"
-	"net/url"
 	"path"
 
 	"arhat.dev/rs"
 	"github.com/minio/minio-go/v7"
-	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"arhat.dev/dukkha/pkg/s3utils"
 )
 
 type inputS3Sepc struct {
@@ -56,18 +55,9 @@ func (c *s3Client) download(ctx context.Context, objPath string) (io.ReadCloser,
 }
 
 func (c *rendererS3Config) createClient() (*s3Client, error) {
-	eURL, err := url.Parse(c.EndpointURL)
+	client, err := s3utils.NewClient(c.EndpointURL, c.Region, c.AccessKeyID, c.AccessKeySecret)
 	if err != nil {
-		return nil, fmt.Errorf("invalid endpoint url: %w", err)
-	}
-
-	client, err := minio.New(eURL.Host, &minio.Options{
-		Creds:  credentials.NewStaticV4(c.AccessKeyID, c.AccessKeySecret, ""),
-		Secure: eURL.Scheme == "https",
-		Region: c.Region,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("creating s3 client: %w", err)
+		return nil, err
 	}
 
 	return &s3Client{
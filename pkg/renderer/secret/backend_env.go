@@ -0,0 +1,33 @@
+package secret
+
+import (
+	"fmt"
+
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+var _ backend = (*envBackend)(nil)
+
+// envBackend resolves secret values from environment variables, secret
+// reference is the name of the environment variable
+type envBackend struct {
+	rs.BaseField `yaml:"-"`
+
+	// Prefix is prepended to the secret reference to form the env name
+	//
+	// e.g. with prefix `CI_SECRET_`, `foo@secret: TOKEN` resolves to
+	// value of env `CI_SECRET_TOKEN`
+	Prefix string `yaml:"prefix"`
+}
+
+func (b *envBackend) Get(rc dukkha.RenderingContext, ref string) (any, error) {
+	name := b.Prefix + ref
+	v, ok := rc.Env()[name]
+	if !ok {
+		return nil, fmt.Errorf("env %q not set", name)
+	}
+
+	return v.GetLazyValue(), nil
+}
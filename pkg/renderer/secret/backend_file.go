@@ -0,0 +1,371 @@
+package secret
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"arhat.dev/pkg/exechelper"
+	"arhat.dev/rs"
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+var _ backend = (*fileBackend)(nil)
+
+// fileBackend decrypts values in sops encrypted yaml/json files, secret
+// reference is `<file>#<dot.separated.key.path>`, the whole decrypted file
+// is returned when key path is omitted
+//
+// NOTE: the sops message authentication code is not verified
+type fileBackend struct {
+	rs.BaseField `yaml:"-"`
+
+	// Age keys to decrypt the sops data key
+	Age ageConfig `yaml:"age"`
+
+	// PGP options to decrypt the sops data key using gpg
+	PGP pgpConfig `yaml:"pgp"`
+
+	mu sync.Mutex
+	// files are decrypted file content, keyed by absolute path
+	files map[string]any
+}
+
+type ageConfig struct {
+	rs.BaseField `yaml:"-"`
+
+	// Identities are age private keys (`AGE-SECRET-KEY-1...`)
+	//
+	// Defaults to value of env SOPS_AGE_KEY
+	Identities []string `yaml:"identities"`
+
+	// IdentityFiles are paths to files containing age private keys
+	//
+	// Defaults to value of env SOPS_AGE_KEY_FILE
+	IdentityFiles []string `yaml:"identity_files"`
+}
+
+type pgpConfig struct {
+	rs.BaseField `yaml:"-"`
+
+	// GPG is the gpg executable used to decrypt the data key, the private key
+	// MUST be available to gpg (e.g. imported or provided by gpg-agent)
+	//
+	// Defaults to `"gpg"`
+	GPG string `yaml:"gpg"`
+}
+
+// sopsMetadata is the `sops` section in sops encrypted files, only fields
+// used for decryption are included
+type sopsMetadata struct {
+	Age []struct {
+		Recipient string `yaml:"recipient"`
+		Enc       string `yaml:"enc"`
+	} `yaml:"age"`
+
+	PGP []struct {
+		FP  string `yaml:"fp"`
+		Enc string `yaml:"enc"`
+	} `yaml:"pgp"`
+}
+
+func (b *fileBackend) Get(rc dukkha.RenderingContext, ref string) (any, error) {
+	file, field := splitRef(ref)
+
+	path, err := rc.FS().Abs(file)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	data, ok := b.files[path]
+	b.mu.Unlock()
+
+	if !ok {
+		data, err = b.decryptFile(rc, path)
+		if err != nil {
+			return nil, err
+		}
+
+		b.mu.Lock()
+		if b.files == nil {
+			b.files = make(map[string]any)
+		}
+		b.files[path] = data
+		b.mu.Unlock()
+	}
+
+	return lookupField(data, field)
+}
+
+func (b *fileBackend) decryptFile(rc dukkha.RenderingContext, path string) (any, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		doc  map[string]any
+		meta struct {
+			Sops *sopsMetadata `yaml:"sops"`
+		}
+	)
+
+	err = yaml.Unmarshal(content, &meta)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sops file: %w", err)
+	}
+
+	if meta.Sops == nil {
+		return nil, fmt.Errorf("invalid sops file: no sops metadata")
+	}
+
+	err = yaml.Unmarshal(content, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sops file: %w", err)
+	}
+	delete(doc, "sops")
+
+	dataKey, err := b.decryptDataKey(rc, meta.Sops)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := decryptTree(dataKey, doc, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// decryptDataKey tries all configured keys to decrypt the sops data key
+func (b *fileBackend) decryptDataKey(rc dukkha.RenderingContext, meta *sopsMetadata) ([]byte, error) {
+	var errs []string
+
+	if len(meta.Age) != 0 {
+		identities, err := b.Age.identities(rc)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(identities) != 0 {
+			for _, k := range meta.Age {
+				dataKey, err := decryptAgeDataKey(k.Enc, identities)
+				if err == nil {
+					return dataKey, nil
+				}
+
+				errs = append(errs, fmt.Sprintf("age recipient %q: %v", k.Recipient, err))
+			}
+		}
+	}
+
+	for _, k := range meta.PGP {
+		dataKey, err := b.PGP.decrypt(rc, k.Enc)
+		if err == nil {
+			return dataKey, nil
+		}
+
+		errs = append(errs, fmt.Sprintf("pgp key %q: %v", k.FP, err))
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no usable key to decrypt sops data key")
+	}
+
+	return nil, fmt.Errorf("failed to decrypt sops data key: %s", strings.Join(errs, ", "))
+}
+
+func (c *ageConfig) identities(rc dukkha.RenderingContext) ([]age.Identity, error) {
+	var (
+		keys  = c.Identities
+		files = c.IdentityFiles
+		env   = rc.Env()
+	)
+
+	if len(keys) == 0 && len(files) == 0 {
+		if v, ok := env["SOPS_AGE_KEY"]; ok {
+			keys = []string{v.GetLazyValue()}
+		}
+
+		if v, ok := env["SOPS_AGE_KEY_FILE"]; ok {
+			files = []string{v.GetLazyValue()}
+		}
+	}
+
+	var ret []age.Identity
+	for _, k := range keys {
+		rc.AddSecrets(k)
+
+		ids, err := age.ParseIdentities(strings.NewReader(k))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age identity: %w", err)
+		}
+
+		ret = append(ret, ids...)
+	}
+
+	for _, file := range files {
+		data, err := rc.FS().ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading age identity file: %w", err)
+		}
+
+		rc.AddSecrets(string(data))
+
+		ids, err := age.ParseIdentities(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age identity file %q: %w", file, err)
+		}
+
+		ret = append(ret, ids...)
+	}
+
+	return ret, nil
+}
+
+func decryptAgeDataKey(enc string, identities []age.Identity) ([]byte, error) {
+	r, err := age.Decrypt(armor.NewReader(strings.NewReader(enc)), identities...)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
+
+func (c *pgpConfig) decrypt(rc dukkha.RenderingContext, enc string) ([]byte, error) {
+	gpg := c.GPG
+	if len(gpg) == 0 {
+		gpg = "gpg"
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd, err := exechelper.Do(exechelper.Spec{
+		Context: rc,
+		Command: []string{gpg, "--quiet", "--batch", "--decrypt"},
+		Stdin:   strings.NewReader(enc),
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = cmd.Wait()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// encValuePattern matches values encrypted by sops
+var encValuePattern = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// decryptTree decrypts all encrypted values in v, path is the key path to v
+//
+// sops authenticates each value with its key path (`key1:key2:`) as additional
+// data, list items share key path of the list
+func decryptTree(dataKey []byte, v any, path []string) (any, error) {
+	switch t := v.(type) {
+	case string:
+		return decryptValue(dataKey, t, strings.Join(path, ":")+":")
+	case map[string]any:
+		for k, item := range t {
+			ret, err := decryptTree(dataKey, item, append(path[:len(path):len(path)], k))
+			if err != nil {
+				return nil, err
+			}
+
+			t[k] = ret
+		}
+
+		return t, nil
+	case map[any]any:
+		for k, item := range t {
+			ret, err := decryptTree(dataKey, item, append(path[:len(path):len(path)], fmt.Sprint(k)))
+			if err != nil {
+				return nil, err
+			}
+
+			t[k] = ret
+		}
+
+		return t, nil
+	case []any:
+		for i, item := range t {
+			ret, err := decryptTree(dataKey, item, path)
+			if err != nil {
+				return nil, err
+			}
+
+			t[i] = ret
+		}
+
+		return t, nil
+	default:
+		// not encrypted
+		return v, nil
+	}
+}
+
+func decryptValue(dataKey []byte, value, aad string) (any, error) {
+	m := encValuePattern.FindStringSubmatch(value)
+	if m == nil {
+		// not encrypted (e.g. keys with unencrypted suffix)
+		return value, nil
+	}
+
+	var parts [3][]byte
+	for i := range parts {
+		var err error
+		parts[i], err = base64.StdEncoding.DecodeString(m[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid encrypted value: %w", err)
+		}
+	}
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	data, iv, tag := parts[0], parts[1], parts[2]
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(aad))
+	if err != nil {
+		return nil, fmt.Errorf("decrypting value of %q: %w", strings.TrimSuffix(aad, ":"), err)
+	}
+
+	switch typ := m[4]; typ {
+	case "str", "comment":
+		return string(plaintext), nil
+	case "bytes":
+		return plaintext, nil
+	case "int":
+		return strconv.Atoi(string(plaintext))
+	case "float":
+		return strconv.ParseFloat(string(plaintext), 64)
+	case "bool":
+		return strconv.ParseBool(string(plaintext))
+	default:
+		return nil, fmt.Errorf("unknown encrypted value type %q", typ)
+	}
+}
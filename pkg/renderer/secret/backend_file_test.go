@@ -0,0 +1,142 @@
+package secret
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/stretchr/testify/assert"
+
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/utils"
+)
+
+func TestFileBackend(t *testing.T) {
+	t.Parallel()
+
+	identity, err := age.GenerateX25519Identity()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	dataKey := make([]byte, 32)
+	_, err = rand.Read(dataKey)
+	assert.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "secrets.enc.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(fmt.Sprintf(`
+db:
+  password: %s
+  port: %s
+tokens:
+- %s
+plain_unencrypted: visible
+sops:
+  age:
+  - recipient: %s
+    enc: |
+%s
+  version: 3.7.3
+`,
+		sopsEncrypt(t, dataKey, "db-password", "str", "db:password:"),
+		sopsEncrypt(t, dataKey, "5432", "int", "db:port:"),
+		sopsEncrypt(t, dataKey, "list-token", "str", "tokens:"),
+		identity.Recipient().String(),
+		indent(ageEncrypt(t, identity.Recipient(), dataKey), "      "),
+	)), 0600))
+
+	rc := dt.NewTestContext(context.TODO(), t.TempDir())
+
+	d := NewDefault(DefaultName).(*Driver)
+	d.File = &fileBackend{Age: ageConfig{Identities: []string{identity.String()}}}
+	assert.NoError(t, d.Init(nil))
+
+	ret, err := d.RenderYaml(rc, file+"#db.password", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "db-password", string(ret))
+
+	ret, err = d.RenderYaml(rc, file+"#db.port", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "5432\n", string(ret))
+
+	ret, err = d.RenderYaml(rc, file+"#tokens.0", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "list-token", string(ret))
+
+	ret, err = d.RenderYaml(rc, file+"#plain_unencrypted", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "visible", string(ret))
+
+	assert.Equal(t,
+		utils.MaskedValue+" "+utils.MaskedValue,
+		rc.Masker().Mask("db-password "+identity.String()),
+	)
+
+	t.Run("Wrong Identity", func(t *testing.T) {
+		other, err := age.GenerateX25519Identity()
+		assert.NoError(t, err)
+
+		d := NewDefault(DefaultName).(*Driver)
+		d.File = &fileBackend{Age: ageConfig{Identities: []string{other.String()}}}
+		assert.NoError(t, d.Init(nil))
+
+		_, err = d.RenderYaml(rc, file+"#db.password", nil)
+		assert.ErrorContains(t, err, "failed to decrypt sops data key")
+	})
+
+	t.Run("Tampered Key Path", func(t *testing.T) {
+		_, err := decryptValue(dataKey, sopsEncrypt(t, dataKey, "value", "str", "a:"), "b:")
+		assert.Error(t, err)
+	})
+}
+
+// sopsEncrypt encrypts value the same way sops does
+func sopsEncrypt(t *testing.T, dataKey []byte, value, typ, aad string) string {
+	block, err := aes.NewCipher(dataKey)
+	assert.NoError(t, err)
+
+	gcm, err := cipher.NewGCMWithNonceSize(block, 32)
+	assert.NoError(t, err)
+
+	iv := make([]byte, 32)
+	_, err = rand.Read(iv)
+	assert.NoError(t, err)
+
+	out := gcm.Seal(nil, iv, []byte(value), []byte(aad))
+	data, tag := out[:len(out)-gcm.Overhead()], out[len(out)-gcm.Overhead():]
+
+	enc := base64.StdEncoding.EncodeToString
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]", enc(data), enc(iv), enc(tag), typ)
+}
+
+func ageEncrypt(t *testing.T, recipient age.Recipient, data []byte) string {
+	var buf strings.Builder
+	aw := armor.NewWriter(&buf)
+	w, err := age.Encrypt(aw, recipient)
+	assert.NoError(t, err)
+
+	_, err = w.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.NoError(t, aw.Close())
+
+	return buf.String()
+}
+
+func indent(s, prefix string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i := range lines {
+		lines[i] = prefix + lines[i]
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,259 @@
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"arhat.dev/pkg/tlshelper"
+	"arhat.dev/rs"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+var _ backend = (*httpBackend)(nil)
+
+// httpBackend reads secrets from vault compatible kv secret engines, secret
+// reference is `<path>#<field>`, all fields of the secret are returned when
+// field is omitted
+type httpBackend struct {
+	rs.BaseField `yaml:"-"`
+
+	// URL of the vault server (e.g. `https://vault.example.com:8200`)
+	//
+	// when not set, secrets are read from the Local file
+	URL string `yaml:"url"`
+
+	// Token to authenticate requests, sent in `X-Vault-Token` header
+	//
+	// Defaults to value of env VAULT_TOKEN
+	Token string `yaml:"token"`
+
+	// Namespace of the secret engine (vault enterprise), sent in
+	// `X-Vault-Namespace` header
+	Namespace string `yaml:"namespace"`
+
+	// Mount path of the kv secret engine
+	//
+	// Defaults to `"secret"`
+	Mount string `yaml:"mount"`
+
+	// KVVersion is the version of the kv secret engine, one of [1, 2]
+	//
+	// Defaults to `2`
+	KVVersion int `yaml:"kv_version"`
+
+	// Headers are extra http headers sent with requests
+	Headers []*dukkha.NameValueEntry `yaml:"headers"`
+
+	TLS tlshelper.TLSConfig `yaml:"tls"`
+
+	// Timeout of a single request
+	//
+	// Defaults to `0` (no timeout)
+	Timeout time.Duration `yaml:"timeout"`
+
+	// Local is the path to a yaml file used as a local stand-in of the vault
+	// server when URL is not set, its content is a map of secret path to
+	// secret fields:
+	//
+	// 	path/to/secret:
+	// 	  field: value
+	Local string `yaml:"local"`
+
+	client *http.Client
+
+	mu sync.Mutex
+	// secrets are fields of secrets resolved, keyed by secret path
+	secrets map[string]map[string]any
+}
+
+func (b *httpBackend) init() error {
+	switch b.KVVersion {
+	case 0:
+		b.KVVersion = 2
+	case 1, 2:
+	default:
+		return fmt.Errorf("unsupported kv_version %d", b.KVVersion)
+	}
+
+	if len(b.Mount) == 0 {
+		b.Mount = "secret"
+	}
+
+	switch {
+	case len(b.URL) != 0:
+		tlsConfig, err := b.TLS.GetTLSConfig(false)
+		if err != nil {
+			return err
+		}
+
+		b.client = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+			Timeout: b.Timeout,
+		}
+	case len(b.Local) == 0:
+		return fmt.Errorf("one of url, local is required")
+	}
+
+	return nil
+}
+
+func (b *httpBackend) Get(rc dukkha.RenderingContext, ref string) (any, error) {
+	path, field := splitRef(ref)
+	path = strings.Trim(path, "/")
+
+	b.mu.Lock()
+	fields, ok := b.secrets[path]
+	b.mu.Unlock()
+
+	if !ok {
+		var err error
+		if len(b.URL) != 0 {
+			fields, err = b.read(rc, path)
+		} else {
+			fields, err = b.readLocal(rc, path)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		b.mu.Lock()
+		if b.secrets == nil {
+			b.secrets = make(map[string]map[string]any)
+		}
+		b.secrets[path] = fields
+		b.mu.Unlock()
+	}
+
+	if len(field) == 0 {
+		return fields, nil
+	}
+
+	v, ok := fields[field]
+	if !ok {
+		return nil, fmt.Errorf("field %q not found", field)
+	}
+
+	return v, nil
+}
+
+func (b *httpBackend) read(rc dukkha.RenderingContext, path string) (map[string]any, error) {
+	var reqURL string
+	if b.KVVersion == 2 {
+		reqURL = fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimSuffix(b.URL, "/"), b.Mount, path)
+	} else {
+		reqURL = fmt.Sprintf("%s/v1/%s/%s", strings.TrimSuffix(b.URL, "/"), b.Mount, path)
+	}
+
+	req, err := http.NewRequestWithContext(rc, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	token := b.Token
+	if len(token) == 0 {
+		if v, ok := rc.Env()["VAULT_TOKEN"]; ok {
+			token = v.GetLazyValue()
+		}
+	}
+
+	if len(token) != 0 {
+		rc.AddSecrets(token)
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	if len(b.Namespace) != 0 {
+		req.Header.Set("X-Vault-Namespace", b.Namespace)
+	}
+
+	for _, h := range b.Headers {
+		req.Header.Add(h.Name, h.Value)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Errors []string `json:"errors"`
+		}
+
+		_ = json.Unmarshal(body, &errResp)
+		if len(errResp.Errors) != 0 {
+			return nil, fmt.Errorf("unexpected response status %q: %s",
+				resp.Status, strings.Join(errResp.Errors, ", "),
+			)
+		}
+
+		return nil, fmt.Errorf("unexpected response status %q", resp.Status)
+	}
+
+	var secret struct {
+		Data json.RawMessage `json:"data"`
+	}
+
+	err = json.Unmarshal(body, &secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+
+	data := secret.Data
+	if b.KVVersion == 2 {
+		var kv2 struct {
+			Data json.RawMessage `json:"data"`
+		}
+
+		err = json.Unmarshal(data, &kv2)
+		if err != nil {
+			return nil, fmt.Errorf("invalid response: %w", err)
+		}
+
+		data = kv2.Data
+	}
+
+	var fields map[string]any
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret data: %w", err)
+	}
+
+	return fields, nil
+}
+
+func (b *httpBackend) readLocal(rc dukkha.RenderingContext, path string) (map[string]any, error) {
+	data, err := rc.FS().ReadFile(b.Local)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets map[string]map[string]any
+	err = yaml.Unmarshal(data, &secrets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid local secrets file: %w", err)
+	}
+
+	fields, ok := secrets[path]
+	if !ok {
+		return nil, fmt.Errorf("secret %q not found in local secrets file", path)
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,208 @@
+package secret
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"arhat.dev/pkg/fshelper"
+	"arhat.dev/pkg/yamlhelper"
+	"arhat.dev/rs"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/renderer"
+)
+
+const (
+	DefaultName = "secret"
+)
+
+func init() { dukkha.RegisterRenderer(DefaultName, NewDefault) }
+
+func NewDefault(name string) dukkha.Renderer { return &Driver{name: name} }
+
+var _ dukkha.Renderer = (*Driver)(nil)
+
+// Driver resolves secret values from the configured backend, all values
+// resolved are registered to the rendering context and masked in task output
+// and logs
+//
+// NOTE: secret values are never cached on disk
+type Driver struct {
+	rs.BaseField `yaml:"-"`
+
+	renderer.BaseRenderer `yaml:",inline"`
+
+	name string
+
+	// Env backend resolves secret values from environment variables
+	//
+	// it's the default backend when no backend is configured
+	Env *envBackend `yaml:"env"`
+
+	// File backend decrypts sops encrypted yaml/json files
+	File *fileBackend `yaml:"file"`
+
+	// HTTP backend reads secrets from vault compatible kv secret engines
+	HTTP *httpBackend `yaml:"http"`
+
+	backend backend
+}
+
+// backend is the source of secret values
+type backend interface {
+	// Get resolves value of the secret referenced by ref
+	Get(rc dukkha.RenderingContext, ref string) (any, error)
+}
+
+func (d *Driver) Init(cacheFS *fshelper.OSFS) error {
+	var backends []backend
+	if d.Env != nil {
+		backends = append(backends, d.Env)
+	}
+
+	if d.File != nil {
+		backends = append(backends, d.File)
+	}
+
+	if d.HTTP != nil {
+		err := d.HTTP.init()
+		if err != nil {
+			return fmt.Errorf("renderer.%s: invalid http backend: %w", d.name, err)
+		}
+
+		backends = append(backends, d.HTTP)
+	}
+
+	switch len(backends) {
+	case 0:
+		d.backend = &envBackend{}
+	case 1:
+		d.backend = backends[0]
+	default:
+		return fmt.Errorf("renderer.%s: only one of env, file, http backend can be set", d.name)
+	}
+
+	return d.BaseRenderer.Init(cacheFS)
+}
+
+func (d *Driver) RenderYaml(
+	rc dukkha.RenderingContext, rawData interface{}, _ []dukkha.RendererAttribute,
+) ([]byte, error) {
+	rawData, err := rs.NormalizeRawData(rawData)
+	if err != nil {
+		return nil, err
+	}
+
+	refBytes, err := yamlhelper.ToYamlBytes(rawData)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"renderer.%s: unsupported input type %T: %w",
+			d.name, rawData, err,
+		)
+	}
+
+	ref := strings.TrimSpace(string(refBytes))
+	if len(ref) == 0 {
+		return nil, fmt.Errorf("renderer.%s: empty secret reference", d.name)
+	}
+
+	if d.backend == nil {
+		// not initialized
+		d.backend = &envBackend{}
+	}
+
+	value, err := d.backend.Get(rc, ref)
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: resolving secret %q: %w", d.name, ref, err)
+	}
+
+	addSecrets(rc, value)
+
+	switch t := value.(type) {
+	case string:
+		return []byte(t), nil
+	case []byte:
+		return t, nil
+	default:
+		ret, err := yaml.Marshal(t)
+		if err != nil {
+			return nil, fmt.Errorf("renderer.%s: marshaling secret %q: %w", d.name, ref, err)
+		}
+
+		return ret, nil
+	}
+}
+
+// addSecrets registers all scalar values in v as secrets
+func addSecrets(rc dukkha.RenderingContext, v any) {
+	switch t := v.(type) {
+	case nil, bool:
+	case string:
+		rc.AddSecrets(t)
+	case []byte:
+		rc.AddSecrets(string(t))
+	case map[string]any:
+		for _, item := range t {
+			addSecrets(rc, item)
+		}
+	case map[any]any:
+		for _, item := range t {
+			addSecrets(rc, item)
+		}
+	case []any:
+		for _, item := range t {
+			addSecrets(rc, item)
+		}
+	default:
+		rc.AddSecrets(fmt.Sprint(t))
+	}
+}
+
+// splitRef splits secret reference `<target>#<field>`
+func splitRef(ref string) (target, field string) {
+	idx := strings.LastIndexByte(ref, '#')
+	if idx == -1 {
+		return ref, ""
+	}
+
+	return ref[:idx], ref[idx+1:]
+}
+
+// lookupField finds value in v by dot separated key path
+func lookupField(v any, field string) (any, error) {
+	if len(field) == 0 {
+		return v, nil
+	}
+
+	for _, key := range strings.Split(field, ".") {
+		switch t := v.(type) {
+		case map[string]any:
+			val, ok := t[key]
+			if !ok {
+				return nil, fmt.Errorf("field %q not found", field)
+			}
+
+			v = val
+		case map[any]any:
+			val, ok := t[key]
+			if !ok {
+				return nil, fmt.Errorf("field %q not found", field)
+			}
+
+			v = val
+		case []any:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(t) {
+				return nil, fmt.Errorf("invalid list index %q in field %q", key, field)
+			}
+
+			v = t[idx]
+		default:
+			return nil, fmt.Errorf("field %q not found", field)
+		}
+	}
+
+	return v, nil
+}
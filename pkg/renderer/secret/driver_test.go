@@ -0,0 +1,146 @@
+package secret
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"arhat.dev/rs"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/utils"
+)
+
+func TestNewDriver(t *testing.T) {
+	t.Parallel()
+
+	assert.NotNil(t, NewDefault(""))
+}
+
+func TestDriver_Init(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Default Env Backend", func(t *testing.T) {
+		d := NewDefault(DefaultName).(*Driver)
+		assert.NoError(t, d.Init(nil))
+		assert.IsType(t, &envBackend{}, d.backend)
+	})
+
+	t.Run("Config", func(t *testing.T) {
+		d := rs.Init(NewDefault(DefaultName), nil).(*Driver)
+		assert.NoError(t, yaml.Unmarshal([]byte(`
+http:
+  local: secrets.yaml
+  mount: kv
+`), d))
+		assert.NoError(t, d.Init(nil))
+
+		if assert.IsType(t, &httpBackend{}, d.backend) {
+			assert.Equal(t, "kv", d.HTTP.Mount)
+			assert.Equal(t, 2, d.HTTP.KVVersion)
+		}
+	})
+
+	t.Run("Multiple Backends", func(t *testing.T) {
+		d := NewDefault(DefaultName).(*Driver)
+		d.Env = &envBackend{}
+		d.File = &fileBackend{}
+		assert.Error(t, d.Init(nil))
+	})
+}
+
+func TestDriver_RenderYaml_env(t *testing.T) {
+	t.Parallel()
+
+	rc := dt.NewTestContext(context.TODO(), t.TempDir())
+	rc.AddEnv(true, &dukkha.NameValueEntry{
+		Name:  "CI_SECRET_PASSWORD",
+		Value: "my-password",
+	})
+
+	d := NewDefault(DefaultName).(*Driver)
+	d.Env = &envBackend{Prefix: "CI_SECRET_"}
+	assert.NoError(t, d.Init(nil))
+
+	ret, err := d.RenderYaml(rc, "PASSWORD", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "my-password", string(ret))
+	assert.Equal(t, "login --password "+utils.MaskedValue, rc.Masker().Mask("login --password my-password"))
+
+	_, err = d.RenderYaml(rc, "NOT_SET", nil)
+	assert.Error(t, err)
+}
+
+func TestDriver_RenderYaml_http(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/app/db":
+			_, _ = w.Write([]byte(`{"data":{"data":{"user":"admin","password":"db-password"},"metadata":{"version":1}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	rc := dt.NewTestContext(context.TODO(), t.TempDir())
+	rc.AddEnv(true, &dukkha.NameValueEntry{
+		Name:  "VAULT_TOKEN",
+		Value: "test-token",
+	})
+
+	d := NewDefault(DefaultName).(*Driver)
+	d.HTTP = &httpBackend{URL: srv.URL}
+	assert.NoError(t, d.Init(nil))
+
+	ret, err := d.RenderYaml(rc, "app/db#password", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "db-password", string(ret))
+
+	ret, err = d.RenderYaml(rc, "app/db", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "password: db-password\nuser: admin\n", string(ret))
+
+	assert.Equal(t, utils.MaskedValue+" "+utils.MaskedValue, rc.Masker().Mask("test-token admin"))
+
+	_, err = d.RenderYaml(rc, "app/none#password", nil)
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestDriver_RenderYaml_httpLocal(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "secrets.yaml"), []byte(`
+app/db:
+  password: local-password
+`), 0600))
+
+	rc := dt.NewTestContext(context.TODO(), t.TempDir())
+
+	d := NewDefault(DefaultName).(*Driver)
+	d.HTTP = &httpBackend{Local: filepath.Join(dir, "secrets.yaml")}
+	assert.NoError(t, d.Init(nil))
+
+	ret, err := d.RenderYaml(rc, "app/db#password", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "local-password", string(ret))
+	assert.Equal(t, utils.MaskedValue, rc.Masker().Mask("local-password"))
+
+	_, err = d.RenderYaml(rc, "app/db#user", nil)
+	assert.Error(t, err)
+}
@@ -35,7 +35,7 @@ func (s *Spec) Clone() *Spec {
 		User:       s.User,
 		Host:       s.Host,
 		Port:       s.Port,
-		HostKey:    s.Host,
+		HostKey:    s.HostKey,
 		PrivateKey: s.PrivateKey,
 		Password:   s.Password,
 	}
@@ -1,4 +1,204 @@
 package ssh
 
-// TODO: implement ssh renderer to execute command on remote host
-// 		 then use output from stdout as result
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"arhat.dev/pkg/yamlhelper"
+	"arhat.dev/rs"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/cache"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/renderer"
+)
+
+const (
+	DefaultName = "ssh"
+)
+
+func init() { dukkha.RegisterRenderer(DefaultName, NewDefault) }
+
+func NewDefault(name string) dukkha.Renderer {
+	return &Driver{name: name}
+}
+
+var _ dukkha.Renderer = (*Driver)(nil)
+
+// Driver is the ssh renderer implementation, it runs command on remote host
+// and uses the stdout as rendered value
+type Driver struct {
+	rs.BaseField `yaml:"-"`
+
+	renderer.BaseTwoTierCachedRenderer `yaml:",inline"`
+
+	name string
+
+	SSHConfig Spec `yaml:",inline"`
+}
+
+// inputExecSpec for renderer value
+type inputExecSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	// SSH config overrides ssh config of the renderer
+	SSH *Spec `yaml:"ssh,omitempty"`
+
+	// Cmd is the command to run on remote host
+	Cmd string `yaml:"cmd"`
+
+	// Env for the remote command, remote sshd MUST accept these env (AcceptEnv in sshd_config)
+	Env dukkha.NameValueList `yaml:"env"`
+
+	// Stdin data for the remote command
+	Stdin *string `yaml:"stdin"`
+}
+
+func (d *Driver) RenderYaml(
+	rc dukkha.RenderingContext, rawData any, attributes []dukkha.RendererAttribute,
+) ([]byte, error) {
+	var (
+		sshConfig *Spec
+		spec      inputExecSpec
+	)
+
+	rawData, err := rs.NormalizeRawData(rawData)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := rawData.(type) {
+	case string:
+		spec.Cmd = t
+		sshConfig = &d.SSHConfig
+	case []byte:
+		spec.Cmd = string(t)
+		sshConfig = &d.SSHConfig
+	default:
+		var rawBytes []byte
+		rawBytes, err = yamlhelper.ToYamlBytes(rawData)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"renderer.%s: unexpected non yaml input: %w",
+				d.name, err,
+			)
+		}
+
+		rs.InitRecursively(reflect.ValueOf(&spec), &rs.Options{
+			InterfaceTypeHandler: rc,
+		})
+
+		err = yaml.Unmarshal(rawBytes, &spec)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"renderer.%s: unmarshal input spec: %w",
+				d.name, err,
+			)
+		}
+
+		err = spec.ResolveFields(rc, -1)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"renderer.%s: resolving input spec: %w",
+				d.name, err,
+			)
+		}
+
+		sshConfig = spec.SSH
+		if sshConfig == nil {
+			sshConfig = &d.SSHConfig
+		}
+	}
+
+	if len(strings.TrimSpace(spec.Cmd)) == 0 {
+		return nil, fmt.Errorf("renderer.%s: invalid empty command", d.name)
+	}
+
+	data, err := renderer.HandleRenderingRequestWithRemoteFetch(
+		d.Cache,
+		cache.IdentifiableString(spec.cacheKey(sshConfig)),
+		func(_ cache.IdentifiableObject) (io.ReadCloser, error) {
+			return spec.execRemote(sshConfig)
+		},
+		d.Attributes(attributes),
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf(
+			"renderer.%s: running remote command: %w",
+			d.name, err,
+		)
+	}
+
+	return data, nil
+}
+
+// cacheKey generates a scope unique id for the remote command execution
+//
+// the command is placed at last so file extension in command can be detected
+func (s *inputExecSpec) cacheKey(sshConfig *Spec) string {
+	var sb strings.Builder
+
+	sb.WriteString(sshConfig.User)
+	sb.WriteByte('@')
+	sb.WriteString(sshConfig.Host)
+	sb.WriteByte(':')
+	sb.WriteString(strconv.FormatInt(int64(sshConfig.Port), 10))
+	sb.WriteByte('\n')
+
+	for _, e := range s.Env {
+		sb.WriteString(e.Name)
+		sb.WriteByte('=')
+		sb.WriteString(e.Value)
+		sb.WriteByte('\n')
+	}
+
+	if s.Stdin != nil {
+		sb.WriteString(strconv.Quote(*s.Stdin))
+		sb.WriteByte('\n')
+	}
+
+	sb.WriteString(s.Cmd)
+	return sb.String()
+}
+
+func (s *inputExecSpec) execRemote(sshConfig *Spec) (io.ReadCloser, error) {
+	client, err := NewClient(sshConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create ssh client: %w", err)
+	}
+
+	defer func() { _ = client.Close() }()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("open ssh session: %w", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	for _, e := range s.Env {
+		err = session.Setenv(e.Name, e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("set env %q: %w", e.Name, err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if s.Stdin != nil {
+		session.Stdin = strings.NewReader(*s.Stdin)
+	}
+
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	err = session.Run(s.Cmd)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return io.NopCloser(&stdout), nil
+}
@@ -0,0 +1,165 @@
+package ssh
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+)
+
+// newTestServer starts a ssh server echoing `<env> <cmd> <stdin>` for exec requests
+func newTestServer(t *testing.T) (host string, port int) {
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "foo" && string(password) == "bar" {
+				return nil, nil
+			}
+
+			return nil, io.ErrUnexpectedEOF
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go serveTestConn(conn, config)
+		}
+	}()
+
+	addr := l.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func serveTestConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+
+	go ssh.DiscardRequests(reqs)
+
+	for newCh := range chans {
+		if newCh.ChannelType() != "session" {
+			_ = newCh.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+
+		ch, chReqs, err := newCh.Accept()
+		if err != nil {
+			continue
+		}
+
+		go func() {
+			defer func() { _ = ch.Close() }()
+
+			var env []string
+			for req := range chReqs {
+				switch req.Type {
+				case "env":
+					var kv struct{ Name, Value string }
+					_ = ssh.Unmarshal(req.Payload, &kv)
+					env = append(env, kv.Name+"="+kv.Value)
+					_ = req.Reply(true, nil)
+				case "exec":
+					var cmd struct{ Command string }
+					_ = ssh.Unmarshal(req.Payload, &cmd)
+					_ = req.Reply(true, nil)
+
+					stdin, _ := io.ReadAll(ch)
+					_, _ = ch.Write([]byte(strings.Join(env, ",") + " " + cmd.Command + " " + string(stdin)))
+
+					status := make([]byte, 4)
+					binary.BigEndian.PutUint32(status, 0)
+					_, _ = ch.SendRequest("exit-status", false, status)
+					return
+				default:
+					_ = req.Reply(false, nil)
+				}
+			}
+		}()
+	}
+}
+
+func TestDriver_RenderYaml(t *testing.T) {
+	t.Parallel()
+
+	host, port := newTestServer(t)
+
+	d := &Driver{
+		name: DefaultName,
+		SSHConfig: Spec{
+			User:     "foo",
+			Password: "bar",
+			Host:     host,
+			Port:     port,
+		},
+	}
+
+	rc := dt.NewTestContext(context.TODO(), t.TempDir())
+	assert.NoError(t, d.Init(rc.RendererCacheFS("test")))
+
+	t.Run("String", func(t *testing.T) {
+		ret, err := d.RenderYaml(rc, "echo foo", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, " echo foo ", string(ret))
+	})
+
+	t.Run("Spec", func(t *testing.T) {
+		ret, err := d.RenderYaml(rc, map[string]any{"cmd": " "}, nil)
+		assert.Error(t, err, "empty command should be rejected")
+		assert.Nil(t, ret)
+
+		ret, err = d.RenderYaml(rc, map[string]any{
+			"cmd":   "cat -",
+			"stdin": "data",
+			"env": []any{
+				map[string]any{"name": "FOO", "value": "bar"},
+			},
+		}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "FOO=bar cat - data", string(ret))
+	})
+
+	t.Run("Auth Failure", func(t *testing.T) {
+		_, err := d.RenderYaml(rc, map[string]any{
+			"ssh": map[string]any{
+				"user":     "foo",
+				"password": "invalid",
+				"host":     host,
+				"port":     port,
+			},
+			"cmd": "echo foo",
+		}, nil)
+		assert.Error(t, err)
+	})
+}
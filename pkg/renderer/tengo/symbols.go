@@ -77,6 +77,15 @@ var static_symbols = [tu.FuncID_LAST_Static_FUNC + 1]tengo.Symbol{
 	tu.FuncID_cred:                        {Name: "cred", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_cred)},
 	tu.FuncID_cred_Htpasswd:               {Name: "cred.Htpasswd", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_cred_Htpasswd)},
 	tu.FuncID_cred_Totp:                   {Name: "cred.Totp", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_cred_Totp)},
+	tu.FuncID_crypto:                      {Name: "crypto", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_crypto)},
+	tu.FuncID_crypto_Decrypt:              {Name: "crypto.Decrypt", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_crypto_Decrypt)},
+	tu.FuncID_crypto_Encrypt:              {Name: "crypto.Encrypt", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_crypto_Encrypt)},
+	tu.FuncID_crypto_GenerateKey:          {Name: "crypto.GenerateKey", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_crypto_GenerateKey)},
+	tu.FuncID_crypto_PEMDecode:            {Name: "crypto.PEMDecode", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_crypto_PEMDecode)},
+	tu.FuncID_crypto_PEMEncode:            {Name: "crypto.PEMEncode", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_crypto_PEMEncode)},
+	tu.FuncID_crypto_PublicKey:            {Name: "crypto.PublicKey", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_crypto_PublicKey)},
+	tu.FuncID_crypto_Sign:                 {Name: "crypto.Sign", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_crypto_Sign)},
+	tu.FuncID_crypto_Verify:               {Name: "crypto.Verify", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_crypto_Verify)},
 	tu.FuncID_default:                     {Name: "default", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_default)},
 	tu.FuncID_dict:                        {Name: "dict", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_dict)},
 	tu.FuncID_div:                         {Name: "div", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_div)},
@@ -91,8 +100,13 @@ var static_symbols = [tu.FuncID_LAST_Static_FUNC + 1]tengo.Symbol{
 	tu.FuncID_enc:                         {Name: "enc", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_enc)},
 	tu.FuncID_enc_Base32:                  {Name: "enc.Base32", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_enc_Base32)},
 	tu.FuncID_enc_Base64:                  {Name: "enc.Base64", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_enc_Base64)},
+	tu.FuncID_enc_CSV:                     {Name: "enc.CSV", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_enc_CSV)},
+	tu.FuncID_enc_HCL:                     {Name: "enc.HCL", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_enc_HCL)},
 	tu.FuncID_enc_Hex:                     {Name: "enc.Hex", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_enc_Hex)},
+	tu.FuncID_enc_INI:                     {Name: "enc.INI", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_enc_INI)},
 	tu.FuncID_enc_JSON:                    {Name: "enc.JSON", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_enc_JSON)},
+	tu.FuncID_enc_TOML:                    {Name: "enc.TOML", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_enc_TOML)},
+	tu.FuncID_enc_XML:                     {Name: "enc.XML", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_enc_XML)},
 	tu.FuncID_enc_YAML:                    {Name: "enc.YAML", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_enc_YAML)},
 	tu.FuncID_eq:                          {Name: "eq", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_eq)},
 	tu.FuncID_ge:                          {Name: "ge", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_ge)},
@@ -188,6 +202,16 @@ var static_symbols = [tu.FuncID_LAST_Static_FUNC + 1]tengo.Symbol{
 	tu.FuncID_removePrefix:                {Name: "removePrefix", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_removePrefix)},
 	tu.FuncID_removeSuffix:                {Name: "removeSuffix", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_removeSuffix)},
 	tu.FuncID_replaceAll:                  {Name: "replaceAll", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_replaceAll)},
+	tu.FuncID_semver:                      {Name: "semver", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_semver)},
+	tu.FuncID_semver_BumpMajor:            {Name: "semver.BumpMajor", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_semver_BumpMajor)},
+	tu.FuncID_semver_BumpMinor:            {Name: "semver.BumpMinor", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_semver_BumpMinor)},
+	tu.FuncID_semver_BumpPatch:            {Name: "semver.BumpPatch", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_semver_BumpPatch)},
+	tu.FuncID_semver_BumpPrerelease:       {Name: "semver.BumpPrerelease", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_semver_BumpPrerelease)},
+	tu.FuncID_semver_Check:                {Name: "semver.Check", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_semver_Check)},
+	tu.FuncID_semver_Compare:              {Name: "semver.Compare", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_semver_Compare)},
+	tu.FuncID_semver_Parse:                {Name: "semver.Parse", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_semver_Parse)},
+	tu.FuncID_semver_Sort:                 {Name: "semver.Sort", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_semver_Sort)},
+	tu.FuncID_semver_Valid:                {Name: "semver.Valid", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_semver_Valid)},
 	tu.FuncID_seq:                         {Name: "seq", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_seq)},
 	tu.FuncID_sha1:                        {Name: "sha1", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_sha1)},
 	tu.FuncID_sha256:                      {Name: "sha256", Scope: tengo.ScopeGlobal, Index: int(tu.FuncID_sha256)},
@@ -0,0 +1,158 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	FormatJSON  = "json"
+	FormatJUnit = "junit"
+)
+
+// Write report to w in format
+func (r *Report) Write(w io.Writer, format string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch format {
+	case FormatJSON, "":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case FormatJUnit:
+		_, err := io.WriteString(w, xml.Header)
+		if err != nil {
+			return err
+		}
+
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		err = enc.Encode(r.junit())
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(w, "\n")
+		return err
+	default:
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name `xml:"testsuites"`
+
+	Tests    int     `xml:"tests,attr"`
+	Failures int     `xml:"failures,attr"`
+	Skipped  int     `xml:"skipped,attr"`
+	Time     float64 `xml:"time,attr"`
+
+	Suites []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string  `xml:"name,attr"`
+	Tests     int     `xml:"tests,attr"`
+	Failures  int     `xml:"failures,attr"`
+	Skipped   int     `xml:"skipped,attr"`
+	Time      float64 `xml:"time,attr"`
+	Timestamp string  `xml:"timestamp,attr"`
+
+	Cases []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string  `xml:"name,attr"`
+	ClassName string  `xml:"classname,attr"`
+	Time      float64 `xml:"time,attr"`
+
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// junit converts report as junit test suites, every task is a test suite,
+// and every matrix entry and task hook stage is a test case
+func (r *Report) junit() *junitTestSuites {
+	ret := &junitTestSuites{
+		Time: time.Since(r.StartedAt).Seconds(),
+	}
+
+	for _, t := range r.Tasks {
+		name := t.Tool + ":" + t.Task
+		suite := &junitTestSuite{
+			Name:      name,
+			Time:      t.Duration.Seconds(),
+			Timestamp: t.StartedAt.Format("2006-01-02T15:04:05"),
+		}
+
+		addCase := func(caseName string, res *result, out string) {
+			tc := &junitTestCase{
+				Name:      caseName,
+				ClassName: name,
+				Time:      res.Duration.Seconds(),
+				SystemOut: out,
+			}
+
+			switch res.Status {
+			case StatusFailed:
+				tc.Failure = &junitMessage{Message: res.Error}
+				suite.Failures++
+			case StatusSkipped:
+				tc.Skipped = &junitMessage{Message: res.Error}
+				suite.Skipped++
+			}
+
+			suite.Tests++
+			suite.Cases = append(suite.Cases, tc)
+		}
+
+		for _, h := range t.Hooks {
+			addCase("hook "+h.Stage, &h.result, formatCommands(&h.commands))
+		}
+
+		t.mu.Lock()
+		for _, m := range t.Matrix {
+			var sb strings.Builder
+			for _, h := range m.Hooks {
+				sb.WriteString("# hook " + h.Stage + "\n")
+				sb.WriteString(formatCommands(&h.commands))
+			}
+
+			sb.WriteString(formatCommands(&m.commands))
+
+			addCase("{ "+m.Matrix+" }", &m.result, sb.String())
+		}
+		t.mu.Unlock()
+
+		ret.Tests += suite.Tests
+		ret.Failures += suite.Failures
+		ret.Skipped += suite.Skipped
+		ret.Suites = append(ret.Suites, suite)
+	}
+
+	return ret
+}
+
+func formatCommands(c *commands) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var sb strings.Builder
+	for _, cmd := range c.Commands {
+		sb.WriteString(fmt.Sprintf("$ %s # exit %d, %s\n",
+			strings.Join(cmd.Command, " "), cmd.ExitCode, cmd.Duration,
+		))
+	}
+
+	return sb.String()
+}
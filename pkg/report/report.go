@@ -0,0 +1,274 @@
+// Package report records results of task execution for machine readable
+// summaries (e.g. json, junit)
+//
+// all methods are safe to call on nil receivers, so callers do not need to
+// check whether reporting is enabled
+package report
+
+import (
+	"sync"
+	"time"
+)
+
+// Status of execution
+type Status string
+
+const (
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusSkipped   Status = "skipped"
+)
+
+func statusOf(err error) Status {
+	if err != nil {
+		return StatusFailed
+	}
+
+	return StatusSucceeded
+}
+
+func errString(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
+
+// CommandRecorder records commands executed
+type CommandRecorder interface {
+	RecordCommand(cmd []string, exitCode int, startedAt time.Time, err error)
+}
+
+// HookRecorder creates results of hook stages
+type HookRecorder interface {
+	NewHook(stage string) *Hook
+}
+
+// Command is the result of an executed command
+type Command struct {
+	Command   []string      `json:"command"`
+	ExitCode  int           `json:"exit_code"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// commands is a list of executed commands
+type commands struct {
+	mu sync.Mutex
+
+	Commands []*Command `json:"commands,omitempty"`
+}
+
+func (c *commands) add(cmd []string, exitCode int, startedAt time.Time, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Commands = append(c.Commands, &Command{
+		Command:   append([]string{}, cmd...),
+		ExitCode:  exitCode,
+		StartedAt: startedAt,
+		Duration:  time.Since(startedAt),
+		Error:     errString(err),
+	})
+}
+
+// result is the common part of all execution results
+type result struct {
+	Status    Status        `json:"status"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+}
+
+func (r *result) finish(status Status, err error) {
+	r.Status = status
+	r.Duration = time.Since(r.StartedAt)
+	r.Error = errString(err)
+}
+
+// Hook is the result of a hook stage
+type Hook struct {
+	result
+	commands
+
+	Stage string `json:"stage"`
+
+	parent *hooks
+}
+
+// Start the hook stage, the hook stage is not included in the report
+// until started
+func (h *Hook) Start() {
+	if h == nil {
+		return
+	}
+
+	h.StartedAt = time.Now()
+
+	h.parent.mu.Lock()
+	defer h.parent.mu.Unlock()
+
+	h.parent.Hooks = append(h.parent.Hooks, h)
+}
+
+// RecordCommand implements CommandRecorder
+func (h *Hook) RecordCommand(cmd []string, exitCode int, startedAt time.Time, err error) {
+	if h == nil {
+		return
+	}
+
+	h.add(cmd, exitCode, startedAt, err)
+}
+
+// Finish the hook stage
+func (h *Hook) Finish(err error) {
+	if h == nil {
+		return
+	}
+
+	h.finish(statusOf(err), err)
+}
+
+// hooks is a list of hook stage results
+type hooks struct {
+	mu sync.Mutex
+
+	Hooks []*Hook `json:"hooks,omitempty"`
+}
+
+func (hs *hooks) newHook(stage string) *Hook {
+	return &Hook{
+		Stage:  stage,
+		parent: hs,
+	}
+}
+
+// Matrix is the result of a task matrix entry
+type Matrix struct {
+	result
+	commands
+	hooks
+
+	Matrix string `json:"matrix"`
+}
+
+// RecordCommand implements CommandRecorder
+func (m *Matrix) RecordCommand(cmd []string, exitCode int, startedAt time.Time, err error) {
+	if m == nil {
+		return
+	}
+
+	m.add(cmd, exitCode, startedAt, err)
+}
+
+// NewHook implements HookRecorder
+func (m *Matrix) NewHook(stage string) *Hook {
+	if m == nil {
+		return nil
+	}
+
+	return m.newHook(stage)
+}
+
+// Finish the matrix entry
+func (m *Matrix) Finish(err error) {
+	if m == nil {
+		return
+	}
+
+	m.finish(statusOf(err), err)
+}
+
+// Skip marks the matrix entry as skipped
+func (m *Matrix) Skip(reason string) {
+	if m == nil {
+		return
+	}
+
+	m.finish(StatusSkipped, nil)
+	m.Error = reason
+}
+
+// Task is the result of a task
+type Task struct {
+	result
+	hooks
+
+	Tool string `json:"tool"`
+	Task string `json:"task"`
+
+	mu     sync.Mutex
+	Matrix []*Matrix `json:"matrix,omitempty"`
+}
+
+// StartMatrix creates a new matrix entry result of the task
+func (t *Task) StartMatrix(matrix string) *Matrix {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m := &Matrix{
+		result: result{StartedAt: time.Now()},
+		Matrix: matrix,
+	}
+
+	t.Matrix = append(t.Matrix, m)
+	return m
+}
+
+// NewHook implements HookRecorder
+func (t *Task) NewHook(stage string) *Hook {
+	if t == nil {
+		return nil
+	}
+
+	return t.newHook(stage)
+}
+
+// Finish the task
+func (t *Task) Finish(err error) {
+	if t == nil {
+		return
+	}
+
+	t.finish(statusOf(err), err)
+}
+
+// Report of a dukkha run
+type Report struct {
+	mu sync.Mutex
+
+	StartedAt time.Time `json:"started_at"`
+	Tasks     []*Task   `json:"tasks"`
+}
+
+// NewReport creates a new report started now
+func NewReport() *Report {
+	return &Report{
+		StartedAt: time.Now(),
+	}
+}
+
+// StartTask creates a new task result
+func (r *Report) StartTask(tool, task string) *Task {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := &Task{
+		result: result{StartedAt: time.Now()},
+		Tool:   tool,
+		Task:   task,
+	}
+
+	r.Tasks = append(r.Tasks, t)
+	return t
+}
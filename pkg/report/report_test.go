@@ -0,0 +1,143 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestReport() *Report {
+	r := NewReport()
+
+	t := r.StartTask("golang:local", "build:foo")
+
+	before := t.NewHook("before")
+	before.Start()
+	before.RecordCommand([]string{"go", "version"}, 0, time.Now(), nil)
+	before.Finish(nil)
+
+	m := t.StartMatrix("arch: amd64")
+	m.RecordCommand([]string{"go", "build"}, 0, time.Now(), nil)
+	m.Finish(nil)
+
+	m = t.StartMatrix("arch: arm64")
+	m.RecordCommand([]string{"go", "build"}, 1, time.Now(), fmt.Errorf("exit status 1"))
+	h := m.NewHook("after:matrix:failure")
+	h.Start()
+	h.RecordCommand([]string{"echo", "failed"}, 0, time.Now(), nil)
+	h.Finish(nil)
+	m.Finish(fmt.Errorf("exit status 1"))
+
+	// not started
+	t.NewHook("after").Finish(nil)
+
+	t.StartMatrix("arch: riscv64").Skip("up to date")
+	t.Finish(fmt.Errorf("exit status 1"))
+
+	return r
+}
+
+func TestReport_Write(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Nil", func(t *testing.T) {
+		var r *Report
+
+		task := r.StartTask("a", "b")
+		assert.Nil(t, task)
+
+		m := task.StartMatrix("c")
+		m.RecordCommand(nil, 0, time.Now(), nil)
+		m.NewHook("before").Start()
+		m.Finish(nil)
+		task.Finish(nil)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		if !assert.NoError(t, newTestReport().Write(&buf, FormatJSON)) {
+			return
+		}
+
+		var actual struct {
+			Tasks []struct {
+				Tool   string `json:"tool"`
+				Task   string `json:"task"`
+				Status Status `json:"status"`
+				Hooks  []struct {
+					Stage    string `json:"stage"`
+					Commands []struct {
+						Command []string `json:"command"`
+					} `json:"commands"`
+				} `json:"hooks"`
+				Matrix []struct {
+					Matrix   string `json:"matrix"`
+					Status   Status `json:"status"`
+					Error    string `json:"error"`
+					Commands []struct {
+						ExitCode int `json:"exit_code"`
+					} `json:"commands"`
+				} `json:"matrix"`
+			} `json:"tasks"`
+		}
+
+		if !assert.NoError(t, json.Unmarshal(buf.Bytes(), &actual)) || !assert.Len(t, actual.Tasks, 1) {
+			return
+		}
+
+		task := actual.Tasks[0]
+		assert.Equal(t, "golang:local", task.Tool)
+		assert.Equal(t, "build:foo", task.Task)
+		assert.Equal(t, StatusFailed, task.Status)
+
+		if assert.Len(t, task.Hooks, 1) {
+			assert.Equal(t, "before", task.Hooks[0].Stage)
+			assert.Len(t, task.Hooks[0].Commands, 1)
+		}
+
+		if assert.Len(t, task.Matrix, 3) {
+			assert.Equal(t, StatusSucceeded, task.Matrix[0].Status)
+			assert.Equal(t, StatusFailed, task.Matrix[1].Status)
+			assert.Equal(t, "exit status 1", task.Matrix[1].Error)
+			assert.Equal(t, 1, task.Matrix[1].Commands[0].ExitCode)
+			assert.Equal(t, StatusSkipped, task.Matrix[2].Status)
+		}
+	})
+
+	t.Run("JUnit", func(t *testing.T) {
+		var buf bytes.Buffer
+		if !assert.NoError(t, newTestReport().Write(&buf, FormatJUnit)) {
+			return
+		}
+
+		var actual junitTestSuites
+		if !assert.NoError(t, xml.Unmarshal(buf.Bytes(), &actual)) {
+			return
+		}
+
+		assert.Equal(t, 4, actual.Tests)
+		assert.Equal(t, 1, actual.Failures)
+		assert.Equal(t, 1, actual.Skipped)
+
+		if assert.Len(t, actual.Suites, 1) && assert.Len(t, actual.Suites[0].Cases, 4) {
+			cases := actual.Suites[0].Cases
+			assert.Equal(t, "hook before", cases[0].Name)
+			assert.Equal(t, "{ arch: arm64 }", cases[2].Name)
+			assert.Equal(t, "golang:local:build:foo", cases[2].ClassName)
+			if assert.NotNil(t, cases[2].Failure) {
+				assert.Equal(t, "exit status 1", cases[2].Failure.Message)
+			}
+			assert.Contains(t, cases[2].SystemOut, "# hook after:matrix:failure")
+			assert.NotNil(t, cases[3].Skipped)
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		assert.Error(t, NewReport().Write(&bytes.Buffer{}, "yaml"))
+	})
+}
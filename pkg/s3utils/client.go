@@ -0,0 +1,28 @@
+package s3utils
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// NewClient creates a minio client for the s3 compatible service at endpointURL
+func NewClient(endpointURL, region, accessKeyID, accessKeySecret string) (*minio.Client, error) {
+	eURL, err := url.Parse(endpointURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint url: %w", err)
+	}
+
+	client, err := minio.New(eURL.Host, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKeyID, accessKeySecret, ""),
+		Secure: eURL.Scheme == "https",
+		Region: region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating s3 client: %w", err)
+	}
+
+	return client, nil
+}
@@ -1,101 +1,632 @@
 package templateutils
 
-// import (
-// 	"crypto"
-// 	"crypto/aes"
-// 	"crypto/cipher"
-// 	"crypto/des"
-// 	"crypto/elliptic"
-// 	"crypto/rand"
-// 	"crypto/rc4"
-// 	"crypto/rsa"
-// 	"crypto/sha256"
-// )
-//
-// type (
-// 	// rc4-
-// 	rc4NS struct{}
-// 	// des-
-// 	desNS struct{}
-// 	// des3-
-// 	tripleDESNS struct{}
-//
-// 	// aes-{128,192,256}-{gcm,cbc,cfb,ofb}
-// 	aesNS struct{}
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"arhat.dev/pkg/clihelper"
+	"arhat.dev/pkg/stringhelper"
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/spf13/pflag"
+)
+
+// cryptoNS for encryption and signing
 //
-// 	rsaNS       struct{}
-// 	secp224r1NS struct{}
-// 	eddsaNS     struct{}
-// )
+// kind of the key determines the algorithm used:
+//   - 16, 24 or 32 bytes long symmetric keys (encoded in the same format as
+//     data, see options below): AES-GCM, encrypted data is the nonce followed
+//     by the sealed data
+//   - PEM encoded rsa keys: RSA-OAEP with SHA-256 for encryption,
+//     RSASSA-PKCS1-v1_5 with SHA-256 for signing
+//   - PEM encoded ecdsa keys: ECDSA with SHA-256 (P-224, P-256), SHA-384 (P-384)
+//     or SHA-512 (P-521), signatures are ASN.1 encoded
+//   - PEM encoded ed25519 keys: Ed25519
+//   - age identities (`AGE-SECRET-KEY-1...`) and recipients (`age1...`):
+//     age X25519
 //
-// func (rc4NS) Decrypt(key Bytes, args ...any) (ret string, err error) {
-// 	data, err := toBytes(key)
-// 	if err != nil {
-// 		return
-// 	}
-// 	rc4.NewCipher(data)
-// 	return
-// }
+// PEM encoded private keys can be in PKCS#8, PKCS#1 (rsa) or SEC 1 (ecdsa)
+// format, public keys can be in PKIX or PKCS#1 (rsa) format, private keys are
+// accepted where public keys are expected
 //
-// func (desNS) Decrypt(key Bytes, args ...any) (ret string, err error) {
-// 	data, err := toBytes(key)
-// 	if err != nil {
-// 		return
-// 	}
-// 	des.NewCipher(data)
-// 	return
-// }
+// functions producing or consuming encrypted data and signatures share
+// the following options:
+// - `--base64`: encode/decode data and symmetric keys in base64 format (std encoding) (default)
+// - `--hex`: encode/decode data and symmetric keys in hex format
+// - `--raw`: do not encode/decode data and symmetric keys
+type cryptoNS struct{}
+
+// Encrypt data with key
 //
-// func (tripleDESNS) Decrypt(key Bytes, args ...any) (ret string, err error) {
-// 	data, err := toBytes(key)
-// 	if err != nil {
-// 		return
-// 	}
-// 	des.NewTripleDESCipher(data)
-// 	return
-// }
+// Encrypt(...<options>, key Bytes, data Bytes)
 //
-// func (aesNS) Decrypt(key Bytes, args ...any) (ret string, err error) {
-// 	data, err := toBytes(key)
-// 	if err != nil {
-// 		return
-// 	}
-// 	b, err := aes.NewCipher(data)
-// 	if err != nil {
-// 		return
-// 	}
+// where options are:
+// - `--aad` data: additional authenticated data for AES-GCM, label for RSA-OAEP
+// - `--armor`: output age encrypted data with ASCII armor instead of encoding
+func (cryptoNS) Encrypt(args ...any) (ret string, err error) {
+	opts, fixed, err := parseCryptoArgs(args, "encrypt", 2)
+	if err != nil {
+		return
+	}
+
+	k, err := toCryptoKey(&opts, fixed[0])
+	if err != nil {
+		return
+	}
+
+	data, err := toBytes(fixed[1])
+	if err != nil {
+		return
+	}
+
+	var out []byte
+	switch key := k.(type) {
+	case []byte:
+		var aead cipher.AEAD
+		aead, err = newAESGCM(key)
+		if err != nil {
+			return
+		}
+
+		nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+		_, err = io.ReadFull(rand.Reader, nonce)
+		if err != nil {
+			return
+		}
+
+		out = aead.Seal(nonce, nonce, data, opts.aad)
+	case *rsa.PrivateKey:
+		out, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, &key.PublicKey, data, opts.aad)
+	case *rsa.PublicKey:
+		out, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, key, data, opts.aad)
+	case []age.Recipient:
+		out, err = ageEncrypt(key, data, opts.armor)
+		if opts.armor {
+			return stringhelper.Convert[string, byte](out), err
+		}
+	default:
+		err = fmt.Errorf("encryption is not supported by key type %T", k)
+	}
+
+	if err != nil {
+		return
+	}
+
+	return opts.encode(out), nil
+}
+
+// Decrypt data with key
 //
-// 	a, err := cipher.NewGCM(b)
-// 	if err != nil {
-// 		return
-// 	}
-// 	_ = a
+// Decrypt(...<options>, key Bytes, data Bytes)
 //
-// 	cipher.NewCFBEncrypter(b, nil)
+// where options are:
+// - `--aad` data: additional authenticated data for AES-GCM, label for RSA-OAEP
 //
-// 	cipher.NewOFB(b, nil)
-// 	return
-// }
+// age encrypted data with ASCII armor is always accepted
+func (cryptoNS) Decrypt(args ...any) (ret string, err error) {
+	opts, fixed, err := parseCryptoArgs(args, "decrypt", 2)
+	if err != nil {
+		return
+	}
+
+	k, err := toCryptoKey(&opts, fixed[0])
+	if err != nil {
+		return
+	}
+
+	data, err := toBytes(fixed[1])
+	if err != nil {
+		return
+	}
+
+	isArmored := bytes.HasPrefix(bytes.TrimSpace(data), []byte(armor.Header))
+	if !isArmored {
+		data, err = opts.decode(data)
+		if err != nil {
+			return
+		}
+	}
+
+	var out []byte
+	switch key := k.(type) {
+	case []byte:
+		var aead cipher.AEAD
+		aead, err = newAESGCM(key)
+		if err != nil {
+			return
+		}
+
+		if len(data) < aead.NonceSize() {
+			err = fmt.Errorf("invalid encrypted data too short")
+			return
+		}
+
+		out, err = aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], opts.aad)
+	case *rsa.PrivateKey:
+		out, err = rsa.DecryptOAEP(sha256.New(), rand.Reader, key, data, opts.aad)
+	case []age.Identity:
+		var r io.Reader = bytes.NewReader(data)
+		if isArmored {
+			r = armor.NewReader(r)
+		}
+
+		r, err = age.Decrypt(r, key...)
+		if err != nil {
+			return
+		}
+
+		out, err = io.ReadAll(r)
+	default:
+		err = fmt.Errorf("decryption is not supported by key type %T", k)
+	}
+
+	if err != nil {
+		return
+	}
+
+	return stringhelper.Convert[string, byte](out), nil
+}
+
+// Sign data with private key
 //
-// func (rsaNS) Decrypt(k PrivateKey, args ...any) (ret string, err error) {
-// 	rsa.DecryptOAEP(sha256.New(), rand.Reader, nil /* private key */, nil /* ciphertext */, nil /* label */)
-// 	return
-// }
+// Sign(...<options>, privateKey Bytes, data Bytes)
+func (cryptoNS) Sign(args ...any) (ret string, err error) {
+	opts, fixed, err := parseCryptoArgs(args, "sign", 2)
+	if err != nil {
+		return
+	}
+
+	k, err := toCryptoKey(&opts, fixed[0])
+	if err != nil {
+		return
+	}
+
+	data, err := toBytes(fixed[1])
+	if err != nil {
+		return
+	}
+
+	var sig []byte
+	switch key := k.(type) {
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(key, data)
+	case *ecdsa.PrivateKey:
+		sig, err = ecdsa.SignASN1(rand.Reader, key, ecdsaDigest(key.Curve, data))
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256(data)
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	default:
+		err = fmt.Errorf("signing is not supported by key type %T", k)
+	}
+
+	if err != nil {
+		return
+	}
+
+	return opts.encode(sig), nil
+}
+
+// Verify signature of data with public key, returns false if the signature is invalid
 //
-// func (secp224r1NS) Decrypt(k PrivateKey, args ...any) (ret string, err error) {
-// 	// switch t := toPrivateKey(k).(type) {
-// 	// case ecdsa.PrivateKey:
-// 	// case ed25519.PrivateKey:
-// 	// }
-// 	elliptic.GenerateKey(elliptic.P224(), rand.Reader)
-// 	return
-// }
+// Verify(...<options>, publicKey Bytes, signature Bytes, data Bytes)
+func (cryptoNS) Verify(args ...any) (_ bool, err error) {
+	opts, fixed, err := parseCryptoArgs(args, "verify", 3)
+	if err != nil {
+		return
+	}
+
+	k, err := toCryptoKey(&opts, fixed[0])
+	if err != nil {
+		return
+	}
+
+	sig, err := toBytes(fixed[1])
+	if err != nil {
+		return
+	}
+
+	sig, err = opts.decode(sig)
+	if err != nil {
+		return
+	}
+
+	data, err := toBytes(fixed[2])
+	if err != nil {
+		return
+	}
+
+	switch key := publicKeyOf(k).(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, data, sig), nil
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(key, ecdsaDigest(key.Curve, data), sig), nil
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil, nil
+	default:
+		return false, fmt.Errorf("signature verification is not supported by key type %T", k)
+	}
+}
+
+// GenerateKey generates a new key
 //
-// type PrivateKey any
+// GenerateKey(...<options>, kind String)
 //
-// func toPrivateKey(k PrivateKey) crypto.PrivateKey {
-// 	// data := toBytes(k)
-// 	// _ = data
+// where kind is one of:
+// - `aes-128`, `aes-192`, `aes-256`: random bytes encoded according to options
+// - `rsa-2048`, `rsa-3072`, `rsa-4096`: PEM encoded PKCS#8 private key
+// - `ecdsa-p256`, `ecdsa-p384`, `ecdsa-p521`: PEM encoded PKCS#8 private key
+// - `ed25519`: PEM encoded PKCS#8 private key
+// - `age`: age X25519 identity
+func (cryptoNS) GenerateKey(args ...any) (ret string, err error) {
+	opts, fixed, err := parseCryptoArgs(args, "generate-key", 1)
+	if err != nil {
+		return
+	}
+
+	kind, err := toString(fixed[0])
+	if err != nil {
+		return
+	}
+
+	var key any
+	switch kind {
+	case "aes-128", "aes-192", "aes-256":
+		bits, _ := strconv.Atoi(strings.TrimPrefix(kind, "aes-"))
+		buf := make([]byte, bits/8)
+		_, err = io.ReadFull(rand.Reader, buf)
+		if err != nil {
+			return
+		}
+
+		return opts.encode(buf), nil
+	case "age":
+		var id *age.X25519Identity
+		id, err = age.GenerateX25519Identity()
+		if err != nil {
+			return
+		}
+
+		return id.String(), nil
+	case "rsa-2048":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "rsa-3072":
+		key, err = rsa.GenerateKey(rand.Reader, 3072)
+	case "rsa-4096":
+		key, err = rsa.GenerateKey(rand.Reader, 4096)
+	case "ecdsa-p256":
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ecdsa-p384":
+		key, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "ecdsa-p521":
+		key, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case "ed25519":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		err = fmt.Errorf("unknown key kind %q", kind)
+	}
+
+	if err != nil {
+		return
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return
+	}
+
+	return encodePEM("PRIVATE KEY", der), nil
+}
+
+// PublicKey returns PEM encoded PKIX public key of the private key, or
+// age recipient of the age identity
+func (cryptoNS) PublicKey(privateKey Bytes) (ret string, err error) {
+	k, err := toCryptoKey(&cryptoOptions{}, privateKey)
+	if err != nil {
+		return
+	}
+
+	if ids, ok := k.([]age.Identity); ok {
+		var recipients []string
+		for _, id := range ids {
+			x, ok := id.(*age.X25519Identity)
+			if !ok {
+				return "", fmt.Errorf("unsupported age identity type %T", id)
+			}
+
+			recipients = append(recipients, x.Recipient().String())
+		}
+
+		return strings.Join(recipients, "\n"), nil
+	}
+
+	pub := publicKeyOf(k)
+	if _, ok := pub.([]byte); ok {
+		return "", fmt.Errorf("no public key for symmetric key")
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return
+	}
+
+	return encodePEM("PUBLIC KEY", der), nil
+}
+
+// PEMEncode encodes data as a PEM block
 //
-// 	return nil
-// }
+// PEMEncode(blockType String, data Bytes) (e.g. `PEMEncode "CERTIFICATE" $der`)
+func (cryptoNS) PEMEncode(blockType String, data Bytes) (ret string, err error) {
+	typ, err := toString(blockType)
+	if err != nil {
+		return
+	}
+
+	der, err := toBytes(data)
+	if err != nil {
+		return
+	}
+
+	return encodePEM(typ, der), nil
+}
+
+// PEMDecode decodes all PEM blocks in data, each block is a map with keys:
+// - `type`: block type (e.g. `PRIVATE KEY`)
+// - `headers`: map of block headers
+// - `bytes`: decoded block content
+func (cryptoNS) PEMDecode(data Bytes) (ret []map[string]any, err error) {
+	rest, err := toBytes(data)
+	if err != nil {
+		return
+	}
+
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		headers := make(map[string]any, len(block.Headers))
+		for k, v := range block.Headers {
+			headers[k] = v
+		}
+
+		ret = append(ret, map[string]any{
+			"type":    block.Type,
+			"headers": headers,
+			"bytes":   string(block.Bytes),
+		})
+	}
+
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("no pem block found")
+	}
+
+	return
+}
+
+type cryptoOptions struct {
+	encHex    bool
+	encBase64 bool
+	encRaw    bool
+
+	aad   []byte
+	armor bool
+}
+
+func (opts *cryptoOptions) encode(b []byte) string {
+	switch {
+	case opts.encRaw:
+		return stringhelper.Convert[string, byte](b)
+	case opts.encHex:
+		return hex.EncodeToString(b)
+	default:
+		return base64.StdEncoding.EncodeToString(b)
+	}
+}
+
+func (opts *cryptoOptions) decode(b []byte) ([]byte, error) {
+	switch {
+	case opts.encRaw:
+		return b, nil
+	case opts.encHex:
+		return hex.DecodeString(strings.TrimSpace(stringhelper.Convert[string, byte](b)))
+	default:
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(stringhelper.Convert[string, byte](b)))
+	}
+}
+
+// parseCryptoArgs parses options in args, the last nFixed args are returned as fixed
+func parseCryptoArgs(args []any, name string, nFixed int) (opts cryptoOptions, fixed []any, err error) {
+	n := len(args)
+	if n < nFixed {
+		err = fmt.Errorf("at least %d args expected: got %d", nFixed, n)
+		return
+	}
+
+	fixed = args[n-nFixed:]
+	if n == nFixed {
+		return
+	}
+
+	flags, err := toStrings(args[:n-nFixed])
+	if err != nil {
+		return
+	}
+
+	var (
+		fs  pflag.FlagSet
+		aad string
+	)
+
+	clihelper.InitFlagSet(&fs, name)
+
+	fs.BoolVar(&opts.encBase64, "base64", true, "")
+	fs.BoolVar(&opts.encHex, "hex", false, "")
+	fs.BoolVar(&opts.encRaw, "raw", false, "")
+	fs.StringVar(&aad, "aad", "", "")
+	fs.BoolVar(&opts.armor, "armor", false, "")
+
+	err = fs.Parse(flags)
+	if len(aad) != 0 {
+		opts.aad = []byte(aad)
+	}
+
+	return
+}
+
+// toCryptoKey parses key data, symmetric keys are decoded according to opts,
+// returned value is one of
+// - []byte (symmetric key)
+// - *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey
+// - *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey
+// - []age.Identity, []age.Recipient
+func toCryptoKey(opts *cryptoOptions, k Bytes) (_ any, err error) {
+	data, err := toBytes(k)
+	if err != nil {
+		return
+	}
+
+	trimmed := strings.TrimSpace(stringhelper.Convert[string, byte](data))
+	switch {
+	case strings.HasPrefix(trimmed, "AGE-SECRET-KEY-1"):
+		return age.ParseIdentities(strings.NewReader(trimmed))
+	case strings.HasPrefix(trimmed, "age1"):
+		return age.ParseRecipients(strings.NewReader(trimmed))
+	case strings.HasPrefix(trimmed, "-----BEGIN "):
+		return parsePEMKey(trimmed)
+	}
+
+	data, err = opts.decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid symmetric key encoding: %w", err)
+	}
+
+	switch len(data) {
+	case 16, 24, 32:
+		return data, nil
+	default:
+		return nil, fmt.Errorf("invalid symmetric key size %d, expecting 16, 24 or 32", len(data))
+	}
+}
+
+func parsePEMKey(data string) (any, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("invalid pem encoded key")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported pem block type %q", block.Type)
+	}
+}
+
+func publicKeyOf(k any) any {
+	switch key := k.(type) {
+	case *rsa.PrivateKey:
+		return &key.PublicKey
+	case *ecdsa.PrivateKey:
+		return &key.PublicKey
+	case ed25519.PrivateKey:
+		return key.Public()
+	default:
+		return k
+	}
+}
+
+func ecdsaDigest(curve elliptic.Curve, data []byte) []byte {
+	switch curve.Params().BitSize {
+	case 384:
+		d := sha512.Sum384(data)
+		return d[:]
+	case 521:
+		d := sha512.Sum512(data)
+		return d[:]
+	default:
+		d := sha256.Sum256(data)
+		return d[:]
+	}
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func ageEncrypt(recipients []age.Recipient, data []byte, useArmor bool) (_ []byte, err error) {
+	var (
+		buf bytes.Buffer
+
+		out io.Writer = &buf
+		aw  io.WriteCloser
+	)
+
+	if useArmor {
+		aw = armor.NewWriter(&buf)
+		out = aw
+	}
+
+	w, err := age.Encrypt(out, recipients...)
+	if err != nil {
+		return
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		return
+	}
+
+	err = w.Close()
+	if err != nil {
+		return
+	}
+
+	if aw != nil {
+		err = aw.Close()
+		if err != nil {
+			return
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encodePEM(typ string, der []byte) string {
+	return stringhelper.Convert[string, byte](pem.EncodeToMemory(&pem.Block{
+		Type:  typ,
+		Bytes: der,
+	}))
+}
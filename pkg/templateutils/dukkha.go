@@ -8,9 +8,11 @@ import (
 	"io"
 	"strings"
 
+	"arhat.dev/pkg/clihelper"
 	"arhat.dev/pkg/textquery"
 	"arhat.dev/rs"
 	"github.com/itchyny/gojq"
+	"github.com/spf13/pflag"
 	"gopkg.in/yaml.v3"
 
 	di "arhat.dev/dukkha/internal"
@@ -353,6 +355,87 @@ func (ns dukkhaNS) FromJson(v Bytes) (any, error) {
 	)
 }
 
+// FromToml unmarshals toml doc into map[string]any, date and time values are
+// converted to strings
+// nolint:revive
+func (ns dukkhaNS) FromToml(v Bytes) (any, error) {
+	return fromGenericText(ns.rc, v, decodeTOML)
+}
+
+// FromIni unmarshals ini doc into map[string]any
+//
+// keys in the default section are at the top level, sections are maps (`[a.b]` as
+// nested maps), and keys with multiple values are lists
+// nolint:revive
+func (ns dukkhaNS) FromIni(v Bytes) (any, error) {
+	return fromGenericText(ns.rc, v, decodeINI)
+}
+
+// FromCsv unmarshals csv data into list of maps keyed by the header row
+// Usage: FromCsv(...<options>, data)
+// where options are:
+// - `--delimiter` or `-d` char: field delimiter (defaults to `,`)
+// - `--no-header`: data has no header row, return list of lists instead
+// nolint:revive
+func (ns dukkhaNS) FromCsv(args ...any) (_ any, err error) {
+	n := len(args)
+	if n == 0 {
+		err = errAtLeastOneArgGotZero
+		return
+	}
+
+	flags, err := toStrings(args[:n-1])
+	if err != nil {
+		return
+	}
+
+	var (
+		fs   pflag.FlagSet
+		opts csvOptions
+	)
+
+	clihelper.InitFlagSet(&fs, "fromCsv")
+
+	fs.StringVarP(&opts.delimiter, "delimiter", "d", ",", "")
+	fs.BoolVar(&opts.noHeader, "no-header", false, "")
+
+	err = fs.Parse(flags)
+	if err != nil {
+		return
+	}
+
+	return fromGenericText(ns.rc, args[n-1], func(data []byte) (any, error) {
+		return decodeCSV(data, &opts)
+	})
+}
+
+// FromXml unmarshals xml doc into `{root: value}`
+//
+// value of an element without attributes and child elements is its text, otherwise
+// it's a map with attributes as `-name` keys, child elements as keys (lists for
+// repeated elements) and non-empty text as `#text`
+// nolint:revive
+func (ns dukkhaNS) FromXml(v Bytes) (any, error) {
+	return fromGenericText(ns.rc, v, decodeXML)
+}
+
+// FromHcl unmarshals hcl (v1) doc into map[string]any
+//
+// blocks and objects are decoded as lists of maps, use enc.HCL for encoding
+// nolint:revive
+func (ns dukkhaNS) FromHcl(v Bytes) (any, error) {
+	return fromGenericText(ns.rc, v, decodeHCL)
+}
+
+func fromGenericText(
+	rc dukkha.RenderingContext,
+	v Bytes,
+	decode func(data []byte) (any, error),
+) (any, error) {
+	newDecoder, unmarshal := newGenericDecoder(decode)
+	return FromText(rc, v, newDecoder, unmarshal)
+}
+
 // TODO: support optional resolving
 func FromText(
 	rc dukkha.RenderingContext,
@@ -138,6 +138,177 @@ func (encNS) JSON(args ...any) (ret string, err error) {
 	return
 }
 
+// TOML encodes object into toml
+// Usage: TOML(...<options>, <optional writer>, Object)
+// where options are:
+// - `--indent` or `-i` str: indent nested tables with str
+// - `--indent-count` or `-c` N: repeat indent str N times (defaults to 2)
+//
+// NOTE: there's no toml decoding support in this namespace, use dukkha.FromToml instead
+func (encNS) TOML(args ...any) (string, error) {
+	var (
+		indentN   int
+		indentStr string
+	)
+
+	return encodeObject(args, "toml",
+		func(fs *pflag.FlagSet) {
+			fs.IntVarP(&indentN, "indent-count", "c", 2, "")
+			fs.StringVarP(&indentStr, "indent", "i", "", "")
+		},
+		func(w io.Writer, obj any) error {
+			return encodeTOML(w, obj, strings.Repeat(indentStr, indentN))
+		},
+	)
+}
+
+// INI encodes map object into ini
+// Usage: INI(...<options>, <optional writer>, Object)
+// where options are:
+// - `--compact` or `-C`: write `key=value` without spaces around `=` (e.g. systemd units)
+//
+// top level scalar values are written before all sections, maps are written as sections
+// (nested maps as `[parent.child]`) and lists are written as repeated keys
+//
+// NOTE: there's no ini decoding support in this namespace, use dukkha.FromIni instead
+func (encNS) INI(args ...any) (string, error) {
+	var compact bool
+
+	return encodeObject(args, "ini",
+		func(fs *pflag.FlagSet) {
+			fs.BoolVarP(&compact, "compact", "C", false, "")
+		},
+		func(w io.Writer, obj any) error {
+			return EncodeINI(w, obj, compact)
+		},
+	)
+}
+
+// CSV encodes list of lists or list of maps into csv
+// Usage: CSV(...<options>, <optional writer>, Object)
+// where options are:
+// - `--delimiter` or `-d` char: field delimiter (defaults to `,`)
+// - `--columns` or `-C` col1,col2: columns to write for list of maps (defaults to all keys sorted)
+// - `--no-header`: do not write header row for list of maps
+//
+// NOTE: there's no csv decoding support in this namespace, use dukkha.FromCsv instead
+func (encNS) CSV(args ...any) (string, error) {
+	var opts csvOptions
+
+	return encodeObject(args, "csv",
+		func(fs *pflag.FlagSet) {
+			fs.StringVarP(&opts.delimiter, "delimiter", "d", ",", "")
+			fs.StringSliceVarP(&opts.columns, "columns", "C", nil, "")
+			fs.BoolVar(&opts.noHeader, "no-header", false, "")
+		},
+		func(w io.Writer, obj any) error {
+			return encodeCSV(w, obj, &opts)
+		},
+	)
+}
+
+// XML encodes object into xml
+// Usage: XML(...<options>, <optional writer>, Object)
+// where options are:
+// - `--root` or `-r` name: name of the root element (defaults to the single key of map Object)
+// - `--indent` or `-i` str: indent nested elements with str
+// - `--indent-count` or `-c` N: repeat indent str N times (defaults to 2)
+// - `--pretty` or `-P`: same as `--indent " "` when `--indent` is not set
+// - `--no-header`: do not write `<?xml ... ?>` header
+//
+// map keys with `-` prefix are written as attributes, value of `#text` key is written as
+// char data of the element, and lists are written as repeated elements
+//
+// NOTE: there's no xml decoding support in this namespace, use dukkha.FromXml instead
+func (encNS) XML(args ...any) (string, error) {
+	var (
+		opts xmlOptions
+
+		indentN int
+		pretty  bool
+	)
+
+	return encodeObject(args, "xml",
+		func(fs *pflag.FlagSet) {
+			fs.StringVarP(&opts.root, "root", "r", "", "")
+			fs.IntVarP(&indentN, "indent-count", "c", 2, "")
+			fs.StringVarP(&opts.indent, "indent", "i", "", "")
+			fs.BoolVarP(&pretty, "pretty", "P", false, "")
+			fs.BoolVar(&opts.noHeader, "no-header", false, "")
+		},
+		func(w io.Writer, obj any) error {
+			if pretty && len(opts.indent) == 0 {
+				opts.indent = " "
+			}
+
+			opts.indent = strings.Repeat(opts.indent, indentN)
+			return encodeXML(w, obj, &opts)
+		},
+	)
+}
+
+// HCL encodes map object into hcl (v1)
+// Usage: HCL(...<options>, <optional writer>, Object)
+// where options are:
+// - `--indent` or `-i` str: indent nested blocks with str (defaults to ` `)
+// - `--indent-count` or `-c` N: repeat indent str N times (defaults to 2)
+//
+// maps are written as blocks, lists are written as `[...]` with maps in lists as objects,
+// null values are not supported
+//
+// NOTE: there's no hcl decoding support in this namespace, use dukkha.FromHcl instead
+func (encNS) HCL(args ...any) (string, error) {
+	var (
+		indentN   int
+		indentStr string
+	)
+
+	return encodeObject(args, "hcl",
+		func(fs *pflag.FlagSet) {
+			fs.IntVarP(&indentN, "indent-count", "c", 2, "")
+			fs.StringVarP(&indentStr, "indent", "i", " ", "")
+		},
+		func(w io.Writer, obj any) error {
+			return EncodeHCL(w, obj, strings.Repeat(indentStr, indentN))
+		},
+	)
+}
+
+// encodeObject handles args in the form of (...<options>, <optional writer>, Object)
+//
+// setFlags is called to set options before parsing, encode writes obj to w
+func encodeObject(
+	args []any,
+	name string,
+	setFlags func(fs *pflag.FlagSet),
+	encode func(w io.Writer, obj any) error,
+) (ret string, err error) {
+	obj, outWriter, args := parseArgs_MaybeOUTPUT_OBJ(args)
+	flags, err := toStrings(args)
+	if err != nil {
+		return
+	}
+
+	var fs pflag.FlagSet
+
+	clihelper.InitFlagSet(&fs, name)
+	setFlags(&fs)
+
+	err = fs.Parse(flags)
+	if err != nil {
+		return
+	}
+
+	if outWriter != nil {
+		err = encode(outWriter, obj)
+		return
+	}
+
+	var tmpWriter bytes.Buffer
+	err = encode(&tmpWriter, obj)
+	return stringhelper.Convert[string, byte](tmpWriter.Next(tmpWriter.Len())), err
+}
+
 // Hex to encode/decode hex data (default works in encoding mode)
 //
 // Hex(...<options>, <optional writer>, inputDataOrReader)
@@ -0,0 +1,895 @@
+package templateutils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/hashicorp/hcl"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v3"
+)
+
+// encoding and decoding of generic data (map[string]any, []any and scalars)
+// in formats other than json and yaml
+
+const (
+	// xmlAttrPrefix is the key prefix for xml attributes in generic data
+	//
+	// NOTE: not using `@` as it's reserved for rendering suffix
+	xmlAttrPrefix = "-"
+
+	// xmlTextKey is the key of xml char data in generic data
+	xmlTextKey = "#text"
+)
+
+// newGenericDecoder creates DataDecoder and unmarshal func to be used with FromText
+//
+// the decoded generic data is converted to yaml first, so rendering suffixes in it
+// are handled in the same way as FromYaml
+func newGenericDecoder(
+	decode func(data []byte) (any, error),
+) (func(r io.Reader) DataDecoder, func(data []byte, out any) error) {
+	unmarshal := func(data []byte, out any) error {
+		v, err := decode(data)
+		if err != nil {
+			return err
+		}
+
+		data, err = yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		return yaml.Unmarshal(data, out)
+	}
+
+	return func(r io.Reader) DataDecoder {
+		return &genericDecoder{r: r, unmarshal: unmarshal}
+	}, unmarshal
+}
+
+type genericDecoder struct {
+	r         io.Reader
+	unmarshal func(data []byte, out any) error
+}
+
+func (d *genericDecoder) Decode(out any) error {
+	data, err := io.ReadAll(d.r)
+	if err != nil {
+		return err
+	}
+
+	return d.unmarshal(data, out)
+}
+
+// TOML
+
+func encodeTOML(w io.Writer, obj any, indent string) error {
+	enc := toml.NewEncoder(w)
+	if len(indent) != 0 {
+		enc.SetIndentTables(true).SetIndentSymbol(indent)
+	}
+
+	return enc.Encode(obj)
+}
+
+func decodeTOML(data []byte) (any, error) {
+	var out map[string]any
+	err := toml.Unmarshal(data, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	return normalizeTOMLValue(out), nil
+}
+
+// normalizeTOMLValue converts toml date and time values to strings
+func normalizeTOMLValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, v := range t {
+			t[k] = normalizeTOMLValue(v)
+		}
+		return t
+	case []any:
+		for i, v := range t {
+			t[i] = normalizeTOMLValue(v)
+		}
+		return t
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	case toml.LocalDate:
+		return t.String()
+	case toml.LocalTime:
+		return t.String()
+	case toml.LocalDateTime:
+		return t.String()
+	default:
+		return v
+	}
+}
+
+// INI
+
+// EncodeINI writes obj as ini to w
+//
+// scalar values at top level are written before any section, maps are written as
+// sections (nested maps as `[parent.child]`), lists are written as repeated keys
+//
+// when compact is true, key and value are joined by `=` without spaces (e.g. systemd units)
+func EncodeINI(w io.Writer, obj any, compact bool) error {
+	m, ok := toGenericMap(obj)
+	if !ok {
+		return fmt.Errorf("invalid non map object %T for ini", obj)
+	}
+
+	sep := " = "
+	if compact {
+		sep = "="
+	}
+
+	var buf bytes.Buffer
+	err := writeINISection(&buf, "", m, sep)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(bytes.TrimPrefix(buf.Bytes(), []byte{'\n'}))
+	return err
+}
+
+func writeINISection(buf *bytes.Buffer, name string, m map[string]any, sep string) error {
+	var (
+		keys     = sortedKeys(m)
+		sections []string
+	)
+
+	if len(name) != 0 {
+		buf.WriteString("\n[" + name + "]\n")
+	}
+
+	for _, k := range keys {
+		v := m[k]
+		if _, ok := toGenericMap(v); ok {
+			sections = append(sections, k)
+			continue
+		}
+
+		values, ok := toGenericSlice(v)
+		if !ok {
+			values = []any{v}
+		}
+
+		for _, v := range values {
+			s, err := formatINIValue(v)
+			if err != nil {
+				return fmt.Errorf("key %q: %w", k, err)
+			}
+
+			buf.WriteString(k + sep + s + "\n")
+		}
+	}
+
+	for _, k := range sections {
+		child, _ := toGenericMap(m[k])
+		if len(name) != 0 {
+			k = name + "." + k
+		}
+
+		err := writeINISection(buf, k, child, sep)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func formatINIValue(v any) (string, error) {
+	s, err := formatScalarValue(v)
+	if err != nil || !strings.Contains(s, "\n") {
+		return s, err
+	}
+
+	if strings.Contains(s, `"""`) {
+		return "", fmt.Errorf("multi-line value cannot contain `\"\"\"`")
+	}
+
+	return `"""` + s + `"""`, nil
+}
+
+// formatScalarValue formats non map and non list value as string
+func formatScalarValue(v any) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return t, nil
+	case []byte:
+		return string(t), nil
+	}
+
+	if _, ok := toGenericMap(v); ok {
+		return "", fmt.Errorf("unexpected map value")
+	}
+
+	if _, ok := toGenericSlice(v); ok {
+		return "", fmt.Errorf("unexpected list value")
+	}
+
+	return fmt.Sprint(v), nil
+}
+
+func decodeINI(data []byte) (any, error) {
+	f, err := ini.LoadSources(ini.LoadOptions{
+		AllowShadows: true,
+	}, data)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]any)
+	for _, sec := range f.Sections() {
+		m := ret
+		if name := sec.Name(); name != ini.DefaultSection {
+			for _, part := range strings.Split(name, ".") {
+				child, ok := m[part].(map[string]any)
+				if !ok {
+					child = make(map[string]any)
+					m[part] = child
+				}
+
+				m = child
+			}
+		}
+
+		for _, key := range sec.Keys() {
+			values := key.ValueWithShadows()
+			if len(values) == 1 {
+				m[key.Name()] = values[0]
+				continue
+			}
+
+			list := make([]any, len(values))
+			for i, v := range values {
+				list[i] = v
+			}
+
+			m[key.Name()] = list
+		}
+	}
+
+	return ret, nil
+}
+
+// CSV
+
+type csvOptions struct {
+	delimiter string
+	noHeader  bool
+	columns   []string
+}
+
+func (opts *csvOptions) comma() (rune, error) {
+	if len(opts.delimiter) == 0 {
+		return ',', nil
+	}
+
+	r := []rune(opts.delimiter)
+	if len(r) != 1 {
+		return 0, fmt.Errorf("invalid csv delimiter %q: expecting single character", opts.delimiter)
+	}
+
+	return r[0], nil
+}
+
+// encodeCSV writes obj as csv, obj MUST be a list of lists or a list of maps
+//
+// for list of maps, the header row is opts.columns if set, otherwise sorted keys of all maps
+func encodeCSV(w io.Writer, obj any, opts *csvOptions) (err error) {
+	rows, ok := toGenericSlice(obj)
+	if !ok {
+		return fmt.Errorf("invalid non list object %T for csv", obj)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma, err = opts.comma()
+	if err != nil {
+		return err
+	}
+
+	columns := opts.columns
+	if len(columns) == 0 {
+		set := make(map[string]any)
+		for _, row := range rows {
+			m, ok := toGenericMap(row)
+			if !ok {
+				continue
+			}
+
+			for k := range m {
+				set[k] = nil
+			}
+		}
+
+		columns = sortedKeys(set)
+	}
+
+	if len(columns) != 0 && !opts.noHeader {
+		err = cw.Write(columns)
+		if err != nil {
+			return err
+		}
+	}
+
+	var record []string
+	for i, row := range rows {
+		record = record[:0]
+
+		if m, ok := toGenericMap(row); ok {
+			for _, col := range columns {
+				var s string
+				s, err = formatCSVValue(m[col])
+				if err != nil {
+					return fmt.Errorf("row %d column %q: %w", i, col, err)
+				}
+
+				record = append(record, s)
+			}
+		} else if values, ok := toGenericSlice(row); ok {
+			for j, v := range values {
+				var s string
+				s, err = formatCSVValue(v)
+				if err != nil {
+					return fmt.Errorf("row %d column %d: %w", i, j, err)
+				}
+
+				record = append(record, s)
+			}
+		} else {
+			return fmt.Errorf("row %d: invalid non list or map value %T", i, row)
+		}
+
+		err = cw.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatCSVValue(v any) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return t, nil
+	case []byte:
+		return string(t), nil
+	}
+
+	_, isMap := toGenericMap(v)
+	_, isSlice := toGenericSlice(v)
+	if isMap || isSlice {
+		data, err := json.Marshal(v)
+		return string(data), err
+	}
+
+	return fmt.Sprint(v), nil
+}
+
+// decodeCSV decodes csv data as a list of maps keyed by the header row, or a list
+// of lists when opts.noHeader is set
+func decodeCSV(data []byte, opts *csvOptions) (_ any, err error) {
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.Comma, err = opts.comma()
+	if err != nil {
+		return
+	}
+	cr.FieldsPerRecord = -1
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return
+	}
+
+	ret := make([]any, 0, len(records))
+	if opts.noHeader {
+		for _, record := range records {
+			row := make([]any, len(record))
+			for i, v := range record {
+				row[i] = v
+			}
+
+			ret = append(ret, row)
+		}
+
+		return ret, nil
+	}
+
+	if len(records) == 0 {
+		return ret, nil
+	}
+
+	header := records[0]
+	for _, record := range records[1:] {
+		row := make(map[string]any, len(header))
+		for i, col := range header {
+			if i < len(record) {
+				row[col] = record[i]
+			} else {
+				row[col] = ""
+			}
+		}
+
+		ret = append(ret, row)
+	}
+
+	return ret, nil
+}
+
+// XML
+
+type xmlOptions struct {
+	root     string
+	indent   string
+	noHeader bool
+}
+
+// encodeXML writes obj as xml
+//
+// obj MUST be a map with single key as the root element unless opts.root is set,
+// map keys prefixed with `-` are attributes, `#text` is the char data, lists are
+// written as repeated elements
+func encodeXML(w io.Writer, obj any, opts *xmlOptions) error {
+	root := opts.root
+	if len(root) == 0 {
+		m, ok := toGenericMap(obj)
+		if !ok || len(m) != 1 {
+			return fmt.Errorf("invalid xml object: expecting map with single root element")
+		}
+
+		for k, v := range m {
+			root, obj = k, v
+		}
+	}
+
+	if !opts.noHeader {
+		_, err := io.WriteString(w, xml.Header)
+		if err != nil {
+			return err
+		}
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", opts.indent)
+
+	err := writeXMLElement(enc, root, obj)
+	if err != nil {
+		return err
+	}
+
+	err = enc.Flush()
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func writeXMLElement(enc *xml.Encoder, name string, v any) error {
+	if values, ok := toGenericSlice(v); ok {
+		for _, v := range values {
+			err := writeXMLElement(enc, name, v)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+
+	m, isMap := toGenericMap(v)
+	if !isMap {
+		text, err := formatScalarValue(v)
+		if err != nil {
+			return fmt.Errorf("element %q: %w", name, err)
+		}
+
+		return enc.EncodeElement(text, start)
+	}
+
+	var children []string
+	for _, k := range sortedKeys(m) {
+		switch {
+		case strings.HasPrefix(k, xmlAttrPrefix):
+			value, err := formatScalarValue(m[k])
+			if err != nil {
+				return fmt.Errorf("attribute %q of element %q: %w", k, name, err)
+			}
+
+			start.Attr = append(start.Attr, xml.Attr{
+				Name:  xml.Name{Local: strings.TrimPrefix(k, xmlAttrPrefix)},
+				Value: value,
+			})
+		case k == xmlTextKey:
+		default:
+			children = append(children, k)
+		}
+	}
+
+	err := enc.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+
+	if text, ok := m[xmlTextKey]; ok {
+		s, err := formatScalarValue(text)
+		if err != nil {
+			return fmt.Errorf("text of element %q: %w", name, err)
+		}
+
+		err = enc.EncodeToken(xml.CharData(s))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, k := range children {
+		err = writeXMLElement(enc, k, m[k])
+		if err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// decodeXML decodes xml data as `{root: value}`, see encodeXML for the layout of value
+func decodeXML(data []byte) (any, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.RawToken()
+		if err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("no xml element found")
+			}
+
+			return nil, err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		value, err := readXMLElement(dec, start)
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]any{xmlName(start.Name): value}, nil
+	}
+}
+
+func readXMLElement(dec *xml.Decoder, start xml.StartElement) (any, error) {
+	var (
+		m    = make(map[string]any)
+		text strings.Builder
+	)
+
+	for _, attr := range start.Attr {
+		m[xmlAttrPrefix+xmlName(attr.Name)] = attr.Value
+	}
+
+	for {
+		tok, err := dec.RawToken()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			value, err := readXMLElement(dec, t)
+			if err != nil {
+				return nil, err
+			}
+
+			name := xmlName(t.Name)
+			switch prev := m[name].(type) {
+			case nil:
+				m[name] = value
+			case []any:
+				m[name] = append(prev, value)
+			default:
+				m[name] = []any{prev, value}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			s := strings.TrimSpace(text.String())
+			if len(m) == 0 {
+				return s, nil
+			}
+
+			if len(s) != 0 {
+				m[xmlTextKey] = s
+			}
+
+			return m, nil
+		}
+	}
+}
+
+func xmlName(n xml.Name) string {
+	if len(n.Space) == 0 {
+		return n.Local
+	}
+
+	return n.Space + ":" + n.Local
+}
+
+// HCL (v1)
+
+// EncodeHCL writes map obj as hcl to w, nested maps are written as blocks,
+// lists are written as `[...]` with maps in lists as objects `{...}`
+//
+// NOTE: hcl v1 has no null value, nil values are rejected
+func EncodeHCL(w io.Writer, obj any, indent string) error {
+	m, ok := toGenericMap(obj)
+	if !ok {
+		return fmt.Errorf("invalid non map object %T for hcl", obj)
+	}
+
+	var buf bytes.Buffer
+	err := writeHCLBody(&buf, m, indent, "")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func writeHCLBody(buf *bytes.Buffer, m map[string]any, indent, prefix string) error {
+	for _, k := range sortedKeys(m) {
+		key := formatHCLKey(k)
+
+		if child, ok := toGenericMap(m[k]); ok {
+			if len(child) == 0 {
+				buf.WriteString(prefix + key + " {}\n")
+				continue
+			}
+
+			buf.WriteString(prefix + key + " {\n")
+			err := writeHCLBody(buf, child, indent, prefix+indent)
+			if err != nil {
+				return fmt.Errorf("block %q: %w", k, err)
+			}
+			buf.WriteString(prefix + "}\n")
+			continue
+		}
+
+		buf.WriteString(prefix + key + " = ")
+		err := writeHCLValue(buf, m[k], indent, prefix)
+		if err != nil {
+			return fmt.Errorf("key %q: %w", k, err)
+		}
+		buf.WriteString("\n")
+	}
+
+	return nil
+}
+
+// writeHCLValue writes v as value expression, prefix is the indent of the line
+// v starts at
+func writeHCLValue(buf *bytes.Buffer, v any, indent, prefix string) error {
+	if m, ok := toGenericMap(v); ok {
+		if len(m) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+
+		buf.WriteString("{\n")
+		err := writeHCLBody(buf, m, indent, prefix+indent)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(prefix + "}")
+		return nil
+	}
+
+	if list, ok := toGenericSlice(v); ok {
+		return writeHCLList(buf, list, indent, prefix)
+	}
+
+	s, err := formatHCLScalar(v)
+	if err != nil {
+		return err
+	}
+
+	buf.WriteString(s)
+	return nil
+}
+
+// writeHCLList writes list of scalars in single line, other lists one element
+// per line
+func writeHCLList(buf *bytes.Buffer, list []any, indent, prefix string) error {
+	multiline := false
+	for _, v := range list {
+		_, isMap := toGenericMap(v)
+		_, isSlice := toGenericSlice(v)
+		if isMap || isSlice {
+			multiline = true
+			break
+		}
+	}
+
+	if !multiline {
+		buf.WriteString("[")
+		for i, v := range list {
+			if i != 0 {
+				buf.WriteString(", ")
+			}
+
+			s, err := formatHCLScalar(v)
+			if err != nil {
+				return fmt.Errorf("list item %d: %w", i, err)
+			}
+
+			buf.WriteString(s)
+		}
+		buf.WriteString("]")
+		return nil
+	}
+
+	buf.WriteString("[\n")
+	for i, v := range list {
+		buf.WriteString(prefix + indent)
+		err := writeHCLValue(buf, v, indent, prefix+indent)
+		if err != nil {
+			return fmt.Errorf("list item %d: %w", i, err)
+		}
+		buf.WriteString(",\n")
+	}
+	buf.WriteString(prefix + "]")
+	return nil
+}
+
+func formatHCLKey(k string) string {
+	for i, c := range k {
+		switch {
+		case c == '_', unicode.IsLetter(c):
+		case i != 0 && (c == '-' || c == '.' || unicode.IsDigit(c)):
+		default:
+			return strconv.Quote(k)
+		}
+	}
+
+	if len(k) == 0 {
+		return `""`
+	}
+
+	return k
+}
+
+func formatHCLScalar(v any) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "", fmt.Errorf("unsupported null value")
+	case string:
+		return strconv.Quote(t), nil
+	case []byte:
+		return strconv.Quote(string(t)), nil
+	case bool, json.Number,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(t), nil
+	case float32:
+		return formatHCLFloat(float64(t), 32)
+	case float64:
+		return formatHCLFloat(t, 64)
+	}
+
+	s, err := formatScalarValue(v)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Quote(s), nil
+}
+
+func formatHCLFloat(f float64, bitSize int) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("unsupported float value %v", f)
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, bitSize), nil
+}
+
+func decodeHCL(data []byte) (any, error) {
+	var out map[string]any
+	err := hcl.Unmarshal(data, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// helpers
+
+func toGenericMap(v any) (map[string]any, bool) {
+	switch t := v.(type) {
+	case map[string]any:
+		return t, true
+	case map[string]string:
+		ret := make(map[string]any, len(t))
+		for k, v := range t {
+			ret[k] = v
+		}
+
+		return ret, true
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+
+	ret := make(map[string]any, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		ret[iter.Key().String()] = iter.Value().Interface()
+	}
+
+	return ret, true
+}
+
+func toGenericSlice(v any) ([]any, bool) {
+	switch t := v.(type) {
+	case []any:
+		return t, true
+	case []byte, string:
+		return nil, false
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+
+	ret := make([]any, rv.Len())
+	for i := range ret {
+		ret[i] = rv.Index(i).Interface()
+	}
+
+	return ret, true
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -2,13 +2,17 @@ package templateutils
 
 import (
 	"bytes"
+	"context"
 	"encoding/base32"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/xml"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
 )
 
 func TestEncNS_Hex(t *testing.T) {
@@ -293,3 +297,221 @@ func TestEncNS_BaseX(t *testing.T) {
 		})
 	}
 }
+
+func TestEncNS_Formats(t *testing.T) {
+	var ns encNS
+
+	dukkha := createDukkhaNS(dt.NewTestContext(context.TODO(), t.TempDir()))
+
+	for _, test := range []struct {
+		name     string
+		encode   func(args ...any) (string, error)
+		decode   func(args ...any) (any, error)
+		flags    []any
+		obj      any
+		expected string
+	}{
+		{
+			name:   "TOML",
+			encode: ns.TOML,
+			decode: func(args ...any) (any, error) { return dukkha.FromToml(args[0]) },
+			obj: map[string]any{
+				"package": map[string]any{
+					"name":    "foo",
+					"version": "0.1.0",
+				},
+				"dependencies": map[string]any{
+					"serde": map[string]any{
+						"version":  "1",
+						"features": []any{"derive"},
+					},
+				},
+			},
+			expected: `[dependencies]
+[dependencies.serde]
+features = ['derive']
+version = '1'
+
+[package]
+name = 'foo'
+version = '0.1.0'
+`,
+		},
+		{
+			name:   "INI",
+			encode: ns.INI,
+			decode: func(args ...any) (any, error) { return dukkha.FromIni(args[0]) },
+			flags:  []any{"--compact"},
+			obj: map[string]any{
+				"Unit": map[string]any{
+					"Description": "foo",
+				},
+				"Service": map[string]any{
+					"ExecStart":    "/usr/bin/foo",
+					"ExecStartPre": []any{"/bin/true", "/bin/echo"},
+				},
+			},
+			expected: `[Service]
+ExecStart=/usr/bin/foo
+ExecStartPre=/bin/true
+ExecStartPre=/bin/echo
+
+[Unit]
+Description=foo
+`,
+		},
+		{
+			name:   "CSV",
+			encode: ns.CSV,
+			decode: dukkha.FromCsv,
+			obj: []any{
+				map[string]any{"arch": "amd64", "os": "linux"},
+				map[string]any{"arch": "arm64", "os": "darwin, ios"},
+			},
+			expected: "arch,os\namd64,linux\narm64,\"darwin, ios\"\n",
+		},
+		{
+			name:   "XML",
+			encode: ns.XML,
+			decode: func(args ...any) (any, error) { return dukkha.FromXml(args[0]) },
+			flags:  []any{"--no-header", "-P"},
+			obj: map[string]any{
+				"project": map[string]any{
+					"-xmlns":     "http://maven.apache.org/POM/4.0.0",
+					"artifactId": "foo",
+					"modules": map[string]any{
+						"module": []any{"a", "b"},
+					},
+				},
+			},
+			expected: `<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <artifactId>foo</artifactId>
+  <modules>
+    <module>a</module>
+    <module>b</module>
+  </modules>
+</project>
+`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ret, err := test.encode(append(test.flags, test.obj)...)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, ret)
+
+			buf := &bytes.Buffer{}
+			ret, err = test.encode(append(test.flags, buf, test.obj)...)
+			assert.NoError(t, err)
+			assert.Equal(t, "", ret)
+			assert.Equal(t, test.expected, buf.String())
+
+			decoded, err := test.decode(strings.NewReader(test.expected))
+			assert.NoError(t, err)
+			assert.EqualValues(t, test.obj, decoded)
+		})
+	}
+
+	t.Run("CSV Lists", func(t *testing.T) {
+		ret, err := ns.CSV("-d", ";", []any{[]any{"a", 1}, []string{"b", "c"}})
+		assert.NoError(t, err)
+		assert.Equal(t, "a;1\nb;c\n", ret)
+
+		decoded, err := dukkha.FromCsv("--no-header", "-d", ";", ret)
+		assert.NoError(t, err)
+		assert.Equal(t, []any{[]any{"a", "1"}, []any{"b", "c"}}, decoded)
+	})
+
+	t.Run("XML Root", func(t *testing.T) {
+		ret, err := ns.XML("--root", "a", map[string]any{"-id": 1, "#text": "x & y"})
+		assert.NoError(t, err)
+		assert.Equal(t, xml.Header+`<a id="1">x &amp; y</a>`+"\n", ret)
+
+		decoded, err := dukkha.FromXml(ret)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": map[string]any{"-id": "1", "#text": "x & y"}}, decoded)
+	})
+
+	t.Run("TOML Dates", func(t *testing.T) {
+		decoded, err := dukkha.FromToml("a = 2022-01-02\nb = 1979-05-27T07:32:00Z\n")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": "2022-01-02", "b": "1979-05-27T07:32:00Z"}, decoded)
+	})
+
+	t.Run("HCL", func(t *testing.T) {
+		decoded, err := dukkha.FromHcl(`foo = "bar"` + "\n" + `baz = 1`)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"foo": "bar", "baz": 1}, decoded)
+
+		ret, err := ns.HCL(map[string]any{
+			"name":     "a \"b\"\n",
+			"count":    2,
+			"ratio":    1.5,
+			"enabled":  true,
+			"tags":     []string{"x", "y"},
+			"some key": "v",
+			"empty":    map[string]any{},
+			"service": map[string]any{
+				"port": 80,
+				"env":  []any{map[string]any{"name": "A"}, []any{1, 2}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, `count = 2
+empty {}
+enabled = true
+name = "a \"b\"\n"
+ratio = 1.5
+service {
+  env = [
+    {
+      name = "A"
+    },
+    [1, 2],
+  ]
+  port = 80
+}
+"some key" = "v"
+tags = ["x", "y"]
+`, ret)
+
+		decoded, err = dukkha.FromHcl(ret)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"name":     "a \"b\"\n",
+			"count":    2,
+			"ratio":    1.5,
+			"enabled":  true,
+			"tags":     []any{"x", "y"},
+			"some key": "v",
+			"empty":    []any{map[string]any(nil)},
+			"service": []any{map[string]any{
+				"port": 80,
+				"env":  []any{map[string]any{"name": "A"}, []any{1, 2}},
+			}},
+		}, decoded)
+
+		ret, err = ns.HCL("--indent", "\t", "-c", "1", map[string]any{"a": map[string]any{"b": "c"}})
+		assert.NoError(t, err)
+		assert.Equal(t, "a {\n\tb = \"c\"\n}\n", ret)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ns.INI([]any{"a"})
+		assert.Error(t, err)
+
+		_, err = ns.CSV(map[string]any{"a": "b"})
+		assert.Error(t, err)
+
+		_, err = ns.XML(map[string]any{"a": "b", "c": "d"})
+		assert.Error(t, err)
+
+		_, err = ns.HCL([]any{"a"})
+		assert.Error(t, err)
+
+		_, err = ns.HCL(map[string]any{"a": map[string]any{"b": nil}})
+		assert.Error(t, err)
+
+		_, err = dukkha.FromCsv()
+		assert.Error(t, err)
+	})
+}
@@ -150,6 +150,24 @@ func FuncNameToFuncID(name string) FuncID {
 		return FuncID_cred_Htpasswd
 	case FuncName_cred_Totp:
 		return FuncID_cred_Totp
+	case FuncName_crypto:
+		return FuncID_crypto
+	case FuncName_crypto_Decrypt:
+		return FuncID_crypto_Decrypt
+	case FuncName_crypto_Encrypt:
+		return FuncID_crypto_Encrypt
+	case FuncName_crypto_GenerateKey:
+		return FuncID_crypto_GenerateKey
+	case FuncName_crypto_PEMDecode:
+		return FuncID_crypto_PEMDecode
+	case FuncName_crypto_PEMEncode:
+		return FuncID_crypto_PEMEncode
+	case FuncName_crypto_PublicKey:
+		return FuncID_crypto_PublicKey
+	case FuncName_crypto_Sign:
+		return FuncID_crypto_Sign
+	case FuncName_crypto_Verify:
+		return FuncID_crypto_Verify
 	case FuncName_default:
 		return FuncID_default
 	case FuncName_dict:
@@ -178,10 +196,20 @@ func FuncNameToFuncID(name string) FuncID {
 		return FuncID_enc_Base32
 	case FuncName_enc_Base64:
 		return FuncID_enc_Base64
+	case FuncName_enc_CSV:
+		return FuncID_enc_CSV
+	case FuncName_enc_HCL:
+		return FuncID_enc_HCL
 	case FuncName_enc_Hex:
 		return FuncID_enc_Hex
+	case FuncName_enc_INI:
+		return FuncID_enc_INI
 	case FuncName_enc_JSON:
 		return FuncID_enc_JSON
+	case FuncName_enc_TOML:
+		return FuncID_enc_TOML
+	case FuncName_enc_XML:
+		return FuncID_enc_XML
 	case FuncName_enc_YAML:
 		return FuncID_enc_YAML
 	case FuncName_eq:
@@ -372,6 +400,26 @@ func FuncNameToFuncID(name string) FuncID {
 		return FuncID_removeSuffix
 	case FuncName_replaceAll:
 		return FuncID_replaceAll
+	case FuncName_semver:
+		return FuncID_semver
+	case FuncName_semver_BumpMajor:
+		return FuncID_semver_BumpMajor
+	case FuncName_semver_BumpMinor:
+		return FuncID_semver_BumpMinor
+	case FuncName_semver_BumpPatch:
+		return FuncID_semver_BumpPatch
+	case FuncName_semver_BumpPrerelease:
+		return FuncID_semver_BumpPrerelease
+	case FuncName_semver_Check:
+		return FuncID_semver_Check
+	case FuncName_semver_Compare:
+		return FuncID_semver_Compare
+	case FuncName_semver_Parse:
+		return FuncID_semver_Parse
+	case FuncName_semver_Sort:
+		return FuncID_semver_Sort
+	case FuncName_semver_Valid:
+		return FuncID_semver_Valid
 	case FuncName_seq:
 		return FuncID_seq
 	case FuncName_sha1:
@@ -662,8 +710,18 @@ func FuncNameToFuncID(name string) FuncID {
 		return FuncID_dukkha_CacheDir
 	case FuncName_dukkha_CrossPlatform:
 		return FuncID_dukkha_CrossPlatform
+	case FuncName_dukkha_FromCsv:
+		return FuncID_dukkha_FromCsv
+	case FuncName_dukkha_FromHcl:
+		return FuncID_dukkha_FromHcl
+	case FuncName_dukkha_FromIni:
+		return FuncID_dukkha_FromIni
 	case FuncName_dukkha_FromJson:
 		return FuncID_dukkha_FromJson
+	case FuncName_dukkha_FromToml:
+		return FuncID_dukkha_FromToml
+	case FuncName_dukkha_FromXml:
+		return FuncID_dukkha_FromXml
 	case FuncName_dukkha_FromYaml:
 		return FuncID_dukkha_FromYaml
 	case FuncName_dukkha_JQ:
@@ -784,6 +842,8 @@ func FuncNameToFuncID(name string) FuncID {
 		return FuncID_matrix
 	case FuncName_mkdir:
 		return FuncID_mkdir
+	case FuncName_nextVersion:
+		return FuncID_nextVersion
 	case FuncName_os:
 		return FuncID_os
 	case FuncName_os_Stderr:
@@ -975,6 +1035,24 @@ func (id FuncID) String() string {
 		return FuncName_cred_Htpasswd
 	case FuncID_cred_Totp:
 		return FuncName_cred_Totp
+	case FuncID_crypto:
+		return FuncName_crypto
+	case FuncID_crypto_Decrypt:
+		return FuncName_crypto_Decrypt
+	case FuncID_crypto_Encrypt:
+		return FuncName_crypto_Encrypt
+	case FuncID_crypto_GenerateKey:
+		return FuncName_crypto_GenerateKey
+	case FuncID_crypto_PEMDecode:
+		return FuncName_crypto_PEMDecode
+	case FuncID_crypto_PEMEncode:
+		return FuncName_crypto_PEMEncode
+	case FuncID_crypto_PublicKey:
+		return FuncName_crypto_PublicKey
+	case FuncID_crypto_Sign:
+		return FuncName_crypto_Sign
+	case FuncID_crypto_Verify:
+		return FuncName_crypto_Verify
 	case FuncID_default:
 		return FuncName_default
 	case FuncID_dict:
@@ -1003,10 +1081,20 @@ func (id FuncID) String() string {
 		return FuncName_enc_Base32
 	case FuncID_enc_Base64:
 		return FuncName_enc_Base64
+	case FuncID_enc_CSV:
+		return FuncName_enc_CSV
+	case FuncID_enc_HCL:
+		return FuncName_enc_HCL
 	case FuncID_enc_Hex:
 		return FuncName_enc_Hex
+	case FuncID_enc_INI:
+		return FuncName_enc_INI
 	case FuncID_enc_JSON:
 		return FuncName_enc_JSON
+	case FuncID_enc_TOML:
+		return FuncName_enc_TOML
+	case FuncID_enc_XML:
+		return FuncName_enc_XML
 	case FuncID_enc_YAML:
 		return FuncName_enc_YAML
 	case FuncID_eq:
@@ -1197,6 +1285,26 @@ func (id FuncID) String() string {
 		return FuncName_removeSuffix
 	case FuncID_replaceAll:
 		return FuncName_replaceAll
+	case FuncID_semver:
+		return FuncName_semver
+	case FuncID_semver_BumpMajor:
+		return FuncName_semver_BumpMajor
+	case FuncID_semver_BumpMinor:
+		return FuncName_semver_BumpMinor
+	case FuncID_semver_BumpPatch:
+		return FuncName_semver_BumpPatch
+	case FuncID_semver_BumpPrerelease:
+		return FuncName_semver_BumpPrerelease
+	case FuncID_semver_Check:
+		return FuncName_semver_Check
+	case FuncID_semver_Compare:
+		return FuncName_semver_Compare
+	case FuncID_semver_Parse:
+		return FuncName_semver_Parse
+	case FuncID_semver_Sort:
+		return FuncName_semver_Sort
+	case FuncID_semver_Valid:
+		return FuncName_semver_Valid
 	case FuncID_seq:
 		return FuncName_seq
 	case FuncID_sha1:
@@ -1487,8 +1595,18 @@ func (id FuncID) String() string {
 		return FuncName_dukkha_CacheDir
 	case FuncID_dukkha_CrossPlatform:
 		return FuncName_dukkha_CrossPlatform
+	case FuncID_dukkha_FromCsv:
+		return FuncName_dukkha_FromCsv
+	case FuncID_dukkha_FromHcl:
+		return FuncName_dukkha_FromHcl
+	case FuncID_dukkha_FromIni:
+		return FuncName_dukkha_FromIni
 	case FuncID_dukkha_FromJson:
 		return FuncName_dukkha_FromJson
+	case FuncID_dukkha_FromToml:
+		return FuncName_dukkha_FromToml
+	case FuncID_dukkha_FromXml:
+		return FuncName_dukkha_FromXml
 	case FuncID_dukkha_FromYaml:
 		return FuncName_dukkha_FromYaml
 	case FuncID_dukkha_JQ:
@@ -1609,6 +1727,8 @@ func (id FuncID) String() string {
 		return FuncName_matrix
 	case FuncID_mkdir:
 		return FuncName_mkdir
+	case FuncID_nextVersion:
+		return FuncName_nextVersion
 	case FuncID_os:
 		return FuncName_os
 	case FuncID_os_Stderr:
@@ -1730,6 +1850,15 @@ const (
 	FuncID_cred                        // func() credentialNS
 	FuncID_cred_Htpasswd               // func(String, String) (string, error)
 	FuncID_cred_Totp                   // func(...any) (string, error)
+	FuncID_crypto                      // func() cryptoNS
+	FuncID_crypto_Decrypt              // func(...any) (string, error)
+	FuncID_crypto_Encrypt              // func(...any) (string, error)
+	FuncID_crypto_GenerateKey          // func(...any) (string, error)
+	FuncID_crypto_PEMDecode            // func(Bytes) ([]map[string]any, error)
+	FuncID_crypto_PEMEncode            // func(String, Bytes) (string, error)
+	FuncID_crypto_PublicKey            // func(Bytes) (string, error)
+	FuncID_crypto_Sign                 // func(...any) (string, error)
+	FuncID_crypto_Verify               // func(...any) (bool, error)
 	FuncID_default                     // func(any, any) any
 	FuncID_dict                        // func(...any) (map[string]any, error)
 	FuncID_div                         // func(...Number) (Number, error)
@@ -1744,8 +1873,13 @@ const (
 	FuncID_enc                         // func() encNS
 	FuncID_enc_Base32                  // func(...any) (string, error)
 	FuncID_enc_Base64                  // func(...any) (string, error)
+	FuncID_enc_CSV                     // func(...any) (string, error)
+	FuncID_enc_HCL                     // func(...any) (string, error)
 	FuncID_enc_Hex                     // func(...any) (string, error)
+	FuncID_enc_INI                     // func(...any) (string, error)
 	FuncID_enc_JSON                    // func(...any) (string, error)
+	FuncID_enc_TOML                    // func(...any) (string, error)
+	FuncID_enc_XML                     // func(...any) (string, error)
 	FuncID_enc_YAML                    // func(...any) (string, error)
 	FuncID_eq                          // func(any, ...any) (bool, error)
 	FuncID_ge                          // func(any, any) (bool, error)
@@ -1841,6 +1975,16 @@ const (
 	FuncID_removePrefix                // func(...String) (string, error)
 	FuncID_removeSuffix                // func(...String) (string, error)
 	FuncID_replaceAll                  // func(String, String, String) (string, error)
+	FuncID_semver                      // func() semverNS
+	FuncID_semver_BumpMajor            // func(String) (string, error)
+	FuncID_semver_BumpMinor            // func(String) (string, error)
+	FuncID_semver_BumpPatch            // func(String) (string, error)
+	FuncID_semver_BumpPrerelease       // func(...String) (string, error)
+	FuncID_semver_Check                // func(String, String) (bool, error)
+	FuncID_semver_Compare              // func(String, String) (int, error)
+	FuncID_semver_Parse                // func(String) (*Semver, error)
+	FuncID_semver_Sort                 // func(Slice) ([]string, error)
+	FuncID_semver_Valid                // func(String) bool
 	FuncID_seq                         // func(...Number) ([]int64, error)
 	FuncID_sha1                        // func(...any) (string, error)
 	FuncID_sha256                      // func(...any) (string, error)
@@ -1988,7 +2132,12 @@ const (
 	FuncID_dukkha               // func() dukkhaNS
 	FuncID_dukkha_CacheDir      // func() string
 	FuncID_dukkha_CrossPlatform // func(...String) bool
+	FuncID_dukkha_FromCsv       // func(...any) (any, error)
+	FuncID_dukkha_FromHcl       // func(Bytes) (any, error)
+	FuncID_dukkha_FromIni       // func(Bytes) (any, error)
 	FuncID_dukkha_FromJson      // func(Bytes) (any, error)
+	FuncID_dukkha_FromToml      // func(Bytes) (any, error)
+	FuncID_dukkha_FromXml       // func(Bytes) (any, error)
 	FuncID_dukkha_FromYaml      // func(Bytes) (any, error)
 	FuncID_dukkha_JQ            // func(...any) (string, error)
 	FuncID_dukkha_JQObj         // func(...any) (any, error)
@@ -2047,8 +2196,9 @@ const (
 	FuncID_host                 // func() map[string]tlang.LazyValueType[string]
 	FuncID_jq                   // func(...any) (string, error)
 	FuncID_jqObj                // func(...any) (any, error)
-	FuncID_matrix               // func() map[string]string
+	FuncID_matrix               // func() map[string]any
 	FuncID_mkdir                // func(...String) (None, error)
+	FuncID_nextVersion          // func(...String) (string, error)
 	FuncID_os                   // func() osNS
 	FuncID_os_Stderr            // func() io.Writer
 	FuncID_os_Stdin             // func() io.Reader
@@ -2154,6 +2304,15 @@ const (
 	FuncName_cred                        = "cred"
 	FuncName_cred_Htpasswd               = "cred.Htpasswd"
 	FuncName_cred_Totp                   = "cred.Totp"
+	FuncName_crypto                      = "crypto"
+	FuncName_crypto_Decrypt              = "crypto.Decrypt"
+	FuncName_crypto_Encrypt              = "crypto.Encrypt"
+	FuncName_crypto_GenerateKey          = "crypto.GenerateKey"
+	FuncName_crypto_PEMDecode            = "crypto.PEMDecode"
+	FuncName_crypto_PEMEncode            = "crypto.PEMEncode"
+	FuncName_crypto_PublicKey            = "crypto.PublicKey"
+	FuncName_crypto_Sign                 = "crypto.Sign"
+	FuncName_crypto_Verify               = "crypto.Verify"
 	FuncName_default                     = "default"
 	FuncName_dict                        = "dict"
 	FuncName_div                         = "div"
@@ -2168,8 +2327,13 @@ const (
 	FuncName_enc                         = "enc"
 	FuncName_enc_Base32                  = "enc.Base32"
 	FuncName_enc_Base64                  = "enc.Base64"
+	FuncName_enc_CSV                     = "enc.CSV"
+	FuncName_enc_HCL                     = "enc.HCL"
 	FuncName_enc_Hex                     = "enc.Hex"
+	FuncName_enc_INI                     = "enc.INI"
 	FuncName_enc_JSON                    = "enc.JSON"
+	FuncName_enc_TOML                    = "enc.TOML"
+	FuncName_enc_XML                     = "enc.XML"
 	FuncName_enc_YAML                    = "enc.YAML"
 	FuncName_eq                          = "eq"
 	FuncName_ge                          = "ge"
@@ -2265,6 +2429,16 @@ const (
 	FuncName_removePrefix                = "removePrefix"
 	FuncName_removeSuffix                = "removeSuffix"
 	FuncName_replaceAll                  = "replaceAll"
+	FuncName_semver                      = "semver"
+	FuncName_semver_BumpMajor            = "semver.BumpMajor"
+	FuncName_semver_BumpMinor            = "semver.BumpMinor"
+	FuncName_semver_BumpPatch            = "semver.BumpPatch"
+	FuncName_semver_BumpPrerelease       = "semver.BumpPrerelease"
+	FuncName_semver_Check                = "semver.Check"
+	FuncName_semver_Compare              = "semver.Compare"
+	FuncName_semver_Parse                = "semver.Parse"
+	FuncName_semver_Sort                 = "semver.Sort"
+	FuncName_semver_Valid                = "semver.Valid"
 	FuncName_seq                         = "seq"
 	FuncName_sha1                        = "sha1"
 	FuncName_sha256                      = "sha256"
@@ -2412,7 +2586,12 @@ const (
 	FuncName_dukkha               = "dukkha"
 	FuncName_dukkha_CacheDir      = "dukkha.CacheDir"
 	FuncName_dukkha_CrossPlatform = "dukkha.CrossPlatform"
+	FuncName_dukkha_FromCsv       = "dukkha.FromCsv"
+	FuncName_dukkha_FromHcl       = "dukkha.FromHcl"
+	FuncName_dukkha_FromIni       = "dukkha.FromIni"
 	FuncName_dukkha_FromJson      = "dukkha.FromJson"
+	FuncName_dukkha_FromToml      = "dukkha.FromToml"
+	FuncName_dukkha_FromXml       = "dukkha.FromXml"
 	FuncName_dukkha_FromYaml      = "dukkha.FromYaml"
 	FuncName_dukkha_JQ            = "dukkha.JQ"
 	FuncName_dukkha_JQObj         = "dukkha.JQObj"
@@ -2473,6 +2652,7 @@ const (
 	FuncName_jqObj                = "jqObj"
 	FuncName_matrix               = "matrix"
 	FuncName_mkdir                = "mkdir"
+	FuncName_nextVersion          = "nextVersion"
 	FuncName_os                   = "os"
 	FuncName_os_Stderr            = "os.Stderr"
 	FuncName_os_Stdin             = "os.Stdin"
@@ -2569,6 +2749,15 @@ var staticFuncs = [FuncID_LAST_Static_FUNC + 1]any{
 	FuncID_cred:                        get_ns_cred,
 	FuncID_cred_Htpasswd:               ns_cred.Htpasswd,
 	FuncID_cred_Totp:                   ns_cred.Totp,
+	FuncID_crypto:                      get_ns_crypto,
+	FuncID_crypto_Decrypt:              ns_crypto.Decrypt,
+	FuncID_crypto_Encrypt:              ns_crypto.Encrypt,
+	FuncID_crypto_GenerateKey:          ns_crypto.GenerateKey,
+	FuncID_crypto_PEMDecode:            ns_crypto.PEMDecode,
+	FuncID_crypto_PEMEncode:            ns_crypto.PEMEncode,
+	FuncID_crypto_PublicKey:            ns_crypto.PublicKey,
+	FuncID_crypto_Sign:                 ns_crypto.Sign,
+	FuncID_crypto_Verify:               ns_crypto.Verify,
 	FuncID_default:                     ns_type.Default,
 	FuncID_dict:                        ns_coll.MapStringAny,
 	FuncID_div:                         ns_math.Div,
@@ -2583,8 +2772,13 @@ var staticFuncs = [FuncID_LAST_Static_FUNC + 1]any{
 	FuncID_enc:                         get_ns_enc,
 	FuncID_enc_Base32:                  ns_enc.Base32,
 	FuncID_enc_Base64:                  ns_enc.Base64,
+	FuncID_enc_CSV:                     ns_enc.CSV,
+	FuncID_enc_HCL:                     ns_enc.HCL,
 	FuncID_enc_Hex:                     ns_enc.Hex,
+	FuncID_enc_INI:                     ns_enc.INI,
 	FuncID_enc_JSON:                    ns_enc.JSON,
+	FuncID_enc_TOML:                    ns_enc.TOML,
+	FuncID_enc_XML:                     ns_enc.XML,
 	FuncID_enc_YAML:                    ns_enc.YAML,
 	FuncID_eq:                          ns_golang.Eq,
 	FuncID_ge:                          ns_golang.Ge,
@@ -2680,6 +2874,16 @@ var staticFuncs = [FuncID_LAST_Static_FUNC + 1]any{
 	FuncID_removePrefix:                ns_strings.RemovePrefix,
 	FuncID_removeSuffix:                ns_strings.RemoveSuffix,
 	FuncID_replaceAll:                  ns_strings.ReplaceAll,
+	FuncID_semver:                      get_ns_semver,
+	FuncID_semver_BumpMajor:            ns_semver.BumpMajor,
+	FuncID_semver_BumpMinor:            ns_semver.BumpMinor,
+	FuncID_semver_BumpPatch:            ns_semver.BumpPatch,
+	FuncID_semver_BumpPrerelease:       ns_semver.BumpPrerelease,
+	FuncID_semver_Check:                ns_semver.Check,
+	FuncID_semver_Compare:              ns_semver.Compare,
+	FuncID_semver_Parse:                ns_semver.Parse,
+	FuncID_semver_Sort:                 ns_semver.Sort,
+	FuncID_semver_Valid:                ns_semver.Valid,
 	FuncID_seq:                         ns_math.Seq,
 	FuncID_sha1:                        ns_hash.SHA1,
 	FuncID_sha256:                      ns_hash.SHA256,
@@ -2844,7 +3048,12 @@ func createContextualFuncs(rc dukkha.RenderingContext) *ContextualFuncs {
 		FuncID_dukkha - FuncID_LAST_Static_FUNC - 1:               reflect.ValueOf(get_ns_dukkha),
 		FuncID_dukkha_CacheDir - FuncID_LAST_Static_FUNC - 1:      reflect.ValueOf(ns_dukkha.CacheDir),
 		FuncID_dukkha_CrossPlatform - FuncID_LAST_Static_FUNC - 1: reflect.ValueOf(ns_dukkha.CrossPlatform),
+		FuncID_dukkha_FromCsv - FuncID_LAST_Static_FUNC - 1:       reflect.ValueOf(ns_dukkha.FromCsv),
+		FuncID_dukkha_FromHcl - FuncID_LAST_Static_FUNC - 1:       reflect.ValueOf(ns_dukkha.FromHcl),
+		FuncID_dukkha_FromIni - FuncID_LAST_Static_FUNC - 1:       reflect.ValueOf(ns_dukkha.FromIni),
 		FuncID_dukkha_FromJson - FuncID_LAST_Static_FUNC - 1:      reflect.ValueOf(ns_dukkha.FromJson),
+		FuncID_dukkha_FromToml - FuncID_LAST_Static_FUNC - 1:      reflect.ValueOf(ns_dukkha.FromToml),
+		FuncID_dukkha_FromXml - FuncID_LAST_Static_FUNC - 1:       reflect.ValueOf(ns_dukkha.FromXml),
 		FuncID_dukkha_FromYaml - FuncID_LAST_Static_FUNC - 1:      reflect.ValueOf(ns_dukkha.FromYaml),
 		FuncID_dukkha_JQ - FuncID_LAST_Static_FUNC - 1:            reflect.ValueOf(ns_dukkha.JQ),
 		FuncID_dukkha_JQObj - FuncID_LAST_Static_FUNC - 1:         reflect.ValueOf(ns_dukkha.JQObj),
@@ -2905,6 +3114,7 @@ func createContextualFuncs(rc dukkha.RenderingContext) *ContextualFuncs {
 		FuncID_jqObj - FuncID_LAST_Static_FUNC - 1:                reflect.ValueOf(ns_dukkha.JQObj),
 		FuncID_matrix - FuncID_LAST_Static_FUNC - 1:               reflect.ValueOf(ns_misc.Matrix),
 		FuncID_mkdir - FuncID_LAST_Static_FUNC - 1:                reflect.ValueOf(ns_fs.Mkdir),
+		FuncID_nextVersion - FuncID_LAST_Static_FUNC - 1:          reflect.ValueOf(ns_misc.NextVersion),
 		FuncID_os - FuncID_LAST_Static_FUNC - 1:                   reflect.ValueOf(get_ns_os),
 		FuncID_os_Stderr - FuncID_LAST_Static_FUNC - 1:            reflect.ValueOf(ns_os.Stderr),
 		FuncID_os_Stdin - FuncID_LAST_Static_FUNC - 1:             reflect.ValueOf(ns_os.Stdin),
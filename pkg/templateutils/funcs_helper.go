@@ -73,12 +73,14 @@ var (
 	ns_type     typeNS
 	ns_coll     collNS
 	ns_cred     credentialNS
+	ns_crypto   cryptoNS
 	ns_dns      dnsNS
 	ns_enc      encNS
 	ns_hash     hashNS
 	ns_path     pathNS
 	ns_re       regexpNS
 	ns_sockaddr sockaddrNS
+	ns_semver   semverNS
 	ns_time     timeNS
 	ns_uuid     uuidNS
 	ns_golang   golangNS
@@ -90,12 +92,14 @@ func get_ns_strings() stringsNS   { return ns_strings }
 func get_ns_type() typeNS         { return ns_type }
 func get_ns_coll() collNS         { return ns_coll }
 func get_ns_cred() credentialNS   { return ns_cred }
+func get_ns_crypto() cryptoNS     { return ns_crypto }
 func get_ns_dns() dnsNS           { return ns_dns }
 func get_ns_enc() encNS           { return ns_enc }
 func get_ns_hash() hashNS         { return ns_hash }
 func get_ns_path() pathNS         { return ns_path }
 func get_ns_re() regexpNS         { return ns_re }
 func get_ns_sockaddr() sockaddrNS { return ns_sockaddr }
+func get_ns_semver() semverNS     { return ns_semver }
 func get_ns_time() timeNS         { return ns_time }
 func get_ns_uuid() uuidNS         { return ns_uuid }
 
@@ -331,12 +331,20 @@ var staticFuncMaps = map[string]any{
 
 	"totp": FuncRef{"cred", credentialNS{}, "Totp"},
 
+	// Encryption and signing
+
+	"crypto": func() cryptoNS { return cryptoNS{} },
+
 	// Time
 
 	"time": func() timeNS { return timeNS{} },
 
 	"now": FuncRef{"time", timeNS{}, "Now"},
 
+	// Semantic versions
+
+	"semver": func() semverNS { return semverNS{} },
+
 	// Encoding
 
 	"enc": func() encNS { return encNS{} },
@@ -438,6 +446,9 @@ var contextualFuncs = map[string]any{
 	"values": FuncRef{"misc", miscNS{}, "Values"},
 	"matrix": FuncRef{"misc", miscNS{}, "Matrix"},
 	"VALUE":  FuncRef{"misc", miscNS{}, "VALUE"},
+
+	// semantic version from git tags
+	"nextVersion": FuncRef{"misc", miscNS{}, "NextVersion"},
 }
 
 // placeholder functions to be overridden before template.Execute
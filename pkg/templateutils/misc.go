@@ -20,9 +20,9 @@ func (ns miscNS) Host() map[string]tlang.LazyValueType[string] { return ns.rc.Ho
 func (ns miscNS) Env() map[string]tlang.LazyValueType[string]  { return ns.rc.Env() }
 func (ns miscNS) Values() map[string]any                       { return ns.rc.Values() }
 
-func (ns miscNS) Matrix() map[string]string {
+func (ns miscNS) Matrix() map[string]any {
 	mf := ns.rc.MatrixFilter()
-	return mf.AsEntry()
+	return mf.AsEntry().Values()
 }
 
 // for transform renderer
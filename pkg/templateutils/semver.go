@@ -0,0 +1,756 @@
+package templateutils
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"arhat.dev/pkg/clihelper"
+	"arhat.dev/pkg/exechelper"
+	"github.com/spf13/pflag"
+)
+
+// semverNS for semantic versions (https://semver.org)
+//
+// versions are accepted with or without the `v` prefix, missing minor and patch
+// numbers are treated as 0 (e.g. `v1` is `v1.0.0`), functions returning version
+// strings keep the prefix of the input version
+type semverNS struct{}
+
+// Semver is a parsed semantic version
+type Semver struct {
+	// Prefix is `v` when the version was prefixed with `v`, or empty
+	Prefix string
+
+	Major uint64
+	Minor uint64
+	Patch uint64
+
+	// Prerelease is the dot separated pre-release identifiers without leading `-`
+	Prerelease string
+
+	// Metadata is the dot separated build metadata without leading `+`
+	Metadata string
+}
+
+func (v *Semver) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(v.Prefix)
+	sb.WriteString(strconv.FormatUint(v.Major, 10))
+	sb.WriteByte('.')
+	sb.WriteString(strconv.FormatUint(v.Minor, 10))
+	sb.WriteByte('.')
+	sb.WriteString(strconv.FormatUint(v.Patch, 10))
+
+	if len(v.Prerelease) != 0 {
+		sb.WriteByte('-')
+		sb.WriteString(v.Prerelease)
+	}
+
+	if len(v.Metadata) != 0 {
+		sb.WriteByte('+')
+		sb.WriteString(v.Metadata)
+	}
+
+	return sb.String()
+}
+
+// Parse version string
+func (semverNS) Parse(v String) (*Semver, error) { return toSemver(v) }
+
+// Valid returns true when v is a valid version
+func (semverNS) Valid(v String) bool {
+	_, err := toSemver(v)
+	return err == nil
+}
+
+// Compare returns 0 if a == b, -1 if a < b, or 1 if a > b, build metadata is ignored
+func (semverNS) Compare(a, b String) (_ int, err error) {
+	va, err := toSemver(a)
+	if err != nil {
+		return
+	}
+
+	vb, err := toSemver(b)
+	if err != nil {
+		return
+	}
+
+	return compareSemver(va, vb), nil
+}
+
+// Check returns true when v satisfies the constraint
+//
+// Check(constraint String, v String)
+//
+// constraint is a list of comparisons separated by spaces or commas, all
+// comparisons MUST be satisfied, and multiple constraints can be joined by `||`
+// e.g. `>= 1.2, < 2 || ^3.1`
+//
+// supported comparisons are:
+// - `=`, `!=`, `>`, `>=`, `<`, `<=`: compare with the version
+// - `~1.2.3`: `>= 1.2.3, < 1.3.0`
+// - `^1.2.3`: `>= 1.2.3, < 2.0.0` (for `^0.2.3`: `>= 0.2.3, < 0.3.0`)
+// - `1.2.x`, `1.2.*` or `1.2`: `>= 1.2.0, < 1.3.0`
+// - `1.2 - 1.4.5`: `>= 1.2, <= 1.4.5`
+//
+// versions with pre-release identifiers only satisfy constraints containing
+// pre-release versions with the same major, minor and patch numbers
+func (semverNS) Check(constraint String, v String) (_ bool, err error) {
+	c, err := toString(constraint)
+	if err != nil {
+		return
+	}
+
+	ver, err := toSemver(v)
+	if err != nil {
+		return
+	}
+
+	return checkSemverConstraint(c, ver)
+}
+
+// BumpMajor increases major number, resets minor and patch number
+//
+// for pre-release versions of x.0.0, only pre-release identifiers are removed
+func (semverNS) BumpMajor(v String) (string, error) { return handleSemverBump(v, "major", "") }
+
+// BumpMinor increases minor number, resets patch number
+//
+// for pre-release versions of x.y.0, only pre-release identifiers are removed
+func (semverNS) BumpMinor(v String) (string, error) { return handleSemverBump(v, "minor", "") }
+
+// BumpPatch increases patch number
+//
+// for pre-release versions, only pre-release identifiers are removed
+func (semverNS) BumpPatch(v String) (string, error) { return handleSemverBump(v, "patch", "") }
+
+// BumpPrerelease increases the pre-release number
+//
+// BumpPrerelease(v String): `1.2.3` to `1.2.4-0`, `1.2.4-rc.1` to `1.2.4-rc.2`
+//
+// BumpPrerelease(id String, v String): `1.2.3` to `1.2.4-<id>.0`,
+// `1.2.4-<id>.1` to `1.2.4-<id>.2`, `1.2.4-<other>.1` to `1.2.4-<id>.0`
+func (semverNS) BumpPrerelease(args ...String) (_ string, err error) {
+	var id string
+	switch n := len(args); n {
+	case 0:
+		err = errAtLeastOneArgGotZero
+		return
+	case 1:
+	case 2:
+		id, err = toString(args[0])
+		if err != nil {
+			return
+		}
+	default:
+		err = fmt.Errorf("at most 2 args expected, got %d", n)
+		return
+	}
+
+	return handleSemverBump(args[len(args)-1], "prerelease", id)
+}
+
+// Sort versions in ascending order, versions are returned as is
+func (semverNS) Sort(versions Slice) (ret []string, err error) {
+	var strs []string
+	switch t := versions.(type) {
+	case []string:
+		strs = t
+	case []any:
+		strs, err = toStrings(t)
+	default:
+		val := reflect.Indirect(reflect.ValueOf(versions))
+		if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+			err = fmt.Errorf("unsupported non slice type %T", versions)
+			return
+		}
+
+		strs = make([]string, val.Len())
+		for i := range strs {
+			strs[i], err = toString(val.Index(i).Interface())
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	if err != nil {
+		return
+	}
+
+	vers := make([]*Semver, len(strs))
+	for i, s := range strs {
+		vers[i], err = parseSemver(s)
+		if err != nil {
+			return
+		}
+	}
+
+	idx := make([]int, len(strs))
+	for i := range idx {
+		idx[i] = i
+	}
+
+	sort.SliceStable(idx, func(i, j int) bool {
+		return compareSemver(vers[idx[i]], vers[idx[j]]) < 0
+	})
+
+	ret = make([]string, len(idx))
+	for i, j := range idx {
+		ret[i] = strs[j]
+	}
+
+	return
+}
+
+// NextVersion generates next version from the latest git tag reachable from
+// HEAD and the count of commits since that tag (like `git describe`)
+//
+// NextVersion(...<options>)
+//
+// where options are:
+//   - `--bump` kind: one of [major, minor, patch, prerelease], defaults to `patch`
+//   - `--pre` id: pre-release identifier of the next version, the count of commits
+//     since the tag is appended (e.g. `--pre dev` generates `v1.2.4-dev.5`)
+//   - `--match` glob: only consider tags matching the glob pattern
+//   - `--with-commit`: add abbreviated commit sha as build metadata (e.g. `+g1a2b3c4`)
+//
+// the latest tag is returned as is when HEAD is tagged, and `v0.0.0` is used
+// as the latest tag when there is no tag
+func (ns miscNS) NextVersion(args ...String) (_ string, err error) {
+	flags, err := toStrings(args)
+	if err != nil {
+		return
+	}
+
+	var (
+		fs   pflag.FlagSet
+		opts nextVersionOptions
+	)
+
+	clihelper.InitFlagSet(&fs, "NextVersion")
+	fs.StringVar(&opts.bump, "bump", "patch", "")
+	fs.StringVar(&opts.pre, "pre", "", "")
+	fs.StringVar(&opts.match, "match", "", "")
+	fs.BoolVar(&opts.withCommit, "with-commit", false, "")
+
+	err = fs.Parse(flags)
+	if err != nil {
+		return
+	}
+
+	cmd := []string{"git", "describe", "--tags", "--long", "--abbrev=7"}
+	if len(opts.match) != 0 {
+		cmd = append(cmd, "--match", opts.match)
+	}
+
+	desc, err := ns.runGit(cmd...)
+	if err != nil {
+		// no tag reachable
+		var count, sha string
+		count, err = ns.runGit("git", "rev-list", "--count", "HEAD")
+		if err != nil {
+			return
+		}
+
+		sha, err = ns.runGit("git", "rev-parse", "--short=7", "HEAD")
+		if err != nil {
+			return
+		}
+
+		desc = "v0.0.0-" + count + "-g" + sha
+	}
+
+	return nextVersion(desc, &opts)
+}
+
+func (ns miscNS) runGit(cmd ...string) (string, error) {
+	var buf strings.Builder
+	p, err := exechelper.Do(exechelper.Spec{
+		Context: ns.rc,
+		Dir:     ns.rc.WorkDir(),
+		Command: cmd,
+		Stdout:  &buf,
+		Stderr:  io.Discard,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	_, err = p.Wait()
+	if err != nil {
+		return "", fmt.Errorf("run %q: %w", strings.Join(cmd, " "), err)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+type nextVersionOptions struct {
+	bump       string
+	pre        string
+	match      string
+	withCommit bool
+}
+
+// nextVersion generates next version from output of `git describe --long`
+// (`<tag>-<count>-g<sha>`)
+func nextVersion(desc string, opts *nextVersionOptions) (string, error) {
+	idx := strings.LastIndexByte(desc, '-')
+	if idx == -1 {
+		return "", fmt.Errorf("invalid git describe output %q", desc)
+	}
+
+	sha := desc[idx+1:]
+	desc = desc[:idx]
+
+	idx = strings.LastIndexByte(desc, '-')
+	if idx == -1 {
+		return "", fmt.Errorf("invalid git describe output %q", desc)
+	}
+
+	count, err := strconv.ParseUint(desc[idx+1:], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid commit count in git describe output: %w", err)
+	}
+
+	tag := desc[:idx]
+	ver, err := parseSemver(tag)
+	if err != nil {
+		return "", fmt.Errorf("invalid tag %q: %w", tag, err)
+	}
+
+	if count == 0 {
+		return tag, nil
+	}
+
+	ver.Metadata = ""
+	err = bumpSemver(ver, opts.bump, "")
+	if err != nil {
+		return "", err
+	}
+
+	if len(opts.pre) != 0 {
+		ver.Prerelease = opts.pre + "." + strconv.FormatUint(count, 10)
+	}
+
+	if opts.withCommit {
+		ver.Metadata = sha
+	}
+
+	return ver.String(), nil
+}
+
+func handleSemverBump(v String, kind, id string) (string, error) {
+	ver, err := toSemver(v)
+	if err != nil {
+		return "", err
+	}
+
+	ver.Metadata = ""
+	err = bumpSemver(ver, kind, id)
+	if err != nil {
+		return "", err
+	}
+
+	return ver.String(), nil
+}
+
+// bumpSemver increases version number of kind, one of [major, minor, patch, prerelease]
+func bumpSemver(ver *Semver, kind, id string) error {
+	switch kind {
+	case "major":
+		if len(ver.Prerelease) == 0 || ver.Minor != 0 || ver.Patch != 0 {
+			ver.Major++
+			ver.Minor, ver.Patch = 0, 0
+		}
+	case "minor":
+		if len(ver.Prerelease) == 0 || ver.Patch != 0 {
+			ver.Minor++
+			ver.Patch = 0
+		}
+	case "patch":
+		if len(ver.Prerelease) == 0 {
+			ver.Patch++
+		}
+	case "prerelease":
+		bumpSemverPrerelease(ver, id)
+		return nil
+	default:
+		return fmt.Errorf("unknown bump kind %q", kind)
+	}
+
+	ver.Prerelease = ""
+	return nil
+}
+
+// bumpSemverPrerelease increases pre-release number of ver, id is the expected
+// pre-release identifier (optional)
+func bumpSemverPrerelease(ver *Semver, id string) {
+	if len(ver.Prerelease) == 0 {
+		ver.Patch++
+		if len(id) != 0 {
+			ver.Prerelease = id + ".0"
+		} else {
+			ver.Prerelease = "0"
+		}
+
+		return
+	}
+
+	if len(id) != 0 && ver.Prerelease != id && !strings.HasPrefix(ver.Prerelease, id+".") {
+		ver.Prerelease = id + ".0"
+		return
+	}
+
+	parts := strings.Split(ver.Prerelease, ".")
+	last := parts[len(parts)-1]
+	if n, err := strconv.ParseUint(last, 10, 64); err == nil {
+		parts[len(parts)-1] = strconv.FormatUint(n+1, 10)
+	} else {
+		parts = append(parts, "0")
+	}
+
+	ver.Prerelease = strings.Join(parts, ".")
+}
+
+func toSemver(v String) (*Semver, error) {
+	if ver, ok := v.(*Semver); ok {
+		ret := *ver
+		return &ret, nil
+	}
+
+	s, err := toString(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSemver(s)
+}
+
+func parseSemver(s string) (_ *Semver, err error) {
+	var ret Semver
+
+	str := strings.TrimSpace(s)
+	if strings.HasPrefix(str, "v") {
+		ret.Prefix = "v"
+		str = str[1:]
+	}
+
+	var hasMetadata, hasPrerelease bool
+	str, ret.Metadata, hasMetadata = strings.Cut(str, "+")
+	str, ret.Prerelease, hasPrerelease = strings.Cut(str, "-")
+	if (hasMetadata && len(ret.Metadata) == 0) || (hasPrerelease && len(ret.Prerelease) == 0) {
+		return nil, fmt.Errorf("invalid version %q: empty identifiers", s)
+	}
+
+	nums := strings.Split(str, ".")
+	if len(nums) > 3 {
+		return nil, fmt.Errorf("invalid version %q: too many numbers", s)
+	}
+
+	dst := [3]*uint64{&ret.Major, &ret.Minor, &ret.Patch}
+	for i, n := range nums {
+		if len(n) == 0 || (len(n) > 1 && n[0] == '0') {
+			return nil, fmt.Errorf("invalid version %q: invalid number %q", s, n)
+		}
+
+		*dst[i], err = strconv.ParseUint(n, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q: %w", s, err)
+		}
+	}
+
+	for _, ids := range []string{ret.Prerelease, ret.Metadata} {
+		if len(ids) == 0 {
+			continue
+		}
+
+		for _, id := range strings.Split(ids, ".") {
+			if len(id) == 0 || strings.IndexFunc(id, func(r rune) bool {
+				return !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-')
+			}) != -1 {
+				return nil, fmt.Errorf("invalid version %q: invalid identifier %q", s, id)
+			}
+		}
+	}
+
+	return &ret, nil
+}
+
+// compareSemver returns 0 if a == b, -1 if a < b, or 1 if a > b
+func compareSemver(a, b *Semver) int {
+	for _, p := range [][2]uint64{
+		{a.Major, b.Major},
+		{a.Minor, b.Minor},
+		{a.Patch, b.Patch},
+	} {
+		switch {
+		case p[0] < p[1]:
+			return -1
+		case p[0] > p[1]:
+			return 1
+		}
+	}
+
+	switch {
+	case a.Prerelease == b.Prerelease:
+		return 0
+	case len(a.Prerelease) == 0:
+		// pre-release version has lower precedence
+		return 1
+	case len(b.Prerelease) == 0:
+		return -1
+	}
+
+	pa, pb := strings.Split(a.Prerelease, "."), strings.Split(b.Prerelease, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if ret := comparePrereleaseID(pa[i], pb[i]); ret != 0 {
+			return ret
+		}
+	}
+
+	switch {
+	case len(pa) < len(pb):
+		return -1
+	case len(pa) > len(pb):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func comparePrereleaseID(a, b string) int {
+	na, errA := strconv.ParseUint(a, 10, 64)
+	nb, errB := strconv.ParseUint(b, 10, 64)
+
+	switch {
+	case errA == nil && errB == nil:
+		switch {
+		case na < nb:
+			return -1
+		case na > nb:
+			return 1
+		default:
+			return 0
+		}
+	case errA == nil:
+		// numeric identifiers have lower precedence
+		return -1
+	case errB == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func checkSemverConstraint(constraint string, v *Semver) (bool, error) {
+	for _, group := range strings.Split(constraint, "||") {
+		cmps, err := parseSemverComparisons(group)
+		if err != nil {
+			return false, fmt.Errorf("invalid constraint %q: %w", constraint, err)
+		}
+
+		// pre-release versions only satisfy constraints containing pre-release
+		// versions with the same major, minor and patch numbers
+		ok := len(v.Prerelease) == 0
+		for _, c := range cmps {
+			if len(c.ver.Prerelease) != 0 &&
+				c.ver.Major == v.Major && c.ver.Minor == v.Minor && c.ver.Patch == v.Patch {
+				ok = true
+				break
+			}
+		}
+
+		for _, c := range cmps {
+			if !ok {
+				break
+			}
+
+			ok = c.check(v)
+		}
+
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+type semverComparison struct {
+	op  string
+	ver *Semver
+}
+
+func (c *semverComparison) check(v *Semver) bool {
+	ret := compareSemver(v, c.ver)
+	switch c.op {
+	case "=":
+		return ret == 0
+	case "!=":
+		return ret != 0
+	case ">":
+		return ret > 0
+	case ">=":
+		return ret >= 0
+	case "<":
+		return ret < 0
+	default: // "<="
+		return ret <= 0
+	}
+}
+
+// parseSemverComparisons parses comparisons joined by AND
+func parseSemverComparisons(group string) (ret []*semverComparison, err error) {
+	fields := strings.FieldsFunc(group, func(r rune) bool { return r == ' ' || r == ',' })
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty constraint")
+	}
+
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+
+		// hyphen range `a - b`
+		if i+2 < len(fields) && fields[i+1] == "-" {
+			var low, high []*semverComparison
+			low, err = expandSemverComparison(">=", f)
+			if err != nil {
+				return
+			}
+
+			high, err = expandSemverComparison("<=", fields[i+2])
+			if err != nil {
+				return
+			}
+
+			ret = append(append(ret, low...), high...)
+			i += 2
+			continue
+		}
+
+		op := strings.TrimRightFunc(f, func(r rune) bool {
+			return r != '=' && r != '!' && r != '>' && r != '<' && r != '~' && r != '^'
+		})
+		ver := f[len(op):]
+		if len(ver) == 0 {
+			// operator separated from version by space
+			if i+1 == len(fields) {
+				return nil, fmt.Errorf("missing version after %q", op)
+			}
+
+			i++
+			ver = fields[i]
+		}
+
+		var cmps []*semverComparison
+		cmps, err = expandSemverComparison(op, ver)
+		if err != nil {
+			return
+		}
+
+		ret = append(ret, cmps...)
+	}
+
+	return
+}
+
+// expandSemverComparison converts comparison with wildcard version, tilde and
+// caret operators to basic comparisons
+func expandSemverComparison(op, ver string) ([]*semverComparison, error) {
+	ver = strings.TrimPrefix(ver, "v")
+
+	// count of numbers set, wildcards are treated as not set
+	core, suffix := ver, ""
+	if idx := strings.IndexAny(ver, "-+"); idx != -1 {
+		core, suffix = ver[:idx], ver[idx:]
+	}
+
+	nums := strings.Split(core, ".")
+	set := len(nums)
+	for i, n := range nums {
+		if n == "x" || n == "X" || n == "*" {
+			set = i
+			break
+		}
+	}
+
+	if set == 0 {
+		switch op {
+		case "", "=", ">=", "<=", "~", "^":
+			// any version
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("invalid wildcard version %q for %q", ver, op)
+		}
+	}
+
+	v, err := parseSemver(strings.Join(nums[:set], ".") + suffix)
+	if err != nil {
+		return nil, err
+	}
+
+	if set < 3 && len(suffix) != 0 {
+		return nil, fmt.Errorf("invalid partial version %q", ver)
+	}
+
+	// upper bound of the version range
+	upper := func(set int) *Semver {
+		switch set {
+		case 1:
+			return &Semver{Major: v.Major + 1}
+		default:
+			return &Semver{Major: v.Major, Minor: v.Minor + 1}
+		}
+	}
+
+	switch op {
+	case "", "=":
+		if set == 3 {
+			return []*semverComparison{{"=", v}}, nil
+		}
+
+		return []*semverComparison{{">=", v}, {"<", upper(set)}}, nil
+	case "!=":
+		if set == 3 {
+			return []*semverComparison{{"!=", v}}, nil
+		}
+
+		return nil, fmt.Errorf("partial version %q is not supported for %q", ver, op)
+	case ">":
+		if set == 3 {
+			return []*semverComparison{{">", v}}, nil
+		}
+
+		return []*semverComparison{{">=", upper(set)}}, nil
+	case ">=", "<":
+		return []*semverComparison{{op, v}}, nil
+	case "<=":
+		if set == 3 {
+			return []*semverComparison{{"<=", v}}, nil
+		}
+
+		return []*semverComparison{{"<", upper(set)}}, nil
+	case "~":
+		if set == 1 {
+			return []*semverComparison{{">=", v}, {"<", upper(1)}}, nil
+		}
+
+		return []*semverComparison{{">=", v}, {"<", upper(2)}}, nil
+	case "^":
+		switch {
+		case v.Major != 0 || set == 1:
+			return []*semverComparison{{">=", v}, {"<", upper(1)}}, nil
+		case v.Minor != 0 || set == 2:
+			return []*semverComparison{{">=", v}, {"<", upper(2)}}, nil
+		default:
+			return []*semverComparison{{">=", v}, {"<", &Semver{Patch: v.Patch + 1}}}, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown operator %q", op)
+	}
+}
@@ -0,0 +1,288 @@
+package templateutils
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"arhat.dev/tlang"
+	"github.com/stretchr/testify/assert"
+
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+)
+
+func TestSemverNS_Parse(t *testing.T) {
+	var ns semverNS
+
+	for _, test := range []struct {
+		in       string
+		expected *Semver
+	}{
+		{"1.2.3", &Semver{Major: 1, Minor: 2, Patch: 3}},
+		{"v1.2.3", &Semver{Prefix: "v", Major: 1, Minor: 2, Patch: 3}},
+		{"v1", &Semver{Prefix: "v", Major: 1}},
+		{"1.2", &Semver{Major: 1, Minor: 2}},
+		{"1.2.3-rc.1+build.5", &Semver{Major: 1, Minor: 2, Patch: 3, Prerelease: "rc.1", Metadata: "build.5"}},
+		{"1.2.3-x-y.0", &Semver{Major: 1, Minor: 2, Patch: 3, Prerelease: "x-y.0"}},
+
+		{"", nil},
+		{"a.b.c", nil},
+		{"01.2.3", nil},
+		{"1.2.3.4", nil},
+		{"1.2.3-", nil},
+		{"1.2.3+", nil},
+		{"1.2.3-rc..1", nil},
+		{"1.2.3-rc_1", nil},
+	} {
+		t.Run(test.in, func(t *testing.T) {
+			ret, err := ns.Parse(test.in)
+			if test.expected == nil {
+				assert.Error(t, err)
+				assert.False(t, ns.Valid(test.in))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, ret)
+			assert.True(t, ns.Valid(test.in))
+		})
+	}
+}
+
+func TestSemverNS_Compare(t *testing.T) {
+	var ns semverNS
+
+	// in ascending order (https://semver.org/#spec-item-11)
+	versions := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"v1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+	}
+
+	for i := range versions {
+		for j := range versions {
+			expected := 0
+			switch {
+			case i < j:
+				expected = -1
+			case i > j:
+				expected = 1
+			}
+
+			ret, err := ns.Compare(versions[i], versions[j])
+			assert.NoError(t, err)
+			assert.Equal(t, expected, ret, "%s <=> %s", versions[i], versions[j])
+		}
+	}
+
+	ret, err := ns.Compare("1.0.0+a", "1.0.0+b")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, ret)
+
+	_, err = ns.Compare("1.0.0", "invalid")
+	assert.Error(t, err)
+
+	t.Run("Sort", func(t *testing.T) {
+		shuffled := []any{
+			versions[5], versions[10], versions[0], versions[7], versions[3], versions[9],
+			versions[1], versions[8], versions[4], versions[6], versions[2],
+		}
+
+		ret, err := ns.Sort(shuffled)
+		assert.NoError(t, err)
+		assert.Equal(t, versions, ret)
+
+		ret, err = ns.Sort([]string{"v2", "v1.10.0", "v1.9.0"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"v1.9.0", "v1.10.0", "v2"}, ret)
+
+		_, err = ns.Sort([]string{"v1", "foo"})
+		assert.Error(t, err)
+	})
+}
+
+func TestSemverNS_Check(t *testing.T) {
+	var ns semverNS
+
+	for _, test := range []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{"1.2.3", "1.2.3", true},
+		{"=1.2.3", "1.2.4", false},
+		{"!=1.2.3", "1.2.4", true},
+		{">1.2.3", "1.2.4", true},
+		{"> 1.2.3", "1.2.3", false},
+		{">=1.2.3", "v1.2.3", true},
+		{"<1.2.3", "1.2.2", true},
+		{"<=1.2.3", "1.2.4", false},
+
+		{">= 1.2, < 2", "1.9.9", true},
+		{">= 1.2, < 2", "2.0.0", false},
+		{">= 1.2 < 2", "1.1.9", false},
+		{"< 1 || >= 2", "1.5.0", false},
+		{"< 1 || >= 2", "2.5.0", true},
+
+		{"1.2.x", "1.2.9", true},
+		{"1.2.*", "1.3.0", false},
+		{"1.2", "1.2.0", true},
+		{"1", "1.9.0", true},
+		{"*", "3.0.0", true},
+		{">1.2", "1.2.9", false},
+		{">1.2", "1.3.0", true},
+		{"<=1.2", "1.2.9", true},
+		{"<=1.2", "1.3.0", false},
+
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"~1", "1.9.0", true},
+
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "2.0.0", false},
+		{"^1.2.3", "1.2.2", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.4", false},
+
+		{"1.2 - 1.4.5", "1.4.5", true},
+		{"1.2 - 1.4.5", "1.4.6", false},
+		{"1.2 - 1.4.5", "1.1.9", false},
+
+		// pre-release
+		{">=1.2.0", "1.3.0-rc.1", false},
+		{">=1.3.0-rc.0", "1.3.0-rc.1", true},
+		{">=1.3.0-rc.0, <1.4", "1.3.0-rc.1", true},
+		{">=1.3.0-rc.2", "1.3.0-rc.1", false},
+		{">=1.3.0-rc.0", "1.3.0", true},
+	} {
+		t.Run(test.constraint+"@"+test.version, func(t *testing.T) {
+			ret, err := ns.Check(test.constraint, test.version)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, ret)
+		})
+	}
+
+	for _, c := range []string{"", ">", "~>1", "!=1.x", "1.2-rc.1"} {
+		_, err := ns.Check(c, "1.0.0")
+		assert.Error(t, err, c)
+	}
+}
+
+func TestSemverNS_Bump(t *testing.T) {
+	var ns semverNS
+
+	for _, test := range []struct {
+		bump     func(v String) (string, error)
+		in       string
+		expected string
+	}{
+		{ns.BumpMajor, "v1.2.3", "v2.0.0"},
+		{ns.BumpMajor, "1.2.3+build", "2.0.0"},
+		{ns.BumpMajor, "2.0.0-rc.1", "2.0.0"},
+		{ns.BumpMajor, "2.1.0-rc.1", "3.0.0"},
+		{ns.BumpMinor, "v1.2.3", "v1.3.0"},
+		{ns.BumpMinor, "1.3.0-rc.1", "1.3.0"},
+		{ns.BumpMinor, "1.3.1-rc.1", "1.4.0"},
+		{ns.BumpPatch, "v1.2.3", "v1.2.4"},
+		{ns.BumpPatch, "1.2.4-rc.1", "1.2.4"},
+		{func(v String) (string, error) { return ns.BumpPrerelease(v) }, "1.2.3", "1.2.4-0"},
+		{func(v String) (string, error) { return ns.BumpPrerelease(v) }, "1.2.4-rc.1", "1.2.4-rc.2"},
+		{func(v String) (string, error) { return ns.BumpPrerelease(v) }, "1.2.4-rc", "1.2.4-rc.0"},
+		{func(v String) (string, error) { return ns.BumpPrerelease("rc", v) }, "v1.2.3", "v1.2.4-rc.0"},
+		{func(v String) (string, error) { return ns.BumpPrerelease("rc", v) }, "1.2.4-rc.1", "1.2.4-rc.2"},
+		{func(v String) (string, error) { return ns.BumpPrerelease("rc", v) }, "1.2.4-beta.1", "1.2.4-rc.0"},
+	} {
+		ret, err := test.bump(test.in)
+		assert.NoError(t, err, test.in)
+		assert.Equal(t, test.expected, ret, test.in)
+	}
+
+	_, err := ns.BumpPrerelease()
+	assert.Error(t, err)
+}
+
+func TestNextVersion(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		opts     nextVersionOptions
+		expected string
+	}{
+		{"v1.2.3-0-gabcdef0", nextVersionOptions{bump: "patch"}, "v1.2.3"},
+		{"v1.2.3-5-gabcdef0", nextVersionOptions{bump: "patch"}, "v1.2.4"},
+		{"v1.2.3-5-gabcdef0", nextVersionOptions{bump: "minor"}, "v1.3.0"},
+		{"v1.2.3-5-gabcdef0", nextVersionOptions{bump: "major"}, "v2.0.0"},
+		{"v1.2.3-rc.1-5-gabcdef0", nextVersionOptions{bump: "prerelease"}, "v1.2.3-rc.2"},
+		{"v1.2.3-5-gabcdef0", nextVersionOptions{bump: "patch", pre: "dev"}, "v1.2.4-dev.5"},
+		{"foo-v1.2.3-5-gabcdef0", nextVersionOptions{bump: "patch", withCommit: true}, ""},
+		{"1.2.3-5-gabcdef0", nextVersionOptions{bump: "patch", withCommit: true}, "1.2.4+gabcdef0"},
+		{"1.2.3-5-gabcdef0", nextVersionOptions{bump: "foo"}, ""},
+	} {
+		ret, err := nextVersion(test.desc, &test.opts)
+		if len(test.expected) == 0 {
+			assert.Error(t, err, test.desc)
+			continue
+		}
+
+		assert.NoError(t, err, test.desc)
+		assert.Equal(t, test.expected, ret, test.desc)
+	}
+
+	t.Run("Git", func(t *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git not found")
+		}
+
+		dir := t.TempDir()
+		git := func(args ...string) {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = dir
+			cmd.Env = append(cmd.Environ(),
+				"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+				"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+			)
+			out, err := cmd.CombinedOutput()
+			if !assert.NoError(t, err, string(out)) {
+				t.FailNow()
+			}
+		}
+
+		rc := dt.NewTestContextWithGlobalEnv(context.TODO(), &dukkha.GlobalEnvSet{
+			constant.GlobalEnv_DUKKHA_WORKDIR:   tlang.ImmediateString(dir),
+			constant.GlobalEnv_DUKKHA_CACHE_DIR: tlang.ImmediateString(t.TempDir()),
+		})
+		ns := createMiscNS(rc)
+
+		git("init", "-q")
+		git("commit", "-q", "--allow-empty", "-m", "first")
+
+		ret, err := ns.NextVersion()
+		assert.NoError(t, err)
+		assert.Equal(t, "v0.0.1", ret)
+
+		git("tag", "v1.2.3")
+		ret, err = ns.NextVersion()
+		assert.NoError(t, err)
+		assert.Equal(t, "v1.2.3", ret)
+
+		git("commit", "-q", "--allow-empty", "-m", "second")
+		git("commit", "-q", "--allow-empty", "-m", "third")
+		ret, err = ns.NextVersion("--bump", "minor", "--pre", "dev")
+		assert.NoError(t, err)
+		assert.Equal(t, "v1.3.0-dev.2", ret)
+
+		ret, err = ns.NextVersion("--match", "x*")
+		assert.NoError(t, err)
+		assert.Equal(t, "v0.0.1", ret)
+	})
+}
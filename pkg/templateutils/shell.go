@@ -191,7 +191,7 @@ func RunScript(
 
 	err = runner.Run(ctx, f)
 	if err != nil {
-		return fmt.Errorf("embedded shell exited with error (%v):\n%s", err, script)
+		return fmt.Errorf("embedded shell exited with error (%w):\n%s", err, script)
 	}
 
 	return nil
@@ -29,7 +29,6 @@ package templateutils
 // 	"time"
 //
 // 	util "github.com/Masterminds/goutils"
-// 	sv2 "github.com/Masterminds/semver/v3"
 // )
 //
 // /*
@@ -161,24 +160,6 @@ package templateutils
 // 	}
 // }
 //
-// func semverCompare(constraint, version string) (bool, error) {
-// 	c, err := sv2.NewConstraint(constraint)
-// 	if err != nil {
-// 		return false, err
-// 	}
-//
-// 	v, err := sv2.NewVersion(version)
-// 	if err != nil {
-// 		return false, err
-// 	}
-//
-// 	return c.Check(v), nil
-// }
-//
-// func semver(version string) (*sv2.Version, error) {
-// 	return sv2.NewVersion(version)
-// }
-//
 // // parses given URL to return dict object
 // func urlParse(v string) map[string]interface{} {
 // 	dict := map[string]interface{}{}
@@ -0,0 +1,242 @@
+package archive
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"arhat.dev/pkg/fshelper"
+	"github.com/bmatcuk/doublestar/v4"
+
+	"arhat.dev/dukkha/pkg/renderer/af"
+)
+
+type extractOptions struct {
+	password string
+
+	include []string
+	exclude []string
+
+	stripComponents int
+}
+
+// getName returns the path relative to dest dir of the in archive path
+//
+// ok is false when the entry should not be extracted
+func (opts *extractOptions) getName(name string) (_ string, ok bool) {
+	name, ok = opts.strip(name)
+	if !ok {
+		return
+	}
+
+	for dir := name; dir != "."; dir = path.Dir(dir) {
+		for _, p := range opts.exclude {
+			if matchGlob(p, dir) {
+				return "", false
+			}
+		}
+	}
+
+	// parent dirs of included files are created when extracting files
+	if len(opts.include) == 0 {
+		return name, true
+	}
+
+	for _, p := range opts.include {
+		if matchGlob(p, name) {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// strip leading path components of the normalized in archive path
+func (opts *extractOptions) strip(name string) (string, bool) {
+	if len(name) == 0 || name == "." {
+		return "", false
+	}
+
+	parts := strings.Split(name, "/")
+	if len(parts) <= opts.stripComponents {
+		return "", false
+	}
+
+	return strings.Join(parts[opts.stripComponents:], "/"), true
+}
+
+func matchGlob(pattern, name string) bool {
+	ok, _ := doublestar.Match(strings.TrimPrefix(pattern, "/"), name)
+	return ok
+}
+
+// extract files in archive to dest dir
+func extract(ofs *fshelper.OSFS, archive, dest string, opts *extractOptions) error {
+	destDir, err := ofs.Abs(dest)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(destDir, 0755)
+	if err != nil {
+		return fmt.Errorf("creating dest dir: %w", err)
+	}
+
+	type dirEntry struct {
+		path string
+		mode fs.FileMode
+	}
+
+	// set mode of dirs after all files extracted in case of read-only dirs
+	var dirs []dirEntry
+
+	err = af.Walk(ofs, archive, opts.password, func(ent *af.Entry, r io.Reader) error {
+		name, ok := opts.getName(ent.Name)
+		if !ok {
+			return nil
+		}
+
+		target, err := secureJoin(destDir, name)
+		if err != nil {
+			return err
+		}
+
+		perm := ent.Mode.Perm()
+		switch {
+		case ent.Mode.IsDir():
+			if perm == 0 {
+				perm = 0755
+			}
+
+			// replace existing symlink or file, never chmod through symlinks
+			info, err := os.Lstat(target)
+			switch {
+			case err == nil && !info.IsDir():
+				err = os.Remove(target)
+				if err != nil {
+					return err
+				}
+			case err != nil && !os.IsNotExist(err):
+				return err
+			}
+
+			err = os.MkdirAll(target, 0755)
+			if err != nil {
+				return err
+			}
+
+			dirs = append(dirs, dirEntry{path: target, mode: perm})
+			return nil
+		case ent.Hardlink:
+			linkName, ok := opts.strip(strings.TrimPrefix(path.Clean("/"+ent.Linkname), "/"))
+			if !ok {
+				return fmt.Errorf("hardlink %q: target %q is stripped", ent.Name, ent.Linkname)
+			}
+
+			src, err := secureJoin(destDir, linkName)
+			if err != nil {
+				return err
+			}
+
+			err = prepareExtractTarget(target)
+			if err != nil {
+				return err
+			}
+
+			return os.Link(src, target)
+		case ent.Mode&fs.ModeSymlink != 0:
+			err = prepareExtractTarget(target)
+			if err != nil {
+				return err
+			}
+
+			return os.Symlink(ent.Linkname, target)
+		case ent.Mode.IsRegular():
+			if perm == 0 {
+				perm = 0644
+			}
+
+			err = prepareExtractTarget(target)
+			if err != nil {
+				return err
+			}
+
+			// O_EXCL to never write through symlinks
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = f.Close() }()
+
+			_, err = io.Copy(f, r)
+			if err != nil {
+				return fmt.Errorf("extracting %q: %w", ent.Name, err)
+			}
+
+			return nil
+		default:
+			// devices, fifos and other special files
+			return nil
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("extracting %q: %w", archive, err)
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		err = os.Chmod(dirs[i].path, dirs[i].mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// secureJoin joins the normalized in archive path to dest dir, and ensures
+// no parent dir in dest dir is a symlink, so files will never be extracted
+// outside dest dir
+func secureJoin(destDir, name string) (string, error) {
+	target := filepath.Join(destDir, filepath.FromSlash(name))
+
+	rel, err := filepath.Rel(destDir, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q is outside dest dir", name)
+	}
+
+	for dir := filepath.Dir(target); len(dir) > len(destDir); dir = filepath.Dir(dir) {
+		info, err := os.Lstat(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return "", err
+		}
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("path %q is inside symlink %q", name, dir)
+		}
+	}
+
+	return target, nil
+}
+
+// prepareExtractTarget ensures parent dirs exist and target does not
+func prepareExtractTarget(target string) error {
+	err := os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(target)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
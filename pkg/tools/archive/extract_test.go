@@ -0,0 +1,167 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"arhat.dev/pkg/fshelper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	ofs := fshelper.NewOSFS(false, nil)
+
+	for _, archive := range []string{
+		"../../renderer/af/testdata/002.tar.gz",
+		"../../renderer/af/testdata/101.zip",
+	} {
+		t.Run(filepath.Base(archive), func(t *testing.T) {
+			dest := t.TempDir()
+			err := extract(ofs, archive, dest, &extractOptions{})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expected, err := os.ReadFile("../../renderer/af/testdata/_archive_content/top-level-data.yaml")
+			assert.NoError(t, err)
+
+			for _, name := range []string{
+				"top-level-data.yaml",
+				"level-1/level-2/top-level-data-symlink",
+			} {
+				data, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+				assert.NoError(t, err, name)
+				assert.Equal(t, string(expected), string(data), name)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dest, "level-1", "level-1-data.yaml"))
+			assert.NoError(t, err)
+			assert.NotEmpty(t, data)
+		})
+	}
+}
+
+func TestExtract_filters(t *testing.T) {
+	t.Parallel()
+
+	ofs := fshelper.NewOSFS(false, nil)
+	archive := writeTestTar(t, []tar.Header{
+		{Name: "pkg/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "pkg/bin/foo", Typeflag: tar.TypeReg, Mode: 0755},
+		{Name: "pkg/bin/foo.tmp", Typeflag: tar.TypeReg, Mode: 0644},
+		{Name: "pkg/docs/README", Typeflag: tar.TypeReg, Mode: 0644},
+		{Name: "pkg/etc/foo.conf", Typeflag: tar.TypeReg, Mode: 0600},
+	})
+
+	dest := t.TempDir()
+	err := extract(ofs, archive, dest, &extractOptions{
+		include:         []string{"bin/*", "etc/**"},
+		exclude:         []string{"**/*.tmp"},
+		stripComponents: 1,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	info, err := os.Stat(filepath.Join(dest, "bin", "foo"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	}
+
+	info, err = os.Stat(filepath.Join(dest, "etc", "foo.conf"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	for _, name := range []string{"bin/foo.tmp", "docs", "pkg"} {
+		_, err = os.Stat(filepath.Join(dest, filepath.FromSlash(name)))
+		assert.ErrorIs(t, err, os.ErrNotExist, name)
+	}
+}
+
+func TestExtract_pathTraversal(t *testing.T) {
+	t.Parallel()
+
+	ofs := fshelper.NewOSFS(false, nil)
+
+	t.Run("Dot Dot", func(t *testing.T) {
+		archive := writeTestTar(t, []tar.Header{
+			{Name: "../../escaped", Typeflag: tar.TypeReg, Mode: 0644},
+		})
+
+		root := t.TempDir()
+		dest := filepath.Join(root, "a", "b")
+		assert.NoError(t, extract(ofs, archive, dest, &extractOptions{}))
+
+		_, err := os.Stat(filepath.Join(dest, "escaped"))
+		assert.NoError(t, err)
+
+		_, err = os.Stat(filepath.Join(root, "escaped"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("Symlink", func(t *testing.T) {
+		outside := t.TempDir()
+		archive := writeTestTar(t, []tar.Header{
+			{Name: "link", Typeflag: tar.TypeSymlink, Linkname: outside},
+			{Name: "link/escaped", Typeflag: tar.TypeReg, Mode: 0644},
+		})
+
+		assert.Error(t, extract(ofs, archive, t.TempDir(), &extractOptions{}))
+
+		_, err := os.Stat(filepath.Join(outside, "escaped"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("Symlink Replaced By Dir", func(t *testing.T) {
+		outside := t.TempDir()
+		assert.NoError(t, os.Chmod(outside, 0700))
+
+		archive := writeTestTar(t, []tar.Header{
+			{Name: "link", Typeflag: tar.TypeSymlink, Linkname: outside},
+			{Name: "link/", Typeflag: tar.TypeDir, Mode: 0777},
+		})
+
+		dest := t.TempDir()
+		assert.NoError(t, extract(ofs, archive, dest, &extractOptions{}))
+
+		info, err := os.Lstat(filepath.Join(dest, "link"))
+		if assert.NoError(t, err) {
+			assert.True(t, info.IsDir())
+		}
+
+		info, err = os.Stat(outside)
+		if assert.NoError(t, err) {
+			assert.Equal(t, fs.FileMode(0700), info.Mode().Perm())
+		}
+	})
+}
+
+func writeTestTar(t *testing.T, headers []tar.Header) string {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for i := range headers {
+		hdr := &headers[i]
+
+		var data []byte
+		if hdr.Typeflag == tar.TypeReg {
+			data = []byte(hdr.Name)
+			hdr.Size = int64(len(data))
+		}
+
+		assert.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write(data)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+
+	file := filepath.Join(t.TempDir(), "test.tar")
+	assert.NoError(t, os.WriteFile(file, buf.Bytes(), 0644))
+	return file
+}
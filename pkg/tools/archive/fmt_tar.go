@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"io"
 	"strings"
+	"time"
 
 	"arhat.dev/pkg/fshelper"
 )
@@ -15,6 +16,7 @@ func createTar(
 	enableCompression bool,
 	compressionMethod string,
 	compressionLevel string,
+	mtime *time.Time,
 ) (err error) {
 	var (
 		tw  *tar.Writer
@@ -47,6 +49,15 @@ func createTar(
 		hdr.Format = tar.FormatPAX
 		hdr.Name = f.to
 
+		if mtime != nil {
+			// normalize file metadata not from content for reproducible output
+			hdr.ModTime = *mtime
+			hdr.AccessTime = time.Time{}
+			hdr.ChangeTime = time.Time{}
+			hdr.Uid, hdr.Gid = 0, 0
+			hdr.Uname, hdr.Gname = "", ""
+		}
+
 		mode := f.info.Mode()
 		if mode.IsDir() && !strings.HasSuffix(hdr.Name, "/") {
 			hdr.Name += "/"
@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/fs"
 	"strings"
+	"time"
 
 	"github.com/dsnet/compress/bzip2"
 	"github.com/klauspost/compress/zstd"
@@ -26,6 +27,7 @@ func createZip(
 	enableCompression bool,
 	compressionMethod string,
 	compressionLevel string,
+	mtime *time.Time,
 ) (err error) {
 	zw := zip.NewWriter(w)
 	defer func() { _ = zw.Close() }()
@@ -100,6 +102,10 @@ func createZip(
 		hdr.Name = f.to
 		hdr.Method = method
 
+		if mtime != nil {
+			hdr.Modified = *mtime
+		}
+
 		mode = f.info.Mode()
 		if mode.IsDir() && !strings.HasSuffix(hdr.Name, "/") {
 			hdr.Name += "/"
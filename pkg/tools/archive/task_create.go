@@ -1,9 +1,13 @@
 package archive
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"arhat.dev/rs"
 
@@ -14,6 +18,15 @@ import (
 
 const TaskKindCreate = "create"
 
+const (
+	// envSourceDateEpoch is the env for reproducible builds
+	//
+	// ref: https://reproducible-builds.org/specs/source-date-epoch/
+	envSourceDateEpoch = "SOURCE_DATE_EPOCH"
+
+	replace_ARCHIVE_GIT_COMMIT_TIME = "<ARCHIVE_GIT_COMMIT_TIME>"
+)
+
 func init() {
 	dukkha.RegisterTask(ToolKind, TaskKindCreate, tools.NewTask[TaskCreate, *TaskCreate])
 }
@@ -38,6 +51,9 @@ type ArchiveCreate struct {
 	// Files to be archived
 	Files []*fileFromToSpec `yaml:"files"`
 
+	// Reproducible output configuration
+	Reproducible reproducibleSpec `yaml:"reproducible"`
+
 	parent tools.BaseTaskType
 }
 
@@ -88,12 +104,57 @@ func (c *ArchiveCreate) GetExecSpecs(
 			}
 		}
 
+		var (
+			reproducible = c.Reproducible.Enabled
+			modTime      = c.Reproducible.ModTime
+		)
+
+		if reproducible && len(modTime) == 0 {
+			if v, ok := rc.Env()[envSourceDateEpoch]; ok {
+				modTime = v.GetLazyValue()
+			}
+		}
+
+		if reproducible && len(modTime) == 0 {
+			// use time of last git commit
+			steps = append(steps, dukkha.TaskExecSpec{
+				StdoutAsReplace:          replace_ARCHIVE_GIT_COMMIT_TIME,
+				FixStdoutValueForReplace: bytes.TrimSpace,
+
+				IgnoreError: true,
+				Command:     []string{"git", "log", "-1", "--format=%ct"},
+			})
+		}
+
 		steps = append(steps, dukkha.TaskExecSpec{
 			AlterExecFunc: func(
 				replace dukkha.ReplaceEntries,
 				stdin io.Reader,
 				stdout, stderr io.Writer,
 			) (dukkha.RunTaskOrRunCmd, error) {
+				var mtime *time.Time
+				if reproducible {
+					value := modTime
+					if len(value) == 0 {
+						v := replace[replace_ARCHIVE_GIT_COMMIT_TIME]
+						if v.Err != nil || len(v.Data) == 0 {
+							return nil, fmt.Errorf(
+								"no mod_time for reproducible archive: %s not set and failed to get git commit time",
+								envSourceDateEpoch,
+							)
+						}
+
+						value = string(v.Data)
+					}
+
+					t, err := parseModTime(value)
+					if err != nil {
+						return nil, err
+					}
+
+					mtime = &t
+				}
+
 				archiveFile, err := rc.FS().OpenFile(output, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
 				if err != nil {
 					return nil, err
@@ -104,10 +165,10 @@ func (c *ArchiveCreate) GetExecSpecs(
 
 				switch format {
 				case constant.ArchiveFormat_Tar:
-					err = createTar(rc.FS(), out, files, enableCompression, compressionMethod, compressionLevel)
+					err = createTar(rc.FS(), out, files, enableCompression, compressionMethod, compressionLevel, mtime)
 					return nil, err
 				case constant.ArchiveFormat_Zip:
-					err = createZip(rc.FS(), out, files, enableCompression, compressionMethod, compressionLevel)
+					err = createZip(rc.FS(), out, files, enableCompression, compressionMethod, compressionLevel, mtime)
 					return nil, err
 				default:
 					return nil, fmt.Errorf("unsupported format: %q", format)
@@ -121,6 +182,41 @@ func (c *ArchiveCreate) GetExecSpecs(
 	return steps, err
 }
 
+// parseModTime parses unix timestamp in seconds or RFC3339 time
+func parseModTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	sec, err := strconv.ParseInt(value, 10, 64)
+	if err == nil {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid mod_time %q: %w", value, err)
+	}
+
+	return t.UTC().Truncate(time.Second), nil
+}
+
+type reproducibleSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	// Enabled to create byte-for-byte reproducible archive
+	//
+	// files are sorted by in archive path, and all files use the same mod_time,
+	// uid, gid, user name and group name are cleared
+	//
+	// Defaults to `false`
+	Enabled bool `yaml:"enabled"`
+
+	// ModTime of all files, unix timestamp in seconds or RFC3339 time
+	//
+	// Defaults to value of env SOURCE_DATE_EPOCH, or time of last git commit
+	// when SOURCE_DATE_EPOCH is not set
+	ModTime string `yaml:"mod_time"`
+}
+
 type compressionSpec struct {
 	rs.BaseField `yaml:"-"`
 
@@ -1,11 +1,17 @@
 package archive
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"arhat.dev/pkg/fshelper"
 	"arhat.dev/rs"
 	"github.com/stretchr/testify/assert"
 
+	"arhat.dev/dukkha/pkg/constant"
 	"arhat.dev/dukkha/pkg/tools"
 	"arhat.dev/dukkha/pkg/tools/tests"
 )
@@ -28,3 +34,59 @@ func TestTaskCreate(t *testing.T) {
 		},
 	)
 }
+
+func TestCreateReproducible(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ofs := fshelper.NewOSFS(false, func(fshelper.Op, string) (string, error) { return dir, nil })
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "src", "b"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "src", "a"), []byte("a"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "src", "b", "c"), []byte("c"), 0755))
+
+	mtime, err := parseModTime("1600000000")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	create := func(format string, fileTime time.Time) []byte {
+		for _, name := range []string{"src/a", "src/b/c", "src/b", "src"} {
+			assert.NoError(t, os.Chtimes(filepath.Join(dir, name), fileTime, fileTime))
+		}
+
+		files, err := collectFiles(ofs, []*fileFromToSpec{{From: "src/**", To: "/"}})
+		if !assert.NoError(t, err) {
+			return nil
+		}
+
+		var buf bytes.Buffer
+		switch format {
+		case constant.ArchiveFormat_Tar:
+			assert.NoError(t, createTar(ofs, &buf, files, true, constant.CompressionMethod_Gzip, "", &mtime))
+		case constant.ArchiveFormat_Zip:
+			assert.NoError(t, createZip(ofs, &buf, files, true, constant.CompressionMethod_DEFLATE, "", &mtime))
+		}
+
+		return buf.Bytes()
+	}
+
+	for _, format := range []string{constant.ArchiveFormat_Tar, constant.ArchiveFormat_Zip} {
+		a := create(format, time.Now().Add(-time.Hour))
+		b := create(format, time.Now())
+		assert.NotEmpty(t, a, format)
+		assert.Equal(t, a, b, format)
+	}
+}
+
+func TestParseModTime(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []string{"1600000000", "2020-09-13T12:26:40Z", "2020-09-13T20:26:40.5+08:00"} {
+		mtime, err := parseModTime(v)
+		assert.NoError(t, err, v)
+		assert.Equal(t, time.Unix(1600000000, 0).UTC(), mtime, v)
+	}
+
+	_, err := parseModTime("foo")
+	assert.Error(t, err)
+}
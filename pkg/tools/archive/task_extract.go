@@ -0,0 +1,88 @@
+package archive
+
+import (
+	"io"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/tools"
+)
+
+const TaskKindExtract = "extract"
+
+func init() {
+	dukkha.RegisterTask(ToolKind, TaskKindExtract, tools.NewTask[TaskExtract, *TaskExtract])
+}
+
+type TaskExtract struct {
+	tools.BaseTask[ArchiveExtract, *ArchiveExtract]
+}
+
+// nolint:revive
+type ArchiveExtract struct {
+	// Archive file to extract
+	//
+	// format and compression are detected from file content, supports all
+	// archive types supported by the `af` renderer
+	Archive string `yaml:"archive"`
+
+	// Password for encrypted zip archives
+	Password string `yaml:"password"`
+
+	// Dest dir to extract files to
+	//
+	// Defaults to current working dir
+	Dest string `yaml:"dest"`
+
+	// Include glob patterns of in archive path (after strip_components) to extract
+	//
+	// Defaults to all files
+	Include []string `yaml:"include"`
+
+	// Exclude glob patterns of in archive path (after strip_components), matched dirs
+	// and their contents are not extracted
+	Exclude []string `yaml:"exclude"`
+
+	// StripComponents strips leading path components of in archive path,
+	// entries with no more path components are not extracted
+	StripComponents int `yaml:"strip_components"`
+
+	parent tools.BaseTaskType
+}
+
+func (c *ArchiveExtract) ToolKind() dukkha.ToolKind       { return ToolKind }
+func (c *ArchiveExtract) Kind() dukkha.TaskKind           { return TaskKindExtract }
+func (c *ArchiveExtract) LinkParent(p tools.BaseTaskType) { c.parent = p }
+
+func (c *ArchiveExtract) GetExecSpecs(
+	rc dukkha.TaskExecContext, options dukkha.TaskMatrixExecOptions,
+) ([]dukkha.TaskExecSpec, error) {
+	var steps []dukkha.TaskExecSpec
+
+	err := c.parent.DoAfterFieldsResolved(rc, -1, true, func() error {
+		opts := &extractOptions{
+			password:        c.Password,
+			include:         c.Include,
+			exclude:         c.Exclude,
+			stripComponents: c.StripComponents,
+		}
+
+		archive, dest := c.Archive, c.Dest
+		if len(dest) == 0 {
+			dest = "."
+		}
+
+		steps = append(steps, dukkha.TaskExecSpec{
+			AlterExecFunc: func(
+				replace dukkha.ReplaceEntries,
+				stdin io.Reader,
+				stdout, stderr io.Writer,
+			) (dukkha.RunTaskOrRunCmd, error) {
+				return nil, extract(rc.FS(), archive, dest, opts)
+			},
+		})
+
+		return nil
+	})
+
+	return steps, err
+}
@@ -7,6 +7,7 @@ import (
 
 	"arhat.dev/dukkha/pkg/constant"
 	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/oci"
 	"arhat.dev/dukkha/pkg/templateutils"
 	"arhat.dev/dukkha/pkg/tools"
 )
@@ -25,7 +26,11 @@ type TaskPush struct {
 type BuildahPush struct {
 	ImageNames []ImageNameSpec `yaml:"image_names"`
 
+	// OCIIndex assembles manifests as OCI image index natively
+	OCIIndex ociIndexSpec `yaml:"oci_index"`
+
 	manifestCache map[manifestCacheKey]manifestCacheValue
+	ociIndexes    ociIndexCollector
 
 	parent tools.BaseTaskType
 }
@@ -34,6 +39,14 @@ func (c *BuildahPush) ToolKind() dukkha.ToolKind       { return ToolKind }
 func (c *BuildahPush) Kind() dukkha.TaskKind           { return TaskKindPush }
 func (c *BuildahPush) LinkParent(p tools.BaseTaskType) { c.parent = p }
 
+// FinishMatrixExec implements tools.MatrixExecFinisher
+func (c *BuildahPush) FinishMatrixExec(
+	rc dukkha.TaskExecContext, opts dukkha.TaskMatrixExecOptions, err error,
+) error {
+	// successful matrix executions are finished by the oci index push spec
+	return c.ociIndexes.abort(opts)
+}
+
 func (c *BuildahPush) GetExecSpecs(
 	rc dukkha.TaskExecContext,
 	opts dukkha.TaskMatrixExecOptions,
@@ -55,6 +68,14 @@ func (c *BuildahPush) GetExecSpecs(
 			}
 		}
 
+		var ociClient *oci.Client
+		if c.OCIIndex.Enabled {
+			ociClient = &oci.Client{
+				PlainHTTP:   c.OCIIndex.PlainHTTP,
+				Credentials: oci.CredentialsFromAuthFiles(oci.DefaultAuthFiles()...),
+			}
+		}
+
 		for i, spec := range targets {
 			if len(spec.Image) != 0 {
 				imageName := templateutils.GetFullImageName_UseDefault_IfIfNoTagSet(rc, spec.Image, true)
@@ -68,6 +89,19 @@ func (c *BuildahPush) GetExecSpecs(
 					return fmt.Errorf("image id file not found: %w", err)
 				}
 
+				if c.OCIIndex.Enabled && len(spec.Manifest) != 0 {
+					manifestName := templateutils.GetFullManifestName_UseDefault_IfNoTagSet(rc, spec.Manifest)
+					specs, err := c.createOCIIndexSpecs(rc, opts, ociClient, i,
+						string(bytes.TrimSpace(imageIDBytes)), imageName, manifestName,
+					)
+					if err != nil {
+						return err
+					}
+
+					result = append(result, specs...)
+					continue
+				}
+
 				result = append(result, dukkha.TaskExecSpec{
 					Command: []string{constant.DUKKHA_TOOL_CMD, "push",
 						string(bytes.TrimSpace(imageIDBytes)),
@@ -82,10 +116,20 @@ func (c *BuildahPush) GetExecSpecs(
 				continue
 			}
 
+			if c.OCIIndex.Enabled {
+				return fmt.Errorf("oci_index: no image to push for manifest %q", spec.Manifest)
+			}
+
 			manifestName := templateutils.GetFullManifestName_UseDefault_IfNoTagSet(rc, spec.Manifest)
 			c.cacheManifestPushSpec(i, opts, manifestName)
 		}
 
+		if c.OCIIndex.Enabled {
+			// the last finished matrix execution pushes all image indexes
+			result = append(result, c.createOCIIndexPushSpec(rc, opts, ociClient))
+			return nil
+		}
+
 		// push all manifests at last
 		if opts.IsLast() {
 			result = append(result,
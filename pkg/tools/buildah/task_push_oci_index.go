@@ -0,0 +1,365 @@
+package buildah
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/oci"
+)
+
+// ociIndexSpec configures native oci image index assembly
+type ociIndexSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	// Enabled to write OCI image index locally from pushed images and push it
+	// through registry api, instead of updating local manifest lists with
+	// `buildah manifest` commands
+	Enabled bool `yaml:"enabled"`
+
+	// Annotations of the manifest entry for current matrix in the image index
+	Annotations map[string]string `yaml:"annotations"`
+
+	// PlainHTTP to talk to the registry without tls
+	PlainHTTP bool `yaml:"plain_http"`
+}
+
+// ociIndexEntry is a manifest pushed by one matrix execution
+type ociIndexEntry struct {
+	seq      int
+	subIndex int
+
+	desc oci.Descriptor
+}
+
+// ociIndexExec tracks manifests of one task execution
+type ociIndexExec struct {
+	// finished matrix executions (by seq)
+	finished map[int]struct{}
+
+	// incomplete is the count of matrix executions finished without
+	// pushing their images (failed or skipped)
+	incomplete int
+
+	// closed is the count of matrix executions reported by abort
+	closed int
+
+	// manifest name -> entries
+	manifests map[string][]ociIndexEntry
+}
+
+// ociIndexCollector collects pushed images of all matrix executions, and
+// assembles image indexes once every matrix execution has finished
+type ociIndexCollector struct {
+	mu    sync.Mutex
+	execs map[int]*ociIndexExec
+}
+
+func (c *ociIndexCollector) getExec(execID int) *ociIndexExec {
+	if c.execs == nil {
+		c.execs = make(map[int]*ociIndexExec)
+	}
+
+	e, ok := c.execs[execID]
+	if !ok {
+		e = &ociIndexExec{
+			finished:  make(map[int]struct{}),
+			manifests: make(map[string][]ociIndexEntry),
+		}
+		c.execs[execID] = e
+	}
+
+	return e
+}
+
+// add records image pushed for manifestName
+func (c *ociIndexCollector) add(
+	opts dukkha.TaskMatrixExecOptions, subIndex int, manifestName string, desc oci.Descriptor,
+) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.getExec(opts.ID())
+	e.manifests[manifestName] = append(e.manifests[manifestName], ociIndexEntry{
+		seq:      opts.Seq(),
+		subIndex: subIndex,
+		desc:     desc,
+	})
+}
+
+// finish marks the matrix execution finished with all images pushed, when it's
+// the last one finished, returns image indexes to be pushed (manifest name -> index)
+func (c *ociIndexCollector) finish(opts dukkha.TaskMatrixExecOptions) (map[string]*oci.Index, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.getExec(opts.ID())
+	if !c.markFinished(e, opts) {
+		return nil, nil
+	}
+
+	if err := e.checkIncomplete(opts); err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]*oci.Index, len(e.manifests))
+	for name, entries := range e.manifests {
+		// restore matrix order, so later matrix entry wins when platform conflicts
+		sort.SliceStable(entries, func(i, j int) bool {
+			if entries[i].seq != entries[j].seq {
+				return entries[i].seq < entries[j].seq
+			}
+
+			return entries[i].subIndex < entries[j].subIndex
+		})
+
+		descs := make([]oci.Descriptor, len(entries))
+		for i, ent := range entries {
+			descs[i] = ent.desc
+		}
+
+		ret[name] = oci.NewIndex(descs)
+	}
+
+	return ret, nil
+}
+
+// abort is called after every matrix execution, it marks the matrix execution
+// finished without pushing images if it's not finished yet, when it's the last
+// one finished, returns error for image indexes not pushed
+func (c *ociIndexCollector) abort(opts dukkha.TaskMatrixExecOptions) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.getExec(opts.ID())
+	e.closed++
+	if e.closed >= opts.Total() {
+		// no more calls for this task execution
+		delete(c.execs, opts.ID())
+	}
+
+	if _, done := e.finished[opts.Seq()]; done {
+		return nil
+	}
+
+	e.incomplete++
+	if !c.markFinished(e, opts) {
+		return nil
+	}
+
+	return e.checkIncomplete(opts)
+}
+
+// markFinished marks the matrix execution finished, returns true when it's the
+// last one
+func (c *ociIndexCollector) markFinished(e *ociIndexExec, opts dukkha.TaskMatrixExecOptions) bool {
+	e.finished[opts.Seq()] = struct{}{}
+	return len(e.finished) == opts.Total()
+}
+
+// checkIncomplete returns error when there are image indexes not pushed due to
+// incomplete matrix executions
+func (e *ociIndexExec) checkIncomplete(opts dukkha.TaskMatrixExecOptions) error {
+	if e.incomplete == 0 || len(e.manifests) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(e.manifests))
+	for name := range e.manifests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return fmt.Errorf(
+		"oci_index: image indexes %q not pushed, %d of %d matrix executions did not push their images",
+		names, e.incomplete, opts.Total(),
+	)
+}
+
+// getOCIPlatform returns the oci platform of current matrix
+func getOCIPlatform(rc dukkha.TaskExecContext) *oci.Platform {
+	mArch := rc.MatrixArch()
+	os, _ := constant.GetOciOS(rc.MatrixKernel())
+	arch, _ := constant.GetOciArch(mArch)
+	variant, _ := constant.GetOciArchVariant(mArch)
+
+	return &oci.Platform{
+		OS:           os,
+		Architecture: arch,
+		Variant:      variant,
+	}
+}
+
+// GetOCIIndexFileForManifestName returns the local file path of the oci image index
+// assembled for manifestName
+func GetOCIIndexFileForManifestName(rc dukkha.RenderingContext, manifestName string, ensureDir bool) (string, error) {
+	const ociIndexCacheDir = "buildah/oci-index"
+
+	cfs := rc.GlobalCacheFS(ociIndexCacheDir)
+
+	ret, err := cfs.Abs(getLocalManifestName(manifestName) + ".json")
+	if err != nil {
+		return "", err
+	}
+
+	if ensureDir {
+		err = cfs.MkdirAll(".", 0755)
+		if err != nil && !errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+	}
+
+	return ret, nil
+}
+
+// createOCIIndexSpecs generates specs to push image with its digest recorded and
+// add the pushed manifest to the image index of manifestName
+func (c *BuildahPush) createOCIIndexSpecs(
+	rc dukkha.TaskExecContext,
+	opts dukkha.TaskMatrixExecOptions,
+	client *oci.Client,
+	subIndex int,
+	imageID, imageName, manifestName string,
+) ([]dukkha.TaskExecSpec, error) {
+	imageRef, err := oci.ParseReference(imageName)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestRef, err := oci.ParseReference(manifestName)
+	if err != nil {
+		return nil, err
+	}
+
+	if imageRef.Name() != manifestRef.Name() {
+		return nil, fmt.Errorf(
+			"oci_index: image %q is not in the same repository as manifest %q",
+			imageName, manifestName,
+		)
+	}
+
+	digestFile, err := os.CreateTemp(rc.CacheDir(), "buildah-push-digest-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp file for image digest: %w", err)
+	}
+	digestFilePath := digestFile.Name()
+	_ = digestFile.Close()
+
+	platform := getOCIPlatform(rc)
+	var annotations map[string]string
+	if len(c.OCIIndex.Annotations) != 0 {
+		annotations = make(map[string]string, len(c.OCIIndex.Annotations))
+		for k, v := range c.OCIIndex.Annotations {
+			annotations[k] = v
+		}
+	}
+
+	return []dukkha.TaskExecSpec{
+		{
+			Command: []string{constant.DUKKHA_TOOL_CMD, "push",
+				"--digestfile", digestFilePath,
+				imageID,
+				"docker://" + imageName,
+			},
+			IgnoreError: false,
+		},
+		{
+			AlterExecFunc: func(
+				replace dukkha.ReplaceEntries,
+				stdin io.Reader, stdout, stderr io.Writer,
+			) (dukkha.RunTaskOrRunCmd, error) {
+				digest, err := os.ReadFile(digestFilePath)
+				_ = os.Remove(digestFilePath)
+				if err != nil {
+					return nil, fmt.Errorf("reading pushed image digest: %w", err)
+				}
+
+				ref := imageRef
+				ref.Tag, ref.Digest = "", string(bytes.TrimSpace(digest))
+
+				desc, err := client.HeadManifest(rc, ref)
+				if err != nil {
+					return nil, err
+				}
+
+				desc.Platform = platform
+				desc.Annotations = annotations
+
+				c.ociIndexes.add(opts, subIndex, manifestName, *desc)
+				return nil, nil
+			},
+		},
+	}, nil
+}
+
+// createOCIIndexPushSpec generates the spec to finish current matrix execution,
+// image indexes are written and pushed by the last finished matrix execution
+func (c *BuildahPush) createOCIIndexPushSpec(
+	rc dukkha.TaskExecContext,
+	opts dukkha.TaskMatrixExecOptions,
+	client *oci.Client,
+) dukkha.TaskExecSpec {
+	return dukkha.TaskExecSpec{
+		AlterExecFunc: func(
+			replace dukkha.ReplaceEntries,
+			stdin io.Reader, stdout, stderr io.Writer,
+		) (dukkha.RunTaskOrRunCmd, error) {
+			indexes, err := c.ociIndexes.finish(opts)
+			if err != nil {
+				return nil, err
+			}
+
+			names := make([]string, 0, len(indexes))
+			for name := range indexes {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				err := pushOCIIndex(rc, client, name, indexes[name])
+				if err != nil {
+					return nil, err
+				}
+
+				_, _ = fmt.Fprintf(stdout, "pushed oci image index %q\n", name)
+			}
+
+			return nil, nil
+		},
+	}
+}
+
+// pushOCIIndex writes the index to local file and pushes it to manifestName
+func pushOCIIndex(rc dukkha.TaskExecContext, client *oci.Client, manifestName string, idx *oci.Index) error {
+	ref, err := oci.ParseReference(manifestName)
+	if err != nil {
+		return err
+	}
+
+	data, _, err := idx.Marshal()
+	if err != nil {
+		return fmt.Errorf("marshal oci image index: %w", err)
+	}
+
+	indexFile, err := GetOCIIndexFileForManifestName(rc, manifestName, true)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(indexFile, data, 0644)
+	if err != nil {
+		return fmt.Errorf("writing oci image index: %w", err)
+	}
+
+	_, err = client.PutManifest(rc, ref, oci.MediaTypeImageIndex, data)
+	return err
+}
@@ -1,13 +1,24 @@
 package buildah
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
 	"testing"
 
+	"arhat.dev/pkg/archconst"
+	"arhat.dev/pkg/fshelper"
+	"arhat.dev/rs"
 	"github.com/stretchr/testify/assert"
 
 	"arhat.dev/dukkha/pkg/constant"
 	"arhat.dev/dukkha/pkg/dukkha"
 	dukkha_test "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/oci"
+	"arhat.dev/dukkha/pkg/oci/ocitest"
+	"arhat.dev/dukkha/pkg/tools"
 )
 
 func TestTaskPush_ManifestHandling(t *testing.T) {
@@ -43,3 +54,146 @@ func TestTaskPush_ManifestHandling(t *testing.T) {
 		},
 	}}, task.createManifestPushSpecsFromCache(opts.ID()))
 }
+
+func TestTaskPush_OCIIndex(t *testing.T) {
+	t.Parallel()
+
+	reg := ocitest.NewRegistry("", "")
+	defer reg.Close()
+
+	var (
+		cacheDir     = t.TempDir()
+		manifestName = reg.Host() + "/foo:latest"
+	)
+
+	tsk := tools.NewTask[TaskPush, *TaskPush]("").(*TaskPush)
+	tsk.TaskName = "foo"
+	tsk.Impl.OCIIndex = ociIndexSpec{
+		Enabled:     true,
+		PlainHTTP:   true,
+		Annotations: map[string]string{"foo": "bar"},
+	}
+
+	rs.InitRecursively(reflect.ValueOf(tsk), nil)
+	assert.NoError(t, tsk.Init(fshelper.NewOSFS(false, func(op fshelper.Op, name string) (string, error) {
+		return t.TempDir(), nil
+	})))
+
+	arches := []string{archconst.ARCH_ARM64, archconst.ARCH_AMD64}
+	execOpts := dukkha.CreateTaskExecOptions(0, len(arches))
+
+	var finishSteps []dukkha.TaskExecSpec
+	for _, arch := range arches {
+		imageName := reg.Host() + "/foo:" + arch
+
+		ctx := dukkha_test.NewTestContext(context.TODO(), cacheDir)
+		ctx.AddEnv(true,
+			&dukkha.NameValueEntry{Name: constant.EnvName_MATRIX_KERNEL, Value: constant.KERNEL_Linux},
+			&dukkha.NameValueEntry{Name: constant.EnvName_MATRIX_ARCH, Value: arch},
+		)
+
+		imageIDFile, err := GetImageIDFileForImageName(ctx, imageName, true)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, os.WriteFile(imageIDFile, []byte("id-"+arch), 0644))
+
+		tsk.Impl.ImageNames = []ImageNameSpec{{Image: imageName, Manifest: manifestName}}
+		rs.InitRecursively(reflect.ValueOf(&tsk.Impl.ImageNames[0]), nil)
+		specs, err := tsk.GetExecSpecs(ctx, execOpts.NextMatrixExecOptions())
+		if !assert.NoError(t, err) || !assert.Len(t, specs, 3) {
+			return
+		}
+
+		cmd := specs[0].Command
+		if !assert.Len(t, cmd, 6) {
+			return
+		}
+		assert.Equal(t, []string{constant.DUKKHA_TOOL_CMD, "push", "--digestfile"}, cmd[:3])
+		assert.Equal(t, []string{"id-" + arch, "docker://" + imageName}, cmd[4:])
+
+		// what buildah push does
+		digest := reg.AddManifest("foo", arch, oci.MediaTypeImageManifest, []byte(`{"arch":"`+arch+`"}`))
+		assert.NoError(t, os.WriteFile(cmd[3], []byte(digest), 0644))
+
+		_, err = specs[1].AlterExecFunc(nil, nil, io.Discard, io.Discard)
+		assert.NoError(t, err)
+
+		finishSteps = append(finishSteps, specs[2])
+	}
+
+	_, err := finishSteps[0].AlterExecFunc(nil, nil, io.Discard, io.Discard)
+	assert.NoError(t, err)
+
+	_, ok := reg.GetManifest("foo", "latest")
+	assert.False(t, ok, "index pushed before all matrix executions finished")
+
+	_, err = finishSteps[1].AlterExecFunc(nil, nil, io.Discard, io.Discard)
+	assert.NoError(t, err)
+
+	m, ok := reg.GetManifest("foo", "latest")
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, oci.MediaTypeImageIndex, m.MediaType)
+
+	var idx oci.Index
+	assert.NoError(t, json.Unmarshal(m.Data, &idx))
+	if assert.Len(t, idx.Manifests, 2) {
+		assert.Equal(t, &oci.Platform{OS: "linux", Architecture: "amd64"}, idx.Manifests[0].Platform)
+		assert.Equal(t, &oci.Platform{OS: "linux", Architecture: "arm64"}, idx.Manifests[1].Platform)
+		assert.Equal(t, map[string]string{"foo": "bar"}, idx.Manifests[0].Annotations)
+	}
+
+	indexFile, err := GetOCIIndexFileForManifestName(
+		dukkha_test.NewTestContext(context.TODO(), cacheDir), manifestName, false,
+	)
+	assert.NoError(t, err)
+
+	local, err := os.ReadFile(indexFile)
+	assert.NoError(t, err)
+	assert.Equal(t, m.Data, local)
+}
+
+func TestOCIIndexCollector_Incomplete(t *testing.T) {
+	t.Parallel()
+
+	var c ociIndexCollector
+
+	execOpts := dukkha.CreateTaskExecOptions(0, 3)
+	opts := []dukkha.TaskMatrixExecOptions{
+		execOpts.NextMatrixExecOptions(),
+		execOpts.NextMatrixExecOptions(),
+		execOpts.NextMatrixExecOptions(),
+	}
+
+	c.add(opts[0], 0, "foo:latest", oci.Descriptor{})
+	indexes, err := c.finish(opts[0])
+	assert.NoError(t, err)
+	assert.Nil(t, indexes)
+
+	// already finished
+	assert.NoError(t, c.abort(opts[0]))
+
+	// failed
+	assert.NoError(t, c.abort(opts[1]))
+
+	c.add(opts[2], 0, "foo:latest", oci.Descriptor{})
+	indexes, err = c.finish(opts[2])
+	assert.Nil(t, indexes)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `"foo:latest"`)
+		assert.Contains(t, err.Error(), "1 of 3")
+	}
+
+	assert.NoError(t, c.abort(opts[2]))
+	assert.Len(t, c.execs, 0)
+
+	t.Run("No Manifest", func(t *testing.T) {
+		var c ociIndexCollector
+
+		execOpts := dukkha.CreateTaskExecOptions(0, 1)
+		assert.NoError(t, c.abort(execOpts.NextMatrixExecOptions()))
+		assert.Len(t, c.execs, 0)
+	})
+}
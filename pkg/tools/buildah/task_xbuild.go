@@ -43,6 +43,9 @@ type BuildahXBuild struct {
 	ImageNames []*ImageNameSpec `yaml:"image_names"`
 	Steps      []*step          `yaml:"steps"`
 
+	// Cache step results as local images and reuse them when steps are not changed
+	Cache xbuildCacheSpec `yaml:"cache"`
+
 	parent tools.BaseTaskType
 }
 
@@ -72,6 +75,11 @@ func (w *BuildahXBuild) GetExecSpecs(
 
 		var realImageNames []string
 
+		var cache *xbuildCache
+		if w.Cache.Enabled {
+			cache = newXBuildCache(rc)
+		}
+
 		nameSum := sha256.New()
 		for _, spec := range w.ImageNames {
 			if len(spec.Image) == 0 {
@@ -158,10 +166,19 @@ func (w *BuildahXBuild) GetExecSpecs(
 				return fmt.Errorf("xbuild: generate #%d step spec: %w", i, err)
 			}
 
+			if cache != nil {
+				stepRet, err = cache.genSpec(stepID, step, stepRet)
+				if err != nil {
+					return fmt.Errorf("xbuild: generate #%d step cache spec: %w", i, err)
+				}
+			}
+
 			ret = append(ret, stepRet...)
 
 			// update container id when switching image
-			if step.From != nil {
+			//
+			// a new container is also created when using cached step image
+			if step.From != nil || cache != nil {
 				ret = append(ret, dukkha.TaskExecSpec{
 					StdoutAsReplace:          replace_XBUILD_STEP_CONTAINER_ID(stepID),
 					FixStdoutValueForReplace: bytes.TrimSpace,
@@ -0,0 +1,426 @@
+package buildah
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+const (
+	// xbuildCacheRepo is the local image repo of cached step images
+	xbuildCacheRepo = "localhost/dukkha-xbuild-cache"
+
+	// xbuildCacheKeyAnnotation is set on cached step images to the step key
+	xbuildCacheKeyAnnotation = "dev.arhat.dukkha.xbuild.cache-key"
+)
+
+const (
+	replace_XBUILD_CACHED_STEP_KEY        = "<XBUILD_CACHED_STEP_KEY>"
+	replace_XBUILD_CACHED_CONTAINER_NAME  = "<XBUILD_CACHED_CONTAINER_NAME>"
+	replace_XBUILD_CACHED_SOURCE_IMAGE_ID = "<XBUILD_CACHED_SOURCE_IMAGE_ID>"
+)
+
+type xbuildCacheSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	// Enabled to reuse images committed in previous runs for unchanged steps
+	Enabled bool `yaml:"enabled"`
+}
+
+// xbuildCache tracks step keys of one matrix execution
+type xbuildCache struct {
+	rc dukkha.TaskExecContext
+
+	// parentKey is the key of last executed step
+	parentKey string
+
+	// stepKeys are keys of executed steps by step id
+	stepKeys map[string]string
+}
+
+func newXBuildCache(rc dukkha.TaskExecContext) *xbuildCache {
+	return &xbuildCache{
+		rc:       rc,
+		stepKeys: make(map[string]string),
+	}
+}
+
+func getXBuildCacheImageName(key string) string {
+	return xbuildCacheRepo + ":" + key
+}
+
+// genSpec wraps specs of a step
+//
+// the step key is computed right before the step is executed, when there is a local image
+// annotated with the same key, the step is skipped and a new container is created from that
+// image, otherwise the step is executed and the container is committed as the cached image
+func (c *xbuildCache) genSpec(
+	stepID string,
+	s *step,
+	stepSpecs []dukkha.TaskExecSpec,
+) ([]dukkha.TaskExecSpec, error) {
+	stepSpec, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("marshal step spec: %w", err)
+	}
+
+	// image used by the step is pulled first to resolve its id
+	ret := genXBuildCacheSourceImageSpecs(s)
+
+	return append(ret, dukkha.TaskExecSpec{
+		AlterExecFunc: func(
+			replace dukkha.ReplaceEntries,
+			stdin io.Reader,
+			stdout, stderr io.Writer,
+		) (dukkha.RunTaskOrRunCmd, error) {
+			var imageID string
+			if len(ret) != 0 {
+				v, ok := replace[replace_XBUILD_CACHED_SOURCE_IMAGE_ID]
+				if !ok || v.Err != nil || len(v.Data) == 0 {
+					return nil, fmt.Errorf("xbuild: source image id of step %q not resolved", stepID)
+				}
+
+				imageID = string(v.Data)
+			}
+
+			key, err := c.computeKey(s, stepSpec, imageID)
+			if err != nil {
+				return nil, fmt.Errorf("xbuild: compute cache key of step %q: %w", stepID, err)
+			}
+
+			c.parentKey = key
+			c.stepKeys[stepID] = key
+
+			imageName := getXBuildCacheImageName(key)
+			missSpecs := append(
+				append([]dukkha.TaskExecSpec{}, stepSpecs...),
+				genXBuildCacheCommitSpecs(key, imageName)...,
+			)
+			if c.rc.Force() {
+				return missSpecs, nil
+			}
+
+			return []dukkha.TaskExecSpec{
+				{
+					StdoutAsReplace:          replace_XBUILD_CACHED_STEP_KEY,
+					FixStdoutValueForReplace: bytes.TrimSpace,
+
+					// cached image may not exist
+					IgnoreError: true,
+					Command: []string{constant.DUKKHA_TOOL_CMD,
+						"inspect",
+						"--type", "image",
+						"--format", `{{ index .ImageAnnotations "` + xbuildCacheKeyAnnotation + `" }}`,
+						imageName,
+					},
+				},
+				{
+					AlterExecFunc: func(
+						replace dukkha.ReplaceEntries,
+						stdin io.Reader,
+						stdout, stderr io.Writer,
+					) (dukkha.RunTaskOrRunCmd, error) {
+						v, ok := replace[replace_XBUILD_CACHED_STEP_KEY]
+						if !ok || v.Err != nil || string(v.Data) != key {
+							return missSpecs, nil
+						}
+
+						_, err := fmt.Fprintf(stdout, "xbuild: using cached image %s for step %q\n", imageName, stepID)
+						if err != nil {
+							return nil, err
+						}
+
+						return genXBuildCacheFromSpecs(imageName), nil
+					},
+				},
+			}, nil
+		},
+	}), nil
+}
+
+// genXBuildCacheSourceImageSpecs pulls the image used by the step (base image of
+// `from` and source image of `copy` from `image`), the pulled image id is set as
+// replace_XBUILD_CACHED_SOURCE_IMAGE_ID
+func genXBuildCacheSourceImageSpecs(s *step) []dukkha.TaskExecSpec {
+	var (
+		ref, kernel, arch string
+		extraPullArgs     []string
+	)
+
+	switch {
+	case s.From != nil && !strings.EqualFold(s.From.Ref, "scratch"):
+		ref, kernel, arch, extraPullArgs = s.From.Ref, s.From.Kernel, s.From.Arch, s.From.ExtraPullArgs
+	case s.Copy != nil && s.Copy.From.Image != nil:
+		from := s.Copy.From.Image
+		ref, kernel, arch, extraPullArgs = from.Ref, from.Kernel, from.Arch, from.ExtraPullArgs
+	default:
+		return nil
+	}
+
+	pullCmd := []string{constant.DUKKHA_TOOL_CMD, "pull"}
+	pullCmd = append(pullCmd, generatePlatformArgs(kernel, arch)...)
+	pullCmd = append(pullCmd, extraPullArgs...)
+	pullCmd = append(pullCmd, ref)
+
+	return []dukkha.TaskExecSpec{{
+		StdoutAsReplace:          replace_XBUILD_CACHED_SOURCE_IMAGE_ID,
+		FixStdoutValueForReplace: bytes.TrimSpace,
+
+		ShowStdout:  true,
+		IgnoreError: false,
+		Command:     pullCmd,
+	}}
+}
+
+// genXBuildCacheCommitSpecs commits current container as the cached step image
+//
+// the cache key annotation is removed from the container after commit so it won't
+// appear in images of following steps
+func genXBuildCacheCommitSpecs(key, imageName string) []dukkha.TaskExecSpec {
+	return []dukkha.TaskExecSpec{
+		{
+			IgnoreError: false,
+			Command: []string{constant.DUKKHA_TOOL_CMD,
+				"config",
+				"--annotation", xbuildCacheKeyAnnotation + "=" + key,
+				replace_XBUILD_CURRENT_CONTAINER_ID,
+			},
+		},
+		{
+			IgnoreError: false,
+			Command: []string{constant.DUKKHA_TOOL_CMD,
+				"commit", "--quiet", replace_XBUILD_CURRENT_CONTAINER_ID, imageName,
+			},
+		},
+		{
+			IgnoreError: false,
+			Command: []string{constant.DUKKHA_TOOL_CMD,
+				"config",
+				"--annotation", xbuildCacheKeyAnnotation + "-",
+				replace_XBUILD_CURRENT_CONTAINER_ID,
+			},
+		},
+	}
+}
+
+// genXBuildCacheFromSpecs creates a new working container from the cached step image
+func genXBuildCacheFromSpecs(imageName string) []dukkha.TaskExecSpec {
+	return []dukkha.TaskExecSpec{
+		{
+			StdoutAsReplace:          replace_XBUILD_CACHED_CONTAINER_NAME,
+			FixStdoutValueForReplace: bytes.TrimSpace,
+
+			ShowStdout:  true,
+			IgnoreError: false,
+			Command:     []string{constant.DUKKHA_TOOL_CMD, "from", "--pull-never", imageName},
+		},
+		{
+			StdoutAsReplace:          replace_XBUILD_CURRENT_CONTAINER_ID,
+			FixStdoutValueForReplace: bytes.TrimSpace,
+
+			ShowStdout:  true,
+			IgnoreError: false,
+			Command: []string{constant.DUKKHA_TOOL_CMD,
+				"inspect",
+				"--type", "container",
+				"--format", "{{ .ContainerID }}",
+				replace_XBUILD_CACHED_CONTAINER_NAME,
+			},
+		},
+	}
+}
+
+// computeKey generates content key of the step from
+// - key of the parent step (unless it's a from step)
+// - matrix kernel, arch and libc
+// - resolved step spec
+// - id of the image used by this step (imageID)
+// - digests of local files and http content used by this step
+// - key of the step files are copied from
+func (c *xbuildCache) computeKey(s *step, stepSpec []byte, imageID string) (string, error) {
+	h := sha256.New()
+
+	parentKey := c.parentKey
+	if s.From != nil {
+		// from starts a new container unrelated to previous steps
+		parentKey = ""
+	}
+
+	writeHashParts(h,
+		parentKey,
+		c.rc.MatrixKernel(), c.rc.MatrixArch(), c.rc.MatrixLibc(),
+		string(stepSpec),
+		imageID,
+	)
+
+	switch {
+	case s.Copy != nil && s.Copy.From.Local != nil:
+		src, err := c.rc.FS().Abs(s.Copy.From.Local.Path)
+		if err != nil {
+			return "", err
+		}
+
+		err = hashLocalFiles(h, src, s.Copy.included, s.Copy.excludedDir)
+		if err != nil {
+			return "", fmt.Errorf("hash copy source: %w", err)
+		}
+	case s.Copy != nil && s.Copy.From.HTTP != nil:
+		err := c.hashHTTPContent(h, s.Copy.From.HTTP.URL)
+		if err != nil {
+			return "", fmt.Errorf("hash copy source: %w", err)
+		}
+	case s.Copy != nil && s.Copy.From.Step != nil:
+		stepKey, ok := c.stepKeys[s.Copy.From.Step.ID]
+		if !ok {
+			return "", fmt.Errorf("copy source step %q not executed", s.Copy.From.Step.ID)
+		}
+
+		writeHashParts(h, stepKey)
+	case s.Run != nil && len(s.Run.ExecutableFile) != 0:
+		src, err := c.rc.FS().Abs(s.Run.ExecutableFile)
+		if err != nil {
+			return "", err
+		}
+
+		err = hashLocalFiles(h, src, nil, nil)
+		if err != nil {
+			return "", fmt.Errorf("hash executable: %w", err)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// xbuildCacheHTTPClient is used to check http copy sources when computing
+// cache keys
+var xbuildCacheHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// hashHTTPContent writes validators (ETag and Last-Modified) of url to h,
+// when the server provides neither of them, content of url is downloaded
+// and written to h
+func (c *xbuildCache) hashHTTPContent(h hash.Hash, url string) error {
+	resp, err := c.doHTTPRequest(http.MethodHead, url)
+	if err == nil {
+		_ = resp.Body.Close()
+
+		etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+		if resp.StatusCode == http.StatusOK && (len(etag) != 0 || len(lastModified) != 0) {
+			writeHashParts(h, "etag", etag, "last-modified", lastModified)
+			return nil
+		}
+	}
+
+	// HEAD not supported or no validator, fallback to content
+	resp, err = c.doHTTPRequest(http.MethodGet, url)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %q of %q", resp.Status, url)
+	}
+
+	_, err = io.Copy(h, resp.Body)
+	if err != nil {
+		return fmt.Errorf("read content of %q: %w", url, err)
+	}
+
+	writeHashParts(h, "")
+	return nil
+}
+
+func (c *xbuildCache) doHTTPRequest(method, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(c.rc, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return xbuildCacheHTTPClient.Do(req)
+}
+
+func writeHashParts(h hash.Hash, parts ...string) {
+	for _, p := range parts {
+		_, _ = h.Write([]byte(p))
+		_, _ = h.Write([]byte{0})
+	}
+}
+
+// hashLocalFiles writes name, mode and content (or link target) of files in src to h
+//
+// included and excludedDir are used to filter files in dir when not nil
+func hashLocalFiles(
+	h hash.Hash,
+	src string,
+	included, excludedDir func(name string) bool,
+) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if p != src {
+			if d.IsDir() {
+				if excludedDir != nil && excludedDir(rel) {
+					return filepath.SkipDir
+				}
+			} else if included != nil && !included(rel) {
+				return nil
+			}
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		writeHashParts(h, rel, strconv.FormatUint(uint64(info.Mode()), 8))
+
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+
+			writeHashParts(h, target)
+		case info.Mode().IsRegular():
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = f.Close() }()
+
+			_, err = io.Copy(h, f)
+			if err != nil {
+				return err
+			}
+
+			writeHashParts(h, "")
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,227 @@
+package buildah
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+)
+
+func TestXBuildCache_computeKey(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	for name, data := range map[string]string{
+		"foo":         "foo",
+		"bar/foo.tmp": "foo",
+	} {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		assert.NoError(t, os.WriteFile(p, []byte(data), 0644))
+	}
+
+	copyStep := &step{
+		Copy: &stepCopy{
+			From: copyFromSpec{
+				Local:   &copyFromLocalSpec{Path: src},
+				Exclude: []string{"**/*.tmp"},
+			},
+			To: copyToSpec{Path: "/app"},
+		},
+	}
+
+	newCache := func(arch string) *xbuildCache {
+		ctx := dt.NewTestContext(context.TODO(), t.TempDir())
+		ctx.AddEnv(true, &dukkha.NameValueEntry{Name: constant.EnvName_MATRIX_ARCH, Value: arch})
+		return newXBuildCache(ctx)
+	}
+
+	getImageKey := func(c *xbuildCache, s *step, imageID string) string {
+		spec, err := json.Marshal(s)
+		assert.NoError(t, err)
+
+		key, err := c.computeKey(s, spec, imageID)
+		assert.NoError(t, err)
+		return key
+	}
+
+	getKey := func(c *xbuildCache, s *step) string {
+		return getImageKey(c, s, "")
+	}
+
+	c := newCache("amd64")
+	key := getKey(c, copyStep)
+	assert.Len(t, key, 64)
+	assert.Equal(t, key, getKey(c, copyStep))
+
+	// excluded files are not part of the key
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "bar", "foo.tmp"), []byte("changed"), 0644))
+	assert.Equal(t, key, getKey(c, copyStep))
+
+	// matrix entry
+	assert.NotEqual(t, key, getKey(newCache("arm64"), copyStep))
+
+	// parent step key
+	c.parentKey = "parent"
+	assert.NotEqual(t, key, getKey(c, copyStep))
+	c.parentKey = ""
+
+	// copied file content
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "foo"), []byte("changed"), 0644))
+	assert.NotEqual(t, key, getKey(c, copyStep))
+
+	// parent step key is not used by from step
+	fromStep := &step{From: &stepFrom{Ref: "scratch"}}
+	fromKey := getKey(c, fromStep)
+	c.parentKey = "parent"
+	assert.Equal(t, fromKey, getKey(c, fromStep))
+
+	// image id resolved by pulling the base image
+	fromStep = &step{From: &stepFrom{Ref: "alpine:latest"}}
+	assert.NotEqual(t,
+		getImageKey(c, fromStep, "sha256:foo"),
+		getImageKey(c, fromStep, "sha256:bar"),
+	)
+
+	// http content
+	content := "foo"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(content))
+	}))
+	t.Cleanup(srv.Close)
+
+	httpStep := &step{
+		Copy: &stepCopy{
+			From: copyFromSpec{HTTP: &copyFromHTTPSpec{URL: srv.URL}},
+			To:   copyToSpec{Path: "/app"},
+		},
+	}
+	httpKey := getKey(c, httpStep)
+	assert.Equal(t, httpKey, getKey(c, httpStep))
+	content = "changed"
+	assert.NotEqual(t, httpKey, getKey(c, httpStep))
+
+	// http content with validators
+	etag, downloads := `"foo"`, 0
+	srvWithETag := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		if r.Method == http.MethodGet {
+			downloads++
+			_, _ = w.Write([]byte(content))
+		}
+	}))
+	t.Cleanup(srvWithETag.Close)
+
+	httpStep.Copy.From.HTTP.URL = srvWithETag.URL
+	httpKey = getKey(c, httpStep)
+	assert.Equal(t, httpKey, getKey(c, httpStep))
+	etag = `"bar"`
+	assert.NotEqual(t, httpKey, getKey(c, httpStep))
+	assert.Equal(t, 0, downloads)
+
+	// copy from not executed step
+	_, err := c.computeKey(&step{
+		Copy: &stepCopy{From: copyFromSpec{Step: &copyFromStepSpec{ID: "foo"}}},
+	}, nil, "")
+	assert.Error(t, err)
+}
+
+func TestXBuildCache_genSpec_SourceImage(t *testing.T) {
+	t.Parallel()
+
+	ctx := dt.NewTestContext(context.TODO(), t.TempDir())
+	c := newXBuildCache(ctx)
+
+	s := &step{From: &stepFrom{Ref: "alpine:latest", Arch: "arm64"}}
+	specs, err := c.genSpec("foo", s, nil)
+	if !assert.NoError(t, err) || !assert.Len(t, specs, 2) {
+		return
+	}
+
+	assert.Equal(t, replace_XBUILD_CACHED_SOURCE_IMAGE_ID, specs[0].StdoutAsReplace)
+	assert.Equal(t, []string{
+		constant.DUKKHA_TOOL_CMD, "pull", "--arch", "arm64", "alpine:latest",
+	}, specs[0].Command)
+
+	// image id is required
+	_, err = specs[1].AlterExecFunc(dukkha.ReplaceEntries{}, nil, io.Discard, io.Discard)
+	assert.Error(t, err)
+
+	_, err = specs[1].AlterExecFunc(dukkha.ReplaceEntries{
+		replace_XBUILD_CACHED_SOURCE_IMAGE_ID: {Data: []byte("sha256:foo")},
+	}, nil, io.Discard, io.Discard)
+	assert.NoError(t, err)
+	fooKey := c.stepKeys["foo"]
+
+	_, err = specs[1].AlterExecFunc(dukkha.ReplaceEntries{
+		replace_XBUILD_CACHED_SOURCE_IMAGE_ID: {Data: []byte("sha256:bar")},
+	}, nil, io.Discard, io.Discard)
+	assert.NoError(t, err)
+	assert.NotEqual(t, fooKey, c.stepKeys["foo"])
+}
+
+func TestXBuildCache_genSpec(t *testing.T) {
+	t.Parallel()
+
+	ctx := dt.NewTestContext(context.TODO(), t.TempDir())
+	c := newXBuildCache(ctx)
+
+	s := &step{Run: &stepRun{Cmd: []string{"true"}}}
+	stepSpecs := []dukkha.TaskExecSpec{{Command: []string{constant.DUKKHA_TOOL_CMD, "run"}}}
+
+	specs, err := c.genSpec("foo", s, stepSpecs)
+	if !assert.NoError(t, err) || !assert.Len(t, specs, 1) {
+		return
+	}
+
+	ret, err := specs[0].AlterExecFunc(dukkha.ReplaceEntries{}, nil, io.Discard, io.Discard)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	key := c.stepKeys["foo"]
+	imageName := getXBuildCacheImageName(key)
+	checkSpecs := ret.([]dukkha.TaskExecSpec)
+	if !assert.Len(t, checkSpecs, 2) {
+		return
+	}
+	assert.Equal(t, imageName, checkSpecs[0].Command[len(checkSpecs[0].Command)-1])
+
+	// cache miss
+	ret, err = checkSpecs[1].AlterExecFunc(dukkha.ReplaceEntries{
+		replace_XBUILD_CACHED_STEP_KEY: {Data: []byte("other")},
+	}, nil, io.Discard, io.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t,
+		getCommands(append(stepSpecs, genXBuildCacheCommitSpecs(key, imageName)...)),
+		getCommands(ret.([]dukkha.TaskExecSpec)),
+	)
+
+	// cache hit
+	ret, err = checkSpecs[1].AlterExecFunc(dukkha.ReplaceEntries{
+		replace_XBUILD_CACHED_STEP_KEY: {Data: []byte(key)},
+	}, nil, io.Discard, io.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t,
+		getCommands(genXBuildCacheFromSpecs(imageName)),
+		getCommands(ret.([]dukkha.TaskExecSpec)),
+	)
+}
+
+func getCommands(specs []dukkha.TaskExecSpec) (ret [][]string) {
+	for _, s := range specs {
+		ret = append(ret, s.Command)
+	}
+
+	return
+}
@@ -5,6 +5,9 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"os"
+	"path"
+	"path/filepath"
 
 	"arhat.dev/pkg/fshelper"
 	"arhat.dev/pkg/md5helper"
@@ -12,6 +15,7 @@ import (
 
 	"arhat.dev/dukkha/pkg/constant"
 	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/sliceutils"
 )
 
 // stepCopy is structured `buildah copy`
@@ -39,14 +43,55 @@ func (s *stepCopy) genSpec(
 	}
 	copyCmd = append(copyCmd, s.ExtraArgs...)
 
+	staging := s.needStaging()
+	if !staging {
+		copyCmd = append(copyCmd, s.getCopyFlags()...)
+	}
+
+	const (
+		replace_XBUILD_COPY_FROM_TEXT_DATA_SRC_PATH = "<XBUILD_COPY_FROM_TEXT_DATA_FILE>"
+		replace_XBUILD_COPY_FROM_IMAGE_ID           = "<XBUILD_COPY_FROM_IMAGE_ID>"
+		replace_XBUILD_COPY_FROM_CONTAINER_ID       = "<XBUILD_COPY_FROM_CONTAINER_ID>"
+	)
+
+	// source files are staged in the local dir when filtering or altering is required
+	var (
+		stageKey = "copy-stage-" + hex.EncodeToString(md5helper.Sum([]byte(s.From.String()+"\x00"+s.To.Path)))
+
+		// stageDir is created for each execution on first use, matrix entries
+		// share the same cache dir and can run in parallel
+		stageDir    string
+		getStageDir = func() (string, error) {
+			if len(stageDir) != 0 {
+				return stageDir, nil
+			}
+
+			cacheDir, err := cacheFS.Abs(".")
+			if err != nil {
+				return "", err
+			}
+
+			err = os.MkdirAll(cacheDir, 0755)
+			if err != nil {
+				return "", fmt.Errorf("ensure cache dir: %w", err)
+			}
+
+			stageDir, err = os.MkdirTemp(cacheDir, stageKey+"-*")
+			if err != nil {
+				return "", fmt.Errorf("create staging dir: %w", err)
+			}
+
+			return stageDir, nil
+		}
+
+		// getStageSrc returns local path and name for matching of the copy source
+		getStageSrc func(replace dukkha.ReplaceEntries) (src, name string, err error)
+	)
+
 	switch {
 	case s.From.Text != nil:
 		data := s.From.Text.Data
 
-		const (
-			replace_XBUILD_COPY_FROM_TEXT_DATA_SRC_PATH = "<XBUILD_COPY_FROM_TEXT_DATA_FILE>"
-		)
-
 		file := "copy-text-" + hex.EncodeToString(md5helper.Sum([]byte(data)))
 
 		steps = append(steps, dukkha.TaskExecSpec{
@@ -78,19 +123,30 @@ func (s *stepCopy) genSpec(
 			},
 		})
 
+		getStageSrc = func(replace dukkha.ReplaceEntries) (string, string, error) {
+			return string(replace[replace_XBUILD_COPY_FROM_TEXT_DATA_SRC_PATH].Data), path.Base(s.To.Path), nil
+		}
+
 		copyCmd = append(copyCmd,
 			replace_XBUILD_CURRENT_CONTAINER_ID,
 			replace_XBUILD_COPY_FROM_TEXT_DATA_SRC_PATH,
 		)
 	case s.From.Local != nil:
-		copyCmd = append(copyCmd, replace_XBUILD_CURRENT_CONTAINER_ID, s.From.Local.Path)
+		localPath := s.From.Local.Path
+		getStageSrc = func(replace dukkha.ReplaceEntries) (string, string, error) {
+			src, err := rc.FS().Abs(localPath)
+			return src, path.Base(filepath.ToSlash(localPath)), err
+		}
+
+		copyCmd = append(copyCmd, replace_XBUILD_CURRENT_CONTAINER_ID, localPath)
 	case s.From.HTTP != nil:
+		if staging {
+			return nil, fmt.Errorf("include/exclude filters and chmod/chown with match/ignore are not supported for http source")
+		}
+
 		copyCmd = append(copyCmd, replace_XBUILD_CURRENT_CONTAINER_ID, s.From.HTTP.URL)
 	case s.From.Image != nil:
 		from := *s.From.Image
-		const (
-			replace_XBUILD_COPY_FROM_IMAGE_ID = "<XBUILD_COPY_FROM_IMAGE_ID>"
-		)
 
 		pullCmd := []string{constant.DUKKHA_TOOL_CMD, "pull"}
 		pullCmd = append(pullCmd, generatePlatformArgs(from.Kernel, from.Arch)...)
@@ -106,6 +162,35 @@ func (s *stepCopy) genSpec(
 			Command:     pullCmd,
 		})
 
+		if staging {
+			// a working container is required to mount the image
+			steps = append(steps, dukkha.TaskExecSpec{
+				StdoutAsReplace:          replace_XBUILD_COPY_FROM_CONTAINER_ID,
+				FixStdoutValueForReplace: bytes.TrimSpace,
+
+				ShowStdout:  true,
+				IgnoreError: false,
+				Command: []string{
+					constant.DUKKHA_TOOL_CMD, "from", "--pull-never",
+					replace_XBUILD_COPY_FROM_IMAGE_ID,
+				},
+			})
+
+			stageSpecs, getSrc := s.genContainerStageSpecs(
+				getStageDir, replace_XBUILD_COPY_FROM_CONTAINER_ID, from.Path,
+			)
+
+			steps = append(steps, stageSpecs...)
+			steps = append(steps, dukkha.TaskExecSpec{
+				IgnoreError: true,
+				Command: []string{
+					constant.DUKKHA_TOOL_CMD, "rm", replace_XBUILD_COPY_FROM_CONTAINER_ID,
+				},
+			})
+
+			getStageSrc = getSrc
+		}
+
 		copyCmd = append(
 			copyCmd,
 			"--from", replace_XBUILD_COPY_FROM_IMAGE_ID,
@@ -115,6 +200,15 @@ func (s *stepCopy) genSpec(
 	case s.From.Step != nil:
 		from := *s.From.Step
 
+		if staging {
+			stageSpecs, getSrc := s.genContainerStageSpecs(
+				getStageDir, replace_XBUILD_STEP_CONTAINER_ID(from.ID), from.Path,
+			)
+
+			steps = append(steps, stageSpecs...)
+			getStageSrc = getSrc
+		}
+
 		copyCmd = append(
 			copyCmd,
 			"--from", replace_XBUILD_STEP_CONTAINER_ID(from.ID),
@@ -125,6 +219,65 @@ func (s *stepCopy) genSpec(
 		return nil, fmt.Errorf("invalid no copy source specified")
 	}
 
+	if staging {
+		baseCopyCmd := []string{constant.DUKKHA_TOOL_CMD, "copy"}
+		if record {
+			baseCopyCmd = append(baseCopyCmd, "--add-history")
+		}
+		baseCopyCmd = append(baseCopyCmd, s.ExtraArgs...)
+
+		return append(steps, dukkha.TaskExecSpec{
+			AlterExecFunc: func(
+				replace dukkha.ReplaceEntries,
+				stdin io.Reader,
+				stdout, stderr io.Writer,
+			) (dukkha.RunTaskOrRunCmd, error) {
+				src, name, err := getStageSrc(replace)
+				if err != nil {
+					return nil, err
+				}
+
+				dir, err := getStageDir()
+				if err != nil {
+					return nil, err
+				}
+
+				groups, err := s.stage(src, name, filepath.Join(dir, "dst"))
+				if err != nil {
+					return nil, err
+				}
+
+				var ret []dukkha.TaskExecSpec
+				for _, g := range groups {
+					cmd := sliceutils.NewStrings(baseCopyCmd)
+					if len(g.chown) != 0 {
+						cmd = append(cmd, "--chown", g.chown)
+					}
+
+					cmd = append(cmd, replace_XBUILD_CURRENT_CONTAINER_ID, g.path)
+					if len(s.To.Path) != 0 {
+						cmd = append(cmd, s.To.Path)
+					}
+
+					ret = append(ret, dukkha.TaskExecSpec{
+						IgnoreError: false,
+						Command:     cmd,
+					})
+				}
+
+				return append(ret, dukkha.TaskExecSpec{
+					AlterExecFunc: func(
+						replace dukkha.ReplaceEntries,
+						stdin io.Reader,
+						stdout, stderr io.Writer,
+					) (dukkha.RunTaskOrRunCmd, error) {
+						return nil, os.RemoveAll(dir)
+					},
+				}), nil
+			},
+		}), nil
+	}
+
 	// if path not set, will copy to workingdir
 	if len(s.To.Path) != 0 {
 		copyCmd = append(copyCmd, s.To.Path)
@@ -138,6 +291,52 @@ func (s *stepCopy) genSpec(
 	return steps, nil
 }
 
+// genContainerStageSpecs generates specs to copy srcPath in the container to local
+// staging dir, using `buildah unshare --mount` so it works in rootless mode
+func (s *stepCopy) genContainerStageSpecs(
+	getStageDir func() (string, error),
+	containerID, srcPath string,
+) (
+	[]dukkha.TaskExecSpec,
+	func(replace dukkha.ReplaceEntries) (src, name string, err error),
+) {
+	const script = `rm -rf "$1" && mkdir -p "$1" && ` +
+		`cp -RP --preserve=mode,timestamps "${XBUILD_COPY_SRC}/$2" "$1/src"`
+
+	stageSpec := dukkha.TaskExecSpec{
+		AlterExecFunc: func(
+			replace dukkha.ReplaceEntries,
+			stdin io.Reader,
+			stdout, stderr io.Writer,
+		) (dukkha.RunTaskOrRunCmd, error) {
+			dir, err := getStageDir()
+			if err != nil {
+				return nil, err
+			}
+
+			return []dukkha.TaskExecSpec{{
+				IgnoreError: false,
+				Command: []string{
+					constant.DUKKHA_TOOL_CMD, "unshare",
+					"--mount", "XBUILD_COPY_SRC=" + containerID,
+					"--", "sh", "-c", script, "sh", filepath.Join(dir, "src"), srcPath,
+				},
+			}}, nil
+		},
+	}
+
+	getSrc := func(replace dukkha.ReplaceEntries) (string, string, error) {
+		dir, err := getStageDir()
+		if err != nil {
+			return "", "", err
+		}
+
+		return filepath.Join(dir, "src", "src"), path.Base(srcPath), nil
+	}
+
+	return []dukkha.TaskExecSpec{stageSpec}, getSrc
+}
+
 type copyFromSpec struct {
 	rs.BaseField `yaml:"-"`
 
@@ -146,6 +345,31 @@ type copyFromSpec struct {
 	HTTP  *copyFromHTTPSpec  `yaml:"http"`
 	Image *copyFromImageSpec `yaml:"image"`
 	Step  *copyFromStepSpec  `yaml:"step"`
+
+	// Include glob patterns of files to copy (relative to the source path),
+	// defaults to all files
+	Include []string `yaml:"include"`
+
+	// Exclude glob patterns of files not to copy (relative to the source path)
+	Exclude []string `yaml:"exclude"`
+}
+
+// String returns a description of the copy source
+func (s *copyFromSpec) String() string {
+	switch {
+	case s.Text != nil:
+		return "text:" + s.Text.Data
+	case s.Local != nil:
+		return "local:" + s.Local.Path
+	case s.HTTP != nil:
+		return "http:" + s.HTTP.URL
+	case s.Image != nil:
+		return "image:" + s.Image.Ref + ":" + s.Image.Path
+	case s.Step != nil:
+		return "step:" + s.Step.ID + ":" + s.Step.Path
+	default:
+		return ""
+	}
 }
 
 type copyFromTextSpec struct {
@@ -193,39 +417,9 @@ type copyToSpec struct {
 
 	Path string `yaml:"path"`
 
-	// TODO: implement
-	// Chmod []chmodSpec `yaml:"chmod"`
-	// Chown []chownSpec `yaml:"chown"`
-}
+	// Chmod rules applied to copied files in order
+	Chmod []chmodSpec `yaml:"chmod"`
 
-// type chmodSpec struct {
-// 	rs.BaseField `yaml:"-"`
-//
-// 	// Match glob pattern to match files
-// 	Match string `yaml:"match"`
-//
-// 	// Ignore glob pattern to ignore files
-// 	Ignore string `yaml:"ignore"`
-//
-// 	// Value for chmod (e.g. a+x, 0755)
-// 	Value string `yaml:"value"`
-//
-// 	// Recursive run chmod on matched files
-// 	Recursive bool `yaml:"recursive"`
-// }
-
-// type chownSpec struct {
-// 	rs.BaseField `yaml:"-"`
-//
-// 	// Match glob pattern to match files
-// 	Match string `yaml:"match"`
-//
-// 	// Ignore glob pattern to ignore files
-// 	Ignore string `yaml:"ignore"`
-//
-// 	// Value for chown (e.g. user:group, user, uid, uid:gid)
-// 	Value string `yaml:"value"`
-//
-// 	// Recursive run chown on matched files
-// 	Recursive bool `yaml:"recursive"`
-// }
+	// Chown rules, the last matched rule wins
+	Chown []chownSpec `yaml:"chown"`
+}
@@ -0,0 +1,501 @@
+package buildah
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"arhat.dev/rs"
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+type chmodSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	// Match glob pattern to match files
+	Match string `yaml:"match"`
+
+	// Ignore glob pattern to ignore files
+	Ignore string `yaml:"ignore"`
+
+	// Value for chmod (e.g. a+x, 0755)
+	Value string `yaml:"value"`
+
+	// Recursive run chmod on matched files
+	Recursive bool `yaml:"recursive"`
+}
+
+type chownSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	// Match glob pattern to match files
+	Match string `yaml:"match"`
+
+	// Ignore glob pattern to ignore files
+	Ignore string `yaml:"ignore"`
+
+	// Value for chown (e.g. user:group, user, uid, uid:gid)
+	Value string `yaml:"value"`
+
+	// Recursive run chown on matched files
+	Recursive bool `yaml:"recursive"`
+}
+
+// fileMatcher matches path relative to the copy source with glob patterns
+type fileMatcher struct {
+	match     string
+	ignore    string
+	recursive bool
+}
+
+// matches checks whether name (slash separated path relative to copy source) is
+// matched
+//
+// when recursive is set, name is also matched when any of its parent dirs matched
+func (m fileMatcher) matches(name string) bool {
+	if len(m.ignore) != 0 && matchGlob(m.ignore, name) {
+		return false
+	}
+
+	if len(m.match) == 0 || matchGlob(m.match, name) {
+		return true
+	}
+
+	if !m.recursive {
+		return false
+	}
+
+	for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if len(m.ignore) != 0 && matchGlob(m.ignore, dir) {
+			return false
+		}
+
+		if matchGlob(m.match, dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchGlob(pattern, name string) bool {
+	ok, _ := doublestar.Match(strings.TrimPrefix(pattern, "/"), name)
+	return ok
+}
+
+// needStaging returns true when files need to be filtered or altered on the
+// host before copying, otherwise chown and chmod can be done by buildah copy
+func (s *stepCopy) needStaging() bool {
+	if len(s.From.Include) != 0 || len(s.From.Exclude) != 0 {
+		return true
+	}
+
+	for _, c := range s.To.Chown {
+		if len(c.Match) != 0 || len(c.Ignore) != 0 {
+			return true
+		}
+	}
+
+	for _, c := range s.To.Chmod {
+		if len(c.Match) != 0 || len(c.Ignore) != 0 || !isOctalMode(c.Value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getCopyFlags returns buildah copy flags for chown and chmod applying to
+// all files
+func (s *stepCopy) getCopyFlags() []string {
+	var ret []string
+	if n := len(s.To.Chown); n != 0 {
+		ret = append(ret, "--chown", s.To.Chown[n-1].Value)
+	}
+
+	if n := len(s.To.Chmod); n != 0 {
+		ret = append(ret, "--chmod", s.To.Chmod[n-1].Value)
+	}
+
+	return ret
+}
+
+// getChown returns the chown value of the last matched chown rule
+func (s *stepCopy) getChown(name string) string {
+	var ret string
+	for _, c := range s.To.Chown {
+		m := fileMatcher{match: c.Match, ignore: c.Ignore, recursive: c.Recursive}
+		if m.matches(name) {
+			ret = c.Value
+		}
+	}
+
+	return ret
+}
+
+// applyChmod applies all matched chmod rules to mode in order
+func (s *stepCopy) applyChmod(name string, mode fs.FileMode) (fs.FileMode, error) {
+	for _, c := range s.To.Chmod {
+		m := fileMatcher{match: c.Match, ignore: c.Ignore, recursive: c.Recursive}
+		if !m.matches(name) {
+			continue
+		}
+
+		var err error
+		mode, err = chmod(mode, c.Value)
+		if err != nil {
+			return mode, err
+		}
+	}
+
+	return mode, nil
+}
+
+// included checks include and exclude filters for a file
+func (s *stepCopy) included(name string) bool {
+	for _, p := range s.From.Exclude {
+		if matchGlob(p, name) {
+			return false
+		}
+	}
+
+	if len(s.From.Include) == 0 {
+		return true
+	}
+
+	for _, p := range s.From.Include {
+		if matchGlob(p, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// excludedDir checks whether a dir and all its content is excluded
+func (s *stepCopy) excludedDir(name string) bool {
+	for _, p := range s.From.Exclude {
+		if matchGlob(p, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// copyGroup is a staged dir with files sharing the same owner
+type copyGroup struct {
+	chown string
+	path  string
+}
+
+// stage copies included files in src to stageDir with chmod applied, grouped by
+// chown value, returns paths to be copied to the container in order
+//
+// when src is a single file, name is used for matching
+func (s *stepCopy) stage(src, name, stageDir string) ([]copyGroup, error) {
+	err := os.RemoveAll(stageDir)
+	if err != nil {
+		return nil, fmt.Errorf("cleanup staging dir: %w", err)
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, fmt.Errorf("check copy source: %w", err)
+	}
+
+	var (
+		groups   []copyGroup
+		groupIdx = make(map[string]int)
+	)
+
+	// getGroupDir ensures group dir for chown value exists
+	getGroupDir := func(chown string) (string, error) {
+		if i, ok := groupIdx[chown]; ok {
+			return groups[i].path, nil
+		}
+
+		dir := filepath.Join(stageDir, strconv.Itoa(len(groups)))
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			return "", err
+		}
+
+		groupIdx[chown] = len(groups)
+		groups = append(groups, copyGroup{chown: chown, path: dir})
+		return dir, nil
+	}
+
+	if !info.IsDir() {
+		if !s.included(name) {
+			return nil, nil
+		}
+
+		dir, err := getGroupDir(s.getChown(name))
+		if err != nil {
+			return nil, err
+		}
+
+		dst := filepath.Join(dir, filepath.Base(src))
+		err = s.stageEntry(src, dst, name, info)
+		if err != nil {
+			return nil, err
+		}
+
+		groups[0].path = dst
+		return groups, nil
+	}
+
+	err = filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if p == src {
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if s.excludedDir(rel) {
+				return filepath.SkipDir
+			}
+
+			// dirs are created when there are files included
+			if len(s.From.Include) != 0 && !s.included(rel) {
+				return nil
+			}
+		} else if !s.included(rel) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		dir, err := getGroupDir(s.getChown(rel))
+		if err != nil {
+			return err
+		}
+
+		return s.stageEntry(p, filepath.Join(dir, filepath.FromSlash(rel)), rel, info)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("staging copy source: %w", err)
+	}
+
+	// created parent dirs have the same mode as in source
+	for _, g := range groups {
+		err = filepath.WalkDir(g.path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() || p == g.path {
+				return err
+			}
+
+			rel, err := filepath.Rel(g.path, p)
+			if err != nil {
+				return err
+			}
+
+			info, err := os.Stat(filepath.Join(src, rel))
+			if err != nil {
+				return err
+			}
+
+			mode, err := s.applyChmod(filepath.ToSlash(rel), info.Mode())
+			if err != nil {
+				return err
+			}
+
+			return os.Chmod(p, mode|0700)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("staging dirs: %w", err)
+		}
+	}
+
+	return groups, nil
+}
+
+// stageEntry copies one file, symlink or dir from src to dst
+func (s *stepCopy) stageEntry(src, dst, name string, info fs.FileInfo) error {
+	err := os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+
+		return os.Symlink(target, dst)
+	case info.IsDir():
+		// mode of dirs are fixed after all files copied
+		return os.MkdirAll(dst, 0755)
+	case !info.Mode().IsRegular():
+		return fmt.Errorf("unsupported non regular file %q", name)
+	}
+
+	mode, err := s.applyChmod(name, info.Mode())
+	if err != nil {
+		return err
+	}
+
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	w, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = w.Close() }()
+
+	_, err = io.Copy(w, r)
+	if err != nil {
+		return err
+	}
+
+	return os.Chmod(dst, mode)
+}
+
+func isOctalMode(value string) bool {
+	if len(value) == 0 || len(value) > 4 {
+		return false
+	}
+
+	_, err := strconv.ParseUint(value, 8, 32)
+	return err == nil
+}
+
+// chmod applies chmod value (octal or symbolic) to mode
+//
+// symbolic mode is a comma separated list of `[ugoa]*([-+=][rwxXst]*)+`
+func chmod(mode fs.FileMode, value string) (fs.FileMode, error) {
+	if isOctalMode(value) {
+		v, _ := strconv.ParseUint(value, 8, 32)
+		return mode&^(fs.ModePerm|fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky) | unixToFileMode(uint32(v)), nil
+	}
+
+	bits := fileModeToUnix(mode)
+	for _, clause := range strings.Split(value, ",") {
+		i := strings.IndexAny(clause, "+-=")
+		if i < 0 {
+			return mode, fmt.Errorf("invalid chmod value %q", value)
+		}
+
+		var who uint32
+		for _, c := range clause[:i] {
+			switch c {
+			case 'u':
+				who |= 04700
+			case 'g':
+				who |= 02070
+			case 'o':
+				who |= 01007
+			case 'a':
+				who |= 07777
+			default:
+				return mode, fmt.Errorf("invalid chmod value %q", value)
+			}
+		}
+
+		if who == 0 {
+			who = 07777
+		}
+
+		for ops := clause[i:]; len(ops) != 0; {
+			op := ops[0]
+			j := strings.IndexAny(ops[1:], "+-=")
+			var perms string
+			if j < 0 {
+				perms, ops = ops[1:], ""
+			} else {
+				perms, ops = ops[1:j+1], ops[j+1:]
+			}
+
+			var v uint32
+			for _, p := range perms {
+				switch p {
+				case 'r':
+					v |= 0444
+				case 'w':
+					v |= 0222
+				case 'x':
+					v |= 0111
+				case 'X':
+					if mode.IsDir() || bits&0111 != 0 {
+						v |= 0111
+					}
+				case 's':
+					v |= 06000
+				case 't':
+					v |= 01000
+				default:
+					return mode, fmt.Errorf("invalid chmod value %q", value)
+				}
+			}
+
+			v &= who
+			switch op {
+			case '+':
+				bits |= v
+			case '-':
+				bits &^= v
+			case '=':
+				bits = bits&^(who&0777) | v
+			}
+		}
+	}
+
+	return mode&^(fs.ModePerm|fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky) | unixToFileMode(bits), nil
+}
+
+func fileModeToUnix(mode fs.FileMode) uint32 {
+	ret := uint32(mode.Perm())
+	if mode&fs.ModeSetuid != 0 {
+		ret |= 04000
+	}
+
+	if mode&fs.ModeSetgid != 0 {
+		ret |= 02000
+	}
+
+	if mode&fs.ModeSticky != 0 {
+		ret |= 01000
+	}
+
+	return ret
+}
+
+func unixToFileMode(bits uint32) fs.FileMode {
+	ret := fs.FileMode(bits & 0777)
+	if bits&04000 != 0 {
+		ret |= fs.ModeSetuid
+	}
+
+	if bits&02000 != 0 {
+		ret |= fs.ModeSetgid
+	}
+
+	if bits&01000 != 0 {
+		ret |= fs.ModeSticky
+	}
+
+	return ret
+}
@@ -2,6 +2,9 @@ package buildah
 
 import (
 	"context"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -102,6 +105,30 @@ func TestStepCopy_genSpec(t *testing.T) {
 		// 				},
 		// 			},
 		// 		},
+		{
+			name: "From local To bar With chown and chmod",
+			spec: &stepCopy{
+				From: copyFromSpec{
+					Local: &copyFromLocalSpec{
+						Path: "/foo",
+					},
+				},
+				To: copyToSpec{
+					Path:  "/bar",
+					Chmod: []chmodSpec{{Value: "0755"}},
+					Chown: []chownSpec{{Value: "nobody:nobody"}},
+				},
+			},
+			expected: []dukkha.TaskExecSpec{
+				{
+					Command: []string{
+						constant.DUKKHA_TOOL_CMD, "copy",
+						"--chown", "nobody:nobody", "--chmod", "0755",
+						replace_XBUILD_CURRENT_CONTAINER_ID, "/foo", "/bar",
+					},
+				},
+			},
+		},
 		{
 			name: "From step To bar",
 			spec: &stepCopy{
@@ -137,3 +164,143 @@ func TestStepCopy_genSpec(t *testing.T) {
 		})
 	}
 }
+
+func TestStepCopy_stage(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	for name, mode := range map[string]os.FileMode{
+		"bin/foo":         0644,
+		"bin/bar":         0644,
+		"etc/foo.conf":    0644,
+		"etc/ignored.tmp": 0644,
+		"docs/README":     0644,
+	} {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		assert.NoError(t, os.WriteFile(p, []byte(name), mode))
+	}
+
+	spec := &stepCopy{
+		From: copyFromSpec{
+			Local:   &copyFromLocalSpec{Path: src},
+			Exclude: []string{"docs", "**/*.tmp"},
+		},
+		To: copyToSpec{
+			Path: "/app",
+			Chmod: []chmodSpec{
+				{Match: "bin", Recursive: true, Value: "a+x"},
+				{Match: "bin/bar", Value: "u+s,o-r"},
+			},
+			Chown: []chownSpec{
+				{Value: "root:root"},
+				{Match: "etc/*", Value: "nobody"},
+			},
+		},
+	}
+
+	ctx := dt.NewTestContext(context.TODO(), t.TempDir())
+	cacheFS := ctx.GlobalCacheFS("")
+
+	specs, err := spec.genSpec(ctx, cacheFS, false)
+	if !assert.NoError(t, err) || !assert.Len(t, specs, 1) {
+		return
+	}
+
+	ret, err := specs[0].AlterExecFunc(dukkha.ReplaceEntries{}, nil, io.Discard, io.Discard)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// copy specs for each group followed by a cleanup spec
+	copySpecs := ret.([]dukkha.TaskExecSpec)
+	if !assert.Len(t, copySpecs, 3) || !assert.NotNil(t, copySpecs[2].AlterExecFunc) {
+		return
+	}
+	cleanup := copySpecs[2]
+	copySpecs = copySpecs[:2]
+
+	var groups []string
+	for _, s := range copySpecs {
+		cmd := s.Command
+		groups = append(groups, cmd[len(cmd)-2])
+		assert.Equal(t, "/app", cmd[len(cmd)-1])
+	}
+
+	assert.Equal(t, []string{
+		constant.DUKKHA_TOOL_CMD, "copy", "--chown", "root:root", replace_XBUILD_CURRENT_CONTAINER_ID,
+	}, copySpecs[0].Command[:5])
+	assert.Equal(t, []string{
+		constant.DUKKHA_TOOL_CMD, "copy", "--chown", "nobody", replace_XBUILD_CURRENT_CONTAINER_ID,
+	}, copySpecs[1].Command[:5])
+
+	checkMode := func(group, name string, expected os.FileMode) {
+		info, err := os.Stat(filepath.Join(group, filepath.FromSlash(name)))
+		if assert.NoError(t, err, name) {
+			assert.Equal(t, expected, info.Mode()&(os.ModePerm|os.ModeSetuid), name)
+		}
+	}
+
+	checkMode(groups[0], "bin/foo", 0755)
+	checkMode(groups[0], "bin/bar", 0751|os.ModeSetuid)
+	checkMode(groups[1], "etc/foo.conf", 0644)
+
+	for _, name := range []string{"docs", "etc/ignored.tmp", "etc/foo.conf"} {
+		_, err = os.Stat(filepath.Join(groups[0], filepath.FromSlash(name)))
+		assert.ErrorIs(t, err, os.ErrNotExist, name)
+	}
+
+	// another execution (e.g. matrix entry running in parallel) of the same
+	// step MUST NOT share the staging dir
+	otherSpecs, err := spec.genSpec(ctx, cacheFS, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ret, err = otherSpecs[0].AlterExecFunc(dukkha.ReplaceEntries{}, nil, io.Discard, io.Discard)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	otherGroup := ret.([]dukkha.TaskExecSpec)[0].Command
+	assert.NotEqual(t, groups[0], otherGroup[len(otherGroup)-2])
+	checkMode(groups[0], "bin/foo", 0755)
+
+	_, err = cleanup.AlterExecFunc(dukkha.ReplaceEntries{}, nil, io.Discard, io.Discard)
+	assert.NoError(t, err)
+	_, err = os.Stat(groups[0])
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	checkMode(otherGroup[len(otherGroup)-2], "bin/foo", 0755)
+}
+
+func TestChmod(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		mode     os.FileMode
+		value    string
+		expected os.FileMode
+	}{
+		{0644, "0755", 0755},
+		{0644, "4755", 0755 | os.ModeSetuid},
+		{0644, "+x", 0755},
+		{0644, "a+x", 0755},
+		{0644, "u+x,g-r", 0704},
+		{0755, "go=", 0700},
+		{0640, "o=r", 0644},
+		{0644, "a+X", 0644},
+		{0744, "a+X", 0755},
+		{os.ModeDir | 0644, "a+X", os.ModeDir | 0755},
+		{0644, "u+x-w", 0544},
+		{0755, "+t", 0755 | os.ModeSticky},
+	} {
+		actual, err := chmod(test.mode, test.value)
+		assert.NoError(t, err, test.value)
+		assert.Equal(t, test.expected, actual, test.value)
+	}
+
+	for _, invalid := range []string{"", "u", "z+x", "u+q"} {
+		_, err := chmod(0644, invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -123,28 +123,42 @@ func (s *stepFrom) genSpec(
 func generatePlatformArgs(kernel, arch string) []string {
 	var platformArgs []string
 
+	ociOS, ociArch, ociVariant := GetOciPlatform(kernel, arch)
+	if len(ociOS) != 0 {
+		platformArgs = append(platformArgs, "--os", ociOS)
+	}
+
+	if len(ociArch) != 0 {
+		platformArgs = append(platformArgs, "--arch", ociArch)
+	}
+
+	if len(ociVariant) != 0 {
+		platformArgs = append(platformArgs, "--variant", ociVariant)
+	}
+
+	return platformArgs
+}
+
+// GetOciPlatform converts dukkha kernel and arch values to oci os, arch and variant
+//
+// unknown kernel and arch values are returned as is
+func GetOciPlatform(kernel, arch string) (ociOS, ociArch, ociVariant string) {
+	var ok bool
 	if len(kernel) != 0 {
-		ociOS, ok := constant.GetOciOS(kernel)
+		ociOS, ok = constant.GetOciOS(kernel)
 		if !ok {
 			ociOS = kernel
 		}
-
-		platformArgs = append(platformArgs, "--os", ociOS)
 	}
 
 	if len(arch) != 0 {
-		ociArch, ok := constant.GetOciArch(arch)
+		ociArch, ok = constant.GetOciArch(arch)
 		if !ok {
 			ociArch = arch
 		}
 
-		platformArgs = append(platformArgs, "--arch", ociArch)
-
-		ociVariant, ok := constant.GetOciArchVariant(arch)
-		if ok && len(ociVariant) != 0 {
-			platformArgs = append(platformArgs, "--variant", ociVariant)
-		}
+		ociVariant, _ = constant.GetOciArchVariant(arch)
 	}
 
-	return platformArgs
+	return
 }
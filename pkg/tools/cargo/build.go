@@ -0,0 +1,178 @@
+package cargo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"arhat.dev/pkg/fshelper"
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+// getTarget returns the rust target triple for current matrix
+//
+// when override is set, it's returned as is, when there is no kernel and arch
+// in the matrix, returns empty string to build for the host
+func getTarget(v dukkha.EnvValues, override string) (string, error) {
+	if len(override) != 0 {
+		return override, nil
+	}
+
+	mKernel, mArch := v.MatrixKernel(), v.MatrixArch()
+	if len(mKernel) == 0 && len(mArch) == 0 {
+		return "", nil
+	}
+
+	if len(mKernel) == 0 {
+		mKernel = v.HostKernel()
+	}
+
+	if len(mArch) == 0 {
+		mArch = v.HostArch()
+	}
+
+	target, ok := constant.GetRustTripleName(mArch, mKernel, v.MatrixLibc())
+	if !ok {
+		return "", fmt.Errorf(
+			"no rust target for kernel=%s, arch=%s, libc=%s, please set `target` explicitly",
+			mKernel, mArch, v.MatrixLibc(),
+		)
+	}
+
+	return target, nil
+}
+
+// getProfileDir returns the dir name of the profile in cargo target dir
+//
+// ref: https://doc.rust-lang.org/cargo/reference/profiles.html#custom-profiles
+func getProfileDir(profile string) string {
+	switch profile {
+	case "", "dev", "test":
+		return "debug"
+	case "release", "bench":
+		return "release"
+	default:
+		return profile
+	}
+}
+
+type buildOptions struct {
+	rs.BaseField `yaml:"-"`
+
+	// Target (--target) rust target triple, defaults to the triple of
+	// matrix kernel, arch and libc
+	Target string `yaml:"target"`
+
+	// Features (--features) to activate
+	Features []string `yaml:"features"`
+
+	// AllFeatures (--all-features)
+	AllFeatures bool `yaml:"all_features"`
+
+	// NoDefaultFeatures (--no-default-features)
+	NoDefaultFeatures bool `yaml:"no_default_features"`
+
+	// Profile (--profile) to build with, defaults to `dev`
+	Profile string `yaml:"profile"`
+
+	// TargetDir (--target-dir) for all generated artifacts, defaults to `target`
+	TargetDir string `yaml:"target_dir"`
+
+	// Linker for the target, set as `CARGO_TARGET_<TRIPLE>_LINKER`
+	Linker string `yaml:"linker"`
+}
+
+func (opts buildOptions) generateArgs(target string) []string {
+	var args []string
+	if len(target) != 0 {
+		args = append(args, "--target", target)
+	}
+
+	if len(opts.Features) != 0 {
+		args = append(args, "--features", strings.Join(opts.Features, ","))
+	}
+
+	if opts.AllFeatures {
+		args = append(args, "--all-features")
+	}
+
+	if opts.NoDefaultFeatures {
+		args = append(args, "--no-default-features")
+	}
+
+	if len(opts.Profile) != 0 {
+		args = append(args, "--profile", opts.Profile)
+	}
+
+	if len(opts.TargetDir) != 0 {
+		args = append(args, "--target-dir", opts.TargetDir)
+	}
+
+	return args
+}
+
+func (opts buildOptions) generateEnv(target string) (env dukkha.NameValueList) {
+	if len(opts.Linker) == 0 || len(target) == 0 {
+		return
+	}
+
+	// ref: https://doc.rust-lang.org/cargo/reference/config.html#targettriplelinker
+	return append(env, &dukkha.NameValueEntry{
+		Name: "CARGO_TARGET_" +
+			strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(target)) +
+			"_LINKER",
+		Value: opts.Linker,
+	})
+}
+
+// getArtifactDir returns the dir containing built artifacts
+func (opts buildOptions) getArtifactDir(target string) string {
+	targetDir := opts.TargetDir
+	if len(targetDir) == 0 {
+		targetDir = "target"
+	}
+
+	// cargo puts artifacts in a target specific dir when `--target` is set
+	return path.Join(targetDir, target, getProfileDir(opts.Profile))
+}
+
+// copyArtifact copies built artifact src to dst in cwdFS
+func copyArtifact(cwdFS *fshelper.OSFS, src, dst string) error {
+	srcFile, err := cwdFS.Open(src)
+	if err != nil {
+		return fmt.Errorf("open built artifact: %w", err)
+	}
+	defer func() { _ = srcFile.Close() }()
+
+	info, err := srcFile.Stat()
+	if err != nil {
+		return fmt.Errorf("check built artifact: %w", err)
+	}
+
+	if dir := path.Dir(dst); dir != "." {
+		err = cwdFS.MkdirAll(dir, 0755)
+		if err != nil && !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("ensure output dir: %w", err)
+		}
+	}
+
+	dstFile, err := cwdFS.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("create output: %w", err)
+	}
+	defer func() { _ = dstFile.Close() }()
+
+	_, err = io.Copy(dstFile.(io.Writer), srcFile)
+	if err != nil {
+		return fmt.Errorf("copy %q to %q: %w", src, dst, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,2 @@
+// Package cargo provides rust toolchain (cargo) support for dukkha
+package cargo
@@ -0,0 +1,126 @@
+package cargo
+
+import (
+	"io"
+	"path"
+	"strings"
+
+	"arhat.dev/pkg/fshelper"
+
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/tools"
+)
+
+const TaskKindBuild = "build"
+
+func init() {
+	dukkha.RegisterTask(ToolKind, TaskKindBuild, tools.NewTask[TaskBuild, *TaskBuild])
+}
+
+type TaskBuild struct {
+	tools.BaseTask[CargoBuild, *CargoBuild]
+}
+
+// nolint:revive
+type CargoBuild struct {
+	// Chdir into a different dir when running cargo command while keep `dukkha.WorkDir` unchanged
+	// this can be helpful when you are managing multiple cargo workspaces in one repo
+	Chdir string `yaml:"chdir"`
+
+	// Package (--package) to build
+	Package string `yaml:"package"`
+
+	// Bin (--bin) is the name of the binary target to build
+	//
+	// it is also the name of the artifact copied to outputs, defaults to `package`,
+	// then task name
+	Bin string `yaml:"bin"`
+
+	// Outputs are paths to copy the built binary to, artifacts are kept in the
+	// target dir when not set
+	Outputs []string `yaml:"outputs"`
+
+	BuildOptions buildOptions `yaml:",inline"`
+
+	// ExtraArgs for cargo build
+	ExtraArgs []string `yaml:"extra_args"`
+
+	parent tools.BaseTaskType
+}
+
+func (c *CargoBuild) ToolKind() dukkha.ToolKind       { return ToolKind }
+func (c *CargoBuild) Kind() dukkha.TaskKind           { return TaskKindBuild }
+func (c *CargoBuild) LinkParent(p tools.BaseTaskType) { c.parent = p }
+
+func (c *CargoBuild) GetExecSpecs(
+	rc dukkha.TaskExecContext, options dukkha.TaskMatrixExecOptions,
+) ([]dukkha.TaskExecSpec, error) {
+	var steps []dukkha.TaskExecSpec
+
+	err := c.parent.DoAfterFieldsResolved(rc, -1, true, func() error {
+		target, err := getTarget(rc, c.BuildOptions.Target)
+		if err != nil {
+			return err
+		}
+
+		cmd := []string{constant.DUKKHA_TOOL_CMD, "build"}
+		if len(c.Package) != 0 {
+			cmd = append(cmd, "--package", c.Package)
+		}
+
+		if len(c.Bin) != 0 {
+			cmd = append(cmd, "--bin", c.Bin)
+		}
+
+		cmd = append(cmd, c.BuildOptions.generateArgs(target)...)
+		cmd = append(cmd, c.ExtraArgs...)
+
+		steps = append(steps, dukkha.TaskExecSpec{
+			Chdir:      c.Chdir,
+			EnvSuggest: c.BuildOptions.generateEnv(target),
+			Command:    cmd,
+		})
+
+		if len(c.Outputs) == 0 {
+			return nil
+		}
+
+		_fs, err := rc.FS().Sub(c.Chdir)
+		if err != nil {
+			return err
+		}
+		cwdFS := _fs.(*fshelper.OSFS)
+
+		artifact := c.Bin
+		switch {
+		case len(artifact) != 0:
+		case len(c.Package) != 0:
+			artifact = c.Package
+		default:
+			artifact = string(c.parent.Name())
+		}
+
+		if strings.Contains(target, "-windows-") ||
+			(len(target) == 0 && rc.HostKernel() == constant.KERNEL_Windows) {
+			artifact += ".exe"
+		}
+
+		src := path.Join(c.BuildOptions.getArtifactDir(target), artifact)
+		for _, output := range c.Outputs {
+			dst := output
+			steps = append(steps, dukkha.TaskExecSpec{
+				AlterExecFunc: func(
+					replace dukkha.ReplaceEntries,
+					stdin io.Reader, stdout, stderr io.Writer,
+				) (dukkha.RunTaskOrRunCmd, error) {
+					return nil, copyArtifact(cwdFS, src, dst)
+				},
+			})
+		}
+
+		return nil
+	})
+
+	return steps, err
+}
@@ -0,0 +1,115 @@
+package cargo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"arhat.dev/pkg/fshelper"
+	"github.com/stretchr/testify/assert"
+
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	dukkha_test "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/tools"
+	"arhat.dev/dukkha/pkg/tools/tests"
+)
+
+func TestTaskBuild_GetExecSpecs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []tests.ExecSpecGenerationTestCase{
+		{
+			Name: "Default Build Task",
+			Task: func() dukkha.Task {
+				tsk := tools.NewTask[TaskBuild, *TaskBuild]("").(*TaskBuild)
+				tsk.TaskName = "foo"
+				return tsk
+			}(),
+			Options: dukkha_test.CreateTaskMatrixExecOptions(),
+			Expected: []dukkha.TaskExecSpec{
+				{
+					Command: []string{constant.DUKKHA_TOOL_CMD, "build"},
+				},
+			},
+		},
+		{
+			Name: "Build With Options",
+			Task: func() dukkha.Task {
+				tsk := tools.NewTask[TaskBuild, *TaskBuild]("").(*TaskBuild)
+				tsk.TaskName = "foo"
+				tsk.Impl.Package = "foo"
+				tsk.Impl.Bin = "bar"
+				tsk.Impl.BuildOptions.Target = "aarch64-unknown-linux-musl"
+				tsk.Impl.BuildOptions.Features = []string{"a", "b"}
+				tsk.Impl.BuildOptions.NoDefaultFeatures = true
+				tsk.Impl.BuildOptions.Profile = "release"
+				tsk.Impl.BuildOptions.TargetDir = "build/target"
+				tsk.Impl.BuildOptions.Linker = "clang"
+				return tsk
+			}(),
+			Options: dukkha_test.CreateTaskMatrixExecOptions(),
+			Expected: []dukkha.TaskExecSpec{
+				{
+					EnvSuggest: dukkha.NameValueList{
+						{
+							Name:  "CARGO_TARGET_AARCH64_UNKNOWN_LINUX_MUSL_LINKER",
+							Value: "clang",
+						},
+					},
+					Command: []string{
+						constant.DUKKHA_TOOL_CMD, "build",
+						"--package", "foo",
+						"--bin", "bar",
+						"--target", "aarch64-unknown-linux-musl",
+						"--features", "a,b",
+						"--no-default-features",
+						"--profile", "release",
+						"--target-dir", "build/target",
+					},
+				},
+			},
+		},
+	}
+
+	ctx := dukkha_test.NewTestContext(context.TODO(), t.TempDir())
+
+	tests.RunTaskExecSpecGenerationTests(t, ctx, testCases)
+}
+
+func TestBuildOptions_getArtifactDir(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		opts     buildOptions
+		target   string
+		expected string
+	}{
+		{buildOptions{}, "", "target/debug"},
+		{buildOptions{Profile: "release"}, "x86_64-unknown-linux-gnu", "target/x86_64-unknown-linux-gnu/release"},
+		{buildOptions{Profile: "dist", TargetDir: "out"}, "aarch64-apple-darwin", "out/aarch64-apple-darwin/dist"},
+	} {
+		assert.Equal(t, test.expected, test.opts.getArtifactDir(test.target))
+	}
+}
+
+func TestCopyArtifact(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cwdFS := fshelper.NewOSFS(false, func(op fshelper.Op, name string) (string, error) {
+		return dir, nil
+	})
+
+	assert.NoError(t, cwdFS.MkdirAll("target/release", 0755))
+	assert.NoError(t, cwdFS.WriteFile("target/release/foo", []byte("foo"), 0755))
+
+	assert.NoError(t, copyArtifact(cwdFS, "target/release/foo", "build/foo"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "build", "foo"))
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(data))
+
+	assert.Error(t, copyArtifact(cwdFS, "target/release/bar", "build/bar"))
+}
@@ -0,0 +1,83 @@
+package cargo
+
+import (
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/tools"
+)
+
+const TaskKindTest = "test"
+
+func init() {
+	dukkha.RegisterTask(ToolKind, TaskKindTest, tools.NewTask[TaskTest, *TaskTest])
+}
+
+type TaskTest struct {
+	tools.BaseTask[CargoTest, *CargoTest]
+}
+
+// nolint:revive
+type CargoTest struct {
+	// Chdir into a different dir when running cargo command while keep `dukkha.WorkDir` unchanged
+	Chdir string `yaml:"chdir"`
+
+	// Package (--package) to test
+	Package string `yaml:"package"`
+
+	// Workspace (--workspace) tests all packages in the workspace
+	Workspace bool `yaml:"workspace"`
+
+	BuildOptions buildOptions `yaml:",inline"`
+
+	// ExtraArgs for cargo test
+	ExtraArgs []string `yaml:"extra_args"`
+
+	// CustomArgs passed to test binaries (after `--`)
+	CustomArgs []string `yaml:"custom_args"`
+
+	parent tools.BaseTaskType
+}
+
+func (c *CargoTest) ToolKind() dukkha.ToolKind       { return ToolKind }
+func (c *CargoTest) Kind() dukkha.TaskKind           { return TaskKindTest }
+func (c *CargoTest) LinkParent(p tools.BaseTaskType) { c.parent = p }
+
+func (c *CargoTest) GetExecSpecs(
+	rc dukkha.TaskExecContext, options dukkha.TaskMatrixExecOptions,
+) ([]dukkha.TaskExecSpec, error) {
+	var steps []dukkha.TaskExecSpec
+
+	err := c.parent.DoAfterFieldsResolved(rc, -1, true, func() error {
+		target, err := getTarget(rc, c.BuildOptions.Target)
+		if err != nil {
+			return err
+		}
+
+		cmd := []string{constant.DUKKHA_TOOL_CMD, "test"}
+		if len(c.Package) != 0 {
+			cmd = append(cmd, "--package", c.Package)
+		}
+
+		if c.Workspace {
+			cmd = append(cmd, "--workspace")
+		}
+
+		cmd = append(cmd, c.BuildOptions.generateArgs(target)...)
+		cmd = append(cmd, c.ExtraArgs...)
+
+		if len(c.CustomArgs) != 0 {
+			cmd = append(cmd, "--")
+			cmd = append(cmd, c.CustomArgs...)
+		}
+
+		steps = append(steps, dukkha.TaskExecSpec{
+			Chdir:      c.Chdir,
+			EnvSuggest: c.BuildOptions.generateEnv(target),
+			Command:    cmd,
+		})
+
+		return nil
+	})
+
+	return steps, err
+}
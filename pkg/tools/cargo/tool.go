@@ -0,0 +1,36 @@
+package cargo
+
+import (
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/tools"
+)
+
+const ToolKind = "cargo"
+
+func init() {
+	dukkha.RegisterTool(ToolKind, func() dukkha.Tool { return &Tool{} })
+}
+
+type Cargo struct{}
+
+func (t *Cargo) DefaultExecutable() string { return "cargo" }
+func (t *Cargo) Kind() dukkha.ToolKind     { return ToolKind }
+
+// GetEnv implements tools.ToolImplWithEnv
+func (t *Cargo) GetEnv(v dukkha.EnvValues) (dukkha.NameValueList, error) {
+	target, err := getTarget(v, "")
+	if err != nil || len(target) == 0 {
+		return nil, err
+	}
+
+	return dukkha.NameValueList{
+		{
+			Name:  "CARGO_BUILD_TARGET",
+			Value: target,
+		},
+	}, nil
+}
+
+type Tool struct {
+	tools.BaseTool[Cargo, *Cargo]
+}
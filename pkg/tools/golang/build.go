@@ -1,6 +1,7 @@
 package golang
 
 import (
+	"fmt"
 	"strings"
 
 	"arhat.dev/pkg/archconst"
@@ -10,7 +11,9 @@ import (
 	"arhat.dev/dukkha/pkg/dukkha"
 )
 
-func createBuildEnv(v dukkha.EnvValues, buildSpec buildOptions, cgoSpec CGOSepc) (env dukkha.NameValueList) {
+func createBuildEnv(v dukkha.EnvValues, buildSpec buildOptions, cgoSpec CGOSepc) (dukkha.NameValueList, error) {
+	var env dukkha.NameValueList
+
 	// set GOOS
 	goos, _ := constant.GetGolangOS(v.MatrixKernel())
 	switch {
@@ -112,13 +115,18 @@ func createBuildEnv(v dukkha.EnvValues, buildSpec buildOptions, cgoSpec CGOSepc)
 		})
 	}
 
-	return append(env, cgoSpec.getEnv(
+	cgoEnv, err := cgoSpec.getEnv(
 		v.HostKernel() != v.MatrixKernel() || v.HostArch() != mArch, /* doing cross compile */
 		v.MatrixKernel(), /* target kernel */
 		mArch,            /* target arch */
 		v.HostOS(),       /* host os */
 		v.MatrixLibc(),   /* target libc */
-	)...)
+	)
+	if err != nil {
+		return nil, fmt.Errorf("generating cgo env: %w", err)
+	}
+
+	return append(env, cgoEnv...), nil
 }
 
 type buildOptions struct {
@@ -42,7 +42,9 @@ func TestCreateBuildEnv(t *testing.T) {
 				Value: archconst.ARCH_S390X,
 			})
 
-			assert.EqualValues(t, expected, createBuildEnv(rc, buildOptions{}, CGOSepc{}))
+			env, err := createBuildEnv(rc, buildOptions{}, CGOSepc{})
+			assert.NoError(t, err)
+			assert.EqualValues(t, expected, env)
 		})
 	}
 
@@ -185,9 +187,141 @@ func TestCreateBuildEnv(t *testing.T) {
 				Value: test.mArch,
 			})
 
-			assert.Equal(t, expected, createBuildEnv(
-				rc, buildOptions{}, CGOSepc{},
-			))
+			env, err := createBuildEnv(rc, buildOptions{}, CGOSepc{})
+			assert.NoError(t, err)
+			assert.Equal(t, expected, env)
+		})
+	}
+}
+
+func TestCGOSpec_getEnv(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		name string
+
+		spec     CGOSepc
+		cross    bool
+		mKernel  string
+		mArch    string
+		hostOS   string
+		mLibc    string
+		expected dukkha.NameValueList
+
+		expectErr bool
+	}{
+		{
+			name:    "Debian Cross",
+			spec:    CGOSepc{Enabled: true},
+			cross:   true,
+			mKernel: constant.KERNEL_Linux,
+			mArch:   archconst.ARCH_ARM64,
+			hostOS:  constant.Platform_Debian,
+			mLibc:   constant.LIBC_GNU,
+			expected: dukkha.NameValueList{
+				{Name: "CGO_ENABLED", Value: "1"},
+				{Name: "CC", Value: "aarch64-linux-gnu-gcc"},
+				{Name: "CXX", Value: "aarch64-linux-gnu-g++"},
+			},
+		},
+		{
+			name:    "Zig Linux Musl",
+			spec:    CGOSepc{Enabled: true, Toolchain: CGOToolchainZig},
+			cross:   true,
+			mKernel: constant.KERNEL_Linux,
+			mArch:   archconst.ARCH_ARM64,
+			hostOS:  constant.Platform_Debian,
+			mLibc:   constant.LIBC_MUSL,
+			expected: dukkha.NameValueList{
+				{Name: "CGO_ENABLED", Value: "1"},
+				{Name: "CGO_LDFLAGS", Value: "-target aarch64-linux-musl"},
+				{Name: "CC", Value: "zig cc -target aarch64-linux-musl"},
+				{Name: "CXX", Value: "zig c++ -target aarch64-linux-musl"},
+			},
+		},
+		{
+			name:    "Zig Darwin Custom",
+			spec:    CGOSepc{Enabled: true, Toolchain: CGOToolchainZig, Zig: "/opt/zig/zig", LDFlags: []string{"-s"}},
+			cross:   true,
+			mKernel: constant.KERNEL_Darwin,
+			mArch:   archconst.ARCH_AMD64,
+			hostOS:  constant.Platform_Alpine,
+			expected: dukkha.NameValueList{
+				{Name: "CGO_ENABLED", Value: "1"},
+				{Name: "CGO_LDFLAGS", Value: "-s"},
+				{Name: "CC", Value: "/opt/zig/zig cc -target x86_64-macos-none"},
+				{Name: "CXX", Value: "/opt/zig/zig c++ -target x86_64-macos-none"},
+			},
+		},
+		{
+			name:    "Zig Native",
+			spec:    CGOSepc{Enabled: true, Toolchain: CGOToolchainZig},
+			mKernel: constant.KERNEL_Linux,
+			mArch:   archconst.ARCH_AMD64,
+			hostOS:  constant.Platform_MacOS,
+			mLibc:   constant.LIBC_GNU,
+			expected: dukkha.NameValueList{
+				{Name: "CGO_ENABLED", Value: "1"},
+				{Name: "CGO_LDFLAGS", Value: "-target x86_64-linux-gnu"},
+				{Name: "CC", Value: "zig cc -target x86_64-linux-gnu"},
+				{Name: "CXX", Value: "zig c++ -target x86_64-linux-gnu"},
+			},
+		},
+		{
+			name:   "Zig No Target",
+			spec:   CGOSepc{Enabled: true, Toolchain: CGOToolchainZig},
+			hostOS: constant.Platform_Debian,
+			expected: dukkha.NameValueList{
+				{Name: "CGO_ENABLED", Value: "1"},
+				{Name: "CC", Value: "zig cc"},
+				{Name: "CXX", Value: "zig c++"},
+			},
+		},
+		{
+			name:      "Zig Unsupported Target",
+			spec:      CGOSepc{Enabled: true, Toolchain: CGOToolchainZig},
+			cross:     true,
+			mKernel:   "foo",
+			mArch:     archconst.ARCH_AMD64,
+			hostOS:    constant.Platform_Debian,
+			expectErr: true,
+		},
+		{
+			name:    "Zig Unsupported Target Custom CC",
+			spec:    CGOSepc{Enabled: true, Toolchain: CGOToolchainZig, CC: "foo-cc", CXX: "foo-c++"},
+			cross:   true,
+			mKernel: "foo",
+			mArch:   archconst.ARCH_AMD64,
+			hostOS:  constant.Platform_Debian,
+			expected: dukkha.NameValueList{
+				{Name: "CGO_ENABLED", Value: "1"},
+				{Name: "CC", Value: "foo-cc"},
+				{Name: "CXX", Value: "foo-c++"},
+			},
+		},
+		{
+			name:      "Unknown Toolchain",
+			spec:      CGOSepc{Enabled: true, Toolchain: "zgi"},
+			mKernel:   constant.KERNEL_Linux,
+			mArch:     archconst.ARCH_AMD64,
+			hostOS:    constant.Platform_Debian,
+			expectErr: true,
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			env, err := test.spec.getEnv(
+				test.cross, test.mKernel, test.mArch, test.hostOS, test.mLibc,
+			)
+			if test.expectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, env)
 		})
 	}
 }
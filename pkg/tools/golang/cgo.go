@@ -1,6 +1,7 @@
 package golang
 
 import (
+	"fmt"
 	"strings"
 
 	"arhat.dev/rs"
@@ -9,12 +10,31 @@ import (
 	"arhat.dev/dukkha/pkg/dukkha"
 )
 
+const (
+	// CGOToolchainSystem uses cross compilers installed in system (e.g.
+	// `aarch64-linux-gnu-gcc` on debian)
+	CGOToolchainSystem = "system"
+
+	// CGOToolchainZig uses `zig cc` and `zig c++` for all targets
+	CGOToolchainZig = "zig"
+)
+
 type CGOSepc struct {
 	rs.BaseField `yaml:"-"`
 
 	// Enable cgo
 	Enabled bool `yaml:"enabled"`
 
+	// Toolchain to generate default CC, CXX and CGO_LDFLAGS, one of [system, zig]
+	//
+	// defaults to `system`
+	Toolchain string `yaml:"toolchain"`
+
+	// Zig sets zig executable path or name when toolchain is `zig`
+	//
+	// defaults to `zig`
+	Zig string `yaml:"zig"`
+
 	// CPPFlags (env CGO_CPPFLAGS) C preprocessor flags
 	CPPFlags []string `yaml:"cppflags"`
 
@@ -43,14 +63,14 @@ type CGOSepc struct {
 func (c CGOSepc) getEnv(
 	doingCrossCompiling bool,
 	mKernel, mArch, hostOS, targetLibc string,
-) dukkha.NameValueList {
+) (dukkha.NameValueList, error) {
 	if !c.Enabled {
 		return dukkha.NameValueList{
 			{
 				Name:  "CGO_ENABLED",
 				Value: "0",
 			},
-		}
+		}, nil
 	}
 
 	var ret dukkha.NameValueList
@@ -97,7 +117,41 @@ func (c CGOSepc) getEnv(
 		cc, cxx string
 	)
 
-	if doingCrossCompiling {
+	switch c.Toolchain {
+	case CGOToolchainZig:
+		// zig works the same for native and cross compiling
+		zig := c.Zig
+		if len(zig) == 0 {
+			zig = "zig"
+		}
+
+		if len(mKernel) == 0 && len(mArch) == 0 {
+			// no target set, build for host
+			cc = zig + " cc"
+			cxx = zig + " c++"
+			break
+		}
+
+		triple, ok := constant.GetZigTripleName(mArch, mKernel, targetLibc)
+		if !ok {
+			if len(c.CC) != 0 && len(c.CXX) != 0 {
+				break
+			}
+
+			return nil, fmt.Errorf(
+				"no zig target for kernel=%s, arch=%s, libc=%s, please set `cc` and `cxx` explicitly",
+				mKernel, mArch, targetLibc,
+			)
+		}
+
+		cc = zig + " cc -target " + triple
+		cxx = zig + " c++ -target " + triple
+		ldflags = []string{"-target", triple}
+	case "", CGOToolchainSystem:
+		if !doingCrossCompiling {
+			break
+		}
+
 		switch hostOS {
 		case constant.Platform_Debian, constant.Platform_Ubuntu:
 			var tripleName string
@@ -135,6 +189,11 @@ func (c CGOSepc) getEnv(
 				// TODO
 			}
 		}
+	default:
+		return nil, fmt.Errorf(
+			"unknown cgo toolchain %q, expecting one of [%s, %s]",
+			c.Toolchain, CGOToolchainSystem, CGOToolchainZig,
+		)
 	}
 
 	// TODO: generate suitable flags
@@ -148,5 +207,5 @@ func (c CGOSepc) getEnv(
 	appendEnv("CXX", c.CXX, cxx)
 	appendEnv("FC", c.FC, "")
 
-	return ret
+	return ret, nil
 }
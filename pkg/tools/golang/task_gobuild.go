@@ -59,7 +59,11 @@ func (c *GolangBuild) GetExecSpecs(
 			outputs = []string{string(c.parent.Name())}
 		}
 
-		buildEnv := createBuildEnv(rc, c.BuildOptions, c.CGo)
+		buildEnv, err := createBuildEnv(rc, c.BuildOptions, c.CGo)
+		if err != nil {
+			return err
+		}
+
 		for _, output := range outputs {
 			spec := &dukkha.TaskExecSpec{
 				Chdir: c.Chdir,
@@ -99,7 +99,10 @@ func (c *GolangTest) GetExecSpecs(
 
 		var compileArgs []string
 
-		buildEnv := createBuildEnv(rc, c.BuildOptions, c.CGO)
+		buildEnv, err := createBuildEnv(rc, c.BuildOptions, c.CGO)
+		if err != nil {
+			return err
+		}
 
 		compileArgs = append(compileArgs, c.BuildOptions.generateArgs()...)
 		compileArgs = append(compileArgs, c.Test.generateArgs(true)...)
@@ -11,11 +11,19 @@ func init() {
 	dukkha.RegisterTool(ToolKind, func() dukkha.Tool { return &Tool{} })
 }
 
-type Golang struct{}
+type Golang struct {
+	// CGo options used when running this tool directly (`dukkha as golang <name>`)
+	CGo CGOSepc `yaml:"cgo"`
+}
 
 func (t *Golang) DefaultExecutable() string { return "go" }
 func (t *Golang) Kind() dukkha.ToolKind     { return ToolKind }
 
+// GetEnv implements tools.ToolImplWithEnv
+func (t *Golang) GetEnv(v dukkha.EnvValues) (dukkha.NameValueList, error) {
+	return createBuildEnv(v, buildOptions{}, t.CGo)
+}
+
 type Tool struct {
 	tools.BaseTool[Golang, *Golang]
 }
@@ -6,6 +6,7 @@ import (
 	"io"
 	"path"
 	"strings"
+	"time"
 
 	"arhat.dev/pkg/exechelper"
 	"arhat.dev/pkg/log"
@@ -109,18 +110,28 @@ func doRun(
 		// 			}()
 		// 		}
 
-		stdout = utils.TermWriter(
+		stdoutW := utils.TermWriter(
 			ctx.OutputPrefix(), ctx.ColorOutput(),
 			ctx.PrefixColor(), ctx.OutputColor(),
+			ctx.Masker(),
 			stdout,
 		)
 
-		stderr = utils.TermWriter(
+		stderrW := utils.TermWriter(
 			ctx.OutputPrefix(), ctx.ColorOutput(),
 			ctx.PrefixColor(), ctx.OutputColor(),
+			ctx.Masker(),
 			stderr,
 		)
 
+		stdout, stderr = stdoutW, stderrW
+
+		// write output not ending with line break
+		flushOutput := func() {
+			_ = stdoutW.Flush()
+			_ = stderrW.Flush()
+		}
+
 		var (
 			stdoutBuf bytes.Buffer
 			stderrBuf bytes.Buffer
@@ -178,6 +189,7 @@ func doRun(
 			ctx.SetState(dukkha.TaskExecWorking)
 
 			subSpecs, err := es.AlterExecFunc(replace, stdin, stdout, stderr)
+			flushOutput()
 			setReplaceEntry(err)
 			if err != nil {
 				ctx.SetState(dukkha.TaskExecFailed)
@@ -288,11 +300,12 @@ func doRun(
 				ctx.PrefixColor(),
 				ctx.CurrentTool(), cmd,
 				path.Base(shellCmd[len(shellCmd)-1])[:7],
+				ctx.Masker(),
 			)
 
 			cmd = shellCmd
 		} else {
-			output.WriteExecStart(ctx.Stdout(), ctx.PrefixColor(), ctx.CurrentTool(), cmd, "")
+			output.WriteExecStart(ctx.Stdout(), ctx.PrefixColor(), ctx.CurrentTool(), cmd, "", ctx.Masker())
 		}
 
 		ctxEnv := ctx.Env()
@@ -302,6 +315,7 @@ func doRun(
 		}
 
 		ctx.SetState(dukkha.TaskExecWorking)
+		startedAt := time.Now()
 		p, err := exechelper.Do(exechelper.Spec{
 			Context: ctx,
 			Command: cmd,
@@ -314,10 +328,15 @@ func doRun(
 			Stderr: stderr,
 		})
 		if err != nil {
+			flushOutput()
+			recordCommand(ctx, cmd, -1, startedAt, err)
+
 			ctx.SetState(dukkha.TaskExecFailed)
 			setReplaceEntry(err)
 			if !es.IgnoreError {
-				return fmt.Errorf("preparing command [ %s ]: %w", strings.Join(cmd, " "), err)
+				return ctx.Masker().MaskError(
+					fmt.Errorf("preparing command [ %s ]: %w", strings.Join(cmd, " "), err),
+				)
 			}
 
 			// TODO: log error in detail
@@ -326,7 +345,9 @@ func doRun(
 			continue
 		}
 
-		_, err = p.Wait()
+		exitCode, err := p.Wait()
+		flushOutput()
+		recordCommand(ctx, cmd, exitCode, startedAt, err)
 		setReplaceEntry(err)
 
 		if err != nil {
@@ -345,3 +366,45 @@ func doRun(
 
 	return nil
 }
+
+// recordCommand records cmd and err to the command recorder of ctx with secret
+// values masked
+func recordCommand(ctx dukkha.TaskExecContext, cmd []string, exitCode int, startedAt time.Time, err error) {
+	r := ctx.CommandRecorder()
+	if r == nil {
+		return
+	}
+
+	masker := ctx.Masker()
+	maskedCmd := make([]string, len(cmd))
+	for i, arg := range cmd {
+		maskedCmd[i] = masker.Mask(arg)
+	}
+
+	r.RecordCommand(maskedCmd, exitCode, startedAt, masker.MaskError(err))
+}
+
+// RunExecSpecs runs execSpecs in ctx synchronously, DUKKHA_TOOL_CMD in commands
+// is replaced with cmd of the tool (removed when tool is nil)
+func RunExecSpecs(
+	ctx dukkha.TaskExecContext,
+	tool dukkha.Tool,
+	execSpecs []dukkha.TaskExecSpec,
+) error {
+	return doRun(ctx, func(rc dukkha.RenderingContext) ([]string, error) {
+		if tool == nil {
+			return nil, nil
+		}
+
+		var ret []string
+		err := tool.DoAfterFieldsResolved(rc, -1, false, func() error {
+			ret = tool.GetCmd()
+			return nil
+		}, "cmd")
+		if err != nil {
+			return nil, err
+		}
+
+		return ret, nil
+	}, execSpecs, nil)
+}
@@ -12,6 +12,7 @@ import (
 	"arhat.dev/dukkha/pkg/dukkha"
 	"arhat.dev/dukkha/pkg/matrix"
 	"arhat.dev/dukkha/pkg/output"
+	"arhat.dev/dukkha/pkg/report"
 )
 
 type TaskExecRequest struct {
@@ -24,6 +25,10 @@ type TaskExecRequest struct {
 
 	// DryRun do not actually run any thing, just evaluate values
 	DryRun bool
+
+	// executions of matrix entries shared by tasks in the same task graph,
+	// when set, matrix entries already claimed are not executed again
+	executions *executionRegistry
 }
 
 // nolint:gocyclo
@@ -35,11 +40,15 @@ func RunTask(req *TaskExecRequest) (err error) {
 
 	var (
 		errCollection []taskResult
+		entryErrors   = make(map[string]error)
 
 		resultMU = &sync.Mutex{}
 	)
 
 	appendErrorResult := func(spec matrix.Entry, err error) {
+		// errors may contain resolved commands
+		err = req.Context.Masker().MaskError(err)
+
 		resultMU.Lock()
 		defer resultMU.Unlock()
 
@@ -48,6 +57,9 @@ func RunTask(req *TaskExecRequest) (err error) {
 		}
 		if spec != nil {
 			res.matrixSpec = spec.BriefString()
+
+			key := spec.String()
+			entryErrors[key] = multierr.Append(entryErrors[key], err)
 		}
 
 		errCollection = append(errCollection, *res)
@@ -80,20 +92,24 @@ func RunTask(req *TaskExecRequest) (err error) {
 
 	req.Context.SetTask(req.Tool.Key(), req.Task.Key())
 
+	taskReport := req.Context.RunReport().StartTask(
+		req.Tool.Key().String(), req.Task.Key().String(),
+	)
+
 	wg := &sync.WaitGroup{}
 
 	unstoppableTaskCtx := req.Context.WithCustomParent(context.Background())
 	// ensure hook `after` always run
 	defer func() {
 		// TODO: handle hook error
-		hookAfter, err2 := req.Task.GetHookExecSpecs(
-			unstoppableTaskCtx, dukkha.StageAfter,
+		hookAfter, err2 := getHookExec(
+			unstoppableTaskCtx, req.Task, dukkha.StageAfter, taskReport,
 		)
 
 		if err2 != nil {
 			appendErrorResult(nil, err2)
 		} else {
-			err2 = doRun(unstoppableTaskCtx, toolCmd, hookAfter, nil)
+			err2 = runHook(unstoppableTaskCtx, toolCmd, hookAfter)
 			if err2 != nil {
 				appendErrorResult(nil, err2)
 			}
@@ -107,18 +123,20 @@ func RunTask(req *TaskExecRequest) (err error) {
 				err = err2
 			}
 		}
+
+		taskReport.Finish(req.Context.Masker().MaskError(err))
 	}()
 
 	// run hook `before`
-	hookBefore, err := req.Task.GetHookExecSpecs(
-		unstoppableTaskCtx, dukkha.StageBefore,
+	hookBefore, err := getHookExec(
+		unstoppableTaskCtx, req.Task, dukkha.StageBefore, taskReport,
 	)
 	if err != nil {
 		// cancel task execution
 		return err
 	}
 
-	err = doRun(unstoppableTaskCtx, toolCmd, hookBefore, nil)
+	err = runHook(unstoppableTaskCtx, toolCmd, hookBefore)
 	if err != nil {
 		// cancel task execution
 		return err
@@ -133,17 +151,52 @@ func RunTask(req *TaskExecRequest) (err error) {
 	}
 
 	if len(matrixSpecs) == 0 {
+		filter := req.Context.MatrixFilter()
+		if _, total := filter.Shard(); total > 1 {
+			// matched entries all belong to other shards
+			return nil
+		}
+
 		// TODO: write warning and ignore error
 		return fmt.Errorf("no matrix spec match")
 	}
 
-	// TODO: do real global limit
-	workerCount := req.Context.ClaimWorkers(len(matrixSpecs))
-	waitCh := make(chan struct{}, workerCount)
-	for i := 0; i < workerCount; i++ {
-		waitCh <- struct{}{}
+	started := make(map[string]struct{})
+	if req.executions != nil {
+		var ownedExecutions, otherExecutions map[string]*matrixExecution
+		matrixSpecs, ownedExecutions, otherExecutions = req.executions.claim(
+			req.Tool.Key(), req.Task.Key(), matrixSpecs,
+		)
+
+		defer func() {
+			// finish owned executions before waiting for others to avoid deadlock
+			for key, exec := range ownedExecutions {
+				resultMU.Lock()
+				err2 := entryErrors[key]
+				resultMU.Unlock()
+
+				if _, ok := started[key]; !ok && err2 == nil {
+					err2 = fmt.Errorf("matrix entry %q not executed", key)
+				}
+
+				exec.finish(err2)
+			}
+
+			for key, exec := range otherExecutions {
+				if exec.wait() != nil {
+					appendErrorResult(nil, fmt.Errorf("matrix entry %q failed in another run", key))
+				}
+			}
+		}()
 	}
 
+	// the caller always holds a worker (as a task graph node, or as a matrix
+	// entry running hooks), reuse it for matrix entries to avoid deadlock
+	// when all workers are taken
+	reservedWorker := make(chan struct{}, 1)
+	reservedWorker <- struct{}{}
+	workers := req.Context.Workers()
+
 	// TODO: alloc real task exec id
 	opts := dukkha.CreateTaskExecOptions(0, len(matrixSpecs))
 matrixRun:
@@ -161,10 +214,9 @@ matrixRun:
 			continue
 		}
 
-		select {
-		case <-mCtx.Done():
+		releaseWorker, ok := workers.Acquire(mCtx.Done(), reservedWorker)
+		if !ok {
 			break matrixRun
-		case <-waitCh:
 		}
 
 		output.WriteTaskStart(
@@ -174,11 +226,15 @@ matrixRun:
 		)
 
 		wg.Add(1)
+		started[ms.String()] = struct{}{}
 
 		unstoppableMatrixCtx := mCtx.WithCustomParent(context.Background())
 		go func(ms matrix.Entry) {
 			var (
 				err3 error
+
+				matrixReport = taskReport.StartMatrix(ms.String())
+				upToDate     bool
 			)
 
 			toolMatrixCmd := func(ctx dukkha.RenderingContext) ([]string, error) {
@@ -196,48 +252,63 @@ matrixRun:
 
 			defer func() {
 				defer func() {
+					releaseWorker()
 					wg.Done()
-
-					select {
-					case waitCh <- struct{}{}:
-					case <-mCtx.Done():
-						return
-					}
 				}()
 
 				if err3 != nil && req.Context.FailFast() {
 					req.Context.Cancel()
 				}
 
-				hookAfterMatrix, err4 := req.Task.GetHookExecSpecs(
-					unstoppableMatrixCtx, dukkha.StageAfterMatrix,
+				hookAfterMatrix, err4 := getHookExec(
+					unstoppableMatrixCtx, req.Task, dukkha.StageAfterMatrix, matrixReport,
 				)
 				if err4 != nil {
 					appendErrorResult(ms, err4)
 				} else {
 					// TODO: handle hook error
-					err4 = doRun(unstoppableMatrixCtx, toolCmd, hookAfterMatrix, nil)
+					err4 = runHook(unstoppableMatrixCtx, toolCmd, hookAfterMatrix)
+					if err4 != nil {
+						appendErrorResult(ms, err4)
+					}
+				}
+
+				if f, ok := req.Task.(MatrixExecFinisher); ok && options != nil {
+					resultMU.Lock()
+					err4 = entryErrors[ms.String()]
+					resultMU.Unlock()
+
+					err4 = f.FinishMatrixExec(unstoppableMatrixCtx, options, err4)
 					if err4 != nil {
 						appendErrorResult(ms, err4)
 					}
 				}
+
+				if !upToDate {
+					resultMU.Lock()
+					err4 = entryErrors[ms.String()]
+					resultMU.Unlock()
+
+					matrixReport.Finish(err4)
+				}
 			}()
 
-			hookBeofreMatrix, err3 := req.Task.GetHookExecSpecs(
-				unstoppableMatrixCtx, dukkha.StageBeforeMatrix,
+			hookBeofreMatrix, err3 := getHookExec(
+				unstoppableMatrixCtx, req.Task, dukkha.StageBeforeMatrix, matrixReport,
 			)
 			if err3 != nil {
 				appendErrorResult(ms, err3)
 				return
 			}
 
-			err3 = doRun(unstoppableMatrixCtx, toolCmd, hookBeofreMatrix, nil)
+			err3 = runHook(unstoppableMatrixCtx, toolCmd, hookBeofreMatrix)
 			if err3 != nil {
 				appendErrorResult(ms, err3)
 				return
 			}
 
 			// produce a snapshot of what to do
+			mCtx.SetCommandRecorder(matrixReport)
 			execSpecs, err3 := req.Task.GetExecSpecs(mCtx, options)
 			if err3 != nil {
 				appendErrorResult(
@@ -247,13 +318,51 @@ matrixRun:
 				return
 			}
 
+			inc, incremental := req.Task.(IncrementalTask)
+
+			var fingerprint string
+			if incremental {
+				upToDate, fingerprint, err3 = inc.CheckUpToDate(mCtx, ms, execSpecs)
+				if err3 != nil {
+					appendErrorResult(ms, fmt.Errorf("checking task inputs: %w", err3))
+					return
+				}
+
+				upToDate = upToDate && !req.Context.Force()
+				if upToDate {
+					matrixReport.Skip("up to date")
+					output.WriteTaskUpToDate(
+						mCtx.Stderr(),
+						mCtx.PrefixColor(),
+						mCtx.CurrentTool(), mCtx.CurrentTask(), ms,
+					)
+
+					return
+				}
+
+				// invalidate previous fingerprint before running
+				err3 = inc.SaveFingerprint(ms, "")
+				if err3 != nil {
+					appendErrorResult(ms, fmt.Errorf("removing task fingerprint: %w", err3))
+					return
+				}
+			}
+
 			err3 = doRun(mCtx, toolMatrixCmd, execSpecs, nil)
 
+			if err3 == nil && incremental && len(fingerprint) != 0 {
+				err3 = inc.SaveFingerprint(ms, fingerprint)
+				if err3 != nil {
+					err3 = fmt.Errorf("saving task fingerprint: %w", err3)
+				}
+			}
+
 			output.WriteExecResult(
 				mCtx.Stderr(),
 				mCtx.PrefixColor(),
 				mCtx.CurrentTool(), mCtx.CurrentTask(),
 				ms.String(), err3,
+				mCtx.Masker(),
 			)
 
 			if err3 != nil {
@@ -264,13 +373,13 @@ matrixRun:
 
 				appendErrorResult(ms, err3)
 
-				hookAfterMatrixFailure, err4 := req.Task.GetHookExecSpecs(
-					unstoppableMatrixCtx, dukkha.StageAfterMatrixFailure,
+				hookAfterMatrixFailure, err4 := getHookExec(
+					unstoppableMatrixCtx, req.Task, dukkha.StageAfterMatrixFailure, matrixReport,
 				)
 				if err4 != nil {
 					appendErrorResult(ms, err4)
 				} else {
-					err4 = doRun(unstoppableMatrixCtx, toolMatrixCmd, hookAfterMatrixFailure, nil)
+					err4 = runHook(unstoppableMatrixCtx, toolMatrixCmd, hookAfterMatrixFailure)
 					if err4 != nil {
 						appendErrorResult(ms, err4)
 					}
@@ -279,13 +388,13 @@ matrixRun:
 				return
 			}
 
-			hookAfterMatrixSuccess, err3 := req.Task.GetHookExecSpecs(
-				unstoppableMatrixCtx, dukkha.StageAfterMatrixSuccess,
+			hookAfterMatrixSuccess, err3 := getHookExec(
+				unstoppableMatrixCtx, req.Task, dukkha.StageAfterMatrixSuccess, matrixReport,
 			)
 			if err3 != nil {
 				appendErrorResult(ms, err3)
 			} else {
-				err3 = doRun(unstoppableMatrixCtx, toolMatrixCmd, hookAfterMatrixSuccess, nil)
+				err3 = runHook(unstoppableMatrixCtx, toolMatrixCmd, hookAfterMatrixSuccess)
 				if err3 != nil {
 					appendErrorResult(ms, err3)
 				}
@@ -296,15 +405,15 @@ matrixRun:
 	wg.Wait()
 
 	if len(errCollection) != 0 {
-		hookAfterFailure, err2 := req.Task.GetHookExecSpecs(
-			unstoppableTaskCtx, dukkha.StageAfterFailure,
+		hookAfterFailure, err2 := getHookExec(
+			unstoppableTaskCtx, req.Task, dukkha.StageAfterFailure, taskReport,
 		)
 		if err2 != nil {
 			appendErrorResult(nil, err2)
 			return
 		}
 
-		err2 = doRun(unstoppableTaskCtx, toolCmd, hookAfterFailure, nil)
+		err2 = runHook(unstoppableTaskCtx, toolCmd, hookAfterFailure)
 		if err2 != nil {
 			appendErrorResult(nil, err2)
 		}
@@ -312,15 +421,15 @@ matrixRun:
 		return
 	}
 
-	hookAfterSuccess, err := req.Task.GetHookExecSpecs(
-		unstoppableTaskCtx, dukkha.StageAfterSuccess,
+	hookAfterSuccess, err := getHookExec(
+		unstoppableTaskCtx, req.Task, dukkha.StageAfterSuccess, taskReport,
 	)
 	if err != nil {
 		appendErrorResult(nil, err)
 		return
 	}
 
-	err = doRun(unstoppableTaskCtx, toolCmd, hookAfterSuccess, nil)
+	err = runHook(unstoppableTaskCtx, toolCmd, hookAfterSuccess)
 	if err != nil {
 		appendErrorResult(nil, err)
 		return
@@ -329,6 +438,47 @@ matrixRun:
 	return
 }
 
+// hookExec is the hook stage to run
+type hookExec struct {
+	specs  []dukkha.TaskExecSpec
+	report *report.Hook
+}
+
+// getHookExec generates exec specs of the hook stage, commands executed in ctx
+// are recorded in the hook stage result created by rec
+func getHookExec(
+	ctx dukkha.TaskExecContext,
+	task dukkha.Task,
+	stage dukkha.TaskExecStage,
+	rec report.HookRecorder,
+) (ret hookExec, err error) {
+	// set recorder before generating specs, specs may run in contexts derived
+	// from ctx
+	ret.report = rec.NewHook(stage.String())
+	ctx.SetCommandRecorder(ret.report)
+
+	ret.specs, err = task.GetHookExecSpecs(ctx, stage)
+	return
+}
+
+// runHook runs exec specs of the hook stage, result is recorded only when
+// there is any spec to run
+func runHook(
+	ctx dukkha.TaskExecContext,
+	getToolCmd func(ctx dukkha.RenderingContext) ([]string, error),
+	h hookExec,
+) error {
+	if len(h.specs) == 0 {
+		return nil
+	}
+
+	h.report.Start()
+	err := doRun(ctx, getToolCmd, h.specs, nil)
+	h.report.Finish(ctx.Masker().MaskError(err))
+
+	return err
+}
+
 // CreateTaskMatrixContext creates a per matrix entry task exec options
 // with context resolved
 func CreateTaskMatrixContext(
@@ -346,20 +496,7 @@ func CreateTaskMatrixContext(
 
 	mCtx.SetMatrixFilter(mFilter)
 
-	for k, v := range ms {
-		name := "MATRIX_" + strings.ToUpper(k)
-		mCtx.AddEnv(true, &dukkha.NameValueEntry{
-			Name:  name,
-			Value: v,
-		})
-
-		if name == constant.EnvName_MATRIX_ARCH {
-			mCtx.AddEnv(true, &dukkha.NameValueEntry{
-				Name:  constant.EnvName_MATRIX_ARCH_SIMPLE,
-				Value: constant.SimpleArch(v),
-			})
-		}
-	}
+	AddMatrixEnv(mCtx, ms)
 
 	existingPrefix := mCtx.OutputPrefix()
 	if len(existingPrefix) != 0 {
@@ -390,3 +527,22 @@ func CreateTaskMatrixContext(
 
 	return mCtx, options, nil
 }
+
+// AddMatrixEnv adds MATRIX_<KEY> env for all key value pairs in ms, fields of
+// object values are added as MATRIX_<KEY>_<FIELD>
+func AddMatrixEnv(ctx dukkha.EnvValues, ms matrix.Entry) {
+	for k, v := range ms.Flatten() {
+		name := "MATRIX_" + strings.ToUpper(strings.ReplaceAll(k, ".", "_"))
+		ctx.AddEnv(true, &dukkha.NameValueEntry{
+			Name:  name,
+			Value: v,
+		})
+
+		if name == constant.EnvName_MATRIX_ARCH {
+			ctx.AddEnv(true, &dukkha.NameValueEntry{
+				Name:  constant.EnvName_MATRIX_ARCH_SIMPLE,
+				Value: constant.SimpleArch(v),
+			})
+		}
+	}
+}
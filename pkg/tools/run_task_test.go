@@ -0,0 +1,183 @@
+package tools_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/matrix"
+	"arhat.dev/dukkha/pkg/renderer/tmpl"
+	"arhat.dev/dukkha/pkg/report"
+	"arhat.dev/dukkha/pkg/tools"
+	"arhat.dev/dukkha/pkg/utils"
+)
+
+func TestRunTask_Report(t *testing.T) {
+	t.Parallel()
+
+	ctx := newWorkflowTestContext(t, `
+name: a
+matrix:
+  arch: [amd64, arm64]
+hooks:
+  before:
+  - cmd: [go, version]
+  after:matrix:failure:
+  - cmd: [go, version]
+jobs:
+- cmd@tmpl: |-
+    {{- if eq matrix.arch "amd64" -}}
+    [go, version]
+    {{- else -}}
+    [go, no-such-command]
+    {{- end -}}
+`)
+
+	ctx.AddRenderer("tmpl", tmpl.NewDefault("tmpl"))
+
+	r := report.NewReport()
+	ctx.SetRuntimeOptions(dukkha.RuntimeOptions{Workers: 1, Report: r})
+
+	assert.Error(t, tools.RunTaskGraph(ctx, []tools.TaskTarget{runTarget("a")}))
+
+	if !assert.Len(t, r.Tasks, 1) {
+		return
+	}
+
+	task := r.Tasks[0]
+	assert.Equal(t, "workflow:local", task.Tool)
+	assert.Equal(t, "run:a", task.Task)
+	assert.Equal(t, report.StatusFailed, task.Status)
+
+	if assert.Len(t, task.Hooks, 1) {
+		assert.Equal(t, "before", task.Hooks[0].Stage)
+		assert.Equal(t, report.StatusSucceeded, task.Hooks[0].Status)
+		assert.Len(t, task.Hooks[0].Commands, 1)
+	}
+
+	if !assert.Len(t, task.Matrix, 2) {
+		return
+	}
+
+	for _, m := range task.Matrix {
+		if !assert.Len(t, m.Commands, 1) {
+			continue
+		}
+
+		switch m.Matrix {
+		case "arch: amd64":
+			assert.Equal(t, report.StatusSucceeded, m.Status)
+			assert.Equal(t, 0, m.Commands[0].ExitCode)
+			assert.Len(t, m.Hooks, 0)
+		case "arch: arm64":
+			assert.Equal(t, report.StatusFailed, m.Status)
+			assert.NotEmpty(t, m.Error)
+			assert.NotEqual(t, 0, m.Commands[0].ExitCode)
+			if assert.Len(t, m.Hooks, 1) {
+				assert.Equal(t, "after:matrix:failure", m.Hooks[0].Stage)
+			}
+		default:
+			t.Errorf("unexpected matrix %q", m.Matrix)
+		}
+	}
+}
+
+func TestRunTask_ReportMasked(t *testing.T) {
+	t.Parallel()
+
+	const secret = "some-secret-value"
+
+	ctx := newWorkflowTestContext(t, `
+name: a
+jobs:
+- cmd: [go, env, `+secret+`]
+- cmd: [dukkha-no-such-executable, `+secret+`]
+`)
+
+	ctx.Masker().Add(secret)
+
+	r := report.NewReport()
+	ctx.SetRuntimeOptions(dukkha.RuntimeOptions{Workers: 1, Report: r})
+
+	err := tools.RunTaskGraph(ctx, []tools.TaskTarget{runTarget("a")})
+	if !assert.Error(t, err) {
+		return
+	}
+	assert.NotContains(t, err.Error(), secret)
+
+	if assert.Len(t, r.Tasks, 1) && assert.Len(t, r.Tasks[0].Matrix, 1) {
+		cmds := r.Tasks[0].Matrix[0].Commands
+		if assert.Len(t, cmds, 2) {
+			assert.Equal(t, []string{"go", "env", utils.MaskedValue}, cmds[0].Command)
+			assert.Equal(t, []string{"dukkha-no-such-executable", utils.MaskedValue}, cmds[1].Command)
+		}
+	}
+
+	for _, format := range []string{report.FormatJSON, report.FormatJUnit} {
+		var buf bytes.Buffer
+		assert.NoError(t, r.Write(&buf, format))
+		assert.NotContains(t, buf.String(), secret, format)
+		assert.Contains(t, buf.String(), utils.MaskedValue, format)
+	}
+}
+
+func TestRunTask_MatrixObjectValues(t *testing.T) {
+	t.Parallel()
+
+	ctx := newWorkflowTestContext(t, `
+name: a
+matrix:
+  arch: [amd64]
+  runner:
+  - { cpus: 4, qemu: true }
+  computed:
+    libc:
+    - match: { runner.cpus: ["4"] }
+      values: [musl]
+jobs:
+- cmd@tmpl: |-
+    {{- if and (eq matrix.runner.cpus 4) matrix.runner.qemu (eq matrix.libc "musl") -}}
+    [go, version]
+    {{- else -}}
+    [go, no-such-command]
+    {{- end -}}
+`)
+
+	ctx.AddRenderer("tmpl", tmpl.NewDefault("tmpl"))
+
+	r := report.NewReport()
+	ctx.SetRuntimeOptions(dukkha.RuntimeOptions{Workers: 1, Report: r})
+
+	assert.NoError(t, tools.RunTaskGraph(ctx, []tools.TaskTarget{runTarget("a")}))
+	if assert.Len(t, r.Tasks, 1) && assert.Len(t, r.Tasks[0].Matrix, 1) {
+		assert.Equal(t, `arch: amd64, libc: musl, runner: {"cpus":4,"qemu":true}`, r.Tasks[0].Matrix[0].Matrix)
+	}
+}
+
+func TestAddMatrixEnv(t *testing.T) {
+	t.Parallel()
+
+	ctx := dt.NewTestContext(context.TODO(), t.TempDir())
+	tools.AddMatrixEnv(ctx, matrix.Entry{
+		"arch":   "arm64",
+		"runner": `{"cpus":4,"labels":["big"]}`,
+	})
+
+	env := ctx.Env()
+	for k, v := range map[string]string{
+		"MATRIX_ARCH":            "arm64",
+		"MATRIX_ARCH_SIMPLE":     "arm64",
+		"MATRIX_RUNNER_CPUS":     "4",
+		"MATRIX_RUNNER_LABELS_0": "big",
+	} {
+		if assert.Contains(t, env, k) {
+			assert.Equal(t, v, env[k].GetLazyValue(), k)
+		}
+	}
+
+	assert.NotContains(t, env, "MATRIX_RUNNER")
+}
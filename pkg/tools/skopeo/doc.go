@@ -0,0 +1,2 @@
+// Package skopeo provides skopeo task support
+package skopeo
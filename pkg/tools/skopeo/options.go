@@ -0,0 +1,268 @@
+package skopeo
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"arhat.dev/pkg/fshelper"
+	"arhat.dev/pkg/md5helper"
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/tools/buildah"
+)
+
+// imageSpec is a source and destination image pair
+type imageSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	// From is the source image reference
+	From string `yaml:"from"`
+
+	// To is the destination image reference
+	To string `yaml:"to"`
+}
+
+type sourceOptions struct {
+	rs.BaseField `yaml:"-"`
+
+	// RemoveSignatures of source image, useful when the destination registry
+	// doesn't support signatures
+	//
+	// this option takes no effect when destination.signing.enabled is true
+	RemoveSignatures bool `yaml:"remove_signatures"`
+
+	// TLSSkipVerify do not verify certificates of source registry
+	TLSSkipVerify bool `yaml:"tls_skip_verify"`
+
+	// Decryption of encrypted source image (only available to skopeo:copy)
+	Decryption keySpec `yaml:"decryption"`
+}
+
+type destinationOptions struct {
+	rs.BaseField `yaml:"-"`
+
+	// ManifestFormat of destination image, one of [oci, v2s1, v2s2]
+	//
+	// if not set, will stay the same as source manifest
+	ManifestFormat string `yaml:"manifest_format"`
+
+	// TLSSkipVerify do not verify certificates of destination registry
+	TLSSkipVerify bool `yaml:"tls_skip_verify"`
+
+	Signing signingSpec `yaml:"signing"`
+
+	// Encryption of destination image (only available to skopeo:copy)
+	Encryption keySpec `yaml:"encryption"`
+
+	Compression compressionSpec `yaml:"compression"`
+}
+
+type keySpec struct {
+	rs.BaseField `yaml:"-"`
+
+	Enabled bool `yaml:"enabled"`
+
+	// Key is the content of the key
+	Key string `yaml:"key"`
+
+	// Passphrase to the private key (only used in decryption)
+	Passphrase string `yaml:"passphrase"`
+}
+
+type signingSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	Enabled bool `yaml:"enabled"`
+
+	// PGPKeyID is the fingerprint of the pgp key used to sign the image
+	PGPKeyID string `yaml:"pgp_key_id"`
+
+	// Passphrase to the pgp key
+	Passphrase string `yaml:"passphrase"`
+}
+
+type compressionSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	Enabled bool `yaml:"enabled"`
+
+	// Format of compression, one of [gzip, zstd]
+	Format string `yaml:"format"`
+
+	// Level of compression
+	// 	for gzip: [1, 9]
+	// 	for zstd: [1, 20]
+	Level int `yaml:"level"`
+}
+
+func (s *sourceOptions) genArgs(cacheFS *fshelper.OSFS, withDecryption bool) ([]string, error) {
+	var args []string
+	if s.TLSSkipVerify {
+		args = append(args, "--src-tls-verify=false")
+	}
+
+	if withDecryption && s.Decryption.Enabled {
+		if len(s.Decryption.Key) == 0 {
+			return nil, fmt.Errorf("no decryption key provided")
+		}
+
+		keyFile, err := ensureKeyFile(cacheFS, "decryption-key-", s.Decryption.Key)
+		if err != nil {
+			return nil, fmt.Errorf("ensuring decryption key: %w", err)
+		}
+
+		if len(s.Decryption.Passphrase) != 0 {
+			keyFile += ":" + s.Decryption.Passphrase
+		}
+
+		args = append(args, "--decryption-key", keyFile)
+	}
+
+	return args, nil
+}
+
+func (d *destinationOptions) genArgs(
+	cacheFS *fshelper.OSFS,
+	removeSignatures bool,
+	withEncryption bool,
+) ([]string, error) {
+	var args []string
+	if d.TLSSkipVerify {
+		args = append(args, "--dest-tls-verify=false")
+	}
+
+	if len(d.ManifestFormat) != 0 {
+		switch f := d.ManifestFormat; f {
+		case "oci", "v2s1", "v2s2":
+			args = append(args, "--format", f)
+		default:
+			return nil, fmt.Errorf("invalid manifest format %q", f)
+		}
+	}
+
+	if d.Signing.Enabled {
+		if len(d.Signing.PGPKeyID) == 0 {
+			return nil, fmt.Errorf("no pgp key id provided for signing")
+		}
+
+		args = append(args, "--sign-by", d.Signing.PGPKeyID)
+
+		if len(d.Signing.Passphrase) != 0 {
+			passphraseFile, err := ensureKeyFile(cacheFS, "signing-passphrase-", d.Signing.Passphrase)
+			if err != nil {
+				return nil, fmt.Errorf("ensuring signing passphrase: %w", err)
+			}
+
+			args = append(args, "--sign-passphrase-file", passphraseFile)
+		}
+	} else if removeSignatures {
+		args = append(args, "--remove-signatures")
+	}
+
+	if withEncryption && d.Encryption.Enabled {
+		if len(d.Encryption.Key) == 0 {
+			return nil, fmt.Errorf("no encryption key provided")
+		}
+
+		keyFile, err := ensureKeyFile(cacheFS, "encryption-key-", d.Encryption.Key)
+		if err != nil {
+			return nil, fmt.Errorf("ensuring encryption key: %w", err)
+		}
+
+		args = append(args, "--encryption-key", "jwe:"+keyFile)
+	}
+
+	if d.Compression.Enabled {
+		args = append(args, "--dest-compress")
+
+		if len(d.Compression.Format) != 0 {
+			switch f := d.Compression.Format; f {
+			case constant.CompressionMethod_Gzip, constant.CompressionMethod_ZSTD:
+				args = append(args, "--dest-compress-format", f)
+			default:
+				return nil, fmt.Errorf("unsupported compression format %q", f)
+			}
+		}
+
+		if d.Compression.Level != 0 {
+			args = append(args, "--dest-compress-level", strconv.FormatInt(int64(d.Compression.Level), 10))
+		}
+	}
+
+	return args, nil
+}
+
+// genPlatformArgs generates global options to override os/arch/variant
+// of images to be chosen when handling manifest lists according to matrix
+// kernel and arch
+func genPlatformArgs(rc dukkha.RenderingContext) []string {
+	var args []string
+
+	ociOS, ociArch, ociVariant := buildah.GetOciPlatform(rc.MatrixKernel(), rc.MatrixArch())
+	if len(ociOS) != 0 {
+		args = append(args, "--override-os", ociOS)
+	}
+
+	if len(ociArch) != 0 {
+		args = append(args, "--override-arch", ociArch)
+	}
+
+	if len(ociVariant) != 0 {
+		args = append(args, "--override-variant", ociVariant)
+	}
+
+	return args
+}
+
+// supportedTransports are transport prefixes accepted by skopeo
+var supportedTransports = []string{
+	"containers-storage:",
+	"dir:",
+	"docker://",
+	"docker-archive:",
+	"docker-daemon:",
+	"oci:",
+	"oci-archive:",
+	"ostree:",
+	"sif:",
+	"tarball:",
+}
+
+// toImageRef adds `docker://` transport to ref if it has no transport
+func toImageRef(ref string) string {
+	for _, t := range supportedTransports {
+		if strings.HasPrefix(ref, t) {
+			return ref
+		}
+	}
+
+	return "docker://" + ref
+}
+
+func ensureKeyFile(cacheFS *fshelper.OSFS, prefix, content string) (string, error) {
+	keyFile := prefix + hex.EncodeToString(
+		md5helper.Sum([]byte(content)),
+	)
+
+	_, err := cacheFS.Stat(keyFile)
+	if err == nil {
+		return cacheFS.Abs(keyFile)
+	}
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("check key file: %w", err)
+	}
+
+	err = cacheFS.WriteFile(keyFile, []byte(content), 0400)
+	if err != nil {
+		return "", fmt.Errorf("saving key to temporary file: %w", err)
+	}
+
+	return cacheFS.Abs(keyFile)
+}
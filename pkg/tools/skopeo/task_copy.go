@@ -0,0 +1,93 @@
+package skopeo
+
+import (
+	"fmt"
+
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/tools"
+)
+
+const TaskKindCopy = "copy"
+
+func init() {
+	dukkha.RegisterTask(ToolKind, TaskKindCopy, tools.NewTask[TaskCopy, *TaskCopy])
+}
+
+// TaskCopy copies images between registries (or other supported transports)
+type TaskCopy struct {
+	tools.BaseTask[SkopeoCopy, *SkopeoCopy]
+}
+
+// nolint:revive
+type SkopeoCopy struct {
+	// Images to copy
+	Images []*imageSpec `yaml:"images"`
+
+	// All copies all images in the manifest list instead of the one
+	// matching matrix kernel and arch
+	All bool `yaml:"all"`
+
+	Source      sourceOptions      `yaml:"source"`
+	Destination destinationOptions `yaml:"destination"`
+
+	ExtraArgs []string `yaml:"extra_args"`
+
+	parent tools.BaseTaskType
+}
+
+func (c *SkopeoCopy) ToolKind() dukkha.ToolKind       { return ToolKind }
+func (c *SkopeoCopy) Kind() dukkha.TaskKind           { return TaskKindCopy }
+func (c *SkopeoCopy) LinkParent(p tools.BaseTaskType) { c.parent = p }
+
+func (c *SkopeoCopy) GetExecSpecs(
+	rc dukkha.TaskExecContext, options dukkha.TaskMatrixExecOptions,
+) ([]dukkha.TaskExecSpec, error) {
+	var steps []dukkha.TaskExecSpec
+
+	err := c.parent.DoAfterFieldsResolved(rc, -1, true, func() error {
+		if len(c.Images) == 0 {
+			return fmt.Errorf("no image to copy")
+		}
+
+		srcArgs, err := c.Source.genArgs(c.parent.CacheFS(), true)
+		if err != nil {
+			return fmt.Errorf("invalid source options: %w", err)
+		}
+
+		destArgs, err := c.Destination.genArgs(c.parent.CacheFS(), c.Source.RemoveSignatures, true)
+		if err != nil {
+			return fmt.Errorf("invalid destination options: %w", err)
+		}
+
+		copyCmd := []string{constant.DUKKHA_TOOL_CMD}
+		if c.All {
+			copyCmd = append(copyCmd, "copy", "--all")
+		} else {
+			copyCmd = append(copyCmd, genPlatformArgs(rc)...)
+			copyCmd = append(copyCmd, "copy")
+		}
+
+		copyCmd = append(copyCmd, srcArgs...)
+		copyCmd = append(copyCmd, destArgs...)
+		copyCmd = append(copyCmd, c.ExtraArgs...)
+
+		for i, spec := range c.Images {
+			if len(spec.From) == 0 || len(spec.To) == 0 {
+				return fmt.Errorf("invalid image #%d: both from and to are required", i)
+			}
+
+			steps = append(steps, dukkha.TaskExecSpec{
+				Command: append(
+					append([]string{}, copyCmd...),
+					toImageRef(spec.From), toImageRef(spec.To),
+				),
+				IgnoreError: false,
+			})
+		}
+
+		return nil
+	})
+
+	return steps, err
+}
@@ -0,0 +1,125 @@
+package skopeo
+
+import (
+	"context"
+	"testing"
+
+	"arhat.dev/pkg/archconst"
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	dukkha_test "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/tools"
+	"arhat.dev/dukkha/pkg/tools/tests"
+)
+
+func TestTaskCopy_GetExecSpecs(t *testing.T) {
+	t.Parallel()
+
+	newTask := func(images ...*imageSpec) *TaskCopy {
+		tsk := tools.NewTask[TaskCopy, *TaskCopy]("").(*TaskCopy)
+		tsk.TaskName = "foo"
+		for _, img := range images {
+			rs.Init(img, nil)
+		}
+		tsk.Impl.Images = images
+		return tsk
+	}
+
+	testCases := []tests.ExecSpecGenerationTestCase{
+		{
+			Name:      "Invalid No Image",
+			Task:      newTask(),
+			Options:   dukkha_test.CreateTaskMatrixExecOptions(),
+			ExpectErr: true,
+		},
+		{
+			Name:      "Invalid Missing Destination",
+			Task:      newTask(&imageSpec{From: "example.com/foo:latest"}),
+			Options:   dukkha_test.CreateTaskMatrixExecOptions(),
+			ExpectErr: true,
+		},
+		{
+			Name: "Copy With Platform Override",
+			Task: newTask(
+				&imageSpec{From: "example.com/foo:latest", To: "dir:/tmp/foo"},
+			),
+			Options: dukkha_test.CreateTaskMatrixExecOptions(),
+			Expected: []dukkha.TaskExecSpec{
+				{
+					Command: []string{constant.DUKKHA_TOOL_CMD,
+						"--override-os", "linux", "--override-arch", "arm", "--override-variant", "v7",
+						"copy", "docker://example.com/foo:latest", "dir:/tmp/foo",
+					},
+				},
+			},
+		},
+		{
+			Name: "Copy All With Options",
+			Task: func() dukkha.Task {
+				tsk := newTask(&imageSpec{From: "example.com/foo:latest", To: "example.com/bar:latest"})
+				tsk.Impl.All = true
+				tsk.Impl.Source.RemoveSignatures = true
+				tsk.Impl.Source.TLSSkipVerify = true
+				tsk.Impl.Destination.ManifestFormat = "oci"
+				tsk.Impl.Destination.Compression.Enabled = true
+				tsk.Impl.Destination.Compression.Format = "zstd"
+				tsk.Impl.Destination.Compression.Level = 20
+				return tsk
+			}(),
+			Options: dukkha_test.CreateTaskMatrixExecOptions(),
+			Expected: []dukkha.TaskExecSpec{
+				{
+					Command: []string{constant.DUKKHA_TOOL_CMD,
+						"copy", "--all",
+						"--src-tls-verify=false",
+						"--format", "oci",
+						"--remove-signatures",
+						"--dest-compress", "--dest-compress-format", "zstd", "--dest-compress-level", "20",
+						"docker://example.com/foo:latest", "docker://example.com/bar:latest",
+					},
+				},
+			},
+		},
+		{
+			Name: "Signing Keeps Signatures",
+			Task: func() dukkha.Task {
+				tsk := newTask(&imageSpec{From: "example.com/foo:latest", To: "example.com/bar:latest"})
+				tsk.Impl.All = true
+				tsk.Impl.Source.RemoveSignatures = true
+				tsk.Impl.Destination.Signing.Enabled = true
+				tsk.Impl.Destination.Signing.PGPKeyID = "ABCD"
+				return tsk
+			}(),
+			Options: dukkha_test.CreateTaskMatrixExecOptions(),
+			Expected: []dukkha.TaskExecSpec{
+				{
+					Command: []string{constant.DUKKHA_TOOL_CMD,
+						"copy", "--all",
+						"--sign-by", "ABCD",
+						"docker://example.com/foo:latest", "docker://example.com/bar:latest",
+					},
+				},
+			},
+		},
+		{
+			Name: "Invalid Manifest Format",
+			Task: func() dukkha.Task {
+				tsk := newTask(&imageSpec{From: "example.com/foo:latest", To: "example.com/bar:latest"})
+				tsk.Impl.Destination.ManifestFormat = "foo"
+				return tsk
+			}(),
+			Options:   dukkha_test.CreateTaskMatrixExecOptions(),
+			ExpectErr: true,
+		},
+	}
+
+	ctx := dukkha_test.NewTestContext(context.TODO(), t.TempDir())
+	ctx.AddEnv(true,
+		&dukkha.NameValueEntry{Name: constant.EnvName_MATRIX_KERNEL, Value: constant.KERNEL_Linux},
+		&dukkha.NameValueEntry{Name: constant.EnvName_MATRIX_ARCH, Value: archconst.ARCH_ARM_V7},
+	)
+
+	tests.RunTaskExecSpecGenerationTests(t, ctx, testCases)
+}
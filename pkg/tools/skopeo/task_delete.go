@@ -0,0 +1,86 @@
+package skopeo
+
+import (
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/templateutils"
+	"arhat.dev/dukkha/pkg/tools"
+	"arhat.dev/dukkha/pkg/tools/buildah"
+)
+
+const TaskKindDelete = "delete"
+
+func init() {
+	dukkha.RegisterTask(ToolKind, TaskKindDelete, tools.NewTask[TaskDelete, *TaskDelete])
+}
+
+// TaskDelete deletes images and manifests from registries
+type TaskDelete struct {
+	tools.BaseTask[SkopeoDelete, *SkopeoDelete]
+}
+
+// nolint:revive
+type SkopeoDelete struct {
+	ImageNames []buildah.ImageNameSpec `yaml:"image_names"`
+
+	// TLSSkipVerify do not verify certificates of the registry
+	TLSSkipVerify bool `yaml:"tls_skip_verify"`
+
+	ExtraArgs []string `yaml:"extra_args"`
+
+	parent tools.BaseTaskType
+}
+
+func (c *SkopeoDelete) ToolKind() dukkha.ToolKind       { return ToolKind }
+func (c *SkopeoDelete) Kind() dukkha.TaskKind           { return TaskKindDelete }
+func (c *SkopeoDelete) LinkParent(p tools.BaseTaskType) { c.parent = p }
+
+func (c *SkopeoDelete) GetExecSpecs(
+	rc dukkha.TaskExecContext, options dukkha.TaskMatrixExecOptions,
+) ([]dukkha.TaskExecSpec, error) {
+	var steps []dukkha.TaskExecSpec
+
+	err := c.parent.DoAfterFieldsResolved(rc, -1, true, func() error {
+		targets := c.ImageNames
+		if len(targets) == 0 {
+			targets = []buildah.ImageNameSpec{
+				{
+					Image:    string(c.parent.Name()),
+					Manifest: "",
+				},
+			}
+		}
+
+		deleteCmd := []string{constant.DUKKHA_TOOL_CMD, "delete"}
+		if c.TLSSkipVerify {
+			deleteCmd = append(deleteCmd, "--tls-verify=false")
+		}
+
+		deleteCmd = append(deleteCmd, c.ExtraArgs...)
+
+		var refs []string
+		for _, spec := range targets {
+			if len(spec.Image) != 0 {
+				refs = append(refs, templateutils.GetFullImageName_UseDefault_IfIfNoTagSet(rc, spec.Image, true))
+			}
+
+			if len(spec.Manifest) != 0 {
+				refs = append(refs, templateutils.GetFullManifestName_UseDefault_IfNoTagSet(rc, spec.Manifest))
+			}
+		}
+
+		for _, ref := range refs {
+			steps = append(steps, dukkha.TaskExecSpec{
+				Command: append(
+					append([]string{}, deleteCmd...),
+					toImageRef(ref),
+				),
+				IgnoreError: false,
+			})
+		}
+
+		return nil
+	})
+
+	return steps, err
+}
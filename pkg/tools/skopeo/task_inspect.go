@@ -0,0 +1,111 @@
+package skopeo
+
+import (
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/templateutils"
+	"arhat.dev/dukkha/pkg/tools"
+	"arhat.dev/dukkha/pkg/tools/buildah"
+)
+
+const TaskKindInspect = "inspect"
+
+func init() {
+	dukkha.RegisterTask(ToolKind, TaskKindInspect, tools.NewTask[TaskInspect, *TaskInspect])
+}
+
+// TaskInspect inspects remote images and manifests
+type TaskInspect struct {
+	tools.BaseTask[SkopeoInspect, *SkopeoInspect]
+}
+
+// nolint:revive
+type SkopeoInspect struct {
+	ImageNames []buildah.ImageNameSpec `yaml:"image_names"`
+
+	// Raw outputs raw manifest or config of images
+	Raw bool `yaml:"raw"`
+
+	// Config outputs image config instead of manifest
+	Config bool `yaml:"config"`
+
+	// TLSSkipVerify do not verify certificates of the registry
+	TLSSkipVerify bool `yaml:"tls_skip_verify"`
+
+	ExtraArgs []string `yaml:"extra_args"`
+
+	parent tools.BaseTaskType
+}
+
+func (c *SkopeoInspect) ToolKind() dukkha.ToolKind       { return ToolKind }
+func (c *SkopeoInspect) Kind() dukkha.TaskKind           { return TaskKindInspect }
+func (c *SkopeoInspect) LinkParent(p tools.BaseTaskType) { c.parent = p }
+
+func (c *SkopeoInspect) GetExecSpecs(
+	rc dukkha.TaskExecContext, options dukkha.TaskMatrixExecOptions,
+) ([]dukkha.TaskExecSpec, error) {
+	var steps []dukkha.TaskExecSpec
+
+	err := c.parent.DoAfterFieldsResolved(rc, -1, true, func() error {
+		targets := c.ImageNames
+		if len(targets) == 0 {
+			targets = []buildah.ImageNameSpec{
+				{
+					Image:    string(c.parent.Name()),
+					Manifest: "",
+				},
+			}
+		}
+
+		var args []string
+		if c.Raw {
+			args = append(args, "--raw")
+		}
+
+		if c.Config {
+			args = append(args, "--config")
+		}
+
+		if c.TLSSkipVerify {
+			args = append(args, "--tls-verify=false")
+		}
+
+		args = append(args, c.ExtraArgs...)
+
+		for _, spec := range targets {
+			if len(spec.Image) != 0 {
+				imageName := templateutils.GetFullImageName_UseDefault_IfIfNoTagSet(rc, spec.Image, true)
+
+				inspectCmd := []string{constant.DUKKHA_TOOL_CMD}
+				inspectCmd = append(inspectCmd, genPlatformArgs(rc)...)
+				inspectCmd = append(inspectCmd, "inspect")
+				inspectCmd = append(inspectCmd, args...)
+
+				steps = append(steps, dukkha.TaskExecSpec{
+					Command:     append(inspectCmd, toImageRef(imageName)),
+					IgnoreError: false,
+				})
+			}
+
+			if len(spec.Manifest) == 0 {
+				continue
+			}
+
+			// manifest list can only be inspected in raw format
+			manifestName := templateutils.GetFullManifestName_UseDefault_IfNoTagSet(rc, spec.Manifest)
+			inspectCmd := []string{constant.DUKKHA_TOOL_CMD, "inspect", "--raw"}
+			if c.TLSSkipVerify {
+				inspectCmd = append(inspectCmd, "--tls-verify=false")
+			}
+
+			steps = append(steps, dukkha.TaskExecSpec{
+				Command:     append(inspectCmd, toImageRef(manifestName)),
+				IgnoreError: false,
+			})
+		}
+
+		return nil
+	})
+
+	return steps, err
+}
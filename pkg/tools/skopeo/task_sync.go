@@ -0,0 +1,129 @@
+package skopeo
+
+import (
+	"fmt"
+
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/tools"
+)
+
+const TaskKindSync = "sync"
+
+func init() {
+	dukkha.RegisterTask(ToolKind, TaskKindSync, tools.NewTask[TaskSync, *TaskSync])
+}
+
+// TaskSync synchronizes images between registries or directories
+type TaskSync struct {
+	tools.BaseTask[SkopeoSync, *SkopeoSync]
+}
+
+// nolint:revive
+type SkopeoSync struct {
+	// SourceTransport is the transport of sources, one of [docker, dir, yaml]
+	//
+	// defaults to `docker`
+	SourceTransport string `yaml:"source_transport"`
+
+	// DestinationTransport is the transport of destinations, one of [docker, dir]
+	//
+	// defaults to `docker`
+	DestinationTransport string `yaml:"destination_transport"`
+
+	// Images (repositories, directories or yaml files) to sync
+	Images []*imageSpec `yaml:"images"`
+
+	// All copies all images in the manifest list instead of the one
+	// matching matrix kernel and arch
+	All bool `yaml:"all"`
+
+	// Scoped prefixes destination with full source image path
+	Scoped bool `yaml:"scoped"`
+
+	Source      sourceOptions      `yaml:"source"`
+	Destination destinationOptions `yaml:"destination"`
+
+	ExtraArgs []string `yaml:"extra_args"`
+
+	parent tools.BaseTaskType
+}
+
+func (c *SkopeoSync) ToolKind() dukkha.ToolKind       { return ToolKind }
+func (c *SkopeoSync) Kind() dukkha.TaskKind           { return TaskKindSync }
+func (c *SkopeoSync) LinkParent(p tools.BaseTaskType) { c.parent = p }
+
+func (c *SkopeoSync) GetExecSpecs(
+	rc dukkha.TaskExecContext, options dukkha.TaskMatrixExecOptions,
+) ([]dukkha.TaskExecSpec, error) {
+	var steps []dukkha.TaskExecSpec
+
+	err := c.parent.DoAfterFieldsResolved(rc, -1, true, func() error {
+		if len(c.Images) == 0 {
+			return fmt.Errorf("no image to sync")
+		}
+
+		srcTransport := c.SourceTransport
+		switch srcTransport {
+		case "":
+			srcTransport = "docker"
+		case "docker", "dir", "yaml":
+		default:
+			return fmt.Errorf("invalid source transport %q", srcTransport)
+		}
+
+		destTransport := c.DestinationTransport
+		switch destTransport {
+		case "":
+			destTransport = "docker"
+		case "docker", "dir":
+		default:
+			return fmt.Errorf("invalid destination transport %q", destTransport)
+		}
+
+		srcArgs, err := c.Source.genArgs(c.parent.CacheFS(), false)
+		if err != nil {
+			return fmt.Errorf("invalid source options: %w", err)
+		}
+
+		destArgs, err := c.Destination.genArgs(c.parent.CacheFS(), c.Source.RemoveSignatures, false)
+		if err != nil {
+			return fmt.Errorf("invalid destination options: %w", err)
+		}
+
+		syncCmd := []string{constant.DUKKHA_TOOL_CMD}
+		if c.All {
+			syncCmd = append(syncCmd, "sync", "--all")
+		} else {
+			syncCmd = append(syncCmd, genPlatformArgs(rc)...)
+			syncCmd = append(syncCmd, "sync")
+		}
+
+		syncCmd = append(syncCmd, "--src", srcTransport, "--dest", destTransport)
+		if c.Scoped {
+			syncCmd = append(syncCmd, "--scoped")
+		}
+
+		syncCmd = append(syncCmd, srcArgs...)
+		syncCmd = append(syncCmd, destArgs...)
+		syncCmd = append(syncCmd, c.ExtraArgs...)
+
+		for i, spec := range c.Images {
+			if len(spec.From) == 0 || len(spec.To) == 0 {
+				return fmt.Errorf("invalid image #%d: both from and to are required", i)
+			}
+
+			steps = append(steps, dukkha.TaskExecSpec{
+				Command: append(
+					append([]string{}, syncCmd...),
+					spec.From, spec.To,
+				),
+				IgnoreError: false,
+			})
+		}
+
+		return nil
+	})
+
+	return steps, err
+}
@@ -0,0 +1,21 @@
+package skopeo
+
+import (
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/tools"
+)
+
+const ToolKind = "skopeo"
+
+func init() {
+	dukkha.RegisterTool(ToolKind, func() dukkha.Tool { return &Tool{} })
+}
+
+type Skopeo struct{}
+
+func (t *Skopeo) DefaultExecutable() string { return "skopeo" }
+func (t *Skopeo) Kind() dukkha.ToolKind     { return ToolKind }
+
+type Tool struct {
+	tools.BaseTool[Skopeo, *Skopeo]
+}
@@ -23,6 +23,16 @@ type TaskImpl interface {
 	) ([]dukkha.TaskExecSpec, error)
 }
 
+// MatrixExecFinisher is implemented by tasks tracking results of all matrix
+// executions of one task execution
+type MatrixExecFinisher interface {
+	// FinishMatrixExec is called after every started matrix execution, err is the
+	// error of the matrix execution (nil when succeeded or up to date)
+	FinishMatrixExec(
+		rc dukkha.TaskExecContext, opts dukkha.TaskMatrixExecOptions, err error,
+	) error
+}
+
 type BaseTaskType interface {
 	dukkha.Task
 	SetToolName(string)
@@ -48,6 +58,18 @@ type BaseTask[V any, T TaskImpl] struct {
 	Hooks               TaskHooks            `yaml:"hooks,omitempty"`
 	ContinueOnErrorFlag bool                 `yaml:"continue_on_error"`
 
+	// DependsOn are tasks required to run before this task
+	DependsOn []*TaskReference `yaml:"depends_on,omitempty"`
+
+	// Inputs of this task, when set, matrix entries with unchanged inputs
+	// and existing outputs are skipped
+	Inputs *TaskInputs `yaml:"inputs,omitempty"`
+
+	// OutputFiles are glob patterns of files generated by this task
+	//
+	// NOTE: not named `outputs` as some tasks already have that field
+	OutputFiles []string `yaml:"output_files,omitempty"`
+
 	Impl V `yaml:",inline"`
 
 	// fields managed by BaseTask
@@ -179,6 +201,12 @@ func (t *BaseTask[V, T]) GetHookExecSpecs(
 func (t *BaseTask[V, T]) GetMatrixSpecs(rc dukkha.RenderingContext) (ret []matrix.Entry, err error) {
 	err = t.DoAfterFieldsResolved(rc, -1, true, func() error {
 		if t.Matrix.IsEmpty() {
+			// the host entry is the only entry, which belongs to the first shard
+			filter := rc.MatrixFilter()
+			if !filter.InShard(0) {
+				return nil
+			}
+
 			ret = []matrix.Entry{
 				{
 					"kernel": rc.HostKernel(),
@@ -196,3 +224,27 @@ func (t *BaseTask[V, T]) GetMatrixSpecs(rc dukkha.RenderingContext) (ret []matri
 
 	return
 }
+
+// FinishMatrixExec implements MatrixExecFinisher
+func (t *BaseTask[V, T]) FinishMatrixExec(
+	rc dukkha.TaskExecContext, opts dukkha.TaskMatrixExecOptions, err error,
+) error {
+	f, ok := any(t.getTaskImpl()).(MatrixExecFinisher)
+	if !ok {
+		return nil
+	}
+
+	return f.FinishMatrixExec(rc, opts, err)
+}
+
+// GetDependencies implements DependentTask
+func (t *BaseTask[V, T]) GetDependencies(rc dukkha.RenderingContext) (ret []*TaskReference, err error) {
+	err = t.DoAfterFieldsResolved(rc, -1, true, func() error {
+		ret = t.DependsOn
+		return nil
+	},
+		"depends_on",
+	)
+
+	return
+}
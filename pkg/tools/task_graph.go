@@ -0,0 +1,320 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/multierr"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/matrix"
+)
+
+// DependentTask is implemented by tasks supporting `depends_on`
+type DependentTask interface {
+	// GetDependencies returns references to tasks required to run before
+	// this task
+	GetDependencies(rc dukkha.RenderingContext) ([]*TaskReference, error)
+}
+
+// TaskTarget is a task requested to run
+type TaskTarget struct {
+	Tool dukkha.ToolKey
+	Task dukkha.TaskKey
+}
+
+// RunTaskGraph runs targets along with all their dependencies, a task starts
+// as soon as all its dependencies succeeded, independent tasks run in parallel
+//
+// task nodes and their matrix entries share the worker pool of ctx, so that
+// total parallelism never exceeds the worker count
+//
+// every (task, matrix entry) pair is executed at most once
+func RunTaskGraph(ctx dukkha.TaskExecContext, targets []TaskTarget) error {
+	nodes, err := BuildTaskGraph(ctx, targets)
+	if err != nil {
+		return err
+	}
+
+	var (
+		executions = newExecutionRegistry()
+		done       = make(map[*TaskNode]chan struct{}, len(nodes))
+		wg         sync.WaitGroup
+	)
+
+	for _, n := range nodes {
+		done[n] = make(chan struct{})
+	}
+
+	for _, n := range nodes {
+		wg.Add(1)
+		go func(n *TaskNode) {
+			defer func() {
+				close(done[n])
+				wg.Done()
+			}()
+
+			for _, dep := range n.Dependencies {
+				<-done[dep]
+
+				if dep.err != nil {
+					n.err = fmt.Errorf("%s: skipped due to failed dependency %s", n.ID, dep.ID)
+					return
+				}
+			}
+
+			releaseWorker, ok := ctx.Workers().Acquire(ctx.Done(), nil)
+			if !ok {
+				n.err = fmt.Errorf("%s: skipped: %w", n.ID, ctx.Err())
+				return
+			}
+			defer releaseWorker()
+
+			if err2 := ctx.Err(); err2 != nil {
+				n.err = fmt.Errorf("%s: skipped: %w", n.ID, err2)
+				return
+			}
+
+			n.err = RunTask(&TaskExecRequest{
+				Context:    n.ctx,
+				Tool:       n.Tool,
+				Task:       n.Task,
+				executions: executions,
+			})
+
+			if n.err != nil && ctx.FailFast() {
+				ctx.Cancel()
+			}
+		}(n)
+	}
+
+	wg.Wait()
+
+	for _, n := range nodes {
+		err = multierr.Append(err, n.err)
+	}
+
+	return err
+}
+
+// TaskNode is a task with matrix filter in task graph
+type TaskNode struct {
+	// ID of the node in format `<tool-kind>:<tool-name>:<task-kind>(<task-name>)`
+	// with optional matrix filter suffix `[<filter>]`
+	ID string
+
+	Tool         dukkha.Tool
+	Task         dukkha.Task
+	MatrixFilter matrix.Filter
+
+	// Dependencies of this task
+	Dependencies []*TaskNode
+
+	ctx dukkha.TaskExecContext
+	err error
+}
+
+// BuildTaskGraph resolves dependencies of targets, returns all tasks
+// in topological order (dependencies come first)
+func BuildTaskGraph(ctx dukkha.TaskExecContext, targets []TaskTarget) ([]*TaskNode, error) {
+	g := &taskGraph{
+		nodes:    make(map[string]*TaskNode),
+		visiting: make(map[string]int),
+	}
+
+	for _, t := range targets {
+		_, err := g.visit(ctx, t.Tool, t.Task, ctx.MatrixFilter())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return g.order, nil
+}
+
+type taskGraph struct {
+	nodes map[string]*TaskNode
+
+	// visiting tracks nodes on current dfs path (node id to index in path)
+	visiting map[string]int
+	path     []string
+
+	order []*TaskNode
+}
+
+func formatTaskNodeID(toolKey dukkha.ToolKey, taskKey dukkha.TaskKey, filter *matrix.Filter) string {
+	id := toolKey.String() + ":" + taskKey.String()
+	if f := filter.String(); len(f) != 0 {
+		id += "[" + f + "]"
+	}
+
+	if index, total := filter.Shard(); total != 0 {
+		id += "#" + strconv.Itoa(index) + "/" + strconv.Itoa(total)
+	}
+
+	return id
+}
+
+func (g *taskGraph) visit(
+	ctx dukkha.TaskExecContext,
+	toolKey dukkha.ToolKey,
+	taskKey dukkha.TaskKey,
+	filter matrix.Filter,
+) (*TaskNode, error) {
+	id := formatTaskNodeID(toolKey, taskKey, &filter)
+	if n, ok := g.nodes[id]; ok {
+		return n, nil
+	}
+
+	if idx, ok := g.visiting[id]; ok {
+		return nil, fmt.Errorf(
+			"dependency cycle detected: %s",
+			strings.Join(append(g.path[idx:], id), " -> "),
+		)
+	}
+
+	tool, ok := ctx.GetTool(toolKey)
+	if !ok {
+		return nil, fmt.Errorf("tool %q not found", toolKey)
+	}
+
+	task, ok := tool.GetTask(taskKey)
+	if !ok {
+		return nil, fmt.Errorf("task %q not found in tool %q", taskKey, toolKey)
+	}
+
+	nodeCtx := ctx.DeriveNew()
+	nodeCtx.SetTask(toolKey, taskKey)
+	nodeCtx.SetMatrixFilter(filter)
+
+	n := &TaskNode{
+		ID:           id,
+		Tool:         tool,
+		Task:         task,
+		MatrixFilter: filter,
+
+		ctx: nodeCtx,
+	}
+
+	dt, ok := task.(DependentTask)
+	if !ok {
+		g.add(n)
+		return n, nil
+	}
+
+	// dependencies may reference tool specific env
+	resolveCtx := nodeCtx.DeriveNew()
+	err := tool.DoAfterFieldsResolved(resolveCtx, -1, true, func() error { return nil }, "env")
+	if err != nil {
+		return nil, fmt.Errorf("%s: resolving tool specific env: %w", id, err)
+	}
+
+	deps, err := dt.GetDependencies(resolveCtx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: resolving dependencies: %w", id, err)
+	}
+
+	g.visiting[id] = len(g.path)
+	g.path = append(g.path, id)
+	defer func() {
+		delete(g.visiting, id)
+		g.path = g.path[:len(g.path)-1]
+	}()
+
+	for _, ref := range deps {
+		depToolKey, depTaskKey, err := ref.ParseRef(toolKey.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", id, err)
+		}
+
+		var depFilter matrix.Filter
+		switch {
+		case ref.MatrixFilter == nil:
+			// not set, run all matrix entries of the dependency
+		case ref.MatrixFilter.IsEmpty():
+			// empty filter, use matrix filter of this task, but run all
+			// matched entries of the dependency regardless of sharding
+			depFilter = filter.Clone()
+			depFilter.SetShard(0, 0)
+		default:
+			depFilter = ref.MatrixFilter.AsFilter()
+		}
+
+		dep, err := g.visit(ctx, depToolKey, depTaskKey, depFilter)
+		if err != nil {
+			return nil, err
+		}
+
+		n.Dependencies = append(n.Dependencies, dep)
+	}
+
+	g.add(n)
+	return n, nil
+}
+
+func (g *taskGraph) add(n *TaskNode) {
+	g.nodes[n.ID] = n
+	g.order = append(g.order, n)
+}
+
+// executionRegistry records (task, matrix entry) pairs executed in one
+// invocation
+type executionRegistry struct {
+	mu   sync.Mutex
+	runs map[string]*matrixExecution
+}
+
+func newExecutionRegistry() *executionRegistry {
+	return &executionRegistry{
+		runs: make(map[string]*matrixExecution),
+	}
+}
+
+type matrixExecution struct {
+	done chan struct{}
+	err  error
+}
+
+func (e *matrixExecution) finish(err error) {
+	e.err = err
+	close(e.done)
+}
+
+func (e *matrixExecution) wait() error {
+	<-e.done
+	return e.err
+}
+
+// claim marks matrix entries of the task as executing, returns entries
+// not executed by others (owned) along with their executions, and
+// executions of entries claimed by others
+func (r *executionRegistry) claim(
+	toolKey dukkha.ToolKey, taskKey dukkha.TaskKey, entries []matrix.Entry,
+) (owned []matrix.Entry, ownedExecutions, others map[string]*matrixExecution) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ownedExecutions = make(map[string]*matrixExecution)
+	others = make(map[string]*matrixExecution)
+
+	prefix := toolKey.String() + ":" + taskKey.String() + "|"
+	for _, ent := range entries {
+		key := ent.String()
+
+		exec, ok := r.runs[prefix+key]
+		if ok {
+			others[key] = exec
+			continue
+		}
+
+		exec = &matrixExecution{done: make(chan struct{})}
+		r.runs[prefix+key] = exec
+
+		owned = append(owned, ent)
+		ownedExecutions[key] = exec
+	}
+
+	return
+}
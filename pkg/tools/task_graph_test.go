@@ -0,0 +1,305 @@
+package tools_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"arhat.dev/rs"
+	"arhat.dev/tlang"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/matrix"
+	"arhat.dev/dukkha/pkg/tools"
+	"arhat.dev/dukkha/pkg/tools/workflow"
+)
+
+// newWorkflowTestContext creates a context with workflow tool `local` with
+// run tasks defined in yaml, DUKKHA_WORKDIR is set to a temporary dir
+func newWorkflowTestContext(t *testing.T, tasks ...string) dukkha.ConfigResolvingContext {
+	return newWorkflowTestContextWithDirs(t, t.TempDir(), t.TempDir(), tasks...)
+}
+
+// newWorkflowTestContextWithDirs is like newWorkflowTestContext, but uses
+// existing work dir and cache dir
+func newWorkflowTestContextWithDirs(
+	t *testing.T, workDir, cacheDir string, tasks ...string,
+) dukkha.ConfigResolvingContext {
+	ctx := dt.NewTestContextWithGlobalEnv(context.TODO(), &dukkha.GlobalEnvSet{
+		constant.GlobalEnv_DUKKHA_WORKDIR:   tlang.ImmediateString(workDir),
+		constant.GlobalEnv_DUKKHA_CACHE_DIR: tlang.ImmediateString(cacheDir),
+	})
+
+	tool := &workflow.Tool{}
+	tool.ToolName = "local"
+	rs.InitRecursively(reflect.ValueOf(tool), &rs.Options{
+		InterfaceTypeHandler: dukkha.GlobalInterfaceTypeHandler,
+	})
+	if !assert.NoError(t, tool.Init(ctx.ToolCacheFS(tool))) {
+		t.FailNow()
+	}
+
+	var allTasks []dukkha.Task
+	for _, spec := range tasks {
+		tsk := tools.NewTask[workflow.TaskRun, *workflow.TaskRun]("local").(*workflow.TaskRun)
+		rs.InitRecursively(reflect.ValueOf(tsk), &rs.Options{
+			InterfaceTypeHandler: dukkha.GlobalInterfaceTypeHandler,
+		})
+		if !assert.NoError(t, yaml.Unmarshal([]byte(spec), tsk)) ||
+			!assert.NoError(t, tsk.Init(ctx.TaskCacheFS(tsk))) {
+			t.FailNow()
+		}
+
+		allTasks = append(allTasks, tsk)
+	}
+
+	if !assert.NoError(t, tool.AddTasks(allTasks)) {
+		t.FailNow()
+	}
+
+	ctx.AddTool(tool.Key(), tool)
+	ctx.AddToolSpecificTasks(tool.Kind(), tool.Name(), allTasks)
+
+	return ctx
+}
+
+func runTarget(name string) tools.TaskTarget {
+	return tools.TaskTarget{
+		Tool: dukkha.ToolKey{Kind: workflow.ToolKind, Name: "local"},
+		Task: dukkha.TaskKey{Kind: workflow.TaskKindRun, Name: dukkha.TaskName(name)},
+	}
+}
+
+func TestBuildTaskGraph(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Order", func(t *testing.T) {
+		ctx := newWorkflowTestContext(t,
+			`{ name: a, depends_on: [{ ref: "workflow:run(b)" }, { ref: "workflow:local:run(c)" }] }`,
+			`{ name: b, depends_on: [{ ref: "workflow:run(c)" }] }`,
+			`{ name: c }`,
+		)
+
+		nodes, err := tools.BuildTaskGraph(ctx, []tools.TaskTarget{runTarget("a"), runTarget("c")})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var ids []string
+		for _, n := range nodes {
+			ids = append(ids, n.ID)
+		}
+
+		assert.Equal(t, []string{
+			"workflow:local:run:c",
+			"workflow:local:run:b",
+			"workflow:local:run:a",
+		}, ids)
+		assert.Len(t, nodes[2].Dependencies, 2)
+	})
+
+	t.Run("Matrix Filter", func(t *testing.T) {
+		ctx := newWorkflowTestContext(t,
+			`{ name: a, depends_on: [{ ref: "workflow:run(b)", matrix_filter: { arch: [amd64] } }] }`,
+			`{ name: b, matrix: { arch: [amd64, arm64] } }`,
+		)
+
+		nodes, err := tools.BuildTaskGraph(ctx, []tools.TaskTarget{runTarget("a")})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		if assert.Len(t, nodes, 2) {
+			assert.Equal(t, "workflow:local:run:b[arch=amd64]", nodes[0].ID)
+		}
+	})
+
+	t.Run("Matrix Shard", func(t *testing.T) {
+		ctx := newWorkflowTestContext(t,
+			`{ name: a, depends_on: [{ ref: "workflow:run(b)", matrix_filter: {} }] }`,
+			`{ name: b, matrix: { arch: [amd64, arm64] } }`,
+		)
+
+		filter, err := matrix.ParseFilter([]string{"arch in (amd64", "arm64)"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		filter.SetShard(2, 2)
+		ctx.SetMatrixFilter(filter)
+
+		nodes, err := tools.BuildTaskGraph(ctx, []tools.TaskTarget{runTarget("a")})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		if assert.Len(t, nodes, 2) {
+			// dependencies are not sharded
+			assert.Equal(t, "workflow:local:run:b[arch in (amd64,arm64)]", nodes[0].ID)
+			assert.Equal(t, "workflow:local:run:a[arch in (amd64,arm64)]#2/2", nodes[1].ID)
+		}
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		ctx := newWorkflowTestContext(t,
+			`{ name: a, depends_on: [{ ref: "workflow:run(b)" }] }`,
+			`{ name: b, depends_on: [{ ref: "workflow:run(a)" }] }`,
+		)
+
+		_, err := tools.BuildTaskGraph(ctx, []tools.TaskTarget{runTarget("a")})
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(),
+				"workflow:local:run:a -> workflow:local:run:b -> workflow:local:run:a",
+			)
+		}
+	})
+
+	t.Run("Missing Dependency", func(t *testing.T) {
+		ctx := newWorkflowTestContext(t,
+			`{ name: a, depends_on: [{ ref: "workflow:run(none)" }] }`,
+		)
+
+		_, err := tools.BuildTaskGraph(ctx, []tools.TaskTarget{runTarget("a")})
+		assert.Error(t, err)
+	})
+}
+
+func TestRunTaskGraph(t *testing.T) {
+	t.Parallel()
+
+	out := filepath.Join(t.TempDir(), "out")
+	job := func(msg string) string {
+		return `jobs: [{ shell: "echo ` + msg + ` >> ` + filepath.ToSlash(out) + `" }]`
+	}
+
+	ctx := newWorkflowTestContext(t,
+		`{ name: a, depends_on: [{ ref: "workflow:run(b)" }, { ref: "workflow:run(c)" }], `+job("a")+` }`,
+		`{ name: b, depends_on: [{ ref: "workflow:run(c)", matrix_filter: { arch: [amd64] } }], `+job("b")+` }`,
+		`{ name: c, matrix: { arch: [amd64, arm64] }, `+job("c-${MATRIX_ARCH}")+` }`,
+	)
+	ctx.SetRuntimeOptions(dukkha.RuntimeOptions{Workers: 4})
+
+	if !assert.NoError(t, tools.RunTaskGraph(ctx, []tools.TaskTarget{runTarget("a"), runTarget("b")})) {
+		return
+	}
+
+	data, err := os.ReadFile(out)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	lines := strings.Fields(string(data))
+	assert.ElementsMatch(t, []string{"c-amd64", "c-arm64", "b", "a"}, lines)
+	if assert.Len(t, lines, 4) {
+		assert.Equal(t, "a", lines[3])
+	}
+}
+
+func TestRunTaskGraph_WorkerLimit(t *testing.T) {
+	t.Parallel()
+
+	out := filepath.Join(t.TempDir(), "out")
+	// busy loop to keep the job running for a while
+	job := func(name string) string {
+		return `jobs: [{ shell: "echo start-` + name + ` >> ` + filepath.ToSlash(out) +
+			`; i=0; while [ $i -lt 20000 ]; do i=$((i+1)); done; echo end-` + name + ` >> ` + filepath.ToSlash(out) + `" }]`
+	}
+
+	ctx := newWorkflowTestContext(t,
+		`{ name: a, matrix: { arch: [amd64, arm64] }, `+job("a")+` }`,
+		`{ name: b, matrix: { arch: [amd64, arm64] }, `+job("b")+` }`,
+		`{ name: c, matrix: { arch: [amd64, arm64] }, `+job("c")+` }`,
+	)
+	ctx.SetRuntimeOptions(dukkha.RuntimeOptions{Workers: 2})
+
+	if !assert.NoError(t, tools.RunTaskGraph(ctx, []tools.TaskTarget{
+		runTarget("a"), runTarget("b"), runTarget("c"),
+	})) {
+		return
+	}
+
+	data, err := os.ReadFile(out)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var (
+		running    = make(map[string]int)
+		total      = 0
+		maxRunning = 0
+		overlapped = false
+	)
+	for _, line := range strings.Fields(string(data)) {
+		action, name, _ := strings.Cut(line, "-")
+		if action == "start" {
+			running[name]++
+			total++
+		} else {
+			running[name]--
+			total--
+		}
+
+		if total > maxRunning {
+			maxRunning = total
+		}
+
+		busyTasks := 0
+		for _, n := range running {
+			if n > 0 {
+				busyTasks++
+			}
+		}
+
+		if busyTasks > 1 {
+			overlapped = true
+		}
+	}
+
+	assert.Equal(t, 2, maxRunning, "running jobs exceeded worker limit")
+	assert.True(t, overlapped, "independent tasks not running in parallel")
+}
+
+func TestRunTaskGraph_MatrixShard(t *testing.T) {
+	t.Parallel()
+
+	out := filepath.Join(t.TempDir(), "out")
+	job := func(msg string) string {
+		return `jobs: [{ shell: "echo ` + msg + ` >> ` + filepath.ToSlash(out) + `" }]`
+	}
+
+	run := func(index int) []string {
+		ctx := newWorkflowTestContext(t,
+			`{ name: a, `+job("a-"+strconv.Itoa(index))+` }`,
+			`{ name: b, matrix: { arch: [amd64] }, `+job("b-"+strconv.Itoa(index))+` }`,
+		)
+		ctx.SetRuntimeOptions(dukkha.RuntimeOptions{Workers: 1})
+
+		var filter matrix.Filter
+		filter.SetShard(index, 2)
+		ctx.SetMatrixFilter(filter)
+
+		if !assert.NoError(t, tools.RunTaskGraph(ctx, []tools.TaskTarget{runTarget("a"), runTarget("b")})) {
+			t.FailNow()
+		}
+
+		data, err := os.ReadFile(out)
+		if err != nil && !os.IsNotExist(err) {
+			assert.NoError(t, err)
+		}
+
+		return strings.Fields(string(data))
+	}
+
+	// tasks with single entry (including the default host entry of tasks
+	// without matrix) only run in the first shard
+	assert.ElementsMatch(t, []string{"a-1", "b-1"}, run(1))
+	assert.ElementsMatch(t, []string{"a-1", "b-1"}, run(2))
+}
@@ -0,0 +1,282 @@
+package tools
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io/fs"
+	"path"
+	"sort"
+
+	"arhat.dev/rs"
+	"github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/matrix"
+)
+
+// TaskInputs are inputs of a task affecting its outputs
+type TaskInputs struct {
+	rs.BaseField `yaml:"-"`
+
+	// Files are glob patterns of input files (relative to DUKKHA_WORKDIR)
+	Files []string `yaml:"files"`
+
+	// Env are names of environment variables
+	Env []string `yaml:"env"`
+
+	// Values are arbitrary values, usually rendered from other sources
+	Values any `yaml:"values"`
+}
+
+// IncrementalTask is implemented by tasks able to skip matrix entries with
+// unchanged inputs and outputs
+type IncrementalTask interface {
+	// CheckUpToDate calculates fingerprint of the matrix entry from inputs
+	// and resolved exec specs, returns true when it matches the one stored
+	// in last successful run and all outputs exist
+	//
+	// the fingerprint is empty when the task has no inputs declared
+	CheckUpToDate(
+		rc dukkha.TaskExecContext, ms matrix.Entry, specs []dukkha.TaskExecSpec,
+	) (upToDate bool, fingerprint string, err error)
+
+	// SaveFingerprint stores fingerprint of the matrix entry, an empty
+	// fingerprint removes the stored one
+	SaveFingerprint(ms matrix.Entry, fingerprint string) error
+}
+
+const fingerprintsDir = "fingerprints"
+
+func fingerprintFile(ms matrix.Entry) string {
+	sum := md5.Sum([]byte(ms.String()))
+	return path.Join(fingerprintsDir, hex.EncodeToString(sum[:]))
+}
+
+// CheckUpToDate implements IncrementalTask
+func (t *BaseTask[V, T]) CheckUpToDate(
+	rc dukkha.TaskExecContext, ms matrix.Entry, specs []dukkha.TaskExecSpec,
+) (upToDate bool, fingerprint string, err error) {
+	var (
+		inputs   *TaskInputs
+		outputs  []string
+		taskSpec []byte
+
+		tags = []string{"inputs", "output_files"}
+	)
+
+	// work done in AlterExecFunc is not visible in exec specs, use the
+	// resolved task spec instead
+	alterExec := hasAlterExec(specs)
+	if alterExec {
+		tags = append(tags, t.tagsToResolve...)
+	}
+
+	err = t.DoAfterFieldsResolved(rc, -1, false, func() error {
+		inputs, outputs = t.Inputs, t.OutputFiles
+		if inputs == nil || !alterExec {
+			return nil
+		}
+
+		var err2 error
+		taskSpec, err2 = yaml.Marshal(&t.Impl)
+		if err2 != nil {
+			return fmt.Errorf("encoding task spec: %w", err2)
+		}
+
+		return nil
+	}, tags...)
+	if err != nil {
+		return
+	}
+
+	if inputs == nil {
+		return
+	}
+
+	fingerprint, err = calculateFingerprint(rc, inputs, specs, taskSpec)
+	if err != nil || t.cacheFS == nil {
+		return
+	}
+
+	data, err := t.cacheFS.ReadFile(fingerprintFile(ms))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+
+		return
+	}
+
+	if string(data) != fingerprint {
+		return
+	}
+
+	for _, ptn := range outputs {
+		var matches []string
+		matches, err = doublestar.Glob(rc.FS(), ptn)
+		if err != nil {
+			err = fmt.Errorf("checking outputs %q: %w", ptn, err)
+			return
+		}
+
+		if len(matches) == 0 {
+			return
+		}
+	}
+
+	upToDate = true
+	return
+}
+
+// SaveFingerprint implements IncrementalTask
+func (t *BaseTask[V, T]) SaveFingerprint(ms matrix.Entry, fingerprint string) error {
+	if t.cacheFS == nil {
+		return nil
+	}
+
+	file := fingerprintFile(ms)
+	if len(fingerprint) == 0 {
+		err := t.cacheFS.Remove(file)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		return nil
+	}
+
+	err := t.cacheFS.MkdirAll(fingerprintsDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	return t.cacheFS.WriteFile(file, []byte(fingerprint), 0644)
+}
+
+// calculateFingerprint generates sha256 hex digest of inputs, specs and the
+// resolved task spec (only set when specs have AlterExecFunc)
+func calculateFingerprint(
+	rc dukkha.TaskExecContext, inputs *TaskInputs, specs []dukkha.TaskExecSpec, taskSpec []byte,
+) (string, error) {
+	h := sha256.New()
+
+	err := writeSpecsFingerprint(h, specs)
+	if err != nil {
+		return "", err
+	}
+
+	if len(taskSpec) != 0 {
+		_, _ = h.Write([]byte("task:"))
+		_, _ = h.Write(taskSpec)
+		_, _ = h.Write([]byte{0})
+	}
+
+	env := rc.Env()
+	names := append([]string{}, inputs.Env...)
+	sort.Strings(names)
+	for _, name := range names {
+		var value string
+		if v, ok := env[name]; ok {
+			value = v.GetLazyValue()
+		}
+
+		_, _ = h.Write([]byte("env:" + name + "=" + value + "\x00"))
+	}
+
+	data, err := json.Marshal(inputs.Values)
+	if err != nil {
+		return "", fmt.Errorf("encoding input values: %w", err)
+	}
+
+	_, _ = h.Write([]byte("values:"))
+	_, _ = h.Write(data)
+	_, _ = h.Write([]byte{0})
+
+	var files []string
+	for _, ptn := range inputs.Files {
+		matches, err := doublestar.Glob(rc.FS(), ptn)
+		if err != nil {
+			return "", fmt.Errorf("matching input files %q: %w", ptn, err)
+		}
+
+		files = append(files, matches...)
+	}
+
+	sort.Strings(files)
+	for i, file := range files {
+		if i != 0 && files[i-1] == file {
+			continue
+		}
+
+		info, err := rc.FS().Stat(file)
+		if err != nil {
+			return "", fmt.Errorf("checking input file: %w", err)
+		}
+
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		data, err := rc.FS().ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("reading input file: %w", err)
+		}
+
+		sum := sha256.Sum256(data)
+		_, _ = h.Write([]byte("file:" + file + "=" + hex.EncodeToString(sum[:]) + "\x00"))
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hasAlterExec(specs []dukkha.TaskExecSpec) bool {
+	for i := range specs {
+		if specs[i].AlterExecFunc != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeSpecsFingerprint(h hash.Hash, specs []dukkha.TaskExecSpec) error {
+	type specFingerprint struct {
+		StdoutAsReplace string               `json:"stdout_as_replace"`
+		StderrAsReplace string               `json:"stderr_as_replace"`
+		Chdir           string               `json:"chdir"`
+		EnvSuggest      dukkha.NameValueList `json:"env_suggest"`
+		EnvOverride     dukkha.NameValueList `json:"env_override"`
+		Command         []string             `json:"command"`
+		AlterExec       bool                 `json:"alter_exec"`
+		IgnoreError     bool                 `json:"ignore_error"`
+		UseShell        bool                 `json:"use_shell"`
+		ShellName       string               `json:"shell_name"`
+	}
+
+	enc := json.NewEncoder(h)
+	for i := range specs {
+		s := &specs[i]
+		err := enc.Encode(&specFingerprint{
+			StdoutAsReplace: s.StdoutAsReplace,
+			StderrAsReplace: s.StderrAsReplace,
+			Chdir:           s.Chdir,
+			EnvSuggest:      s.EnvSuggest,
+			EnvOverride:     s.EnvOverride,
+			Command:         s.Command,
+			AlterExec:       s.AlterExecFunc != nil,
+			IgnoreError:     s.IgnoreError,
+			UseShell:        s.UseShell,
+			ShellName:       s.ShellName,
+		})
+		if err != nil {
+			return fmt.Errorf("encoding exec spec: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package tools_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/tools"
+)
+
+func TestIncrementalTask(t *testing.T) {
+	t.Parallel()
+
+	ctx := newWorkflowTestContext(t, `
+name: a
+inputs:
+  files: ["src/**/*.txt"]
+  env: [FOO]
+output_files: ["out"]
+jobs:
+- shell: echo a >> "${DUKKHA_WORKDIR}/out"
+`)
+
+	workDir := ctx.WorkDir()
+	src := filepath.Join(workDir, "src", "foo")
+	if !assert.NoError(t, os.MkdirAll(src, 0755)) ||
+		!assert.NoError(t, os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0644)) {
+		return
+	}
+
+	run := func(force bool) int {
+		ctx.SetRuntimeOptions(dukkha.RuntimeOptions{Workers: 1, Force: force})
+		if !assert.NoError(t, tools.RunTaskGraph(ctx, []tools.TaskTarget{runTarget("a")})) {
+			t.FailNow()
+		}
+
+		data, err := os.ReadFile(filepath.Join(workDir, "out"))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		return len(strings.Fields(string(data)))
+	}
+
+	assert.Equal(t, 1, run(false))
+	assert.Equal(t, 1, run(false), "unchanged inputs")
+
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "a.txt"), []byte("b"), 0644))
+	assert.Equal(t, 2, run(false), "input file changed")
+	assert.Equal(t, 2, run(false))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "b.txt"), []byte("b"), 0644))
+	assert.Equal(t, 3, run(false), "input file added")
+
+	assert.Equal(t, 4, run(true), "forced")
+	assert.Equal(t, 4, run(false))
+
+	ctx.AddEnv(true, &dukkha.NameValueEntry{Name: "FOO", Value: "bar"})
+	assert.Equal(t, 5, run(false), "env changed")
+
+	assert.NoError(t, os.Remove(filepath.Join(workDir, "out")))
+	assert.Equal(t, 1, run(false), "output removed")
+}
+
+func TestIncrementalTask_AlterExec(t *testing.T) {
+	t.Parallel()
+
+	// embedded shell jobs are executed in AlterExecFunc, the script is only
+	// visible in the task spec
+	newTask := func(msg string) string {
+		return `{ name: a, inputs: { values: [] }, jobs: [{ shell: "echo ` + msg + ` >> ${DUKKHA_WORKDIR}/out" }] }`
+	}
+
+	workDir, cacheDir := t.TempDir(), t.TempDir()
+	run := func(msg string) string {
+		ctx := newWorkflowTestContextWithDirs(t, workDir, cacheDir, newTask(msg))
+		ctx.SetRuntimeOptions(dukkha.RuntimeOptions{Workers: 1})
+		if !assert.NoError(t, tools.RunTaskGraph(ctx, []tools.TaskTarget{runTarget("a")})) {
+			t.FailNow()
+		}
+
+		data, err := os.ReadFile(filepath.Join(workDir, "out"))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		return strings.Join(strings.Fields(string(data)), " ")
+	}
+
+	assert.Equal(t, "a", run("a"))
+	assert.Equal(t, "a", run("a"), "unchanged")
+	assert.Equal(t, "a b", run("b"), "script changed")
+}
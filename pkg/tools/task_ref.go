@@ -32,28 +32,31 @@ type TaskReference struct {
 	MatrixFilter *matrix.Spec `yaml:"matrix_filter"`
 }
 
-func (tr *TaskReference) genTaskExecReq(
+// GenTaskExecRequest creates a request to run the referenced task in ctx
+func (tr *TaskReference) GenTaskExecRequest(
 	ctx dukkha.TaskExecContext,
-	hookID string,
+	id string,
 	continueOnError bool,
 ) (*TaskExecRequest, error) {
-	var (
-		toolKind dukkha.ToolKind
-		toolName dukkha.ToolName
-		taskKind dukkha.TaskKind
-		taskName dukkha.TaskName
-	)
+	return tr.genTaskExecReq(ctx, id, continueOnError)
+}
 
+// ParseRef parses Ref as tool key and task key, defaultToolName is used
+// when tool name is not set in Ref
+func (tr *TaskReference) ParseRef(
+	defaultToolName dukkha.ToolName,
+) (toolKey dukkha.ToolKey, taskKey dukkha.TaskKey, err error) {
 	tk, name, ok := strings.Cut(strings.TrimSpace(tr.Ref), "(")
 	if !ok {
-		return nil, fmt.Errorf("invalid task reference %q: missing task call `(<name>)`", tr.Ref)
+		err = fmt.Errorf("invalid task reference %q: missing task call `(<name>)`", tr.Ref)
+		return
 	}
 
-	taskName = dukkha.TaskName(strings.TrimSuffix(name, ")"))
+	taskKey.Name = dukkha.TaskName(strings.TrimSuffix(name, ")"))
 
 	// <tool-kind>{:<tool-name>}:<task-kind>
 	parts := strings.Split(tk, ":")
-	toolKind = dukkha.ToolKind(parts[0])
+	toolKey.Kind = dukkha.ToolKind(parts[0])
 
 	switch len(parts) {
 	case 2:
@@ -69,17 +72,30 @@ func (tr *TaskReference) genTaskExecReq(
 		// 		buildah:in-docker:login(foo)	# same kind
 		//		golang:in-docker:build(bar)		# different kind
 
-		toolName = ctx.CurrentTool().Name
-		taskKind = dukkha.TaskKind(parts[1])
+		toolKey.Name = defaultToolName
+		taskKey.Kind = dukkha.TaskKind(parts[1])
 	case 3:
-		toolName = dukkha.ToolName(parts[1])
-		taskKind = dukkha.TaskKind(parts[2])
+		toolKey.Name = dukkha.ToolName(parts[1])
+		taskKey.Kind = dukkha.TaskKind(parts[2])
 	default:
-		return nil, fmt.Errorf(
+		err = fmt.Errorf(
 			"invalid task reference %q: expecting <tool-kind>{:<tool-name>}:<task-kind>", tr.Ref,
 		)
 	}
 
+	return
+}
+
+func (tr *TaskReference) genTaskExecReq(
+	ctx dukkha.TaskExecContext,
+	hookID string,
+	continueOnError bool,
+) (*TaskExecRequest, error) {
+	toolKey, taskKey, err := tr.ParseRef(ctx.CurrentTool().Name)
+	if err != nil {
+		return nil, err
+	}
+
 	if tr.MatrixFilter == nil {
 		// not set or nil, reset filter
 		ctx.SetMatrixFilter(matrix.Filter{})
@@ -87,14 +103,6 @@ func (tr *TaskReference) genTaskExecReq(
 		ctx.SetMatrixFilter(tr.MatrixFilter.AsFilter())
 	} // else { /* empty filter, keep current filter */ }
 
-	toolKey, taskKey := dukkha.ToolKey{
-		Kind: toolKind,
-		Name: toolName,
-	}, dukkha.TaskKey{
-		Kind: taskKind,
-		Name: taskName,
-	}
-
 	tool, ok := ctx.GetTool(toolKey)
 	if !ok {
 		return nil, fmt.Errorf("%q: referenced tool %q not found", hookID, toolKey)
@@ -18,6 +18,18 @@ type ToolImpl interface {
 	Kind() dukkha.ToolKind
 }
 
+// ToolImplWithEnv is an optional interface for ToolImpl to provide
+// matrix specific env when the tool is used directly (e.g. `dukkha as`)
+type ToolImplWithEnv interface {
+	GetEnv(v dukkha.EnvValues) (dukkha.NameValueList, error)
+}
+
+// ToolWithImplEnv is implemented by BaseTool to expose env generated by
+// its ToolImpl
+type ToolWithImplEnv interface {
+	GetImplEnv(v dukkha.EnvValues) (dukkha.NameValueList, error)
+}
+
 // BaseTool is the helper to wrap plain old tool spec as dukkha.Tool
 //
 // NOTE: V MUST be a struct type, T MUST be *V
@@ -65,6 +77,18 @@ func (t *BaseTool[V, T]) GetCmd() []string {
 	return toolCmd
 }
 
+// GetImplEnv returns env generated by the tool impl if it implements ToolImplWithEnv
+//
+// it should be called after all fields of the tool resolved
+func (t *BaseTool[V, T]) GetImplEnv(v dukkha.EnvValues) (dukkha.NameValueList, error) {
+	impl, ok := any(t.getImpl()).(ToolImplWithEnv)
+	if !ok {
+		return nil, nil
+	}
+
+	return impl.GetEnv(v)
+}
+
 func (t *BaseTool[V, T]) GetTask(k dukkha.TaskKey) (dukkha.Task, bool) {
 	tsk, ok := t.tasks[k]
 	return tsk, ok
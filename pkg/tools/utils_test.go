@@ -40,6 +40,9 @@ func TestGetTagNamesToResolve(t *testing.T) {
 			"matrix",
 			"hooks",
 			"continue_on_error",
+			"depends_on",
+			"inputs",
+			"output_files",
 
 			"a",
 			"bar",
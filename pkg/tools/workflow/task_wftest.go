@@ -1,7 +1,19 @@
 package workflow
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"arhat.dev/rs"
+	"mvdan.cc/sh/v3/interp"
 
 	"arhat.dev/dukkha/pkg/dukkha"
 	"arhat.dev/dukkha/pkg/tools"
@@ -21,14 +33,417 @@ type TaskTest struct {
 }
 
 // nolint:revive
-type WorkflowTest struct{}
+type WorkflowTest struct {
+	// Cases to run, each case runs in its own context
+	Cases []*TestCase `yaml:"cases"`
+
+	parent tools.BaseTaskType
+}
 
 func (w *WorkflowTest) ToolKind() dukkha.ToolKind       { return ToolKind }
 func (w *WorkflowTest) Kind() dukkha.TaskKind           { return TaskKindTest }
-func (w *WorkflowTest) LinkParent(p tools.BaseTaskType) {}
+func (w *WorkflowTest) LinkParent(p tools.BaseTaskType) { w.parent = p }
 
 func (w *WorkflowTest) GetExecSpecs(
 	rc dukkha.TaskExecContext, options dukkha.TaskMatrixExecOptions,
 ) ([]dukkha.TaskExecSpec, error) {
-	return nil, fmt.Errorf("UNIMPLEMENTED")
+	var cases []*TestCase
+
+	// depth = 1 to get case list only, cases are resolved when running
+	err := w.parent.DoAfterFieldsResolved(rc, 1, true, func() error {
+		cases = w.Cases
+		return nil
+	}, "cases")
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		steps  []dukkha.TaskExecSpec
+		failed []string
+	)
+
+	for i := range cases {
+		tc := cases[i]
+		index := i
+
+		steps = append(steps, dukkha.TaskExecSpec{
+			AlterExecFunc: func(
+				replace dukkha.ReplaceEntries,
+				stdin io.Reader,
+				stdout, stderr io.Writer,
+			) (dukkha.RunTaskOrRunCmd, error) {
+				name := tc.Name
+				if len(name) == 0 {
+					name = "#" + strconv.FormatInt(int64(index), 10)
+				}
+
+				_, _ = fmt.Fprintf(stdout, "=== RUN   %s\n", name)
+
+				err := tc.run(rc)
+				if err != nil {
+					failed = append(failed, name)
+
+					_, _ = fmt.Fprintf(stdout, "--- FAIL: %s\n    %s\n",
+						name, strings.ReplaceAll(err.Error(), "\n", "\n    "),
+					)
+					return nil, nil
+				}
+
+				_, _ = fmt.Fprintf(stdout, "--- PASS: %s\n", name)
+				return nil, nil
+			},
+		})
+	}
+
+	steps = append(steps, dukkha.TaskExecSpec{
+		AlterExecFunc: func(
+			replace dukkha.ReplaceEntries,
+			stdin io.Reader,
+			stdout, stderr io.Writer,
+		) (dukkha.RunTaskOrRunCmd, error) {
+			if len(failed) != 0 {
+				return nil, fmt.Errorf(
+					"%d of %d test cases failed: %s",
+					len(failed), len(cases), strings.Join(failed, ", "),
+				)
+			}
+
+			return nil, nil
+		},
+	})
+
+	return steps, nil
+}
+
+// TestCase runs actions (or a task) and checks the result
+type TestCase struct {
+	rs.BaseField `yaml:"-"`
+
+	// Name of this test case
+	//
+	// Defaults to `#i` where i is the index of this case in the list (starting from 0)
+	Name string `yaml:"name"`
+
+	// Env specific to this test case
+	Env dukkha.NameValueList `yaml:"env"`
+
+	// Run actions as the test subject
+	//
+	// Run and Task are mutually exclusive
+	Run tools.Actions `yaml:"run"`
+
+	// Task reference as the test subject
+	//
+	// Run and Task are mutually exclusive
+	Task *tools.TaskReference `yaml:"task"`
+
+	// Expect is the expected result of this test case
+	//
+	// it is resolved after the test subject finished, so it's fine to
+	// reference files produced by the test subject
+	Expect TestExpectation `yaml:"expect"`
+
+	mu sync.Mutex
+}
+
+// TestExpectation defines checks applied to the result of a test case
+type TestExpectation struct {
+	rs.BaseField `yaml:"-"`
+
+	// ExitCode of the test subject, non-zero value means the test subject
+	// is expected to fail
+	ExitCode int `yaml:"exit_code"`
+
+	// Stdout is the exact stdout content expected
+	Stdout *string `yaml:"stdout"`
+
+	// StdoutContains lists substrings expected to appear in stdout
+	StdoutContains []string `yaml:"stdout_contains"`
+
+	// Stderr is the exact stderr content expected
+	Stderr *string `yaml:"stderr"`
+
+	// StderrContains lists substrings expected to appear in stderr
+	StderrContains []string `yaml:"stderr_contains"`
+
+	// Files to check after the test subject finished
+	Files []*FileExpectation `yaml:"files"`
+
+	// Env values expected in the context of this test case
+	Env dukkha.NameValueList `yaml:"env"`
+}
+
+// FileExpectation defines checks for a single file
+type FileExpectation struct {
+	rs.BaseField `yaml:"-"`
+
+	// Path of the file, relative path is relative to the working directory
+	Path string `yaml:"path"`
+
+	// Exists sets whether the file is expected to exist
+	//
+	// Defaults to `true`
+	Exists *bool `yaml:"exists"`
+
+	// Content is the exact file content expected
+	Content *string `yaml:"content"`
+
+	// Contains lists substrings expected to appear in the file
+	Contains []string `yaml:"contains"`
+}
+
+// DoAfterFieldsResolved implements dukkha.Resolvable
+func (tc *TestCase) DoAfterFieldsResolved(
+	rc dukkha.RenderingContext,
+	depth int,
+	resolveEnv bool,
+	do func() error,
+	tagNames ...string,
+) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if resolveEnv {
+		err := dukkha.ResolveAndAddEnv(rc, tc, "Env", "env")
+		if err != nil {
+			return fmt.Errorf("resolving test case env: %w", err)
+		}
+	}
+
+	err := tc.ResolveFields(rc, depth, tagNames...)
+	if err != nil {
+		return fmt.Errorf("resolving test case fields: %w", err)
+	}
+
+	return do()
+}
+
+func (tc *TestCase) run(rc dukkha.TaskExecContext) error {
+	var (
+		stdout, stderr bytes.Buffer
+
+		execSpecs []dukkha.TaskExecSpec
+	)
+
+	caseCtx := rc.DeriveNew()
+	caseCtx.SetOutputPrefix("")
+
+	err := tc.DoAfterFieldsResolved(caseCtx, 1, true, func() error {
+		switch {
+		case tc.Task != nil && len(tc.Run) != 0:
+			return fmt.Errorf("unexpected both `run` and `task` set")
+		case tc.Task != nil:
+			err := tc.Task.ResolveFields(caseCtx, -1)
+			if err != nil {
+				return fmt.Errorf("resolving task reference: %w", err)
+			}
+
+			req, err := tc.Task.GenTaskExecRequest(caseCtx.DeriveNew(), "task", false)
+			if err != nil {
+				return err
+			}
+
+			// referenced task writes to context stdio directly
+			req.Context.SetStdIO(caseCtx.Stdin(), &stdout, &stderr)
+			execSpecs = []dukkha.TaskExecSpec{{
+				AlterExecFunc: func(
+					replace dukkha.ReplaceEntries,
+					stdin io.Reader,
+					_, _ io.Writer,
+				) (dukkha.RunTaskOrRunCmd, error) {
+					return req, nil
+				},
+			}}
+
+			return nil
+		default:
+			return nil
+		}
+	}, "name", "task")
+	if err != nil {
+		return err
+	}
+
+	if execSpecs == nil {
+		execSpecs, err = tools.ResolveActions(caseCtx, tc, &tc.Run, "run")
+		if err != nil {
+			return fmt.Errorf("resolving actions: %w", err)
+		}
+	}
+
+	// workflow tool has no cmd, tool is only looked up for
+	// DUKKHA_TOOL_CMD in the commands
+	tool, _ := rc.GetTool(rc.CurrentTool())
+
+	runErr := tools.RunExecSpecs(caseCtx, tool, captureOutput(execSpecs, &stdout, &stderr))
+
+	return tc.DoAfterFieldsResolved(caseCtx, -1, false, func() error {
+		return tc.Expect.check(caseCtx, runErr, stdout.String(), stderr.String())
+	}, "expect")
+}
+
+func (e *TestExpectation) check(
+	rc dukkha.RenderingContext,
+	runErr error,
+	stdout, stderr string,
+) error {
+	var errs []string
+
+	if code := exitCodeOf(runErr); code != e.ExitCode {
+		msg := fmt.Sprintf("exit code: expected %d, got %d", e.ExitCode, code)
+		if runErr != nil {
+			msg += ": " + runErr.Error()
+		}
+
+		errs = append(errs, msg)
+	}
+
+	errs = append(errs, checkContent("stdout", stdout, e.Stdout, e.StdoutContains)...)
+	errs = append(errs, checkContent("stderr", stderr, e.Stderr, e.StderrContains)...)
+
+	for _, f := range e.Files {
+		path := f.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(rc.WorkDir(), path)
+		}
+
+		expectExists := f.Exists == nil || *f.Exists
+
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil && !expectExists:
+			errs = append(errs, fmt.Sprintf("file %q: expected not exist", f.Path))
+			continue
+		case errors.Is(err, os.ErrNotExist) && !expectExists:
+			continue
+		case err != nil:
+			errs = append(errs, fmt.Sprintf("file %q: %v", f.Path, err))
+			continue
+		}
+
+		errs = append(errs, checkContent(
+			"file "+strconv.Quote(f.Path), string(data), f.Content, f.Contains,
+		)...)
+	}
+
+	env := rc.Env()
+	for _, ev := range e.Env {
+		v, ok := env[ev.Name]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("env %q: not set", ev.Name))
+			continue
+		}
+
+		if actual := v.GetLazyValue(); actual != ev.Value {
+			errs = append(errs, fmt.Sprintf(
+				"env %q: expected %q, got %q", ev.Name, ev.Value, actual,
+			))
+		}
+	}
+
+	if len(errs) != 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+
+	return nil
+}
+
+func checkContent(name, actual string, expected *string, contains []string) (errs []string) {
+	if expected != nil && *expected != actual {
+		errs = append(errs, fmt.Sprintf("%s: expected %q, got %q", name, *expected, actual))
+	}
+
+	for _, s := range contains {
+		if !strings.Contains(actual, s) {
+			errs = append(errs, fmt.Sprintf("%s: expected to contain %q, got %q", name, s, actual))
+		}
+	}
+
+	return
+}
+
+// exitCodeOf extracts exit code from the error returned when running exec specs
+func exitCodeOf(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	if status, ok := interp.IsExitStatus(err); ok {
+		return int(status)
+	}
+
+	return 1
+}
+
+// stdoutReplaceKey and stderrReplaceKey are used to capture output of
+// commands in test case, they are not expected to appear in commands
+const (
+	stdoutReplaceKey = "<DUKKHA_WORKFLOW_TEST_STDOUT>"
+	stderrReplaceKey = "<DUKKHA_WORKFLOW_TEST_STDERR>"
+)
+
+// captureOutput updates specs to write output to stdout and stderr
+//
+// commands are captured using StdoutAsReplace and StderrAsReplace so
+// messages from dukkha are excluded
+func captureOutput(specs []dukkha.TaskExecSpec, stdout, stderr io.Writer) []dukkha.TaskExecSpec {
+	ret := make([]dukkha.TaskExecSpec, len(specs))
+	for i, es := range specs {
+		if alter := es.AlterExecFunc; alter != nil {
+			es.AlterExecFunc = func(
+				replace dukkha.ReplaceEntries,
+				stdin io.Reader,
+				_, _ io.Writer,
+			) (dukkha.RunTaskOrRunCmd, error) {
+				sub, err := alter(replace, stdin, stdout, stderr)
+				switch t := sub.(type) {
+				case []dukkha.TaskExecSpec:
+					return captureOutput(t, stdout, stderr), err
+				case *tools.TaskExecRequest:
+					t.Context.SetStdIO(t.Context.Stdin(), stdout, stderr)
+				}
+
+				return sub, err
+			}
+
+			ret[i] = es
+			continue
+		}
+
+		if len(es.StdoutAsReplace) == 0 {
+			es.StdoutAsReplace = stdoutReplaceKey
+			es.FixStdoutValueForReplace = teeReplaceValue(stdout, nil)
+		} else if es.ShowStdout {
+			es.FixStdoutValueForReplace = teeReplaceValue(stdout, es.FixStdoutValueForReplace)
+		}
+
+		if len(es.StderrAsReplace) == 0 {
+			es.StderrAsReplace = stderrReplaceKey
+			es.FixStderrValueForReplace = teeReplaceValue(stderr, nil)
+		} else if es.ShowStderr {
+			es.FixStderrValueForReplace = teeReplaceValue(stderr, es.FixStderrValueForReplace)
+		}
+
+		ret[i] = es
+	}
+
+	return ret
+}
+
+func teeReplaceValue(w io.Writer, fix func(data []byte) []byte) func(data []byte) []byte {
+	return func(data []byte) []byte {
+		_, _ = w.Write(data)
+
+		if fix != nil {
+			return fix(data)
+		}
+
+		return data
+	}
 }
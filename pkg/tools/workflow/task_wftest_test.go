@@ -0,0 +1,25 @@
+package workflow
+
+import (
+	"testing"
+
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/tools"
+	"arhat.dev/dukkha/pkg/tools/tests"
+)
+
+func TestTaskTest(t *testing.T) {
+	t.Parallel()
+
+	type Check struct {
+		rs.BaseField
+	}
+
+	tests.TestTask(t, "./fixtures/test",
+		&Tool{},
+		func() *TaskTest { return tools.NewTask[TaskTest, *TaskTest]("").(*TaskTest) },
+		func() *Check { return &Check{} },
+		func(t *testing.T, expected, actual *Check) {},
+	)
+}
@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"arhat.dev/pkg/stringhelper"
+)
+
+const (
+	// MaskedValue is the replacement of secret values
+	MaskedValue = "***"
+
+	// minMaskedValueSize is the minimum size of secret values to be masked,
+	// shorter values are too common to be masked without breaking output
+	minMaskedValueSize = 4
+)
+
+// NewMasker creates a new Masker without any secret value
+func NewMasker() *Masker {
+	return &Masker{values: make(map[string]struct{})}
+}
+
+// Masker replaces registered secret values with `***`
+//
+// it's safe for concurrent use, a nil Masker masks nothing
+type Masker struct {
+	mu       sync.RWMutex
+	values   map[string]struct{}
+	replacer *strings.Replacer
+}
+
+// Add registers secret values to be masked, for multi-line values, each line is
+// also registered in case of line by line output
+func (m *Masker) Add(values ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	added := false
+	add := func(v string) {
+		if len(v) < minMaskedValueSize {
+			return
+		}
+
+		if _, ok := m.values[v]; !ok {
+			m.values[v] = struct{}{}
+			added = true
+		}
+	}
+
+	for _, v := range values {
+		add(v)
+		add(strings.TrimSpace(v))
+
+		if strings.IndexByte(v, '\n') == -1 {
+			continue
+		}
+
+		for _, line := range strings.Split(v, "\n") {
+			add(strings.TrimSpace(line))
+		}
+	}
+
+	if !added {
+		return
+	}
+
+	all := make([]string, 0, len(m.values))
+	for v := range m.values {
+		all = append(all, v)
+	}
+
+	// match longer values first
+	sort.Slice(all, func(i, j int) bool {
+		if len(all[i]) != len(all[j]) {
+			return len(all[i]) > len(all[j])
+		}
+
+		return all[i] < all[j]
+	})
+
+	oldnew := make([]string, 0, 2*len(all))
+	for _, v := range all {
+		oldnew = append(oldnew, v, MaskedValue)
+	}
+
+	m.replacer = strings.NewReplacer(oldnew...)
+}
+
+func (m *Masker) getReplacer() *strings.Replacer {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.replacer
+}
+
+// Mask returns s with all registered secret values replaced
+func (m *Masker) Mask(s string) string {
+	r := m.getReplacer()
+	if r == nil {
+		return s
+	}
+
+	return r.Replace(s)
+}
+
+// MaskBytes is like Mask, but works on bytes, p is returned as is when there is
+// no secret value registered
+func (m *Masker) MaskBytes(p []byte) []byte {
+	r := m.getReplacer()
+	if r == nil {
+		return p
+	}
+
+	return stringhelper.ToBytes[byte, byte](r.Replace(string(p)))
+}
+
+// MaskError returns an error with all registered secret values replaced in
+// its message, the original error is still accessible with errors.Unwrap
+func (m *Masker) MaskError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	msg := err.Error()
+	masked := m.Mask(msg)
+	if masked == msg {
+		return err
+	}
+
+	return &maskedError{msg: masked, err: err}
+}
+
+type maskedError struct {
+	msg string
+	err error
+}
+
+func (e *maskedError) Error() string { return e.msg }
+func (e *maskedError) Unwrap() error { return e.err }
+
+// maxMaskingWriterBufSize is the size of buffered data to write without
+// waiting for line break
+const maxMaskingWriterBufSize = 64 * 1024
+
+// Writer returns a writer masking data written to w
+//
+// data is buffered until a line break (`\n` or `\r`), so secret values split
+// across writes are still masked, call Flush to write remaining data
+func (m *Masker) Writer(w io.Writer) *MaskingWriter {
+	return &MaskingWriter{m: m, w: w}
+}
+
+// MaskingWriter masks data line by line, created by Masker.Writer
+type MaskingWriter struct {
+	m *Masker
+	w io.Writer
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *MaskingWriter) Write(p []byte) (int, error) {
+	if w.m == nil {
+		return w.w.Write(p)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+
+	n := bytes.LastIndexAny(w.buf, "\r\n") + 1
+	if n == 0 {
+		if len(w.buf) < maxMaskingWriterBufSize {
+			return len(p), nil
+		}
+
+		n = len(w.buf)
+	}
+
+	err := w.writeBuffered(n)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Flush writes buffered data not ending with line break
+func (w *MaskingWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	return w.writeBuffered(len(w.buf))
+}
+
+func (w *MaskingWriter) writeBuffered(n int) error {
+	_, err := w.w.Write(w.m.MaskBytes(w.buf[:n]))
+	w.buf = append(w.buf[:0], w.buf[n:]...)
+	return err
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMasker(t *testing.T) {
+	t.Parallel()
+
+	var nilMasker *Masker
+	assert.Equal(t, "secret-value", nilMasker.Mask("secret-value"))
+
+	m := NewMasker()
+	assert.Equal(t, "secret-value", m.Mask("secret-value"))
+
+	m.Add("abc", "secret", "secret-value", "line-1\nline-2\n")
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Short Value Not Masked",
+			input:    "abc",
+			expected: "abc",
+		},
+		{
+			name:     "Longer Value First",
+			input:    "--password secret-value",
+			expected: "--password ***",
+		},
+		{
+			name:     "Multiple Values",
+			input:    "secret,secret-value",
+			expected: "***,***",
+		},
+		{
+			name:     "Multi-line Value",
+			input:    "line-1\nline-2\n",
+			expected: "***",
+		},
+		{
+			name:     "Single Line Of Multi-line Value",
+			input:    "got line-2",
+			expected: "got ***",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, m.Mask(test.input))
+			assert.Equal(t, test.expected, string(m.MaskBytes([]byte(test.input))))
+		})
+	}
+
+	t.Run("Writer", func(t *testing.T) {
+		var buf bytes.Buffer
+		n, err := m.Writer(&buf).Write([]byte("token: secret\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, len("token: secret\n"), n)
+		assert.Equal(t, "token: ***\n", buf.String())
+	})
+
+	t.Run("Writer Split Value", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := m.Writer(&buf)
+		for _, data := range []string{"token: sec", "ret\nfoo: se", "cret"} {
+			_, err := w.Write([]byte(data))
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, "token: ***\n", buf.String())
+		assert.NoError(t, w.Flush())
+		assert.Equal(t, "token: ***\nfoo: ***", buf.String())
+	})
+
+	t.Run("MaskError", func(t *testing.T) {
+		assert.NoError(t, m.MaskError(nil))
+
+		origErr := fmt.Errorf("wrapped: %w", io.EOF)
+		assert.Equal(t, origErr, m.MaskError(origErr))
+
+		err := m.MaskError(fmt.Errorf("running [ login secret ]: %w", io.EOF))
+		assert.EqualError(t, err, "running [ login *** ]: EOF")
+		assert.ErrorIs(t, err, io.EOF)
+	})
+}
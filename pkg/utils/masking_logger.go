@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"arhat.dev/pkg/log"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewMaskingLogger wraps l to replace secret values registered in masker in
+// log messages and fields
+func NewMaskingLogger(l log.Interface, masker *Masker) log.Interface {
+	return &maskingLogger{l: l, m: masker}
+}
+
+type maskingLogger struct {
+	l log.Interface
+	m *Masker
+
+	// fields added by WithFields, they are masked when logging since secret
+	// values can be registered after this logger was created
+	fields []log.Field
+}
+
+func (l *maskingLogger) Enabled(level log.Level) bool { return l.l.Enabled(level) }
+func (l *maskingLogger) Flush() error                 { return l.l.Flush() }
+
+func (l *maskingLogger) WithName(name string) log.Interface {
+	return &maskingLogger{l: l.l.WithName(name), m: l.m, fields: l.fields}
+}
+
+func (l *maskingLogger) WithFields(fields ...log.Field) log.Interface {
+	all := make([]log.Field, 0, len(l.fields)+len(fields))
+	all = append(all, l.fields...)
+	all = append(all, fields...)
+	return &maskingLogger{l: l.l, m: l.m, fields: all}
+}
+
+func (l *maskingLogger) V(msg string, fields ...log.Field) {
+	if l.l.Enabled(log.LevelVerbose) {
+		l.l.V(l.m.Mask(msg), l.maskFields(fields)...)
+	}
+}
+
+func (l *maskingLogger) D(msg string, fields ...log.Field) {
+	if l.l.Enabled(log.LevelDebug) {
+		l.l.D(l.m.Mask(msg), l.maskFields(fields)...)
+	}
+}
+
+func (l *maskingLogger) I(msg string, fields ...log.Field) {
+	if l.l.Enabled(log.LevelInfo) {
+		l.l.I(l.m.Mask(msg), l.maskFields(fields)...)
+	}
+}
+
+func (l *maskingLogger) E(msg string, fields ...log.Field) {
+	if l.l.Enabled(log.LevelError) {
+		l.l.E(l.m.Mask(msg), l.maskFields(fields)...)
+	}
+}
+
+func (l *maskingLogger) maskFields(fields []log.Field) []log.Field {
+	if len(l.fields) != 0 {
+		fields = append(append(make([]log.Field, 0, len(l.fields)+len(fields)), l.fields...), fields...)
+	}
+
+	if l.m.getReplacer() == nil {
+		return fields
+	}
+
+	ret := make([]log.Field, len(fields))
+	for i, f := range fields {
+		ret[i] = l.maskField(f)
+	}
+
+	return ret
+}
+
+// maskField replaces the field with a masked string field when its value
+// contains secret values
+func (l *maskingLogger) maskField(f log.Field) log.Field {
+	var str string
+	switch f.Type {
+	case zapcore.StringType:
+		str = f.String
+	case zapcore.ErrorType:
+		err, ok := f.Interface.(error)
+		if !ok {
+			return f
+		}
+
+		str = err.Error()
+	case zapcore.StringerType:
+		s, ok := f.Interface.(fmt.Stringer)
+		if !ok {
+			return f
+		}
+
+		str = s.String()
+	case zapcore.ByteStringType, zapcore.BinaryType:
+		data, ok := f.Interface.([]byte)
+		if !ok {
+			return f
+		}
+
+		str = string(data)
+	case zapcore.ReflectType, zapcore.ArrayMarshalerType, zapcore.ObjectMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+
+		data, err := json.Marshal(enc.Fields[f.Key])
+		if err != nil {
+			return f
+		}
+
+		str = string(data)
+	default:
+		// numbers, bools, time values
+		return f
+	}
+
+	masked := l.m.Mask(str)
+	if masked == str {
+		return f
+	}
+
+	return log.String(f.Key, masked)
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"arhat.dev/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedLog struct {
+	msg    string
+	fields []log.Field
+}
+
+type recordingLogger struct {
+	fields []log.Field
+	logs   *[]recordedLog
+}
+
+func (l *recordingLogger) Enabled(log.Level) bool            { return true }
+func (l *recordingLogger) Flush() error                      { return nil }
+func (l *recordingLogger) WithName(string) log.Interface     { return l }
+func (l *recordingLogger) V(msg string, fields ...log.Field) { l.log(msg, fields) }
+func (l *recordingLogger) D(msg string, fields ...log.Field) { l.log(msg, fields) }
+func (l *recordingLogger) I(msg string, fields ...log.Field) { l.log(msg, fields) }
+func (l *recordingLogger) E(msg string, fields ...log.Field) { l.log(msg, fields) }
+
+func (l *recordingLogger) WithFields(fields ...log.Field) log.Interface {
+	return &recordingLogger{fields: append(l.fields, fields...), logs: l.logs}
+}
+
+func (l *recordingLogger) log(msg string, fields []log.Field) {
+	*l.logs = append(*l.logs, recordedLog{msg: msg, fields: append(l.fields, fields...)})
+}
+
+func TestMaskingLogger(t *testing.T) {
+	t.Parallel()
+
+	var (
+		logs []recordedLog
+		m    = NewMasker()
+	)
+
+	logger := NewMaskingLogger(&recordingLogger{logs: &logs}, m).
+		WithFields(log.String("password", "secret-value"))
+
+	// secrets registered after logger creation are also masked
+	m.Add("secret-value")
+
+	logger.I("using secret-value",
+		log.String("cmd", "login --password secret-value"),
+		log.Error(errors.New("invalid password secret-value")),
+		log.Strings("args", []string{"--password", "secret-value"}),
+		log.Int("count", 1),
+	)
+
+	if !assert.Len(t, logs, 1) {
+		return
+	}
+
+	assert.Equal(t, "using ***", logs[0].msg)
+
+	fields := logs[0].fields
+	if !assert.Len(t, fields, 5) {
+		return
+	}
+
+	assert.Equal(t, "password", fields[0].Key)
+	assert.Equal(t, "***", fields[0].String)
+	assert.Equal(t, "login --password ***", fields[1].String)
+	assert.Equal(t, "invalid password ***", fields[2].String)
+	assert.Equal(t, `["--password","***"]`, fields[3].String)
+	assert.Equal(t, int64(1), fields[4].Integer)
+}
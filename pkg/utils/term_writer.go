@@ -54,12 +54,17 @@ func (p *prefixWriter) Write(data []byte) (n int, err error) {
 	return n, err
 }
 
+// TermWriter creates a writer writing output to w with optional color, secret
+// values registered in masker are replaced before writing
+//
+// the returned writer MUST be flushed after use
 func TermWriter(
 	prefix string,
 	useColor bool,
 	prefixColor, outputColor termenv.Color,
+	masker *Masker,
 	w io.Writer,
-) io.Writer {
+) *MaskingWriter {
 	prefixBytes := []byte(prefix)
 	writePrefix := func() error {
 		_, err := w.Write(prefixBytes)
@@ -87,10 +92,10 @@ func TermWriter(
 		}
 	}
 
-	return &prefixWriter{
+	return masker.Writer(&prefixWriter{
 		writePrefix: writePrefix,
 		writeOutput: writeOutput,
 
 		_w: w,
-	}
+	})
 }